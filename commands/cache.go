@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/core/constants"
+	"github.com/penwyp/go-claude-monitor/internal/data/cache"
+	"github.com/penwyp/go-claude-monitor/internal/data/scanner"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Cache stats command flags
+	cacheStatsOutput string
+
+	// Cache prune command flags
+	cachePruneOlderThan string
+	cachePruneMaxSize   string
+)
+
+// byteSizeUnits are the accepted --max-size suffixes; KB, MB and GB are
+// decimal, KiB, MiB and GiB binary
+var byteSizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+}
+
+// cacheStatsReasons are the miss reasons always listed by cache stats, in
+// validation order; other reasons are listed only when they occur
+var cacheStatsReasons = []cache.CacheMissReason{
+	cache.MissReasonSchema,
+	cache.MissReasonInode,
+	cache.MissReasonSize,
+	cache.MissReasonModTime,
+	cache.MissReasonFingerprint,
+	cache.MissReasonNoFingerprint,
+	cache.MissReasonError,
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect the aggregation cache",
+	Long: `Commands for the aggregation cache that keeps parsed usage logs between runs.
+Use reset to remove it.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report cache size, age and how many entries are stale",
+	Long: `Walks the aggregation cache and validates every entry against its source log
+the same way an analysis does. Prints the number of cached sessions, their size
+on disk, the oldest and newest entry, and how many entries would be
+invalidated, by reason:
+
+  inode           the log was replaced by a new file
+  size            the log grew or shrank
+  modtime         the log was touched
+  fingerprint     the log content changed with size and modtime unchanged
+  no_fingerprint  the entry has no usable content fingerprint
+  error           the entry cannot be read or its log is gone
+
+Examples:
+  go-claude-monitor cache stats
+  go-claude-monitor cache stats --output json`,
+	Args: cobra.NoArgs,
+	RunE: runCacheStats,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old cache entries until age and size limits are met",
+	Long: `Deletes aggregation cache entries, least recently written first, until none is
+older than --older-than and the cache is no larger than --max-size. Pruned
+sessions are parsed again from their logs on the next run. Entries of logs in
+--dir written within the last session window are kept, since a running top
+still tracks them. The window history is never removed; use reset for that.
+
+Examples:
+  go-claude-monitor cache prune --older-than 30d
+  go-claude-monitor cache prune --max-size 100MB
+  go-claude-monitor cache prune --older-than 2w --max-size 1GiB`,
+	Args: cobra.NoArgs,
+	RunE: runCachePrune,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+
+	cacheStatsCmd.Flags().StringVarP(&cacheStatsOutput, "output", "o", "table",
+		"Output format (table, json)")
+
+	cachePruneCmd.Flags().StringVar(&cachePruneOlderThan, "older-than", "",
+		"Remove entries written longer ago than this (e.g., 30d, 2w, 12h)")
+	cachePruneCmd.Flags().StringVar(&cachePruneMaxSize, "max-size", "",
+		"Remove the oldest entries until the cache fits (e.g., 100MB, 1GiB)")
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	if cacheStatsOutput != "table" && cacheStatsOutput != "json" {
+		return newCommandError(ErrorCodeInvalidArgument,
+			fmt.Errorf("invalid output format '%s': use table or json", cacheStatsOutput))
+	}
+
+	initCommandLogger()
+
+	cacheDir := expandPath(defaultCacheDir)
+	fileCache, err := cache.NewFileCache(cacheDir)
+	if err != nil {
+		return newCommandError(ErrorCodeIO, err)
+	}
+	stats, err := fileCache.CollectStats()
+	if err != nil {
+		return newCommandError(ErrorCodeIO, err)
+	}
+
+	if cacheStatsOutput == "json" {
+		if err := writeCacheStatsJSON(cmd.OutOrStdout(), cacheDir, stats); err != nil {
+			return newCommandError(ErrorCodeIO, err)
+		}
+		return nil
+	}
+	printCacheStats(cmd.OutOrStdout(), cacheDir, stats, time.Local)
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	if cachePruneOlderThan == "" && cachePruneMaxSize == "" {
+		return newCommandError(ErrorCodeInvalidArgument,
+			fmt.Errorf("at least one of --older-than or --max-size is required"))
+	}
+	var opts cache.PruneOptions
+	if cachePruneOlderThan != "" {
+		age, err := analyzer.ParseDurationSpan(cachePruneOlderThan)
+		if err != nil {
+			return newCommandError(ErrorCodeInvalidArgument, err)
+		}
+		opts.OlderThan = age
+	}
+	if cachePruneMaxSize != "" {
+		size, err := parseByteSize(cachePruneMaxSize)
+		if err != nil {
+			return newCommandError(ErrorCodeInvalidArgument, err)
+		}
+		opts.MaxBytes = size
+	}
+
+	initCommandLogger()
+
+	fileCache, err := cache.NewFileCache(expandPath(defaultCacheDir))
+	if err != nil {
+		return newCommandError(ErrorCodeIO, err)
+	}
+	opts.Keep = activeSessionIDs(expandPath(dataDir), time.Now())
+	result, err := fileCache.Prune(opts)
+	if err != nil {
+		return newCommandError(ErrorCodeIO, err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cache entries, freed %s\n",
+		len(result.Removed), formatByteSize(result.FreedBytes))
+	return nil
+}
+
+// activeSessionIDs returns the session IDs of the logs in dataDir written
+// within a session window of now, whose sessions a running top still tracks
+func activeSessionIDs(dataDir string, now time.Time) map[string]bool {
+	files, err := scanner.NewFileScanner(dataDir).Scan()
+	if err != nil {
+		util.LogDebug(fmt.Sprintf("Failed to scan %s for active sessions: %v", dataDir, err))
+	}
+	cutoff := now.Add(-constants.SessionDuration).Unix()
+	active := make(map[string]bool)
+	for _, file := range files {
+		if info, err := util.GetFileInfo(file); err == nil && info.ModTime >= cutoff {
+			active[scanner.SessionID(file)] = true
+		}
+	}
+	return active
+}
+
+// parseByteSize parses a size such as 512, 100MB or 1.5GiB into bytes
+func parseByteSize(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	split := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := trimmed, ""
+	if split >= 0 {
+		number, unit = trimmed[:split], strings.TrimSpace(trimmed[split:])
+	}
+
+	multiplier, ok := byteSizeUnits[strings.ToUpper(unit)]
+	n, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size '%s': use a positive number with B, KB, MB, GB, KiB, MiB or GiB", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// cacheStatsReport is the JSON form of cache stats
+type cacheStatsReport struct {
+	CacheDir   string         `json:"cache_dir"`
+	Entries    int            `json:"entries"`
+	TotalBytes int64          `json:"total_bytes"`
+	Oldest     *time.Time     `json:"oldest,omitempty"`
+	Newest     *time.Time     `json:"newest,omitempty"`
+	Valid      int            `json:"valid"`
+	Misses     map[string]int `json:"misses"`
+}
+
+// writeCacheStatsJSON writes stats of the cache in cacheDir to w as JSON.
+// Every reason in cacheStatsReasons is present in misses, zero or not.
+func writeCacheStatsJSON(w io.Writer, cacheDir string, stats *cache.Stats) error {
+	report := cacheStatsReport{
+		CacheDir:   cacheDir,
+		Entries:    stats.Entries,
+		TotalBytes: stats.TotalBytes,
+		Valid:      stats.Valid,
+		Misses:     make(map[string]int),
+	}
+	if !stats.Oldest.IsZero() {
+		oldest, newest := stats.Oldest.UTC(), stats.Newest.UTC()
+		report.Oldest, report.Newest = &oldest, &newest
+	}
+	for _, reason := range cacheStatsReasons {
+		report.Misses[reason.String()] = 0
+	}
+	for reason, count := range stats.Misses {
+		report.Misses[reason.String()] = count
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write cache stats: %w", err)
+	}
+	return nil
+}
+
+// printCacheStats writes stats of the cache in cacheDir to out as text
+func printCacheStats(out io.Writer, cacheDir string, stats *cache.Stats, loc *time.Location) {
+	const layout = "2006-01-02 15:04:05"
+
+	fmt.Fprintf(out, "Cache directory: %s\n", cacheDir)
+	fmt.Fprintf(out, "Cached sessions: %d\n", stats.Entries)
+	fmt.Fprintf(out, "Size on disk:    %s\n", formatByteSize(stats.TotalBytes))
+	if stats.Entries == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Oldest entry:    %s\n", stats.Oldest.In(loc).Format(layout))
+	fmt.Fprintf(out, "Newest entry:    %s\n", stats.Newest.In(loc).Format(layout))
+	fmt.Fprintf(out, "Valid entries:   %d\n", stats.Valid)
+
+	fmt.Fprintln(out, "\nInvalidated entries by reason:")
+	listed := make(map[cache.CacheMissReason]bool)
+	for _, reason := range cacheStatsReasons {
+		fmt.Fprintf(out, "  %-15s %d\n", reason.String(), stats.Misses[reason])
+		listed[reason] = true
+	}
+	for reason, count := range stats.Misses {
+		if !listed[reason] {
+			fmt.Fprintf(out, "  %-15s %d\n", reason.String(), count)
+		}
+	}
+}
+
+// formatByteSize formats n bytes with a binary unit
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
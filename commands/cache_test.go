@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/data/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// populatedCacheStats caches two logs and edits one of them in place, keeping
+// its size and modtime so only its fingerprint differs
+func populatedCacheStats(t *testing.T) (string, *cache.Stats) {
+	cacheDir := t.TempDir()
+	sourceDir := t.TempDir()
+	fileCache, err := cache.NewFileCache(cacheDir)
+	require.NoError(t, err)
+
+	for _, id := range []string{"intact", "edited"} {
+		path := filepath.Join(sourceDir, id+".jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(`{"test": "aaaa"}`), 0644))
+		require.NoError(t, fileCache.Set(id, &aggregator.AggregatedData{FilePath: path}))
+	}
+	edited := filepath.Join(sourceDir, "edited.jsonl")
+	info, err := os.Stat(edited)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(edited, []byte(`{"test": "bbbb"}`), 0644))
+	require.NoError(t, os.Chtimes(edited, info.ModTime(), info.ModTime()))
+
+	stats, err := fileCache.CollectStats()
+	require.NoError(t, err)
+	return cacheDir, stats
+}
+
+func TestPrintCacheStats(t *testing.T) {
+	cacheDir, stats := populatedCacheStats(t)
+
+	var out bytes.Buffer
+	printCacheStats(&out, cacheDir, stats, time.UTC)
+	assert.Contains(t, out.String(), "Cache directory: "+cacheDir)
+	assert.Contains(t, out.String(), "Cached sessions: 2")
+	assert.Contains(t, out.String(), "Valid entries:   1")
+	assert.Regexp(t, `fingerprint +1\n`, out.String())
+	assert.Regexp(t, `inode +0\n`, out.String())
+
+	out.Reset()
+	printCacheStats(&out, cacheDir, &cache.Stats{}, time.UTC)
+	assert.Contains(t, out.String(), "Cached sessions: 0")
+	assert.NotContains(t, out.String(), "Oldest entry")
+}
+
+func TestWriteCacheStatsJSON(t *testing.T) {
+	cacheDir, stats := populatedCacheStats(t)
+
+	var out bytes.Buffer
+	require.NoError(t, writeCacheStatsJSON(&out, cacheDir, stats))
+
+	var report cacheStatsReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, cacheDir, report.CacheDir)
+	assert.Equal(t, 2, report.Entries)
+	assert.Equal(t, 1, report.Valid)
+	assert.Equal(t, stats.TotalBytes, report.TotalBytes)
+	require.NotNil(t, report.Oldest)
+	require.NotNil(t, report.Newest)
+	assert.Equal(t, map[string]int{
+		"schema":         0,
+		"inode":          0,
+		"size":           0,
+		"modtime":        0,
+		"fingerprint":    1,
+		"no_fingerprint": 0,
+		"error":          0,
+	}, report.Misses)
+}
+
+func TestFormatByteSize(t *testing.T) {
+	assert.Equal(t, "0 B", formatByteSize(0))
+	assert.Equal(t, "1023 B", formatByteSize(1023))
+	assert.Equal(t, "1.0 KiB", formatByteSize(1024))
+	assert.Equal(t, "1.5 MiB", formatByteSize(3*512*1024))
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"100MB", 100 * 1000 * 1000},
+		{"100mb", 100 * 1000 * 1000},
+		{"1.5 GiB", 3 << 29},
+		{"64KiB", 64 << 10},
+	}
+	for _, tt := range tests {
+		size, err := parseByteSize(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, size, tt.value)
+	}
+
+	for _, value := range []string{"", "MB", "-1MB", "0", "10TB", "1.2.3KB"} {
+		_, err := parseByteSize(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestActiveSessionIDs(t *testing.T) {
+	dataDir := t.TempDir()
+	now := time.Now()
+	for name, modTime := range map[string]time.Time{
+		"recent": now.Add(-time.Hour),
+		"old":    now.Add(-6 * time.Hour),
+	} {
+		path := filepath.Join(dataDir, "app", name+".jsonl")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	assert.Equal(t, map[string]bool{"recent": true}, activeSessionIDs(dataDir, now))
+	assert.Empty(t, activeSessionIDs(filepath.Join(dataDir, "missing"), now))
+}
@@ -1,31 +1,71 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/application/top"
+	"github.com/penwyp/go-claude-monitor/internal/core/constants"
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
 	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	datacache "github.com/penwyp/go-claude-monitor/internal/data/cache"
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Detect command flags
-	detectDataDir        string
-	detectPlan           string
-	detectTimezone       string
-	detectPricingSource  string
-	detectPricingOffline bool
-	detectResetWindows   bool
+	detectDataDir           string
+	detectPlan              string
+	detectTimezone          string
+	detectTimeFormat        string
+	detectPricingSource     string
+	detectPricingOffline    bool
+	detectCacheReadDiscount float64
+	detectPricingFile       string
+	detectPricingMaxAge     time.Duration
+	detectCurrency          string
+	detectExchangeRate      float64
+	detectResetWindows      bool
+	detectNoFutureWindows   bool
+	detectNoSpeculative     bool
+	detectMinGap            time.Duration
+	detectSessionGap        time.Duration
+	detectSessionDuration   time.Duration
+	detectDualTime          bool
+	detectWindowAnchor      string
+	detectWindowAnchorTZ    string
+	detectUTCWindows        bool
+	detectQuiet             bool
+	detectActivitySessions  bool
+	detectDotFile           string
+	detectAllocate          bool
+	detectDryRun            bool
+	detectCacheCompress     bool
+	detectExplain           bool
+	detectTokenThreshold    float64
+	detectStrictTokens      bool
+	detectJSON              bool
+	detectAssertWindows     string
+	detectBillingFile       string
+	detectPrometheus        bool
 )
 
+// detectProgressInterval is the number of files between parsing progress lines
+const detectProgressInterval = 100
+
 var detectCmd = &cobra.Command{
 	Use:    "detect",
 	Short:  "Debug command to analyze sessions and print results",
@@ -48,16 +88,73 @@ func init() {
 	// Display flags
 	detectCmd.Flags().StringVar(&detectTimezone, "timezone", "Local",
 		"Timezone setting (e.g., Asia/Shanghai, UTC)")
+	detectCmd.Flags().StringVar(&detectTimeFormat, "time-format", "24h",
+		"Time format: 12h, 24h, iso or a Go time layout such as '15:04'")
+
+	detectCmd.Flags().BoolVar(&detectDualTime, "dual-time", false,
+		"Show window start, end and reset times in both local time and UTC")
+	detectCmd.Flags().BoolVar(&detectQuiet, "quiet", false,
+		"Suppress progress output on stderr")
 
 	// Pricing flags
 	detectCmd.Flags().StringVar(&detectPricingSource, "pricing-source", "default",
 		"Pricing source (default, litellm)")
 	detectCmd.Flags().BoolVar(&detectPricingOffline, "pricing-offline", false,
 		"Use offline pricing mode")
+	detectCmd.Flags().Float64Var(&detectCacheReadDiscount, "cache-read-discount", 1,
+		"Multiplier applied to the cache-read rate (0-1, 1 = list price)")
+	detectCmd.Flags().StringVar(&detectPricingFile, "pricing-file", "",
+		"JSON file of per-model rates overriding the pricing source")
+	detectCmd.Flags().DurationVar(&detectPricingMaxAge, "pricing-max-age", pricing.DefaultPricingMaxAge,
+		"Reuse cached pricing of a remote --pricing-source until it is this old")
+	detectCmd.Flags().StringVar(&detectCurrency, "currency", "USD",
+		"Currency costs are displayed in by the report (e.g. EUR, GBP, JPY)")
+	detectCmd.Flags().Float64Var(&detectExchangeRate, "exchange-rate", 0,
+		"Units of --currency per USD (0 = built-in approximate rate)")
 	
 	// Window history flags
 	detectCmd.Flags().BoolVar(&detectResetWindows, "reset-windows", false,
 		"Reset window history before analysis")
+	detectCmd.Flags().BoolVar(&detectNoFutureWindows, "no-future-windows", false,
+		"Suppress sessions whose window lies entirely in the future with no activity")
+	detectCmd.Flags().BoolVar(&detectNoSpeculative, "no-speculative-active", false,
+		"Do not create an active window for the current period when it has no logs")
+	detectCmd.Flags().DurationVar(&detectSessionDuration, "session-duration", constants.SessionDuration,
+		"Length of a session window, for plans with a different reset cadence (1h-24h)")
+	detectCmd.Flags().DurationVar(&detectMinGap, "min-gap", 0,
+		"Minimum idle period shown as a gap session (0 = --session-gap)")
+	detectCmd.Flags().DurationVar(&detectSessionGap, "session-gap", 0,
+		"Idle period after which activity starts a new window (0 = session duration)")
+	detectCmd.Flags().StringVar(&detectWindowAnchor, "window-anchor", "",
+		"Align continuous activity windows to a fixed time of day (HH:MM)")
+	detectCmd.Flags().StringVar(&detectWindowAnchorTZ, "window-anchor-timezone", "",
+		"Compute window boundaries and read --window-anchor in this timezone, e.g. that of the server reset (default --timezone)")
+	detectCmd.Flags().BoolVar(&detectUTCWindows, "utc-windows", false,
+		"Alias for --window-anchor-timezone UTC; --timezone then only affects display")
+	detectCmd.Flags().BoolVar(&detectActivitySessions, "activity-sessions", false,
+		"Report contiguous activity as single sessions instead of 5-hour windows")
+	detectCmd.Flags().StringVar(&detectDotFile, "dot", "",
+		"Write the window candidates and selection as a Graphviz DOT graph to this file")
+	detectCmd.Flags().BoolVar(&detectAllocate, "allocate", false,
+		"Report each project's share of the cost of every account-level window")
+	detectCmd.Flags().BoolVar(&detectDryRun, "dry-run", false,
+		"Report how many files would be parsed or served from cache, then exit")
+	detectCmd.Flags().BoolVar(&detectCacheCompress, "cache-compress", false,
+		"Write cache entries gzip-compressed (existing entries are read either way)")
+	detectCmd.Flags().BoolVar(&detectExplain, "explain", false,
+		"Explain how each window's boundaries were chosen")
+	detectCmd.Flags().Float64Var(&detectTokenThreshold, "token-mismatch-threshold", session.DefaultTokenMismatchThreshold,
+		"Percentage difference between session and timeline tokens reported as a mismatch")
+	detectCmd.Flags().BoolVar(&detectStrictTokens, "strict-tokens", false,
+		"Exit with an error when the token mismatch exceeds --token-mismatch-threshold")
+	detectCmd.Flags().BoolVar(&detectJSON, "json", false,
+		"Print only the summary, including active and idle time, as JSON")
+	detectCmd.Flags().StringVar(&detectAssertWindows, "assert-windows", "",
+		"Compare the detected windows with a JSON spec of expected windows and fail with a diff on mismatch")
+	detectCmd.Flags().StringVar(&detectBillingFile, "billing", "",
+		"Write a daily billing CSV to this file, prorating windows that span midnight by their hourly cost")
+	detectCmd.Flags().BoolVar(&detectPrometheus, "prometheus", false,
+		"Print per-window metrics in the Prometheus text exposition format")
 
 }
 
@@ -74,17 +171,17 @@ func runDetect(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	// Handle debug mode (inherited from root command)
-	logLevel := "info"
-	if debug {
-		logLevel = "debug"
+	initCommandLogger()
+	if err := util.InitializeTimeProvider(detectTimezone); err != nil {
+		return newCommandError(ErrorCodeInvalidTimezone, err)
+	}
+	if err := util.ValidateTimeFormat(detectTimeFormat); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	windowTZ, err := windowAnchorTimezone(detectWindowAnchorTZ, detectUTCWindows)
+	if err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
 	}
-
-	// Initialize logging (reuse root logic)
-	logFile := expandPath(defaultLogFile)
-	ensureDir(filepath.Dir(logFile))
-	util.InitLogger(logLevel, logFile, debug)
-	util.InitializeTimeProvider(detectTimezone)
 	
 	// Create configuration first
 	config := &top.TopConfig{
@@ -92,12 +189,52 @@ func runDetect(cmd *cobra.Command, args []string) error {
 		CacheDir:            expandPath(defaultCacheDir),
 		Plan:                detectPlan,
 		Timezone:            detectTimezone,
-		TimeFormat:          "24h",            // Always use 24h for detect
+		TimeFormat:          detectTimeFormat,
 		DataRefreshInterval: 10 * time.Second, // Not used in detect
 		UIRefreshRate:       1.0,              // Not used in detect
 		Concurrency:         runtime.NumCPU(),
+		CacheCompress:       detectCacheCompress,
+		InputFormat:         inputFormat,
+		Projects:            includeProjects,
+		ExcludeProjects:     excludeProjects,
 		PricingSource:       detectPricingSource,
 		PricingOfflineMode:  detectPricingOffline,
+		CacheReadDiscount:   detectCacheReadDiscount,
+		PricingMaxAge:       detectPricingMaxAge,
+		NoFutureWindows:     detectNoFutureWindows,
+		NoSpeculativeActive: detectNoSpeculative,
+		SessionDuration:     detectSessionDuration,
+		MinGapDuration:      detectMinGap,
+		SessionGap:          detectSessionGap,
+		WindowAnchor:        detectWindowAnchor,
+		WindowAnchorTZ:      windowTZ,
+	}
+
+	if err := config.Validate(); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	pricingOverrides, err := loadPricingOverrides(detectPricingFile)
+	if err != nil {
+		return err
+	}
+	config.PricingOverrides = pricingOverrides
+	if err := applyDisplayCurrency(detectCurrency, detectExchangeRate); err != nil {
+		return err
+	}
+	if detectJSON && detectPrometheus {
+		return newCommandError(ErrorCodeInvalidArgument, errors.New("--json cannot be combined with --prometheus"))
+	}
+	if detectTokenThreshold < 0 {
+		return newCommandError(ErrorCodeInvalidArgument,
+			fmt.Errorf("token mismatch threshold %g must not be negative", detectTokenThreshold))
+	}
+	var expectedWindows []windowSpec
+	if detectAssertWindows != "" {
+		specs, err := loadWindowSpec(expandPath(detectAssertWindows))
+		if err != nil {
+			return err
+		}
+		expectedWindows = specs
 	}
 
 	// Create orchestrator
@@ -105,6 +242,17 @@ func runDetect(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create orchestrator: %w", err)
 	}
+	orchestrator.GetDetector().SetTokenMismatchThreshold(detectTokenThreshold)
+	if !detectQuiet {
+		orchestrator.SetProgressFunc(newDetectProgress(os.Stderr))
+	}
+	if detectDryRun {
+		return runDryRun(orchestrator)
+	}
+
+	// Ctrl-C stops loading; files parsed until then stay cached
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	// Handle window history reset if requested
 	if detectResetWindows {
@@ -114,7 +262,7 @@ func runDetect(cmd *cobra.Command, args []string) error {
 		
 		// First pass: rebuild window history from data
 		fmt.Println("Rebuilding window history from data...")
-		_, err := orchestrator.LoadAndAnalyzeData()
+		_, err := orchestrator.LoadAndAnalyzeData(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to rebuild window history: %w", err)
 		}
@@ -133,28 +281,94 @@ func runDetect(cmd *cobra.Command, args []string) error {
 
 	// Load and analyze data
 	planLimit := pricing.GetPlan(detectPlan)
-	fmt.Println(util.FormatSectionSeparator())
-	fmt.Println(util.FormatHeaderTitle("=== Claude Monitor Session Detection ==="))
-	fmt.Printf("Timestamp: %s\n", util.GetTimeProvider().Now().Format("2006-01-02 15:04:05 MST"))
-	fmt.Printf("Data Directory: %s\n", config.DataDir)
-	fmt.Printf("Plan: %s, Cost Limit: %v, Token Limit:%v\n", detectPlan, planLimit.CostLimit, util.FormatNumber(planLimit.TokenLimit))
-	fmt.Println(util.FormatSectionSeparator())
+	if !detectJSON && !detectPrometheus && detectAssertWindows == "" {
+		fmt.Println(util.FormatSectionSeparator())
+		fmt.Println(util.FormatHeaderTitle("=== Claude Monitor Session Detection ==="))
+		fmt.Printf("Timestamp: %s\n", formatDetectTime(util.GetTimeProvider().Now().Unix()))
+		fmt.Printf("Data Directory: %s\n", config.DataDir)
+		fmt.Printf("Plan: %s, Cost Limit: %v, Token Limit:%v\n", detectPlan, planLimit.CostLimit, util.FormatNumber(planLimit.TokenLimit))
+		fmt.Println(util.FormatSectionSeparator())
+	}
 
 	// Load and analyze sessions (second pass if reset, first pass if not)
-	sessions, err := orchestrator.LoadAndAnalyzeData()
+	sessions, err := orchestrator.LoadAndAnalyzeData(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load and analyze data: %w", err)
 	}
 
+	// Regression check of the detected windows only, without the report
+	if detectAssertWindows != "" {
+		return assertWindows(os.Stdout, detectAssertWindows, expectedWindows, sessions, util.GetTimeProvider().Now().Location())
+	}
+
+	utilization := session.CalculateUtilization(sessions, time.Now().Unix())
+
+	if detectBillingFile != "" {
+		if err := writeBillingCSV(expandPath(detectBillingFile), sessions, util.GetTimeProvider().Now().Location()); err != nil {
+			return newCommandError(ErrorCodeIO, err)
+		}
+		if !detectJSON && !detectPrometheus {
+			fmt.Printf("Daily billing written to %s\n", detectBillingFile)
+		}
+	}
+
+	if detectPrometheus {
+		if err := formatter.WritePrometheus(os.Stdout, sessions); err != nil {
+			return newCommandError(ErrorCodeIO, err)
+		}
+		if detectStrictTokens {
+			return strictTokensError(orchestrator.GetDetector().GetTokenDiscrepancy(), detectTokenThreshold)
+		}
+		return nil
+	}
+
+	if detectJSON {
+		if err := writeDetectSummaryJSON(os.Stdout, sessions, utilization, detectReportMeta()); err != nil {
+			return newCommandError(ErrorCodeIO, err)
+		}
+		if detectStrictTokens {
+			return strictTokensError(orchestrator.GetDetector().GetTokenDiscrepancy(), detectTokenThreshold)
+		}
+		return nil
+	}
+
+	// Summaries and statistics of a period without usage are all zero, say so instead
+	if msg := noUsageMessage(sessions); msg != "" {
+		fmt.Println(msg)
+		// --dot and --allocate still report, so their outputs are never silently missing
+		if err := writeDetectDOT(orchestrator.GetDetector()); err != nil {
+			return err
+		}
+		fmt.Println(util.FormatSectionSeparator())
+		if detectAllocate {
+			printCostAllocation(nil)
+			fmt.Println(util.FormatSectionSeparator())
+		}
+		if detectStrictTokens {
+			return strictTokensError(orchestrator.GetDetector().GetTokenDiscrepancy(), detectTokenThreshold)
+		}
+		return nil
+	}
+
 	// Get aggregated metrics
 	aggregated := orchestrator.GetAggregatedMetrics(sessions)
 
 	// Print window detection analysis
 	printWindowAnalysis(sessions)
+	if detectNoFutureWindows {
+		fmt.Printf("Suppressed Future Windows: %d\n", orchestrator.GetDetector().GetSuppressedFutureWindowCount())
+	}
+	discrepancy := orchestrator.GetDetector().GetTokenDiscrepancy()
+	if discrepancy != nil {
+		printTokenDiscrepancy(discrepancy)
+	}
+	if err := writeDetectDOT(orchestrator.GetDetector()); err != nil {
+		return err
+	}
 	fmt.Println(util.FormatSectionSeparator())
 
 	// Print results
-	printSummary(aggregated, len(sessions))
+	printSummary(aggregated, len(sessions), utilization)
 	fmt.Println(util.FormatSectionSeparator())
 
 	// Limit sessions to display only the last 5
@@ -164,14 +378,114 @@ func runDetect(cmd *cobra.Command, args []string) error {
 		sessionsToDisplay = sessions[:5] // Sessions are already sorted with most recent first
 	}
 	
-	printSessions(sessionsToDisplay, aggregated, config.Timezone, config.TimeFormat, totalSessions)
+	if detectActivitySessions {
+		printActivitySessions(orchestrator.GetDetector().GroupActivitySessions(sessions))
+	} else {
+		printSessions(sessionsToDisplay, aggregated, config.Timezone, config.TimeFormat, totalSessions)
+	}
 	fmt.Println(util.FormatSectionSeparator())
+	if detectAllocate {
+		printCostAllocation(session.AllocateWindowCosts(sessions))
+		fmt.Println(util.FormatSectionSeparator())
+	}
 	printModelStatistics(aggregated)
 	fmt.Println(util.FormatSectionSeparator())
 
+	if detectStrictTokens {
+		return strictTokensError(discrepancy, detectTokenThreshold)
+	}
+	return nil
+}
+
+// runDryRun prints the orchestrator's dry-run report
+func runDryRun(orchestrator *top.Orchestrator) error {
+	report, err := orchestrator.DryRun()
+	if err != nil {
+		return fmt.Errorf("dry run failed: %w", err)
+	}
+	printDryRunReport(report)
+	return nil
+}
+
+// printDryRunReport prints file and cache counts, most common miss reason first
+func printDryRunReport(report *top.DryRunReport) {
+	fmt.Printf("Total files:    %d\n", report.TotalFiles)
+	fmt.Printf("Cache hits:     %d\n", report.CacheHits)
+	fmt.Printf("Files to parse: %d\n", report.ToParse)
+	if len(report.MissReasons) == 0 {
+		return
+	}
+
+	reasons := make([]datacache.CacheMissReason, 0, len(report.MissReasons))
+	for reason := range report.MissReasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if report.MissReasons[reasons[i]] != report.MissReasons[reasons[j]] {
+			return report.MissReasons[reasons[i]] > report.MissReasons[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	fmt.Println("Miss reasons:")
+	for _, reason := range reasons {
+		fmt.Printf("  %-15s %d\n", reason, report.MissReasons[reason])
+	}
+}
+
+// writeDetectDOT writes the --dot window selection graph when requested
+func writeDetectDOT(detector *session.SessionDetector) error {
+	if detectDotFile == "" {
+		return nil
+	}
+	if err := writeWindowDOT(detector, expandPath(detectDotFile)); err != nil {
+		return newCommandError(ErrorCodeIO, err)
+	}
+	fmt.Printf("Window selection graph written to %s\n", detectDotFile)
 	return nil
 }
 
+// writeWindowDOT writes the detector's last window selection as DOT to path
+func writeWindowDOT(detector *session.SessionDetector, path string) error {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create DOT output directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create DOT file: %w", err)
+	}
+	defer file.Close()
+
+	if err := detector.WriteWindowSelectionDOT(file); err != nil {
+		return fmt.Errorf("failed to write DOT file: %w", err)
+	}
+	return file.Close()
+}
+
+// newDetectProgress returns a progress callback that writes status lines to w,
+// keeping stdout free for the analysis itself
+func newDetectProgress(w io.Writer) top.ProgressFunc {
+	var start time.Time
+	return func(stage string, done, total int) {
+		switch stage {
+		case top.ProgressStageParsing:
+			if start.IsZero() {
+				start = time.Now()
+			}
+			if total == 0 || (done%detectProgressInterval != 0 && done != total) {
+				return
+			}
+			if done > 0 && done < total {
+				eta := time.Since(start) / time.Duration(done) * time.Duration(total-done)
+				fmt.Fprintf(w, "Parsed %d/%d files (ETA %s)\n", done, total, eta.Round(time.Second))
+			} else {
+				fmt.Fprintf(w, "Parsed %d/%d files\n", done, total)
+			}
+		case top.ProgressStageDetecting:
+			fmt.Fprintln(w, "Detecting sessions...")
+		}
+	}
+}
+
 // getWindowIcon returns an icon based on the window detection source
 func getWindowIcon(source string) string {
 	switch source {
@@ -253,6 +567,50 @@ func printWindowAnalysis(sessions []*session.Session) {
 	printWindowHistoryStats()
 }
 
+// printWindowExplanation describes where the session's window boundaries came
+// from, including any adjustment made by the window history
+func printWindowExplanation(sess *session.Session) {
+	if !sess.AdjustedByHistory {
+		fmt.Printf("    Explanation: boundaries used as detected from %s\n", sess.WindowSource)
+		return
+	}
+	fmt.Printf("    Explanation: boundaries adjusted by window history to avoid a recorded window\n")
+	fmt.Printf("      Detected: %s - %s\n", formatDetectTime(sess.OriginalStartTime), formatDetectTime(sess.OriginalEndTime))
+	fmt.Printf("      Shown:    %s - %s\n", formatDetectTime(sess.StartTime), formatDetectTime(sess.EndTime))
+}
+
+// printTokenDiscrepancy displays which windows share tokens when session and
+// timeline totals disagree
+func printTokenDiscrepancy(discrepancy *session.TokenDiscrepancy) {
+	fmt.Printf("\n⚠️  Token Mismatch: %+.1f%% (Sessions=%s, Timeline=%s, Delta=%+d)\n",
+		discrepancy.Percentage,
+		util.FormatNumber(int(discrepancy.SessionTokens)),
+		util.FormatNumber(int(discrepancy.TimelineTokens)),
+		discrepancy.DeltaTokens)
+	fmt.Printf("  Double-counted: %s tokens, Unassigned: %s tokens\n",
+		util.FormatNumber(int(discrepancy.DoubleCounted)),
+		util.FormatNumber(int(discrepancy.UnassignedTokens)))
+	for _, overlap := range discrepancy.Overlaps {
+		fmt.Printf("  Window %s - %s (%s): %s tokens shared with %s\n",
+			time.Unix(overlap.StartTime, 0).Format("01-02 15:04"),
+			time.Unix(overlap.EndTime, 0).Format("01-02 15:04"),
+			overlap.Source,
+			util.FormatNumber(overlap.OverlapTokens),
+			strings.Join(overlap.OverlapsWith, ", "))
+	}
+}
+
+// strictTokensError returns the --strict-tokens failure for a token mismatch
+// above the threshold, or nil when detection found none
+func strictTokensError(discrepancy *session.TokenDiscrepancy, threshold float64) error {
+	if discrepancy == nil {
+		return nil
+	}
+	return newCommandError(ErrorCodeTokenDiscrepancy,
+		fmt.Errorf("token mismatch of %.1f%% exceeds threshold of %g%% (double-counted=%d, unassigned=%d)",
+			discrepancy.Percentage, threshold, discrepancy.DoubleCounted, discrepancy.UnassignedTokens))
+}
+
 // printWindowHistoryStats displays window history statistics
 func printWindowHistoryStats() {
 	// Get home directory for display
@@ -277,6 +635,102 @@ func printWindowHistoryStats() {
 	}
 }
 
+// noUsageMessage explains that the analyzed period holds no usage, or returns
+// "" when at least one session has usage
+func noUsageMessage(sessions []*session.Session) string {
+	if len(sessions) == 0 {
+		return "No sessions found: there is no usage in the analyzed period"
+	}
+	if !session.OnlyGaps(sessions) {
+		return ""
+	}
+
+	var idle time.Duration
+	for _, sess := range sessions {
+		idle += time.Unix(sess.EndTime, 0).Sub(time.Unix(sess.StartTime, 0))
+	}
+	return fmt.Sprintf("No usage in the analyzed period: only %d idle gap(s) totalling %s were found",
+		len(sessions), util.FormatDuration(idle))
+}
+
+// detectSummary is the summary printed by detect --json
+type detectSummary struct {
+	TotalSessions  int     `json:"total_sessions"`
+	ActiveSessions int     `json:"active_sessions"`
+	GapSessions    int     `json:"gap_sessions"`
+	TotalTokens    int     `json:"total_tokens"`
+	TotalCost      float64 `json:"total_cost"`
+	TotalMessages  int     `json:"total_messages"`
+	ActiveSeconds  int64   `json:"active_seconds"`
+	IdleSeconds    int64   `json:"idle_seconds"`
+	Utilization    float64 `json:"utilization"` // Active share of active plus idle time, 0-1
+
+	// Plan limit usage of the active window; omitted without one or without limits
+	LimitPercentage float64 `json:"limit_percentage,omitempty"`
+	LimitBound      string  `json:"limit_bound,omitempty"`
+
+	Meta *formatter.Meta `json:"meta,omitempty"`
+}
+
+// writeDetectSummaryJSON writes the usage totals of all sessions together
+// with their active and idle time and the meta block describing the run
+func writeDetectSummaryJSON(w io.Writer, sessions []*session.Session, utilization session.Utilization, meta *formatter.Meta) error {
+	summary := detectSummary{
+		Meta:          meta,
+		TotalSessions: len(sessions),
+		GapSessions:   countGaps(sessions),
+		ActiveSeconds: int64(utilization.ActiveTime.Seconds()),
+		IdleSeconds:   int64(utilization.IdleTime.Seconds()),
+		Utilization:   utilization.Ratio(),
+	}
+	for _, sess := range sessions {
+		if sess.IsActive {
+			summary.ActiveSessions++
+			if sess.LimitBound != "" && sess.LimitPercentage >= summary.LimitPercentage {
+				summary.LimitPercentage = sess.LimitPercentage
+				summary.LimitBound = sess.LimitBound
+			}
+		}
+		summary.TotalTokens += sess.TotalTokens
+		summary.TotalCost += sess.TotalCost
+		summary.TotalMessages += sess.MessageCount
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summary); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// detectReportMeta returns the JSON meta block of the detect flags
+func detectReportMeta() *formatter.Meta {
+	return newReportMeta(formatter.MetaConfig{
+		Plan:              detectPlan,
+		PricingSource:     detectPricingSource,
+		PricingOffline:    detectPricingOffline,
+		CacheReadDiscount: detectCacheReadDiscount,
+		PricingFile:       detectPricingFile,
+		Currency:          util.DisplayCurrency().Code,
+		ExchangeRate:      util.DisplayCurrency().Rate,
+		Timezone:          detectTimezone,
+		SessionDuration:   detectSessionDuration.String(),
+		TimelineMode:      detectTimelineMode(),
+		Projects:          includeProjects,
+		ExcludeProjects:   excludeProjects,
+	})
+}
+
+// detectTimelineMode names how detect forms sessions from the log timeline:
+// fixed-length windows, or contiguous activity with --activity-sessions
+func detectTimelineMode() string {
+	if detectActivitySessions {
+		return "activity"
+	}
+	return "windows"
+}
+
 // countGaps counts the number of gap sessions
 func countGaps(sessions []*session.Session) int {
 	count := 0
@@ -288,7 +742,7 @@ func countGaps(sessions []*session.Session) int {
 	return count
 }
 
-func printSummary(aggregated *model.AggregatedMetrics, sessionCount int) {
+func printSummary(aggregated *model.AggregatedMetrics, sessionCount int, utilization session.Utilization) {
 	activeSessions := 0
 	for i := 0; i < sessionCount; i++ {
 		// Count active sessions (this is simplified, would need actual session data)
@@ -301,6 +755,9 @@ func printSummary(aggregated *model.AggregatedMetrics, sessionCount int) {
 	fmt.Printf("Total Cost: %s\n", util.FormatCurrency(aggregated.TotalCost))
 	fmt.Printf("Total Tokens: %s\n", util.FormatNumber(aggregated.TotalTokens))
 	fmt.Printf("Total Messages: %d\n", aggregated.TotalMessages)
+	fmt.Printf("Active Time: %s\n", util.FormatDuration(utilization.ActiveTime))
+	fmt.Printf("Idle Time: %s\n", util.FormatDuration(utilization.IdleTime))
+	fmt.Printf("Utilization: %.1f%%\n", utilization.Ratio()*100)
 
 	if aggregated.AverageBurnRate > 0 {
 		fmt.Printf("Average Burn Rate: %s\n", util.FormatBurnRate(aggregated.AverageBurnRate))
@@ -339,7 +796,7 @@ func printSummary(aggregated *model.AggregatedMetrics, sessionCount int) {
 	if aggregated.PredictedEndTime > 0 && aggregated.HasActiveSession {
 		predictedEnd := time.Unix(aggregated.PredictedEndTime, 0)
 		timeToPredictedEnd := predictedEnd.Sub(time.Now())
-		fmt.Printf("\nPredicted End Time: %s", predictedEnd.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("\nPredicted End Time: %s", formatDetectTime(aggregated.PredictedEndTime))
 		if timeToPredictedEnd > 0 {
 			fmt.Printf(" (in %s)\n", util.FormatDuration(timeToPredictedEnd))
 		} else {
@@ -348,6 +805,16 @@ func printSummary(aggregated *model.AggregatedMetrics, sessionCount int) {
 	}
 }
 
+// formatDetectTime formats a session timestamp in --time-format, pairing it
+// with UTC in --dual-time mode
+func formatDetectTime(timestamp int64) string {
+	t := time.Unix(timestamp, 0)
+	if detectDualTime {
+		return util.FormatDualTime(util.GetTimeProvider().In(t))
+	}
+	return t.Format(util.DateTimeLayout(detectTimeFormat, true))
+}
+
 func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetrics, timezone, timeFormat string, totalSessions int) {
 	fmt.Println(util.FormatDataTitle("=== Sessions ==="))
 	
@@ -367,30 +834,34 @@ func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetr
 		fmt.Printf("Session #%d [%s]\n", i+1, status)
 		fmt.Printf("  ID: %s\n", sess.ID)
 		fmt.Printf("  Project: %s\n", sess.ProjectName)
+		if sess.IsAccountLevel() {
+			fmt.Printf("  Scope: account (%d projects)\n", len(sess.Projects))
+		} else if !sess.IsGap {
+			fmt.Printf("  Scope: project\n")
+		}
 
 		startTime := time.Unix(sess.StartTime, 0)
-		startHour := time.Unix(sess.StartHour, 0)
 		endTime := time.Unix(sess.EndTime, 0)
-		fmt.Printf("  Start: %s\n", startTime.Format("2006-01-02 15:04:05"))
+		fmt.Printf("  Start: %s\n", formatDetectTime(sess.StartTime))
 		if sess.StartHour != sess.StartTime && sess.StartHour > 0 {
-			fmt.Printf("  StartHour: %s\n", startHour.Format("2006-01-02 15:04:05"))
+			fmt.Printf("  StartHour: %s\n", formatDetectTime(sess.StartHour))
 		}
 
 		if sess.IsActive {
 			if sess.PredictedEndTime > 0 {
 				predictedEnd := time.Unix(sess.PredictedEndTime, 0)
 				timeToPredicted := predictedEnd.Sub(time.Now())
-				fmt.Printf("  End: %s (projected", predictedEnd.Format("2006-01-02 15:04:05"))
+				fmt.Printf("  End: %s (projected", formatDetectTime(sess.PredictedEndTime))
 				if timeToPredicted > 0 {
 					fmt.Printf(", in %s)\n", util.FormatDuration(timeToPredicted))
 				} else {
 					fmt.Printf(", reached)\n")
 				}
 			} else {
-				fmt.Printf("  End: %s\n", endTime.Format("2006-01-02 15:04:05"))
+				fmt.Printf("  End: %s\n", formatDetectTime(sess.EndTime))
 			}
 		} else {
-			fmt.Printf("  End: %s\n", endTime.Format("2006-01-02 15:04:05"))
+			fmt.Printf("  End: %s\n", formatDetectTime(sess.EndTime))
 		}
 
 		// Window Detection Information
@@ -398,28 +869,32 @@ func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetr
 		if sess.IsWindowDetected {
 			windowIcon := getWindowIcon(sess.WindowSource)
 			fmt.Printf("    Status: %s Detected via %s\n", windowIcon, sess.WindowSource)
+			if sess.DetectionConfidence != "" {
+				fmt.Printf("    Confidence: %s\n", sess.DetectionConfidence)
+			}
 			if sess.WindowStartTime != nil {
-				windowStart := time.Unix(*sess.WindowStartTime, 0)
-				fmt.Printf("    Window Start: %s (exact)\n", windowStart.Format("2006-01-02 15:04:05"))
+				fmt.Printf("    Window Start: %s (exact)\n", formatDetectTime(*sess.WindowStartTime))
 			}
 		} else {
 			fmt.Printf("    Status: ⚪ Using rounded hour alignment\n")
-			fmt.Printf("    Window Start: %s (estimated)\n", startTime.Format("2006-01-02 15:04:05"))
+			fmt.Printf("    Window Start: %s (estimated)\n", formatDetectTime(sess.StartTime))
+		}
+		if detectExplain && !sess.IsGap {
+			printWindowExplanation(sess)
 		}
 
 		// First Entry Time (for sliding window analysis)
 		if sess.FirstEntryTime > 0 {
-			firstEntry := time.Unix(sess.FirstEntryTime, 0)
-			fmt.Printf("    First Message: %s\n", firstEntry.Format("2006-01-02 15:04:05"))
+			fmt.Printf("    First Message: %s\n", formatDetectTime(sess.FirstEntryTime))
 		}
 
 		// Reset Time Information
 		resetTime := time.Unix(sess.EndTime, 0)
 		timeUntilReset := resetTime.Sub(time.Now())
 		if timeUntilReset > 0 {
-			fmt.Printf("    Reset Time: %s (in %s)\n", resetTime.Format("2006-01-02 15:04:05"), util.FormatDuration(timeUntilReset))
+			fmt.Printf("    Reset Time: %s (in %s)\n", formatDetectTime(sess.EndTime), util.FormatDuration(timeUntilReset))
 		} else {
-			fmt.Printf("    Reset Time: %s (expired)\n", resetTime.Format("2006-01-02 15:04:05"))
+			fmt.Printf("    Reset Time: %s (expired)\n", formatDetectTime(sess.EndTime))
 		}
 
 		// Limit Messages (if any)
@@ -438,19 +913,17 @@ func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetr
 					default:
 						continue
 					}
-					fmt.Printf("      %d. [%s] at %s\n", j+1, msgType, msgTime.Format("15:04:05"))
+					fmt.Printf("      %d. [%s] at %s\n", j+1, msgType, msgTime.Format(util.ClockLayout(timeFormat, true)))
 
 					if resetTimeVal, ok := limitMsg["resetTime"]; ok {
 						switch rt := resetTimeVal.(type) {
 						case float64:
 							if rt > 0 {
-								resetTime := time.Unix(int64(rt), 0)
-								fmt.Printf("         Reset at: %s\n", resetTime.Format("2006-01-02 15:04:05"))
+								fmt.Printf("         Reset at: %s\n", formatDetectTime(int64(rt)))
 							}
 						case int64:
 							if rt > 0 {
-								resetTime := time.Unix(rt, 0)
-								fmt.Printf("         Reset at: %s\n", resetTime.Format("2006-01-02 15:04:05"))
+								fmt.Printf("         Reset at: %s\n", formatDetectTime(rt))
 							}
 						}
 					}
@@ -486,6 +959,36 @@ func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetr
 			util.FormatCurrency(sess.TotalCost),
 			costPercentage)
 		fmt.Printf("    Messages: %d\n", sess.MessageCount)
+		if conversations := sess.ConversationCount(); conversations > 0 {
+			fmt.Printf("    Conversations: %d\n", conversations)
+		}
+		if sess.ThinkingOutputTokens > 0 {
+			thinkingPercentage := 0.0
+			if sess.OutputTokens > 0 {
+				thinkingPercentage = float64(sess.ThinkingOutputTokens) / float64(sess.OutputTokens) * 100
+			}
+			fmt.Printf("    Output with Thinking: %s (%.1f%% of output)\n",
+				util.FormatNumber(sess.ThinkingOutputTokens),
+				thinkingPercentage)
+		}
+		if sess.TurnaroundCount > 0 {
+			fmt.Printf("    Avg Turnaround: %s (%d requests)\n",
+				sess.AverageTurnaround().Round(time.Second), sess.TurnaroundCount)
+			if len(sess.Projects) > 1 {
+				projects := make([]string, 0, len(sess.Projects))
+				for name, stats := range sess.Projects {
+					if stats.TurnaroundCount > 0 {
+						projects = append(projects, name)
+					}
+				}
+				sort.Strings(projects)
+				for _, name := range projects {
+					stats := sess.Projects[name]
+					fmt.Printf("      %s: %s (%d requests)\n",
+						name, stats.AverageTurnaround().Round(time.Second), stats.TurnaroundCount)
+				}
+			}
+		}
 
 		if sess.CostPerHour > 0 {
 			fmt.Printf("    Cost Burn Rate: %s/hour\n", util.FormatCurrency(sess.CostPerHour))
@@ -509,6 +1012,43 @@ func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetr
 			}
 		}
 
+		// Git branches and working directories
+		if len(sess.GitBranches) > 0 {
+			fmt.Printf("    \n  Git Branch: %s", sess.DominantGitBranch())
+			if len(sess.GitBranches) > 1 {
+				fmt.Printf(" (+%d more)", len(sess.GitBranches)-1)
+			}
+			fmt.Println()
+		}
+		if len(sess.WorkingDirs) > 0 {
+			fmt.Printf("  Working Dir: %s", sess.DominantWorkingDir())
+			if len(sess.WorkingDirs) > 1 {
+				fmt.Printf(" (+%d more)", len(sess.WorkingDirs)-1)
+			}
+			fmt.Println()
+		}
+
+		// Service tier distribution
+		if len(sess.TierDistribution) > 0 {
+			fmt.Println("    \n  Service Tiers:")
+			tiers := make([]string, 0, len(sess.TierDistribution))
+			for tier := range sess.TierDistribution {
+				tiers = append(tiers, tier)
+			}
+			sort.Strings(tiers)
+			for _, tier := range tiers {
+				stats := sess.TierDistribution[tier]
+				percentage := 0.0
+				if sess.TotalTokens > 0 {
+					percentage = float64(stats.Tokens) / float64(sess.TotalTokens) * 100
+				}
+				fmt.Printf("    %s: %.0f%% (%s tokens, %s)\n",
+					tier, percentage,
+					util.FormatNumber(stats.Tokens),
+					util.FormatCurrency(stats.Cost))
+			}
+		}
+
 		// Progress for active sessions
 		if sess.IsActive {
 			fmt.Println("  \n  Active Session Info:")
@@ -531,6 +1071,9 @@ func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetr
 				fmt.Printf("    Projected Total: %s tokens, %s\n",
 					util.FormatNumber(sess.ProjectedTokens),
 					util.FormatCurrency(sess.ProjectedCost))
+				if confidence, ok := sess.ProjectionData["confidence"].(string); ok {
+					fmt.Printf("    Projection Confidence: %s\n", confidence)
+				}
 			}
 
 			// Show limit status for this session
@@ -544,12 +1087,64 @@ func printSessions(sessions []*session.Session, aggregated *model.AggregatedMetr
 					fmt.Printf("    Token Usage: %.1f%% of limit\n", tokenUsage)
 				}
 			}
+			if sess.MessagePercentage > 0 {
+				fmt.Printf("    Message Usage: %.1f%% of limit (%d counted, %d projected)\n",
+					sess.MessagePercentage, sess.CountedMessages, sess.ProjectedMessages)
+			}
+			if sess.LimitBound != "" {
+				fmt.Printf("    Plan Limit: %.1f%% (bound by %s)\n", sess.LimitPercentage, sess.LimitBound)
+			}
 		}
 
 		fmt.Println()
 	}
 }
 
+// printActivitySessions prints contiguous activity blocks, most recent first.
+// Limit status above is still computed from the 5-hour windows.
+func printActivitySessions(activities []*session.ActivitySession) {
+	fmt.Println(util.FormatDataTitle("=== Activity Sessions ==="))
+
+	for i := len(activities) - 1; i >= 0; i-- {
+		activity := activities[i]
+		fmt.Printf("Activity Session #%d\n", len(activities)-i)
+		fmt.Printf("  Start: %s\n", formatDetectTime(activity.StartTime))
+		fmt.Printf("  End: %s\n", formatDetectTime(activity.EndTime))
+		fmt.Printf("  Duration: %s\n", util.FormatDuration(time.Duration(activity.EndTime-activity.StartTime)*time.Second))
+		fmt.Printf("  Windows: %d (%s)\n", len(activity.WindowIDs), strings.Join(activity.WindowIDs, ", "))
+		fmt.Printf("  Tokens: %s\n", util.FormatNumber(activity.TotalTokens))
+		fmt.Printf("  Cost: %s\n", util.FormatCurrency(activity.TotalCost))
+		fmt.Printf("  Messages: %d\n", activity.MessageCount)
+		if i > 0 {
+			fmt.Println()
+		}
+	}
+}
+
+// printCostAllocation prints each window's cost split across its projects,
+// most recent first
+func printCostAllocation(allocations []session.WindowAllocation) {
+	fmt.Println(util.FormatDataTitle("=== Cost Allocation ==="))
+	if len(allocations) == 0 {
+		fmt.Println("No windows with usage to allocate")
+		return
+	}
+
+	for i := len(allocations) - 1; i >= 0; i-- {
+		allocation := allocations[i]
+		fmt.Printf("Window %s (%s - %s)\n", allocation.SessionID,
+			formatDetectTime(allocation.StartTime), formatDetectTime(allocation.EndTime))
+		fmt.Printf("  Total Cost: %s\n", util.FormatCurrency(allocation.TotalCost))
+		for _, project := range allocation.Projects {
+			fmt.Printf("  %s: %s (%.1f%%, %s tokens)\n", project.ProjectName,
+				util.FormatCurrency(project.Cost), project.Share*100, util.FormatNumber(project.Tokens))
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+	}
+}
+
 func printModelStatistics(aggregated *model.AggregatedMetrics) {
 	if len(aggregated.ModelDistribution) == 0 {
 		return
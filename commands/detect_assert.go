@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+)
+
+// windowSpec is one window expected by detect --assert-windows
+type windowSpec struct {
+	Start  time.Time `json:"start"`            // RFC 3339
+	End    time.Time `json:"end"`              // RFC 3339
+	Source string    `json:"source,omitempty"` // Compared only when given
+}
+
+// loadWindowSpec reads a JSON array of expected windows
+func loadWindowSpec(path string) ([]windowSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newCommandError(ErrorCodeIO, fmt.Errorf("failed to read window spec: %w", err))
+	}
+	var specs []windowSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, newCommandError(ErrorCodeInvalidArgument, fmt.Errorf("invalid window spec %s: %w", path, err))
+	}
+	for i, spec := range specs {
+		if !spec.End.After(spec.Start) {
+			return nil, newCommandError(ErrorCodeInvalidArgument,
+				fmt.Errorf("invalid window spec %s: window %d must end after it starts", path, i+1))
+		}
+	}
+	return specs, nil
+}
+
+// diffWindows compares the detected non-gap windows with the expected ones,
+// matching them by start time. It returns one line per difference in start
+// time order: "-" for a missing window, "+" for an unexpected one and "~" for
+// a window whose end or source differs.
+func diffWindows(expected []windowSpec, sessions []*session.Session, loc *time.Location) []string {
+	format := func(ts int64) string {
+		return time.Unix(ts, 0).In(loc).Format(time.RFC3339)
+	}
+	describe := func(start, end int64, source string) string {
+		if source == "" {
+			return fmt.Sprintf("%s - %s", format(start), format(end))
+		}
+		return fmt.Sprintf("%s - %s (%s)", format(start), format(end), source)
+	}
+
+	detected := make(map[int64]*session.Session)
+	for _, s := range sessions {
+		if !s.IsGap {
+			detected[s.StartTime] = s
+		}
+	}
+
+	type difference struct {
+		start int64
+		line  string
+	}
+	var diffs []difference
+	for _, spec := range expected {
+		start, end := spec.Start.Unix(), spec.End.Unix()
+		s, ok := detected[start]
+		if !ok {
+			diffs = append(diffs, difference{start, "- " + describe(start, end, spec.Source)})
+			continue
+		}
+		delete(detected, start)
+		if s.EndTime != end || (spec.Source != "" && s.WindowSource != spec.Source) {
+			diffs = append(diffs, difference{start, fmt.Sprintf("~ %s: expected %s, detected %s",
+				format(start), describe(start, end, spec.Source), describe(s.StartTime, s.EndTime, s.WindowSource))})
+		}
+	}
+	for start, s := range detected {
+		diffs = append(diffs, difference{start, "+ " + describe(s.StartTime, s.EndTime, s.WindowSource)})
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].start < diffs[j].start })
+	lines := make([]string, len(diffs))
+	for i, d := range diffs {
+		lines[i] = d.line
+	}
+	return lines
+}
+
+// assertWindows reports whether the detected windows match the expected ones,
+// returning the diff as an error on mismatch
+func assertWindows(w io.Writer, path string, expected []windowSpec, sessions []*session.Session, loc *time.Location) error {
+	diff := diffWindows(expected, sessions, loc)
+	if len(diff) > 0 {
+		return newCommandError(ErrorCodeWindowMismatch,
+			fmt.Errorf("detected windows do not match %s:\n%s", path, strings.Join(diff, "\n")))
+	}
+	fmt.Fprintf(w, "Detected windows match %s (%d windows)\n", path, len(expected))
+	return nil
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertWindows(t *testing.T) {
+	base := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC).Unix()
+	sessions := []*session.Session{
+		{StartTime: base + 7*3600, EndTime: base + 12*3600, WindowSource: "limit_message"},
+		{IsGap: true, StartTime: base + 5*3600, EndTime: base + 7*3600},
+		{StartTime: base, EndTime: base + 5*3600, WindowSource: "continuous_activity"},
+	}
+
+	writeSpec := func(content string) string {
+		path := filepath.Join(t.TempDir(), "spec.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	// Gaps are not windows, and a spec without a source accepts any source
+	matching := writeSpec(`[
+		{"start": "2024-01-15T08:00:00Z", "end": "2024-01-15T13:00:00Z", "source": "continuous_activity"},
+		{"start": "2024-01-15T15:00:00Z", "end": "2024-01-15T20:00:00Z"}
+	]`)
+	specs, err := loadWindowSpec(matching)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, assertWindows(&out, matching, specs, sessions, time.UTC))
+	assert.Contains(t, out.String(), "Detected windows match")
+
+	mismatched := writeSpec(`[
+		{"start": "2024-01-15T08:00:00Z", "end": "2024-01-15T13:00:00Z", "source": "gap"},
+		{"start": "2024-01-15T14:00:00Z", "end": "2024-01-15T19:00:00Z", "source": "limit_message"}
+	]`)
+	specs, err = loadWindowSpec(mismatched)
+	require.NoError(t, err)
+	err = assertWindows(&out, mismatched, specs, sessions, time.UTC)
+	require.Error(t, err)
+
+	var cmdErr *CommandError
+	require.True(t, errors.As(err, &cmdErr))
+	assert.Equal(t, ErrorCodeWindowMismatch, cmdErr.Code)
+	assert.Equal(t, []string{
+		"~ 2024-01-15T08:00:00Z: expected 2024-01-15T08:00:00Z - 2024-01-15T13:00:00Z (gap), detected 2024-01-15T08:00:00Z - 2024-01-15T13:00:00Z (continuous_activity)",
+		"- 2024-01-15T14:00:00Z - 2024-01-15T19:00:00Z (limit_message)",
+		"+ 2024-01-15T15:00:00Z - 2024-01-15T20:00:00Z (limit_message)",
+	}, diffWindows(specs, sessions, time.UTC))
+	assert.Contains(t, err.Error(), "detected windows do not match")
+
+	// Malformed specs are rejected before detection runs
+	_, err = loadWindowSpec(writeSpec(`{"start": "2024-01-15T08:00:00Z"}`))
+	assert.Error(t, err)
+	_, err = loadWindowSpec(writeSpec(`[{"start": "2024-01-15T08:00:00Z", "end": "2024-01-15T08:00:00Z"}]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+)
+
+// billingHeader is the header row of the detect --billing CSV
+var billingHeader = []string{"date", "session_id", "tokens", "cost", "session_cost", "limit_percentage", "limit_bound"}
+
+// writeBillingCSV writes the daily billing export of sessions to path
+func writeBillingCSV(path string, sessions []*session.Session, loc *time.Location) error {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create billing output directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create billing file: %w", err)
+	}
+	defer file.Close()
+
+	if err := writeBilling(file, session.ProrateDailyBilling(sessions, loc)); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writeBilling writes one CSV row per window and day. Costs are written from
+// whole cents so the rows of a window add up exactly to its session_cost.
+func writeBilling(w io.Writer, entries []session.DailyBillingEntry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(billingHeader); err != nil {
+		return fmt.Errorf("failed to write billing header: %w", err)
+	}
+	for _, entry := range entries {
+		row := []string{
+			entry.Date,
+			entry.SessionID,
+			strconv.Itoa(entry.Tokens),
+			formatCents(entry.CostCents),
+			formatCents(entry.SessionCost),
+			formatLimitPercentage(entry),
+			entry.LimitBound,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write billing row: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write billing file: %w", err)
+	}
+	return nil
+}
+
+// formatLimitPercentage formats the window's plan limit usage, empty when no
+// plan limits apply
+func formatLimitPercentage(entry session.DailyBillingEntry) string {
+	if entry.LimitBound == "" {
+		return ""
+	}
+	return strconv.FormatFloat(entry.LimitPercentage, 'f', 1, 64)
+}
+
+// formatCents formats a non-negative amount of cents as dollars
+func formatCents(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
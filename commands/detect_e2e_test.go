@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -429,6 +430,43 @@ func TestDetectCommandLargeDatasetPerformance(t *testing.T) {
 	assert.Contains(t, outputStr, "Sessions Found", "Should show session count")
 }
 
+// TestDetectCommandProgressOnStderr tests that progress goes to stderr while
+// the analysis stays on stdout
+func TestDetectCommandProgressOnStderr(t *testing.T) {
+	tempDir := t.TempDir()
+	generator := fixtures.NewTestDataGenerator(tempDir)
+
+	err := generator.GenerateLargeDataset("progress-test", time.Now().Add(-48*time.Hour), 200)
+	require.NoError(t, err)
+
+	binaryPath := filepath.Join(t.TempDir(), "test-monitor")
+	buildCmd := exec.Command("go", "build", "-o", binaryPath, "../cmd")
+	output, err := buildCmd.CombinedOutput()
+	require.NoError(t, err, "Failed to build binary: %s", string(output))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(binaryPath, "--dir", tempDir, "detect")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), "Detect command should succeed: %s", stderr.String())
+
+	assert.Contains(t, stderr.String(), "files", "Should report parsing progress on stderr")
+	assert.Contains(t, stderr.String(), "Detecting sessions...", "Should report detection on stderr")
+	assert.NotContains(t, stdout.String(), "Detecting sessions...", "Progress should not reach stdout")
+	assert.Contains(t, stdout.String(), "Session Detection", "Analysis should stay on stdout")
+
+	// --quiet suppresses progress entirely
+	stdout.Reset()
+	stderr.Reset()
+	cmd = exec.Command(binaryPath, "--dir", tempDir, "detect", "--quiet")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), "Detect command should succeed: %s", stderr.String())
+
+	assert.NotContains(t, stderr.String(), "Detecting sessions...")
+	assert.Contains(t, stdout.String(), "Session Detection")
+}
+
 // TestDetectCommandRateLimitDetection tests rate limit detection accuracy
 func TestDetectCommandRateLimitDetection(t *testing.T) {
 	tempDir := t.TempDir()
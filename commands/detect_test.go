@@ -1,12 +1,18 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/penwyp/go-claude-monitor/internal/application/top"
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
 	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,6 +28,24 @@ func TestDetectCommandFlags(t *testing.T) {
 		{"pricing-source", "default"},
 		{"pricing-offline", "false"},
 		{"reset-windows", "false"},
+		{"no-future-windows", "false"},
+		{"no-speculative-active", "false"},
+		{"min-gap", "0s"},
+		{"dual-time", "false"},
+		{"window-anchor", ""},
+		{"window-anchor-timezone", ""},
+		{"utc-windows", "false"},
+		{"quiet", "false"},
+		{"activity-sessions", "false"},
+		{"dot", ""},
+		{"allocate", "false"},
+		{"dry-run", "false"},
+		{"json", "false"},
+		{"assert-windows", ""},
+		{"explain", "false"},
+		{"cache-read-discount", "1"},
+		{"token-mismatch-threshold", "1"},
+		{"strict-tokens", "false"},
 	}
 
 	for _, tt := range tests {
@@ -75,6 +99,83 @@ func TestCountGaps(t *testing.T) {
 	assert.Equal(t, 2, count)
 }
 
+func TestWriteDetectSummaryJSON(t *testing.T) {
+	base := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC).Unix()
+	sessions := []*session.Session{
+		{StartTime: base, EndTime: base + 5*3600, TotalTokens: 1000, TotalCost: 1.5, MessageCount: 4},
+		{IsGap: true, StartTime: base + 5*3600, EndTime: base + 10*3600},
+		{StartTime: base + 10*3600, EndTime: base + 15*3600, TotalTokens: 500, TotalCost: 0.5, MessageCount: 2,
+			IsActive: true, LimitPercentage: 37.5, LimitBound: "tokens"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeDetectSummaryJSON(&buf, sessions, session.CalculateUtilization(sessions, base+20*3600), nil))
+
+	var summary detectSummary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &summary))
+	assert.Equal(t, 3, summary.TotalSessions)
+	assert.Equal(t, 1, summary.GapSessions)
+	assert.Equal(t, 1500, summary.TotalTokens)
+	assert.InDelta(t, 2.0, summary.TotalCost, 1e-9)
+	assert.Equal(t, 6, summary.TotalMessages)
+	assert.Equal(t, int64(10*3600), summary.ActiveSeconds)
+	assert.Equal(t, int64(5*3600), summary.IdleSeconds)
+	assert.InDelta(t, 2.0/3.0, summary.Utilization, 1e-9)
+	assert.Equal(t, 37.5, summary.LimitPercentage)
+	assert.Equal(t, "tokens", summary.LimitBound)
+	assert.Nil(t, summary.Meta)
+}
+
+func TestWriteDetectSummaryJSONMeta(t *testing.T) {
+	oldPlan, oldSource, oldTimezone := detectPlan, detectPricingSource, detectTimezone
+	defer func() {
+		detectPlan, detectPricingSource, detectTimezone = oldPlan, oldSource, oldTimezone
+	}()
+	detectPlan, detectPricingSource, detectTimezone = "max20", "litellm", "Asia/Tokyo"
+
+	var buf bytes.Buffer
+	require.NoError(t, writeDetectSummaryJSON(&buf, nil, session.Utilization{}, detectReportMeta()))
+
+	var summary struct {
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &summary))
+	require.NotNil(t, summary.Meta)
+	assert.Equal(t, version, summary.Meta["version"])
+	assert.NotEmpty(t, summary.Meta["generated_at"])
+
+	config, ok := summary.Meta["config"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "max20", config["plan"])
+	assert.Equal(t, "litellm", config["pricing_source"])
+	assert.Equal(t, "Asia/Tokyo", config["timezone"])
+	assert.Equal(t, "5h0m0s", config["session_duration"])
+	assert.Equal(t, "windows", config["timeline_mode"])
+
+	detectActivitySessions = true
+	defer func() { detectActivitySessions = false }()
+	assert.Equal(t, "activity", detectReportMeta().Config.TimelineMode)
+}
+
+func TestNoUsageMessage(t *testing.T) {
+	// A quiet range between two active periods just outside the window
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Unix()
+	gapsOnly := []*session.Session{
+		{IsGap: true, StartTime: base, EndTime: base + 3*3600},
+		{IsGap: true, StartTime: base + 4*3600, EndTime: base + 5*3600 + 1800},
+	}
+
+	msg := noUsageMessage(gapsOnly)
+	assert.Contains(t, msg, "No usage in the analyzed period")
+	assert.Contains(t, msg, "2 idle gap(s)")
+	assert.Contains(t, msg, "4h 30m")
+
+	assert.Contains(t, noUsageMessage(nil), "no usage in the analyzed period")
+
+	withUsage := append(gapsOnly, &session.Session{StartTime: base + 6*3600, MessageCount: 3, TotalTokens: 100})
+	assert.Empty(t, noUsageMessage(withUsage))
+}
+
 func TestPrintWindowAnalysisCalculations(t *testing.T) {
 	// Test window detection statistics calculations
 	sessions := []*session.Session{
@@ -197,4 +298,109 @@ func TestWindowHistoryStatsPath(t *testing.T) {
 	// Verify path construction matches expected
 	assert.Contains(t, expectedPath, ".go-claude-monitor")
 	assert.Contains(t, expectedPath, "window_history.json")
-}
\ No newline at end of file
+}
+func TestFormatDetectTimeHonorsTimeFormat(t *testing.T) {
+	defer func() { detectTimeFormat = "24h" }()
+	timestamp := time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC).Unix()
+
+	detectTimeFormat = "12h"
+	assert.Equal(t, time.Unix(timestamp, 0).Format("2006-01-02 3:04:05 PM"), formatDetectTime(timestamp))
+
+	detectTimeFormat = "02 Jan 15h04"
+	assert.Equal(t, time.Unix(timestamp, 0).Format("02 Jan 15h04"), formatDetectTime(timestamp))
+}
+
+func TestFormatDetectTimeDualTime(t *testing.T) {
+	require.NoError(t, util.InitializeTimeProvider("Asia/Tokyo"))
+	defer util.InitializeTimeProvider("Local")
+	detectDualTime = true
+	defer func() { detectDualTime = false }()
+
+	timestamp := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC).Unix()
+	result := formatDetectTime(timestamp)
+
+	assert.Contains(t, result, "2025-01-15 19:30:00 JST")
+	assert.Contains(t, result, "(2025-01-15 10:30:00 UTC)")
+
+	// The UTC half must equal the local half shifted by the zone offset
+	parts := strings.SplitN(strings.TrimSuffix(result, ")"), " (", 2)
+	require.Len(t, parts, 2)
+	localTime, err := time.Parse(util.DualTimeLayout, parts[0])
+	require.NoError(t, err)
+	utcTime, err := time.Parse(util.DualTimeLayout, parts[1])
+	require.NoError(t, err)
+	_, offset := util.GetTimeProvider().In(time.Unix(timestamp, 0)).Zone()
+	assert.Equal(t, utcTime.Add(time.Duration(offset)*time.Second).Format("2006-01-02 15:04:05"),
+		localTime.Format("2006-01-02 15:04:05"))
+}
+
+func TestDetectProgressLines(t *testing.T) {
+	var buf bytes.Buffer
+	progress := newDetectProgress(&buf)
+
+	// 150 of 250 files come from cache, the rest are parsed one by one
+	progress(top.ProgressStageParsing, 150, 250)
+	for done := 151; done <= 250; done++ {
+		progress(top.ProgressStageParsing, done, 250)
+	}
+	progress(top.ProgressStageDetecting, 0, 0)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "Parsed 200/250 files (ETA "), lines[0])
+	assert.Equal(t, "Parsed 250/250 files", lines[1])
+	assert.Equal(t, "Detecting sessions...", lines[2])
+}
+
+func TestStrictTokensError(t *testing.T) {
+	// Detection of overlapping windows that counted 500 tokens twice
+	overlapping := &session.TokenDiscrepancy{
+		SessionTokens:  4000,
+		TimelineTokens: 3500,
+		DeltaTokens:    500,
+		Percentage:     14.3,
+		DoubleCounted:  500,
+	}
+	err := strictTokensError(overlapping, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "14.3% exceeds threshold of 1%")
+	assert.Equal(t, ErrorCodeTokenDiscrepancy, ClassifyError(err))
+	assert.Equal(t, 6, ExitCode(err))
+
+	// Clean detection leaves no discrepancy
+	assert.NoError(t, strictTokensError(nil, 1))
+}
+
+func TestWriteBilling(t *testing.T) {
+	entries := []session.DailyBillingEntry{
+		{Date: "2024-03-01", SessionID: "late", Tokens: 1000, CostCents: 33, SessionCost: 100, LimitPercentage: 42.5, LimitBound: "cost"},
+		{Date: "2024-03-02", SessionID: "late", Tokens: 2000, CostCents: 67, SessionCost: 100, LimitPercentage: 42.5, LimitBound: "cost"},
+		{Date: "2024-03-02", SessionID: "free", Tokens: 10, CostCents: 1, SessionCost: 1},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeBilling(&buf, entries))
+
+	assert.Equal(t, "date,session_id,tokens,cost,session_cost,limit_percentage,limit_bound\n"+
+		"2024-03-01,late,1000,0.33,1.00,42.5,cost\n"+
+		"2024-03-02,late,2000,0.67,1.00,42.5,cost\n"+
+		"2024-03-02,free,10,0.01,0.01,,\n", buf.String())
+}
+
+func TestWriteDetectDOTWithoutUsage(t *testing.T) {
+	oldDotFile := detectDotFile
+	defer func() { detectDotFile = oldDotFile }()
+
+	// A detector that never saw usage still writes an (empty) graph
+	detector := session.NewSessionDetectorWithAggregator(nil, "UTC", t.TempDir())
+	detectDotFile = filepath.Join(t.TempDir(), "graph", "windows.dot")
+	require.NoError(t, writeDetectDOT(detector))
+
+	data, err := os.ReadFile(detectDotFile)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "digraph windows {"))
+	assert.True(t, strings.HasSuffix(string(data), "}\n"))
+
+	detectDotFile = ""
+	assert.NoError(t, writeDetectDOT(detector))
+}
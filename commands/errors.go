@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+)
+
+// ErrorCode is a stable, machine-readable error category reported by --error-json.
+type ErrorCode string
+
+const (
+	ErrorCodeInternal        ErrorCode = "internal"
+	ErrorCodeInvalidArgument ErrorCode = "invalid_argument"
+	ErrorCodeInvalidTimezone ErrorCode = "invalid_timezone"
+	ErrorCodeNoData          ErrorCode = "no_data"
+	ErrorCodeIO              ErrorCode = "io_error"
+	// ErrorCodeTokenDiscrepancy reports a session-vs-timeline token mismatch
+	// above the threshold in detect --strict-tokens mode
+	ErrorCodeTokenDiscrepancy ErrorCode = "token_discrepancy"
+	// ErrorCodeWindowMismatch reports detected windows differing from the
+	// spec given to detect --assert-windows
+	ErrorCodeWindowMismatch ErrorCode = "window_mismatch"
+	// ErrorCodeInterrupted reports an analysis cancelled with Ctrl-C
+	ErrorCodeInterrupted ErrorCode = "interrupted"
+)
+
+// exitCodes maps each error category to the process exit code used in
+// --error-json mode. These values are part of the CLI contract; do not renumber.
+var exitCodes = map[ErrorCode]int{
+	ErrorCodeInternal:         1,
+	ErrorCodeInvalidArgument:  2,
+	ErrorCodeInvalidTimezone:  3,
+	ErrorCodeNoData:           4,
+	ErrorCodeIO:               5,
+	ErrorCodeTokenDiscrepancy: 6,
+	ErrorCodeWindowMismatch:   7,
+	ErrorCodeInterrupted:      130, // Shell convention for SIGINT
+}
+
+// CommandError attaches an error category to an error returned by a command
+type CommandError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func newCommandError(code ErrorCode, err error) error {
+	return &CommandError{Code: code, Err: err}
+}
+
+// errorReport is the JSON object written to stderr in --error-json mode
+type errorReport struct {
+	Error string    `json:"error"`
+	Code  ErrorCode `json:"code"`
+}
+
+// ClassifyError returns the category of an error returned by Execute
+func ClassifyError(err error) ErrorCode {
+	var cmdErr *CommandError
+	switch {
+	case errors.As(err, &cmdErr):
+		return cmdErr.Code
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeInterrupted
+	case errors.Is(err, analyzer.ErrNoFilesFound), errors.Is(err, analyzer.ErrNoUsageData):
+		return ErrorCodeNoData
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrPermission):
+		return ErrorCodeIO
+	default:
+		return ErrorCodeInternal
+	}
+}
+
+// ExitCode returns the stable exit code for an error's category
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return exitCodes[ClassifyError(err)]
+}
+
+// ReportError writes err to w and returns the process exit code.
+// In --error-json mode the error is written as a JSON object and the exit code
+// reflects the error category; otherwise a plain message is written and 1 is returned.
+func ReportError(w io.Writer, err error) int {
+	if err == nil {
+		return 0
+	}
+	if !errorJSON {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return 1
+	}
+
+	report := errorReport{
+		Error: err.Error(),
+		Code:  ClassifyError(err),
+	}
+	data, marshalErr := json.Marshal(report)
+	if marshalErr != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return exitCodes[ErrorCodeInternal]
+	}
+	fmt.Fprintln(w, string(data))
+	return exitCodes[report.Code]
+}
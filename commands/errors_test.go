@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func categorizedErrors(t *testing.T) []struct {
+	name     string
+	err      error
+	code     ErrorCode
+	exitCode int
+} {
+	t.Helper()
+
+	tzErr := util.InitializeTimeProvider("Invalid/Zone")
+	require.Error(t, tzErr)
+	t.Cleanup(func() { util.InitializeTimeProvider("Local") })
+
+	durationErr := analyzer.ValidateDuration("abc")
+	require.Error(t, durationErr)
+
+	_, openErr := os.Open(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, openErr)
+
+	return []struct {
+		name     string
+		err      error
+		code     ErrorCode
+		exitCode int
+	}{
+		{"invalid timezone", newCommandError(ErrorCodeInvalidTimezone, tzErr), ErrorCodeInvalidTimezone, 3},
+		{"invalid duration", newCommandError(ErrorCodeInvalidArgument, durationErr), ErrorCodeInvalidArgument, 2},
+		{"flag error", rootCmd.FlagErrorFunc()(rootCmd, errors.New("unknown flag: --bogus")), ErrorCodeInvalidArgument, 2},
+		{"no files", fmt.Errorf("analysis failed: %w", analyzer.ErrNoFilesFound), ErrorCodeNoData, 4},
+		{"no usage data", analyzer.ErrNoUsageData, ErrorCodeNoData, 4},
+		{"missing path", fmt.Errorf("Failed to scan files: %w", openErr), ErrorCodeIO, 5},
+		{"token discrepancy", newCommandError(ErrorCodeTokenDiscrepancy, errors.New("mismatch")), ErrorCodeTokenDiscrepancy, 6},
+		{"interrupted", fmt.Errorf("preload failed: %w", context.Canceled), ErrorCodeInterrupted, 130},
+		{"internal", errors.New("something broke"), ErrorCodeInternal, 1},
+	}
+}
+
+func TestClassifyErrorCategories(t *testing.T) {
+	for _, tt := range categorizedErrors(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, ClassifyError(tt.err))
+			assert.Equal(t, tt.exitCode, ExitCode(tt.err))
+		})
+	}
+
+	assert.Equal(t, 0, ExitCode(nil))
+}
+
+func TestReportErrorJSON(t *testing.T) {
+	errorJSON = true
+	defer func() { errorJSON = false }()
+
+	for _, tt := range categorizedErrors(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			exitCode := ReportError(&buf, tt.err)
+			assert.Equal(t, tt.exitCode, exitCode)
+
+			var report map[string]string
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+			assert.Len(t, report, 2)
+			assert.Equal(t, tt.err.Error(), report["error"])
+			assert.Equal(t, string(tt.code), report["code"])
+		})
+	}
+}
+
+func TestReportErrorPlain(t *testing.T) {
+	var buf bytes.Buffer
+	exitCode := ReportError(&buf, newCommandError(ErrorCodeInvalidTimezone, errors.New("bad zone")))
+
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, "Error: bad zone\n", buf.String())
+}
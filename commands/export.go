@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/data/parser"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Export command flags
+	exportLabel    string
+	exportDuration string
+	exportFile     string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export aggregated usage for merging with other machines",
+	Long: `Writes the hourly usage found in the Claude project directory as versioned
+JSON, tagged with a machine or user label. Exports from several machines can be
+combined with the import command for team-wide analysis. Exports hold token
+counts only; costs are calculated when they are imported.
+
+Examples:
+  go-claude-monitor export --label alice --output-file alice.json
+  go-claude-monitor export --duration 1m > $(hostname).json`,
+	RunE: runExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportLabel, "label", "",
+		"Machine or user label for this export (default: hostname)")
+	exportCmd.Flags().StringVarP(&exportDuration, "duration", "d", "",
+		"Time duration to look back (e.g., 12h, 7d, 2w, 1m)")
+	exportCmd.Flags().StringVar(&exportFile, "output-file", "",
+		"Write the export to this file instead of stdout")
+}
+
+func runExport(cmd *cobra.Command, args []string) (err error) {
+	initCommandLogger()
+	if err := analyzer.ValidateDuration(exportDuration); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if _, err := parser.AdapterForFormat(inputFormat); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if err := validateProjectFlags(); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+
+	label := exportLabel
+	if label == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return newCommandError(ErrorCodeInvalidArgument, fmt.Errorf("cannot determine hostname, use --label: %w", err))
+		}
+		label = hostname
+	}
+
+	cacheDir := expandPath(defaultCacheDir)
+	if err := ensureDir(cacheDir); err != nil {
+		return newCommandError(ErrorCodeIO, fmt.Errorf("failed to create cache directory: %w", err))
+	}
+
+	a := analyzer.New(&analyzer.Config{
+		DataDir:         expandPath(dataDir),
+		CacheDir:        cacheDir,
+		Duration:        exportDuration,
+		Concurrency:     runtime.NumCPU(),
+		InputFormat:     inputFormat,
+		Projects:        includeProjects,
+		ExcludeProjects: excludeProjects,
+	})
+	export, err := a.Export(label)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if exportFile != "" {
+		path := expandPath(exportFile)
+		if err := ensureDir(filepath.Dir(path)); err != nil {
+			return newCommandError(ErrorCodeIO, fmt.Errorf("failed to create output directory: %w", err))
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return newCommandError(ErrorCodeIO, fmt.Errorf("failed to create output file: %w", err))
+		}
+		// A failed close can lose buffered data, so it fails the export
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = newCommandError(ErrorCodeIO, fmt.Errorf("failed to write export: %w", closeErr))
+			}
+		}()
+		w = file
+	}
+	if err := analyzer.WriteExport(w, export); err != nil {
+		return newCommandError(ErrorCodeIO, fmt.Errorf("failed to write export: %w", err))
+	}
+	util.LogInfo(fmt.Sprintf("Exported %d records labelled %s", len(export.HourlyStats), export.Label))
+	return nil
+}
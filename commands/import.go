@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Import command flags
+	importGroupBy        string
+	importDuration       string
+	importOutputFormat   string
+	importOutputFile     string
+	importBreakdown      bool
+	importTimezone       string
+	importPricingSource  string
+	importPricingOffline bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <export.json>...",
+	Short: "Analyze usage exported from several machines together",
+	Long: `Reads files written by the export command, merges their usage and reports it
+like the default analysis, priced with the current pricing source. Each export
+keeps the label it was written with, so --group-by label compares machines or
+users.
+
+Examples:
+  go-claude-monitor import alice.json bob.json
+  go-claude-monitor import team/*.json --group-by label --breakdown`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVar(&importGroupBy, "group-by", "day",
+		"Group by field (label, model, project, day, week, month, hour)")
+	importCmd.Flags().StringVarP(&importDuration, "duration", "d", "",
+		"Time duration to look back (e.g., 12h, 7d, 2w, 1m)")
+	importCmd.Flags().StringVarP(&importOutputFormat, "output", "o", "table",
+		"Output format (table, json, jsonl, ndjson, yaml, csv, summary)")
+	importCmd.Flags().StringVar(&importOutputFile, "output-file", "",
+		"Write the formatted result to this file instead of stdout")
+	importCmd.Flags().BoolVarP(&importBreakdown, "breakdown", "b", false,
+		"Show model cost breakdown")
+	importCmd.Flags().StringVar(&importTimezone, "timezone", "Local",
+		"Timezone setting (e.g., Asia/Shanghai, UTC)")
+	importCmd.Flags().StringVar(&importPricingSource, "pricing-source", "default",
+		"Pricing source (default, litellm)")
+	importCmd.Flags().BoolVar(&importPricingOffline, "pricing-offline", false,
+		"Use offline pricing mode")
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	initCommandLogger()
+	if err := util.InitializeTimeProvider(importTimezone); err != nil {
+		return newCommandError(ErrorCodeInvalidTimezone, err)
+	}
+	if err := analyzer.ValidateDuration(importDuration); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+
+	exports := make([]*analyzer.UsageExport, 0, len(args))
+	for _, arg := range args {
+		export, err := analyzer.ReadExport(expandPath(arg))
+		if err != nil {
+			return err
+		}
+		exports = append(exports, export)
+	}
+
+	cacheDir := expandPath(defaultCacheDir)
+	if err := ensureDir(cacheDir); err != nil {
+		return newCommandError(ErrorCodeIO, fmt.Errorf("failed to create cache directory: %w", err))
+	}
+	outputFile := importOutputFile
+	if outputFile != "" {
+		outputFile = expandPath(outputFile)
+	}
+
+	a := analyzer.New(&analyzer.Config{
+		CacheDir:           cacheDir,
+		OutputFormat:       importOutputFormat,
+		OutputFile:         outputFile,
+		Timezone:           importTimezone,
+		Duration:           importDuration,
+		GroupBy:            importGroupBy,
+		Breakdown:          importBreakdown,
+		PricingSource:      importPricingSource,
+		PricingOfflineMode: importPricingOffline,
+		CacheReadDiscount:  1,
+	})
+	return a.RunImport(exports)
+}
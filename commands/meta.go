@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
+)
+
+// version is the tool version reported in JSON meta; release builds set it
+// with -ldflags "-X github.com/penwyp/go-claude-monitor/commands.version=..."
+var version = "dev"
+
+// newReportMeta returns the meta block of a JSON report generated now with
+// the given effective configuration
+func newReportMeta(config formatter.MetaConfig) *formatter.Meta {
+	return &formatter.Meta{
+		Version:     version,
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Config:      config,
+	}
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/data/parser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Models command flags
+	modelsPricingSource  string
+	modelsPricingOffline bool
+)
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List the models found in the data and whether each is priced",
+	Long: `Scans the Claude project directory and lists every model that appears in the
+usage data with its simplified name, message and token counts, and whether the
+selected pricing source has an entry for it. Unpriced models are billed at
+fallback rates, or reported with zero cost in offline mode.`,
+	RunE: runModels,
+}
+
+func init() {
+	rootCmd.AddCommand(modelsCmd)
+
+	modelsCmd.Flags().StringVar(&modelsPricingSource, "pricing-source", "default",
+		"Pricing source (default, litellm)")
+	modelsCmd.Flags().BoolVar(&modelsPricingOffline, "pricing-offline", false,
+		"Use offline pricing mode")
+}
+
+func runModels(cmd *cobra.Command, args []string) error {
+	initCommandLogger()
+	if _, err := parser.AdapterForFormat(inputFormat); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if err := validateProjectFlags(); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+
+	cacheDir := expandPath(defaultCacheDir)
+	if err := ensureDir(cacheDir); err != nil {
+		return newCommandError(ErrorCodeIO, fmt.Errorf("failed to create cache directory: %w", err))
+	}
+
+	a := analyzer.New(&analyzer.Config{
+		DataDir:            expandPath(dataDir),
+		CacheDir:           cacheDir,
+		Concurrency:        runtime.NumCPU(),
+		InputFormat:        inputFormat,
+		Projects:           includeProjects,
+		ExcludeProjects:    excludeProjects,
+		PricingSource:      modelsPricingSource,
+		PricingOfflineMode: modelsPricingOffline,
+		CacheReadDiscount:  1,
+	})
+	models, err := a.Models()
+	if err != nil {
+		return err
+	}
+
+	unpriced := 0
+	fmt.Printf("%-40s %-20s %10s %14s  %s\n", "MODEL", "NAME", "MESSAGES", "TOKENS", "PRICING")
+	for _, m := range models {
+		status := "priced"
+		if !m.Priced {
+			status = "unpriced"
+			unpriced++
+		}
+		fmt.Printf("%-40s %-20s %10d %14d  %s\n", m.Model, m.Simplified, m.Messages, m.Tokens, status)
+	}
+	fmt.Printf("\n%d models, %d unpriced (pricing source: %s)\n", len(models), unpriced, modelsPricingSource)
+	return nil
+}
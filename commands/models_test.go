@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelsCommandFlags(t *testing.T) {
+	tests := []struct {
+		flag         string
+		defaultValue string
+	}{
+		{"pricing-source", "default"},
+		{"pricing-offline", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			flag := modelsCmd.Flags().Lookup(tt.flag)
+			assert.NotNil(t, flag)
+			assert.Equal(t, tt.defaultValue, flag.DefValue)
+		})
+	}
+
+	assert.Equal(t, "models", modelsCmd.Use)
+	assert.NotNil(t, modelsCmd.RunE)
+}
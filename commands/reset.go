@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/application/top"
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/data/cache"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Reset command flags
+	resetYes            bool
+	resetPreserveLimits bool
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Remove all cached state: aggregation cache, window history and watermarks",
+	Long: `Removes every piece of state go-claude-monitor keeps between runs: the
+aggregation cache, the learned window history, the --since-last watermark and
+views exported from top. Claude's own logs are never touched. Each removed path
+is printed.
+
+Unlike the --reset flag, which only clears the aggregation cache before an
+analysis, this starts from a clean slate.
+
+Examples:
+  go-claude-monitor reset
+  go-claude-monitor reset --yes --preserve-limits`,
+	RunE: runReset,
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+
+	resetCmd.Flags().BoolVarP(&resetYes, "yes", "y", false,
+		"Do not ask for confirmation")
+	resetCmd.Flags().BoolVar(&resetPreserveLimits, "preserve-limits", false,
+		"Keep window history learned from limit messages")
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	if !resetYes {
+		fmt.Fprint(out, "Remove the aggregation cache, window history and watermarks? (y/N): ")
+		response, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		response = strings.TrimSpace(response)
+		if response != "y" && response != "Y" {
+			fmt.Fprintln(out, "Reset cancelled.")
+			return nil
+		}
+	}
+
+	cacheDir := expandPath(defaultCacheDir)
+	history := session.NewWindowHistoryManager(cacheDir)
+	if err := resetState(cacheDir, history, resetPreserveLimits, out); err != nil {
+		return newCommandError(ErrorCodeIO, err)
+	}
+	return nil
+}
+
+// resetState removes the aggregation cache, exported views and watermark in
+// cacheDir and the window history, printing each removed path to out. With
+// preserveLimits the history is rewritten keeping only limit message windows.
+func resetState(cacheDir string, history *session.WindowHistoryManager, preserveLimits bool, out io.Writer) error {
+	var removed []string
+	remove := func(path string) error {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return nil
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = append(removed, path)
+		return nil
+	}
+
+	// Aggregation cache files; the history may live in cacheDir as well
+	historyPath := history.GetHistoryPath()
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(cacheDir, entry.Name())
+		if _, ok := cache.SessionIDFromCacheFile(entry.Name()); ok && !entry.IsDir() && path != historyPath {
+			if err := remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Watermark and views exported from top
+	for _, path := range []string{
+		filepath.Join(cacheDir, analyzer.WatermarkFileName),
+		filepath.Join(cacheDir, analyzer.WatermarkFileName+".tmp"),
+		filepath.Join(cacheDir, top.ExportDirName),
+	} {
+		if err := remove(path); err != nil {
+			return err
+		}
+	}
+
+	// Window history, including a copy set aside as corrupt
+	if err := remove(historyPath + ".bad"); err != nil {
+		return err
+	}
+	if preserveLimits {
+		if err := history.Load(); err != nil {
+			return err
+		}
+		kept := history.RetainLimitWindows()
+		if err := history.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Kept %d limit message windows in %s\n", kept, historyPath)
+	} else if err := remove(historyPath); err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		fmt.Fprintln(out, "Nothing to remove.")
+		return nil
+	}
+	for _, path := range removed {
+		fmt.Fprintf(out, "Removed %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/application/top"
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupResetState creates every artifact reset removes and returns the cache
+// directory with a history holding one limit window and one gap window
+func setupResetState(t *testing.T) (string, *session.WindowHistoryManager) {
+	t.Setenv("HOME", t.TempDir())
+	cacheDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "session.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, analyzer.WatermarkFileName), []byte("1\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, top.ExportDirName), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, top.ExportDirName, "view.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "notes.txt"), []byte("keep"), 0644))
+
+	history := session.NewWindowHistoryManager(cacheDir)
+	now := time.Now().Unix()
+	history.AddOrUpdateWindow(session.WindowRecord{
+		SessionID: "limit", Source: "limit_message", IsLimitReached: true,
+		StartTime: now - 8*3600, EndTime: now - 3*3600,
+	})
+	history.AddOrUpdateWindow(session.WindowRecord{
+		SessionID: "gap", Source: "gap",
+		StartTime: now - 20*3600, EndTime: now - 15*3600,
+	})
+	require.NoError(t, history.Save())
+	require.FileExists(t, history.GetHistoryPath())
+
+	return cacheDir, history
+}
+
+func TestResetStateRemovesAllArtifacts(t *testing.T) {
+	cacheDir, history := setupResetState(t)
+
+	var out bytes.Buffer
+	require.NoError(t, resetState(cacheDir, history, false, &out))
+
+	assert.NoFileExists(t, filepath.Join(cacheDir, "session.json"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, analyzer.WatermarkFileName))
+	assert.NoDirExists(t, filepath.Join(cacheDir, top.ExportDirName))
+	assert.NoFileExists(t, history.GetHistoryPath())
+	assert.FileExists(t, filepath.Join(cacheDir, "notes.txt"), "Unrelated files should be left alone")
+	assert.Contains(t, out.String(), "Removed "+history.GetHistoryPath())
+	assert.Contains(t, out.String(), "Removed "+filepath.Join(cacheDir, "session.json"))
+
+	// A second reset has nothing left to remove
+	out.Reset()
+	require.NoError(t, resetState(cacheDir, history, false, &out))
+	assert.Contains(t, out.String(), "Nothing to remove.")
+}
+
+func TestResetStatePreservesLimitWindows(t *testing.T) {
+	cacheDir, history := setupResetState(t)
+
+	var out bytes.Buffer
+	require.NoError(t, resetState(cacheDir, history, true, &out))
+
+	assert.NoFileExists(t, filepath.Join(cacheDir, "session.json"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, analyzer.WatermarkFileName))
+	require.FileExists(t, history.GetHistoryPath())
+
+	reloaded := session.NewWindowHistoryManager(cacheDir)
+	require.NoError(t, reloaded.Load())
+	windows := reloaded.GetRecentWindows(48 * time.Hour)
+	require.Len(t, windows, 1, "Only the limit window should be kept")
+	assert.Equal(t, "limit", windows[0].SessionID)
+	assert.Contains(t, out.String(), "Kept 1 limit message windows")
+}
+
+func TestResetCommandFlags(t *testing.T) {
+	flag := resetCmd.Flags().Lookup("yes")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+	assert.Equal(t, "y", flag.Shorthand)
+
+	flag = resetCmd.Flags().Lookup("preserve-limits")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+}
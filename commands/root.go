@@ -1,38 +1,86 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/analyzer"
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
+	"github.com/penwyp/go-claude-monitor/internal/data/cache"
+	"github.com/penwyp/go-claude-monitor/internal/data/parser"
+	"github.com/penwyp/go-claude-monitor/internal/data/scanner"
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	// Logging related
 	debug bool
 
+	// Error reporting
+	errorJSON bool
+
 	// Data path
-	dataDir string
+	dataDir     string
+	inputFormat string
+
+	// Project filters, applied to every command
+	includeProjects []string
+	excludeProjects []string
 
 	// Output related
 	outputFormat string
+	outputFile   string
+	outputDir    string
 	timezone     string
+	timeFormat   string
+	withMeta     bool
 
 	// Filtering and grouping
 	duration  string
+	sinceLast bool
+	since     string
+	until     string
 	groupBy   string
 	limit     int
 	breakdown bool
+	models    []string
 	reset     bool
+	recost    bool
+
+	// Aggregation cache
+	cacheCompress bool
+
+	// Business day rollups
+	businessDaysOnly bool
+	holidays         []string
+	showExcludedDays bool
+
+	// Project naming
+	disambiguateProjects bool
+	splitByProject       bool
 
 	// Pricing related
-	pricingSource      string
-	pricingOfflineMode bool
+	pricingSource       string
+	pricingOfflineMode  bool
+	strictPricing       bool
+	includeZeroCost     bool
+	unknownModel        string
+	unknownModelPricing string
+	cacheReadDiscount   float64
+	pricingFile         string
+	pricingMaxAge       time.Duration
+	currency            string
+	exchangeRate        float64
 
 	rootCmd = &cobra.Command{
 		Use:   "go-claude-monitor [flags]",
@@ -49,8 +97,23 @@ Examples:
   go-claude-monitor --duration 7d                      # Analyze last 7 days
   go-claude-monitor --duration 2w3d                    # Analyze last 2 weeks and 3 days
   go-claude-monitor --duration 1d12h                   # Analyze last 1 day and 12 hours
-  go-claude-monitor --duration 1m --breakdown          # Analyze last month with cost breakdown`,
-		RunE: runAnalyze,
+  go-claude-monitor --duration 1m --breakdown          # Analyze last month with cost breakdown
+  go-claude-monitor --duration 1w --group-by branch   # Cost per git branch this week
+  go-claude-monitor --duration 1m --model '*opus*'   # Analyze last month's Opus usage only
+  go-claude-monitor --since 2024-06-03 --until 2024-06-04  # Usage on June 3rd and 4th
+  go-claude-monitor --since-last --duration 1d         # Usage since the previous --since-last run
+  go-claude-monitor --recost --pricing-source litellm  # Reprice cached usage without reparsing logs
+  go-claude-monitor --split-by-project --output-dir reports  # One report file per project`,
+		RunE:          runAnalyze,
+		SilenceErrors: true, // Errors are reported by ReportError
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Keep stderr machine-readable in --error-json mode
+			cmd.SilenceUsage = errorJSON
+			if err := applyConfiguredDataDir(cmd); err != nil {
+				return newCommandError(ErrorCodeInvalidArgument, err)
+			}
+			return nil
+		},
 	}
 )
 
@@ -63,66 +126,201 @@ const (
 func init() {
 	// Input data configuration
 	rootCmd.PersistentFlags().StringVar(&dataDir, "dir", defaultDataDir,
-		"Claude project directory path")
+		"Claude project directory path (defaults to projectsDir in .claude.json, else projects in $CLAUDE_CONFIG_DIR)")
+	rootCmd.PersistentFlags().StringVar(&inputFormat, "input-format", parser.FormatCode,
+		"Input log format (code, desktop)")
+	rootCmd.PersistentFlags().StringArrayVar(&includeProjects, "project", nil,
+		"Only read projects whose name contains this text or matches this glob (repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&excludeProjects, "exclude-project", nil,
+		"Skip projects whose name or directory contains this text or matches this glob, after --project; never scanned (repeatable)")
+	rootCmd.SetGlobalNormalizationFunc(projectFlagAlias)
 
 	// Time filtering
 	rootCmd.Flags().StringVarP(&duration, "duration", "d", "",
 		"Time duration to look back (e.g., 12h, 7d, 2w, 1m, 3m2w1d, 1d12h)")
+	rootCmd.Flags().BoolVar(&sinceLast, "since-last", false,
+		"Report only complete hours since the previous --since-last run (first run uses --duration)")
+	rootCmd.Flags().StringVar(&since, "since", "",
+		"Report usage from this time, RFC3339 on the hour or YYYY-MM-DD in --timezone (instead of --duration)")
+	rootCmd.Flags().StringVar(&until, "until", "",
+		"Report usage before this time, RFC3339 or YYYY-MM-DD (the whole day) in --timezone")
 
 	// Data organization and analysis
 	rootCmd.Flags().StringVar(&groupBy, "group-by", "day",
-		"Group by field (model, project, day, week, month, hour)")
+		"Group by field (model, project, branch, day, week, month, hour)")
 	rootCmd.Flags().IntVar(&limit, "limit", 0,
 		"Limit result count (0 = unlimited)")
 	rootCmd.Flags().BoolVarP(&breakdown, "breakdown", "b", false,
 		"Show model cost breakdown")
+	rootCmd.Flags().StringArrayVar(&models, "model", nil,
+		"Glob of models to report, e.g. claude-3-opus* or *opus* (repeatable, default all)")
+	rootCmd.Flags().BoolVar(&disambiguateProjects, "disambiguate-projects", false,
+		"Report projects sharing a name in different directories separately, qualified by parent path")
+	rootCmd.Flags().BoolVar(&businessDaysOnly, "business-days-only", false,
+		"Exclude weekends and --holidays from day/week rollups and averages")
+	rootCmd.Flags().StringSliceVar(&holidays, "holidays", nil,
+		"Holiday dates (YYYY-MM-DD) excluded with --business-days-only")
+	rootCmd.Flags().BoolVar(&showExcludedDays, "show-excluded-days", false,
+		"List days excluded by --business-days-only with zero usage")
 
 	// Output configuration
 	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "table",
-		"Output format (table, json, csv, summary)")
-	rootCmd.Flags().StringVar(&outputFormat, "format", "",
+		"Output format (table, json, jsonl, ndjson, yaml, csv, summary)")
+	// Both flags share outputFormat and the alias registers last, so it must
+	// carry the same default
+	rootCmd.Flags().StringVar(&outputFormat, "format", "table",
 		"Alias for --output")
+	rootCmd.Flags().StringVar(&outputFile, "output-file", "",
+		"Write the formatted result to this file instead of stdout")
+	rootCmd.Flags().BoolVar(&splitByProject, "split-by-project", false,
+		"Write each project's analysis to its own file in --output-dir")
+	rootCmd.Flags().StringVar(&outputDir, "output-dir", "",
+		"Directory for the per-project files written by --split-by-project")
 	rootCmd.Flags().StringVar(&timezone, "timezone", "Local",
 		"Timezone setting (e.g., Asia/Shanghai, UTC)")
+	rootCmd.Flags().StringVar(&timeFormat, "time-format", "24h",
+		"Time format of --group-by hour periods: 12h, 24h, iso or a Go time layout such as '15:04'")
+	rootCmd.Flags().BoolVar(&withMeta, "meta", false,
+		"Wrap JSON output in an object with the tool version and effective config under meta")
 
 	// System and debugging
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false,
 		"Enable debug mode")
+	rootCmd.PersistentFlags().BoolVar(&errorJSON, "error-json", false,
+		"Report errors as JSON on stderr with category-specific exit codes")
 	rootCmd.Flags().BoolVarP(&reset, "reset", "r", false,
 		"Clear cache before analysis")
+	rootCmd.Flags().BoolVar(&recost, "recost", false,
+		"Recompute costs from cached aggregation without reading any log files")
+	rootCmd.Flags().BoolVar(&cacheCompress, "cache-compress", false,
+		"Write cache entries gzip-compressed (existing entries are read either way)")
 
 	// Pricing configuration
 	rootCmd.Flags().StringVar(&pricingSource, "pricing-source", "default",
 		"Pricing source (default, litellm)")
 	rootCmd.Flags().BoolVar(&pricingOfflineMode, "pricing-offline", false,
 		"Use offline pricing mode")
+	rootCmd.Flags().BoolVar(&strictPricing, "strict-pricing", false,
+		"Report models without pricing as unpriced (cost 0) instead of billing them at default rates")
+	rootCmd.Flags().BoolVar(&includeZeroCost, "include-zero-cost", true,
+		"Include models without pricing (reported with zero cost) in results")
+	rootCmd.Flags().StringVar(&unknownModel, "unknown-model", analyzer.UnknownModelKeep,
+		"Handling of usage logged without a model name (keep, drop, price, warn)")
+	rootCmd.Flags().StringVar(&unknownModelPricing, "unknown-model-pricing", "",
+		"Model whose pricing bills usage without a model name with --unknown-model price")
+	rootCmd.Flags().Float64Var(&cacheReadDiscount, "cache-read-discount", 1,
+		"Multiplier applied to the cache-read rate (0-1, 1 = list price)")
+	rootCmd.Flags().StringVar(&pricingFile, "pricing-file", "",
+		"JSON file of per-model rates overriding the pricing source")
+	rootCmd.Flags().DurationVar(&pricingMaxAge, "pricing-max-age", pricing.DefaultPricingMaxAge,
+		"Reuse cached pricing of a remote --pricing-source until it is this old")
+	rootCmd.Flags().StringVar(&currency, "currency", "USD",
+		"Currency costs are displayed in by the table and summary output (e.g. EUR, GBP, JPY)")
+	rootCmd.Flags().Float64Var(&exchangeRate, "exchange-rate", 0,
+		"Units of --currency per USD (0 = built-in approximate rate)")
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		cmd.SilenceUsage = errorJSON
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	})
+
+	// --help runs no hooks, so apply the configured --dir default here too
+	defaultHelp := rootCmd.HelpFunc()
+	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if err := applyConfiguredDataDir(cmd); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), err)
+		}
+		defaultHelp(cmd, args)
+	})
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
-	// Determine log level based on debug flag
-	logLevel := "info"
-	if debug {
-		logLevel = "debug"
-	}
-
 	// Handle format alias
 	if format := cmd.Flags().Lookup("format"); format != nil && format.Changed {
 		outputFormat = format.Value.String()
 	}
 
-	// Initialize logging
-	logFile := expandPath(defaultLogFile)
-	ensureDir(filepath.Dir(logFile))
-	util.InitLogger(logLevel, logFile, debug)
-	util.InitializeTimeProvider(timezone)
+	initCommandLogger()
+	if err := util.InitializeTimeProvider(timezone); err != nil {
+		return newCommandError(ErrorCodeInvalidTimezone, err)
+	}
+	if err := util.ValidateTimeFormat(timeFormat); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if err := analyzer.ValidateOutputFormat(outputFormat); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if err := analyzer.ValidateDuration(duration); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	sinceTime, untilTime, err := parseTimeRange(since, until, timezone)
+	if err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if (since != "" || until != "") && (duration != "" || sinceLast) {
+		return newCommandError(ErrorCodeInvalidArgument,
+			errors.New("--since and --until cannot be combined with --duration or --since-last"))
+	}
+	if _, err := parser.AdapterForFormat(inputFormat); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if err := validateProjectFlags(); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if err := analyzer.ValidateModelPatterns(models); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if err := pricing.ValidateCacheReadDiscount(cacheReadDiscount); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if pricingMaxAge < 0 {
+		return newCommandError(ErrorCodeInvalidArgument,
+			fmt.Errorf("pricing max age %s must not be negative", pricingMaxAge))
+	}
+	pricingOverrides, err := loadPricingOverrides(pricingFile)
+	if err != nil {
+		return err
+	}
+	if err := applyDisplayCurrency(currency, exchangeRate); err != nil {
+		return err
+	}
+	if recost && reset {
+		return newCommandError(ErrorCodeInvalidArgument, errors.New("--recost cannot be combined with --reset"))
+	}
+	if err := analyzer.ValidateUnknownModel(unknownModel, unknownModelPricing); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if businessDaysOnly {
+		if err := analyzer.ValidateBusinessDays(groupBy); err != nil {
+			return newCommandError(ErrorCodeInvalidArgument, err)
+		}
+	}
+	if _, err := analyzer.ParseHolidays(holidays); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	if withMeta && outputFormat != "json" {
+		return newCommandError(ErrorCodeInvalidArgument, errors.New("--meta requires --output json"))
+	}
+	if splitByProject && outputDir == "" {
+		return newCommandError(ErrorCodeInvalidArgument, errors.New("--split-by-project requires --output-dir"))
+	}
+	if splitByProject && outputFile != "" {
+		return newCommandError(ErrorCodeInvalidArgument, errors.New("--split-by-project cannot be combined with --output-file"))
+	}
 
 	// Expand paths
 	dataDir = expandPath(dataDir)
 	cacheDir := expandPath(defaultCacheDir)
+	if outputFile != "" {
+		outputFile = expandPath(outputFile)
+	}
+	if outputDir != "" {
+		outputDir = expandPath(outputDir)
+	}
 
 	// Ensure cache directory exists
 	if err := ensureDir(cacheDir); err != nil {
-		return fmt.Errorf("failed to create cache directory: %w", err)
+		return newCommandError(ErrorCodeIO, fmt.Errorf("failed to create cache directory: %w", err))
 	}
 
 	// Clear cache if needed
@@ -135,22 +333,99 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 
 	// Create analyzer config
 	config := &analyzer.Config{
-		DataDir:            dataDir,
-		CacheDir:           cacheDir,
-		OutputFormat:       outputFormat,
-		Timezone:           timezone,
-		Duration:           duration,
-		GroupBy:            groupBy,
-		Limit:              limit,
-		Breakdown:          breakdown,
-		Concurrency:        runtime.NumCPU(),
-		PricingSource:      pricingSource,
-		PricingOfflineMode: pricingOfflineMode,
+		DataDir:              dataDir,
+		CacheDir:             cacheDir,
+		OutputFormat:         outputFormat,
+		OutputFile:           outputFile,
+		Timezone:             timezone,
+		TimeFormat:           timeFormat,
+		Duration:             duration,
+		SinceLast:            sinceLast,
+		Since:                sinceTime,
+		Until:                untilTime,
+		GroupBy:              groupBy,
+		Limit:                limit,
+		Breakdown:            breakdown,
+		Models:               models,
+		Concurrency:          runtime.NumCPU(),
+		InputFormat:          inputFormat,
+		PricingSource:        pricingSource,
+		PricingOfflineMode:   pricingOfflineMode,
+		StrictPricing:        strictPricing,
+		ExcludeUnpriced:      !includeZeroCost,
+		UnknownModel:         unknownModel,
+		UnknownModelPricing:  unknownModelPricing,
+		CacheReadDiscount:    cacheReadDiscount,
+		PricingOverrides:     pricingOverrides,
+		PricingMaxAge:        pricingMaxAge,
+		BusinessDaysOnly:     businessDaysOnly,
+		Holidays:             holidays,
+		ShowExcludedDays:     showExcludedDays,
+		Projects:             includeProjects,
+		ExcludeProjects:      excludeProjects,
+		DisambiguateProjects: disambiguateProjects,
+		Recost:               recost,
+		CacheCompress:        cacheCompress,
+		SplitByProject:       splitByProject,
+		OutputDir:            outputDir,
+	}
+
+	if withMeta {
+		config.Meta = rootReportMeta()
 	}
 
+	// Ctrl-C stops parsing; files parsed until then stay cached
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	// Create and run analyzer
 	a := analyzer.New(config)
-	return a.Run()
+	return a.Run(ctx)
+}
+
+// rootReportMeta returns the JSON meta block of the analysis flags
+func rootReportMeta() *formatter.Meta {
+	return newReportMeta(formatter.MetaConfig{
+		PricingSource:     pricingSource,
+		PricingOffline:    pricingOfflineMode,
+		StrictPricing:     strictPricing,
+		CacheReadDiscount: cacheReadDiscount,
+		PricingFile:       pricingFile,
+		Currency:          util.DisplayCurrency().Code,
+		ExchangeRate:      util.DisplayCurrency().Rate,
+		Timezone:          timezone,
+		Duration:          duration,
+		Since:             since,
+		Until:             until,
+		GroupBy:           groupBy,
+		Models:            models,
+		Projects:          includeProjects,
+		ExcludeProjects:   excludeProjects,
+	})
+}
+
+// parseTimeRange parses the --since and --until values, reading bare dates
+// in the named timezone. Unset bounds are zero.
+func parseTimeRange(since, until, timezone string) (time.Time, time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	var sinceTime, untilTime time.Time
+	if since != "" {
+		if sinceTime, err = analyzer.ParseTimeBound(since, loc, false); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if until != "" {
+		if untilTime, err = analyzer.ParseTimeBound(until, loc, true); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !sinceTime.IsZero() && !untilTime.IsZero() && !sinceTime.Before(untilTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--since %s must be before --until %s", since, until)
+	}
+	return sinceTime, untilTime, nil
 }
 
 func Execute() error {
@@ -159,6 +434,59 @@ func Execute() error {
 
 // Helper functions
 
+// initCommandLogger sets up logging to the log file, at debug level with
+// --debug
+func initCommandLogger() {
+	logLevel := "info"
+	if debug {
+		logLevel = "debug"
+	}
+	logFile := expandPath(defaultLogFile)
+	ensureDir(filepath.Dir(logFile))
+	util.InitLogger(logLevel, logFile, debug)
+}
+
+// projectFlagAlias makes --ignore-project, which skipped project directories
+// before --exclude-project existed, an alias of --exclude-project
+func projectFlagAlias(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "ignore-project" {
+		name = "exclude-project"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// validateProjectFlags reports the first malformed --project or
+// --exclude-project pattern
+func validateProjectFlags() error {
+	if err := scanner.ValidateProjectPatterns(includeProjects); err != nil {
+		return err
+	}
+	return scanner.ValidateProjectPatterns(excludeProjects)
+}
+
+// loadPricingOverrides loads the --pricing-file of a command, or returns nil
+// when none is given
+func loadPricingOverrides(path string) (map[string]pricing.ModelPricing, error) {
+	if path == "" {
+		return nil, nil
+	}
+	overrides, err := pricing.LoadPricingOverrides(expandPath(path))
+	if err != nil {
+		return nil, newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	return overrides, nil
+}
+
+// applyDisplayCurrency sets the currency costs are displayed in
+func applyDisplayCurrency(code string, rate float64) error {
+	c, err := util.NewCurrency(code, rate)
+	if err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	util.SetDisplayCurrency(c)
+	return nil
+}
+
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
 		home, _ := os.UserHomeDir()
@@ -185,7 +513,7 @@ func clearCache(cacheDir string) error {
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+		if _, ok := cache.SessionIDFromCacheFile(entry.Name()); ok && !entry.IsDir() {
 			path := filepath.Join(cacheDir, entry.Name())
 			if err := os.Remove(path); err != nil {
 				return err
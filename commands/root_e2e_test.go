@@ -4,7 +4,11 @@
 package commands
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -12,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
 	"github.com/penwyp/go-claude-monitor/internal/testing/fixtures"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -76,6 +81,15 @@ func TestRootCommandOutputFormats(t *testing.T) {
 				`"total_cost"`,
 			},
 		},
+		{
+			name:   "YAML format",
+			format: "yaml",
+			expectedChecks: []string{
+				"Date:",
+				"Models:",
+				"Cost:",
+			},
+		},
 		{
 			name:   "CSV format",
 			format: "csv",
@@ -700,4 +714,96 @@ func TestRootCommandOutputConsistency(t *testing.T) {
 			assert.Regexp(t, costRegex, outputStr, "Format %s should contain cost information", format)
 		})
 	}
-}
\ No newline at end of file
+}
+// TestRootCommandNDJSONOutput reads --output ndjson line by line
+func TestRootCommandNDJSONOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	now := time.Now().UTC()
+	lastMonth := time.Date(now.Year(), now.Month()-1, 1, 10, 0, 0, 0, time.UTC)
+
+	// Two projects, one of them using two models, all in last month
+	entries := []struct {
+		project string
+		model   string
+		at      time.Time
+	}{
+		{"web-frontend", "claude-sonnet-4-20250514", lastMonth},
+		{"web-frontend", "claude-3-5-haiku-20241022", lastMonth.Add(time.Hour)},
+		{"api-backend", "claude-sonnet-4-20250514", lastMonth.Add(2 * time.Hour)},
+	}
+	for i, entry := range entries {
+		dir := filepath.Join(tempDir, entry.project)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":"s-%d","uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":%q,"role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+			entry.at.Format(time.RFC3339), i, i, i, i, entry.model)
+		f, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("s-%d.jsonl", i)), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = f.WriteString(line)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	binaryPath := filepath.Join(t.TempDir(), "test-monitor")
+	buildCmd := exec.Command("go", "build", "-o", binaryPath, "../cmd")
+	output, err := buildCmd.CombinedOutput()
+	require.NoError(t, err, "Failed to build binary: %s", string(output))
+
+	cmd := exec.Command(binaryPath, "--dir", tempDir, "--duration", "3m", "--group-by", "month",
+		"--timezone", "UTC", "--pricing-offline", "--output", "ndjson")
+	cmd.Env = append(os.Environ(), "HOME="+t.TempDir())
+	output, err = cmd.Output()
+	require.NoError(t, err, "ndjson output should succeed")
+
+	var records []formatter.JSONLRow
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		var r formatter.JSONLRow
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r), "Every line should be a JSON object: %s", scanner.Text())
+		records = append(records, r)
+	}
+	require.NoError(t, scanner.Err())
+
+	// One line per project and month, ordered by period, then project
+	require.Len(t, records, 2)
+	period := lastMonth.Format("2006-01")
+	assert.Equal(t, "api-backend", records[0].Project)
+	assert.Equal(t, period, records[0].Date)
+	assert.Equal(t, 110, records[0].TotalTokens)
+	assert.Equal(t, "web-frontend", records[1].Project)
+	assert.Equal(t, period, records[1].Date)
+	assert.Equal(t, 220, records[1].TotalTokens)
+	assert.Len(t, records[1].ModelDetails, 2, "Model breakdown should list both models")
+	for _, r := range records {
+		assert.Equal(t, "row", r.Type)
+		assert.Positive(t, r.Cost, "Project %s should have a cost", r.Project)
+	}
+}
+
+// TestRootCommandDefaultOutput runs without --output and expects the table
+func TestRootCommandDefaultOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	dir := filepath.Join(tempDir, "default-output")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-1","sessionId":"s-1","uuid":"u-1",`+
+		`"message":{"id":"msg-1","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+		time.Now().Add(-2*time.Hour).UTC().Format(time.RFC3339))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "s-1.jsonl"), []byte(line), 0644))
+
+	binaryPath := filepath.Join(t.TempDir(), "test-monitor")
+	buildCmd := exec.Command("go", "build", "-o", binaryPath, "../cmd")
+	output, err := buildCmd.CombinedOutput()
+	require.NoError(t, err, "Failed to build binary: %s", string(output))
+
+	for _, args := range [][]string{
+		{"--dir", tempDir},
+		{"--dir", tempDir, "--duration", "7d"},
+	} {
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Env = append(os.Environ(), "HOME="+t.TempDir())
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "%v should succeed: %s", args, string(output))
+		assert.NotContains(t, string(output), "invalid output format")
+		assert.Contains(t, string(output), "Total Tokens", "%v should print the table", args)
+	}
+}
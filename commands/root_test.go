@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
@@ -103,6 +104,16 @@ func TestClearCacheNonExistent(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestIgnoreProjectAliasesExcludeProject(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	var exclude []string
+	flags.StringArrayVar(&exclude, "exclude-project", nil, "")
+	flags.SetNormalizeFunc(projectFlagAlias)
+
+	require.NoError(t, flags.Parse([]string{"--ignore-project", "scratch-*", "--exclude-project", "tmp"}))
+	assert.Equal(t, []string{"scratch-*", "tmp"}, exclude)
+}
+
 func TestRootCommandFlags(t *testing.T) {
 
 	tests := []struct {
@@ -113,13 +124,32 @@ func TestRootCommandFlags(t *testing.T) {
 	}{
 		{"dir", defaultDataDir, "", true},
 		{"debug", "false", "", true},
+		{"error-json", "false", "", true},
+		{"input-format", "code", "", true},
+		{"exclude-project", "[]", "", true},
 		{"duration", "", "d", false},
 		{"group-by", "day", "", false},
 		{"output", "table", "o", false},
+		{"output-file", "", "", false},
 		{"breakdown", "false", "b", false},
 		{"reset", "false", "r", false},
 		{"timezone", "Local", "", false},
 		{"pricing-source", "default", "", false},
+		{"strict-pricing", "false", "", false},
+		{"include-zero-cost", "true", "", false},
+		{"since-last", "false", "", false},
+		{"since", "", "", false},
+		{"until", "", "", false},
+		{"cache-read-discount", "1", "", false},
+		{"disambiguate-projects", "false", "", false},
+		{"recost", "false", "", false},
+		{"split-by-project", "false", "", false},
+		{"output-dir", "", "", false},
+		{"business-days-only", "false", "", false},
+		{"holidays", "[]", "", false},
+		{"show-excluded-days", "false", "", false},
+		{"unknown-model", "keep", "", false},
+		{"unknown-model-pricing", "", "", false},
 	}
 
 	for _, tt := range tests {
@@ -163,8 +193,55 @@ func TestFormatFlagAlias(t *testing.T) {
 	// Test that format flag exists
 	formatFlag := rootCmd.Flags().Lookup("format")
 	assert.NotNil(t, formatFlag)
+	assert.Equal(t, "table", formatFlag.DefValue, "The alias must not reset the --output default")
 	
 	// Test that output flag exists
 	outputFlag := rootCmd.Flags().Lookup("output")
 	assert.NotNil(t, outputFlag)
-}
\ No newline at end of file
+}
+func TestRootReportMeta(t *testing.T) {
+	oldSource, oldTimezone, oldDuration, oldGroupBy := pricingSource, timezone, duration, groupBy
+	oldModels, oldInclude, oldExclude := models, includeProjects, excludeProjects
+	defer func() {
+		pricingSource, timezone, duration, groupBy = oldSource, oldTimezone, oldDuration, oldGroupBy
+		models, includeProjects, excludeProjects = oldModels, oldInclude, oldExclude
+	}()
+	pricingSource, timezone, duration, groupBy = "litellm", "UTC", "7d", "model"
+	models, includeProjects, excludeProjects = []string{"*opus*"}, []string{"acme"}, []string{"acme-sandbox*"}
+
+	meta := rootReportMeta()
+	assert.Equal(t, version, meta.Version)
+	assert.False(t, meta.GeneratedAt.IsZero())
+	assert.Equal(t, "litellm", meta.Config.PricingSource)
+	assert.Equal(t, "UTC", meta.Config.Timezone)
+	assert.Equal(t, "7d", meta.Config.Duration)
+	assert.Equal(t, "model", meta.Config.GroupBy)
+	assert.Equal(t, []string{"*opus*"}, meta.Config.Models)
+	assert.Equal(t, []string{"acme"}, meta.Config.Projects)
+	assert.Equal(t, []string{"acme-sandbox*"}, meta.Config.ExcludeProjects)
+	assert.Empty(t, meta.Config.Plan)
+	assert.Empty(t, meta.Config.TimelineMode)
+}
+
+func TestParseTimeRange(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// Bare dates are read in the timezone; --until covers its whole day
+	since, until, err := parseTimeRange("2024-06-03", "2024-06-04", "Asia/Shanghai")
+	require.NoError(t, err)
+	assert.True(t, since.Equal(time.Date(2024, 6, 3, 0, 0, 0, 0, shanghai)))
+	assert.True(t, until.Equal(time.Date(2024, 6, 5, 0, 0, 0, 0, shanghai)))
+
+	since, until, err = parseTimeRange("2024-06-03T09:00:00Z", "", "Asia/Shanghai")
+	require.NoError(t, err)
+	assert.True(t, since.Equal(time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, until.IsZero())
+
+	_, _, err = parseTimeRange("", "", "UTC")
+	assert.NoError(t, err)
+	_, _, err = parseTimeRange("last tuesday", "", "UTC")
+	assert.Error(t, err)
+	_, _, err = parseTimeRange("2024-06-05", "2024-06-04", "UTC")
+	assert.Error(t, err)
+}
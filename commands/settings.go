@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// claudeConfigDirEnv is the environment variable Claude Code reads its
+// configuration directory from, which holds the projects directory
+const claudeConfigDirEnv = "CLAUDE_CONFIG_DIR"
+
+// claudeSettingsFileName is the Claude settings file, kept in the home
+// directory or, when CLAUDE_CONFIG_DIR is set, in that directory
+const claudeSettingsFileName = ".claude.json"
+
+// claudeSettings holds the fields read from the Claude settings file
+type claudeSettings struct {
+	ProjectsDir string `json:"projectsDir"`
+}
+
+// claudeSettingsPath returns where the Claude settings file is looked for
+func claudeSettingsPath() string {
+	if dir := strings.TrimSpace(os.Getenv(claudeConfigDirEnv)); dir != "" {
+		return filepath.Join(dir, claudeSettingsFileName)
+	}
+	return expandPath("~/" + claudeSettingsFileName)
+}
+
+// settingsProjectsDir returns the projects directory set in the settings
+// file at path, or "" when the file is missing, unreadable or sets none.
+// Relative directories are resolved against the settings file's directory.
+func settingsProjectsDir(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var settings claudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return ""
+	}
+
+	dir := strings.TrimSpace(settings.ProjectsDir)
+	if dir == "" || strings.HasPrefix(dir, "~/") || filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(path), dir)
+}
+
+// configuredProjectsDir returns the projects directory Claude is configured
+// with: projectsDir from the settings file, else the projects directory
+// inside CLAUDE_CONFIG_DIR, or "" when neither is set
+func configuredProjectsDir() string {
+	if dir := settingsProjectsDir(claudeSettingsPath()); dir != "" {
+		return dir
+	}
+	if dir := strings.TrimSpace(os.Getenv(claudeConfigDirEnv)); dir != "" {
+		return filepath.Join(dir, "projects")
+	}
+	return ""
+}
+
+// applyConfiguredDataDir replaces the --dir default of cmd, value and help
+// alike, with the configured projects directory. An explicit --dir always
+// wins.
+func applyConfiguredDataDir(cmd *cobra.Command) error {
+	flag := cmd.Flag("dir")
+	if flag == nil || flag.Changed {
+		return nil
+	}
+	dir := configuredProjectsDir()
+	if dir == "" {
+		return nil
+	}
+	if err := flag.Value.Set(dir); err != nil {
+		return fmt.Errorf("failed to use configured projects directory %s: %w", dir, err)
+	}
+	flag.DefValue = dir
+	return nil
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDirCommand returns a command with a --dir flag bound to dir
+func newDirCommand(dir *string) *cobra.Command {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringVar(dir, "dir", defaultDataDir, "")
+	return cmd
+}
+
+// writeClaudeSettings writes a Claude settings file with content to dir
+func writeClaudeSettings(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, claudeSettingsFileName), []byte(content), 0644))
+}
+
+func TestApplyConfiguredDataDirUsesSettingsFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	projectsDir := filepath.Join(t.TempDir(), "elsewhere", "projects")
+	writeClaudeSettings(t, home, `{"projectsDir":"`+projectsDir+`","theme":"dark"}`)
+
+	var dir string
+	cmd := newDirCommand(&dir)
+	require.NoError(t, applyConfiguredDataDir(cmd))
+	assert.Equal(t, projectsDir, dir)
+	assert.Equal(t, projectsDir, cmd.Flag("dir").DefValue, "--help shows the configured default")
+}
+
+func TestApplyConfiguredDataDirSettingsInClaudeConfigDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+	writeClaudeSettings(t, configDir, `{"projectsDir":"data/projects"}`)
+
+	var dir string
+	require.NoError(t, applyConfiguredDataDir(newDirCommand(&dir)))
+	assert.Equal(t, filepath.Join(configDir, "data", "projects"), dir)
+}
+
+func TestApplyConfiguredDataDirUsesClaudeConfigDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configDir := filepath.Join(t.TempDir(), "elsewhere")
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+
+	var dir string
+	require.NoError(t, applyConfiguredDataDir(newDirCommand(&dir)))
+	assert.Equal(t, filepath.Join(configDir, "projects"), dir)
+}
+
+func TestApplyConfiguredDataDirExplicitFlagWins(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "/from/env")
+	writeClaudeSettings(t, home, `{"projectsDir":"/from/settings"}`)
+
+	var dir string
+	cmd := newDirCommand(&dir)
+	require.NoError(t, cmd.Flags().Set("dir", "/from/flag"))
+	require.NoError(t, applyConfiguredDataDir(cmd))
+	assert.Equal(t, "/from/flag", dir)
+}
+
+func TestApplyConfiguredDataDirFallsBackToDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+
+	var dir string
+	require.NoError(t, applyConfiguredDataDir(newDirCommand(&dir)))
+	assert.Equal(t, defaultDataDir, dir, "no settings file or CLAUDE_CONFIG_DIR")
+
+	writeClaudeSettings(t, home, `not json`)
+	require.NoError(t, applyConfiguredDataDir(newDirCommand(&dir)))
+	assert.Equal(t, defaultDataDir, dir, "malformed settings file")
+
+	t.Setenv("CLAUDE_CONFIG_DIR", "  ")
+	require.NoError(t, applyConfiguredDataDir(newDirCommand(&dir)))
+	assert.Equal(t, defaultDataDir, dir, "blank CLAUDE_CONFIG_DIR")
+}
+
+func TestHelpShowsConfiguredDataDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+
+	flag := rootCmd.Flag("dir")
+	oldValue, oldDefault := flag.Value.String(), flag.DefValue
+	defer func() {
+		_ = flag.Value.Set(oldValue)
+		flag.DefValue = oldDefault
+	}()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.HelpFunc()(rootCmd, nil)
+
+	assert.Contains(t, out.String(), filepath.Join(configDir, "projects"))
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/application/top"
+	"github.com/penwyp/go-claude-monitor/internal/core/constants"
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
 	"github.com/penwyp/go-claude-monitor/internal/core/session"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 	"github.com/spf13/cobra"
@@ -19,19 +21,53 @@ var (
 	// Plan related flags
 	topPlan              string
 	topCustomLimitTokens int
+	topLimitTokenTypes   string
+	topWeeklyTokenLimit  int
 
 	// Display related flags
 	topTimezone         string
 	topTimeFormat       string
 	topRefreshRate      int
 	topRefreshPerSecond float64
+	topRefreshInterval  time.Duration
+	topUIRate           float64
+	topPollInterval     time.Duration
+	topMetricsAddr      string
+	topLimitWebhook     string
+	topWatchDebounce    time.Duration
+	topIdleExit         time.Duration
+	topNotify           bool
+	topNotifyThreshold  time.Duration
+	topClampReset       bool
+	topCollapseModels   bool
+	topShowDaily        bool
+	topBurnSmoothing    float64
+	topFollow           string
+	topMaxSessionAge    time.Duration
+	topSessionDuration  time.Duration
+	topSessionGap       time.Duration
+	topWindowAnchor     string
+	topWindowAnchorTZ   string
+	topUTCWindows       bool
+	topNoSpeculative    bool
 
 	// Pricing related flags
 	topPricingSource      string
 	topPricingOfflineMode bool
+	topCacheReadDiscount  float64
+	topPricingFile        string
+	topPricingMaxAge      time.Duration
+	topCurrency           string
+	topExchangeRate       float64
 	
 	// Window history flags
 	topResetWindows bool
+
+	// Performance flags
+	topPreloadWorkers   int
+	topMaxCachedRawLogs int
+	topCacheCompress    bool
+	topDryRun           bool
 )
 
 var topCmd = &cobra.Command{
@@ -41,7 +77,7 @@ var topCmd = &cobra.Command{
 including token usage, cost, rates, and other key metrics.
 
 Session definition:
-- Session duration: 5-hour window
+- Session duration: 5-hour window (--session-duration to change)
 - Session start: First message timestamp rounded down to hour
 - Supports tracking multiple concurrent sessions`,
 	RunE: runTop,
@@ -55,26 +91,92 @@ func init() {
 		"Plan type (pro, max5, max20, custom)")
 	topCmd.Flags().IntVar(&topCustomLimitTokens, "custom-limit-tokens", 0,
 		"Token limit for custom plan")
+	topCmd.Flags().StringVar(&topLimitTokenTypes, "limit-token-types", "input,output,cache_creation,cache_read",
+		"Token types counted toward the plan's token limit (comma-separated)")
+	topCmd.Flags().IntVar(&topWeeklyTokenLimit, "weekly-token-limit", 0,
+		"Rolling 7-day token cap across all sessions, shown next to the window cap (0 = none)")
 
 	// Display flags
 	topCmd.Flags().StringVar(&topTimezone, "timezone", "Local",
 		"Timezone setting (e.g., Asia/Shanghai, UTC)")
 	topCmd.Flags().StringVar(&topTimeFormat, "time-format", "24h",
-		"Time format (12h or 24h)")
+		"Time format: 12h, 24h, iso or a Go time layout such as '15:04'")
 	topCmd.Flags().IntVar(&topRefreshRate, "refresh-rate", 10,
 		"Data refresh rate in seconds")
 	topCmd.Flags().Float64Var(&topRefreshPerSecond, "refresh-per-second", 0.75,
 		"Display refresh rate (0.1-20 Hz)")
+	topCmd.Flags().DurationVar(&topRefreshInterval, "refresh-interval", 10*time.Second,
+		"Data refresh interval (1s-1h), overrides --refresh-rate")
+	topCmd.Flags().Float64Var(&topUIRate, "ui-rate", 0.75,
+		"Display refresh rate in Hz (0.1-20), overrides --refresh-per-second")
+	topCmd.Flags().DurationVar(&topPollInterval, "poll-interval", 0,
+		"Poll for file changes on this interval instead of watching files (0 = watch)")
+	topCmd.Flags().DurationVar(&topWatchDebounce, "watch-debounce", top.DefaultWatchDebounce,
+		"Collect file change events for this long and process them as one batch")
+	topCmd.Flags().DurationVar(&topIdleExit, "idle-exit", 0,
+		"Exit after this long without keyboard input, e.g. 30m (0 = never)")
+	topCmd.Flags().BoolVar(&topNotify, "notify", false,
+		"Show a desktop notification when a window is about to reset or a limit is hit")
+	topCmd.Flags().DurationVar(&topNotifyThreshold, "notify-threshold", top.DefaultNotifyThreshold,
+		"Time before a window reset at which --notify warns")
+	topCmd.Flags().StringVar(&topMetricsAddr, "metrics-addr", "",
+		"Serve Prometheus metrics of the sessions on /metrics at this address, e.g. :9090")
+	topCmd.Flags().StringVar(&topLimitWebhook, "limit-webhook", "",
+		"POST a JSON payload to this URL when a new rate limit is detected")
+	topCmd.Flags().BoolVar(&topClampReset, "clamp-reset", true,
+		"Cap displayed reset time at one session duration from window start")
+	topCmd.Flags().DurationVar(&topSessionDuration, "session-duration", constants.SessionDuration,
+		"Length of a session window, for plans with a different reset cadence (1h-24h)")
+	topCmd.Flags().DurationVar(&topSessionGap, "session-gap", 0,
+		"Idle period after which activity starts a new window (0 = session duration)")
+	topCmd.Flags().StringVar(&topWindowAnchor, "window-anchor", "",
+		"Align continuous activity windows to a fixed time of day (HH:MM)")
+	topCmd.Flags().StringVar(&topWindowAnchorTZ, "window-anchor-timezone", "",
+		"Compute window boundaries and read --window-anchor in this timezone, e.g. that of the server reset (default --timezone)")
+	topCmd.Flags().BoolVar(&topUTCWindows, "utc-windows", false,
+		"Alias for --window-anchor-timezone UTC; --timezone then only affects display")
+	topCmd.Flags().BoolVar(&topNoSpeculative, "no-speculative-active", false,
+		"Do not create an active window for the current period when it has no logs")
+	topCmd.Flags().BoolVar(&topCollapseModels, "collapse-models", false,
+		"Show only the top model per session in the model distribution")
+	topCmd.Flags().BoolVar(&topShowDaily, "show-daily", false,
+		"Show the cumulative usage of the current day next to the active window")
+	topCmd.Flags().StringVar(&topFollow, "follow", "",
+		"Show only the active window of this project full-screen with a large countdown")
+	topCmd.Flags().DurationVar(&topMaxSessionAge, "max-session-age", 0,
+		"Hide sessions that ended longer ago than this, e.g. 24h (0 = show all)")
+	topCmd.Flags().Float64Var(&topBurnSmoothing, "burn-rate-smoothing", 0,
+		"Project usage from an exponentially smoothed per-minute burn rate with this alpha (0-1, 0 = session average)")
 
 	// Pricing flags
 	topCmd.Flags().StringVar(&topPricingSource, "pricing-source", "default",
 		"Pricing source (default, litellm)")
 	topCmd.Flags().BoolVar(&topPricingOfflineMode, "pricing-offline", false,
 		"Use offline pricing mode")
+	topCmd.Flags().Float64Var(&topCacheReadDiscount, "cache-read-discount", 1,
+		"Multiplier applied to the cache-read rate (0-1, 1 = list price)")
+	topCmd.Flags().StringVar(&topPricingFile, "pricing-file", "",
+		"JSON file of per-model rates overriding the pricing source")
+	topCmd.Flags().DurationVar(&topPricingMaxAge, "pricing-max-age", pricing.DefaultPricingMaxAge,
+		"Reuse cached pricing of a remote --pricing-source until it is this old")
+	topCmd.Flags().StringVar(&topCurrency, "currency", "USD",
+		"Currency costs are displayed in (e.g. EUR, GBP, JPY)")
+	topCmd.Flags().Float64Var(&topExchangeRate, "exchange-rate", 0,
+		"Units of --currency per USD (0 = built-in approximate rate)")
 	
 	// Window history flags
 	topCmd.Flags().BoolVar(&topResetWindows, "reset-windows", false,
 		"Reset window history before starting")
+
+	// Performance flags
+	topCmd.Flags().IntVar(&topPreloadWorkers, "preload-workers", 0,
+		"Workers loading the cache at startup (0 = CPU count)")
+	topCmd.Flags().IntVar(&topMaxCachedRawLogs, "max-cached-raw-logs", top.DefaultMaxCachedRawLogs,
+		"Sessions whose raw logs stay in memory, least recently used evicted first (negative = unlimited)")
+	topCmd.Flags().BoolVar(&topCacheCompress, "cache-compress", false,
+		"Write cache entries gzip-compressed (existing entries are read either way)")
+	topCmd.Flags().BoolVar(&topDryRun, "dry-run", false,
+		"Report how many files would be parsed or served from cache, then exit")
 }
 
 func runTop(cmd *cobra.Command, args []string) error {
@@ -90,17 +192,15 @@ func runTop(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	// Handle debug mode (inherited from root command)
-	logLevel := "info"
-	if debug {
-		logLevel = "debug"
-	}
+	initCommandLogger()
 
-	// Initialize logging (reuse root logic)
-	logFile := expandPath(defaultLogFile)
-	ensureDir(filepath.Dir(logFile))
-	util.InitLogger(logLevel, logFile, debug)
-	util.InitializeTimeProvider(topTimezone)
+	// Handle timezone
+	if topTimezone == "auto" {
+		topTimezone = "Local"
+	}
+	if err := util.InitializeTimeProvider(topTimezone); err != nil {
+		return newCommandError(ErrorCodeInvalidTimezone, err)
+	}
 
 	// Handle window history reset if requested
 	if topResetWindows {
@@ -109,19 +209,24 @@ func runTop(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Handle timezone
-	if topTimezone == "auto" {
-		topTimezone = "Local"
+	// Resolve refresh settings; the newer flags take precedence when set.
+	// Range checks happen in TopConfig.Validate.
+	refreshInterval := time.Duration(topRefreshRate) * time.Second
+	if cmd.Flags().Changed("refresh-interval") {
+		refreshInterval = topRefreshInterval
 	}
-
-	// Validate refresh rate
-	if topRefreshPerSecond < 0.1 || topRefreshPerSecond > 20 {
-		return fmt.Errorf("refresh-per-second must be between 0.1 and 20")
+	uiRate := topRefreshPerSecond
+	if cmd.Flags().Changed("ui-rate") {
+		uiRate = topUIRate
 	}
 
 	// Validate time format
-	if topTimeFormat != "12h" && topTimeFormat != "24h" {
-		return fmt.Errorf("invalid time format '%s': must be either '12h' or '24h'", topTimeFormat)
+	if err := util.ValidateTimeFormat(topTimeFormat); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	windowTZ, err := windowAnchorTimezone(topWindowAnchorTZ, topUTCWindows)
+	if err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
 	}
 
 	// Create configuration
@@ -130,13 +235,53 @@ func runTop(cmd *cobra.Command, args []string) error {
 		CacheDir:            expandPath(defaultCacheDir),
 		Plan:                topPlan,
 		CustomLimitTokens:   topCustomLimitTokens,
+		LimitTokenTypes:     topLimitTokenTypes,
+		WeeklyTokenLimit:    topWeeklyTokenLimit,
 		Timezone:            topTimezone,
 		TimeFormat:          topTimeFormat,
-		DataRefreshInterval: time.Duration(topRefreshRate) * time.Second,
-		UIRefreshRate:       topRefreshPerSecond,
+		DataRefreshInterval: refreshInterval,
+		UIRefreshRate:       uiRate,
+		PollInterval:        topPollInterval,
+		WatchDebounce:       topWatchDebounce,
+		IdleExit:            topIdleExit,
+		Notify:              topNotify,
+		NotifyThreshold:     topNotifyThreshold,
+		MetricsAddr:         topMetricsAddr,
+		LimitWebhook:        topLimitWebhook,
+		ClampResetTime:      topClampReset,
+		CollapseModels:      topCollapseModels,
+		ShowDailyUsage:      topShowDaily,
+		SessionDuration:     topSessionDuration,
+		SessionGap:          topSessionGap,
+		WindowAnchor:        topWindowAnchor,
+		WindowAnchorTZ:      windowTZ,
+		NoSpeculativeActive: topNoSpeculative,
+		BurnRateSmoothing:   topBurnSmoothing,
+		FollowProject:       topFollow,
+		MaxSessionAge:       topMaxSessionAge,
 		Concurrency:         runtime.NumCPU(),
+		PreloadWorkers:      topPreloadWorkers,
+		MaxCachedRawLogs:    topMaxCachedRawLogs,
+		CacheCompress:       topCacheCompress,
+		InputFormat:         inputFormat,
+		Projects:            includeProjects,
+		ExcludeProjects:     excludeProjects,
 		PricingSource:       topPricingSource,
 		PricingOfflineMode:  topPricingOfflineMode,
+		CacheReadDiscount:   topCacheReadDiscount,
+		PricingMaxAge:       topPricingMaxAge,
+	}
+
+	if err := config.Validate(); err != nil {
+		return newCommandError(ErrorCodeInvalidArgument, err)
+	}
+	pricingOverrides, err := loadPricingOverrides(topPricingFile)
+	if err != nil {
+		return err
+	}
+	config.PricingOverrides = pricingOverrides
+	if err := applyDisplayCurrency(topCurrency, topExchangeRate); err != nil {
+		return err
 	}
 
 	// Create orchestrator
@@ -144,6 +289,9 @@ func runTop(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create orchestrator: %w", err)
 	}
+	if topDryRun {
+		return runDryRun(orchestrator)
+	}
 
 	// Set up signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -161,6 +309,18 @@ func runTop(cmd *cobra.Command, args []string) error {
 	return orchestrator.Run(ctx)
 }
 
+// windowAnchorTimezone resolves --window-anchor-timezone and its alias
+// --utc-windows into the timezone window boundaries are computed in
+func windowAnchorTimezone(name string, utcWindows bool) (string, error) {
+	if !utcWindows {
+		return name, nil
+	}
+	if name != "" && name != "UTC" {
+		return "", fmt.Errorf("--utc-windows is --window-anchor-timezone UTC and cannot be combined with --window-anchor-timezone %s", name)
+	}
+	return "UTC", nil
+}
+
 // resetWindowHistory prompts for confirmation and resets the window history
 func resetWindowHistory() error {
 	// Get history file path
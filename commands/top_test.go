@@ -17,13 +17,34 @@ func TestTopCommandFlags(t *testing.T) {
 	}{
 		{"plan", "custom"},
 		{"custom-limit-tokens", "0"},
+		{"limit-token-types", "input,output,cache_creation,cache_read"},
+		{"weekly-token-limit", "0"},
 		{"timezone", "Local"},
 		{"time-format", "24h"},
 		{"refresh-rate", "10"},
 		{"refresh-per-second", "0.75"},
+		{"refresh-interval", "10s"},
+		{"ui-rate", "0.75"},
+		{"poll-interval", "0s"},
+		{"watch-debounce", "500ms"},
+		{"idle-exit", "0s"},
+		{"clamp-reset", "true"},
+		{"collapse-models", "false"},
+		{"show-daily", "false"},
+		{"burn-rate-smoothing", "0"},
+		{"follow", ""},
+		{"max-session-age", "0s"},
+		{"preload-workers", "0"},
+		{"max-cached-raw-logs", "1000"},
+		{"cache-read-discount", "1"},
+		{"window-anchor", ""},
+		{"window-anchor-timezone", ""},
+		{"utc-windows", "false"},
+		{"no-speculative-active", "false"},
 		{"pricing-source", "default"},
 		{"pricing-offline", "false"},
 		{"reset-windows", "false"},
+		{"dry-run", "false"},
 	}
 
 	for _, tt := range tests {
@@ -56,25 +77,42 @@ func TestRunTopValidation(t *testing.T) {
 			name: "invalid time format",
 			args: []string{"--time-format", "invalid"},
 			wantError: true,
-			errorMsg: "invalid time format 'invalid': must be either '12h' or '24h'",
+			errorMsg: "invalid time format 'invalid': must be 12h, 24h, iso or a Go time layout such as '15:04'",
+		},
+		{
+			name: "valid custom time layout",
+			args: []string{"--time-format", "15:04 MST"},
+			wantError: false,
 		},
 		{
 			name: "refresh rate too low",
 			args: []string{"--refresh-per-second", "0.05"},
 			wantError: true,
-			errorMsg: "refresh-per-second must be between 0.1 and 20",
+			errorMsg: "UI refresh rate 0.05 is out of range: must be between 0.1 and 20 Hz",
 		},
 		{
 			name: "refresh rate too high",
 			args: []string{"--refresh-per-second", "25"},
 			wantError: true,
-			errorMsg: "refresh-per-second must be between 0.1 and 20",
+			errorMsg: "UI refresh rate 25 is out of range: must be between 0.1 and 20 Hz",
 		},
 		{
 			name: "valid refresh rate",
 			args: []string{"--refresh-per-second", "1.5"},
 			wantError: false,
 		},
+		{
+			name: "zero ui rate",
+			args: []string{"--ui-rate", "0"},
+			wantError: true,
+			errorMsg: "UI refresh rate 0 is out of range: must be between 0.1 and 20 Hz",
+		},
+		{
+			name: "refresh interval too short",
+			args: []string{"--refresh-interval", "100ms"},
+			wantError: true,
+			errorMsg: "refresh interval 100ms is out of range: must be between 1s and 1h0m0s",
+		},
 	}
 
 	for _, tt := range tests {
@@ -159,4 +197,20 @@ func TestTopAutoTimezone(t *testing.T) {
 	}
 	
 	assert.Equal(t, expected, timezone)
-}
\ No newline at end of file
+}
+func TestWindowAnchorTimezone(t *testing.T) {
+	name, err := windowAnchorTimezone("America/Los_Angeles", false)
+	require.NoError(t, err)
+	assert.Equal(t, "America/Los_Angeles", name)
+
+	// --utc-windows is an alias for --window-anchor-timezone UTC
+	name, err = windowAnchorTimezone("", true)
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", name)
+	name, err = windowAnchorTimezone("UTC", true)
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", name)
+
+	_, err = windowAnchorTimezone("America/Los_Angeles", true)
+	assert.Error(t, err)
+}
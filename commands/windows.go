@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Windows inspect command flags
+	windowsInspectAt string
+)
+
+// windowsTimeLayouts are the accepted --at formats besides RFC 3339, read in
+// local time
+var windowsTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+var windowsCmd = &cobra.Command{
+	Use:   "windows",
+	Short: "Inspect the learned window history",
+	Long: `Commands for the window history that session detection learns across runs.
+When detection results change between runs, the accumulated history is often
+the cause.`,
+}
+
+var windowsInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Print the window history, optionally as it stood at a past time",
+	Long: `Prints every window record in the history with its source and when it was
+recorded. With --at only records created at or before that time are shown,
+reconstructing the history detection would have used then. A record's creation
+time is refreshed when it is updated, so records changed after --at are left
+out too.
+
+Examples:
+  go-claude-monitor windows inspect
+  go-claude-monitor windows inspect --at "2025-07-01 09:00"
+  go-claude-monitor windows inspect --at 2025-07-01T09:00:00Z`,
+	Args: cobra.NoArgs,
+	RunE: runWindowsInspect,
+}
+
+func init() {
+	rootCmd.AddCommand(windowsCmd)
+	windowsCmd.AddCommand(windowsInspectCmd)
+
+	windowsInspectCmd.Flags().StringVar(&windowsInspectAt, "at", "",
+		"Show the history as it stood at this time (RFC 3339 or YYYY-MM-DD [HH:MM[:SS]] local time)")
+}
+
+func runWindowsInspect(cmd *cobra.Command, args []string) error {
+	initCommandLogger()
+
+	var at int64
+	if windowsInspectAt != "" {
+		t, err := parseInspectTime(windowsInspectAt, time.Local)
+		if err != nil {
+			return newCommandError(ErrorCodeInvalidArgument, err)
+		}
+		at = t.Unix()
+	}
+
+	history := session.NewWindowHistoryManager(expandPath(defaultCacheDir))
+	if err := history.Load(); err != nil {
+		return newCommandError(ErrorCodeIO, err)
+	}
+	printWindowHistory(cmd.OutOrStdout(), history, at, time.Local)
+	return nil
+}
+
+// parseInspectTime parses an --at value as RFC 3339 or one of
+// windowsTimeLayouts in loc
+func parseInspectTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range windowsTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s': use RFC 3339 or YYYY-MM-DD [HH:MM[:SS]]", value)
+}
+
+// printWindowHistory writes the records of history as of at (0 = now) to out
+func printWindowHistory(out io.Writer, history *session.WindowHistoryManager, at int64, loc *time.Location) {
+	const layout = "2006-01-02 15:04"
+	windows := history.WindowsAt(at)
+
+	fmt.Fprintf(out, "Window history: %s\n", history.GetHistoryPath())
+	if at != 0 {
+		fmt.Fprintf(out, "As of: %s\n", time.Unix(at, 0).In(loc).Format(layout))
+	}
+	if len(windows) == 0 {
+		fmt.Fprintln(out, "No window records.")
+		return
+	}
+
+	fmt.Fprintf(out, "%-16s  %-16s  %-20s  %-5s  %-7s  %-16s  %s\n",
+		"START", "END", "SOURCE", "LIMIT", "ACCOUNT", "CREATED", "SESSION")
+	for _, w := range windows {
+		fmt.Fprintf(out, "%-16s  %-16s  %-20s  %-5s  %-7s  %-16s  %s\n",
+			time.Unix(w.StartTime, 0).In(loc).Format(layout),
+			time.Unix(w.EndTime, 0).In(loc).Format(layout),
+			w.Source,
+			yesNo(w.IsLimitReached),
+			yesNo(w.IsAccountLevel),
+			time.Unix(w.CreatedAt, 0).In(loc).Format(layout),
+			w.SessionID)
+	}
+	fmt.Fprintf(out, "\nTotal records: %d\n", len(windows))
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
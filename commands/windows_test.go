@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowsInspectAtFiltersLaterRecords(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	history := session.NewWindowHistoryManager(t.TempDir())
+
+	base := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC).Unix()
+	records := session.WindowHistory{Windows: []session.WindowRecord{
+		{SessionID: "early", Source: "gap", StartTime: base, EndTime: base + 5*3600, CreatedAt: base + 5*3600},
+		{SessionID: "late", Source: "limit_message", IsLimitReached: true,
+			StartTime: base + 6*3600, EndTime: base + 11*3600, CreatedAt: base + 11*3600},
+	}}
+	data, err := json.Marshal(&records)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(history.GetHistoryPath()), 0755))
+	require.NoError(t, os.WriteFile(history.GetHistoryPath(), data, 0644))
+	require.NoError(t, history.Load())
+
+	var out bytes.Buffer
+	printWindowHistory(&out, history, 0, time.UTC)
+	assert.Contains(t, out.String(), "early")
+	assert.Contains(t, out.String(), "late")
+	assert.Contains(t, out.String(), "Total records: 2")
+
+	// Between the two records only the first existed
+	at, err := parseInspectTime("2025-07-01 08:00", time.UTC)
+	require.NoError(t, err)
+	out.Reset()
+	printWindowHistory(&out, history, at.Unix(), time.UTC)
+	assert.Contains(t, out.String(), "As of: 2025-07-01 08:00")
+	assert.Contains(t, out.String(), "early")
+	assert.NotContains(t, out.String(), "late")
+	assert.Contains(t, out.String(), "Total records: 1")
+
+	// Before either record the history was empty
+	out.Reset()
+	printWindowHistory(&out, history, base, time.UTC)
+	assert.Contains(t, out.String(), "No window records.")
+}
+
+func TestParseInspectTime(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*3600)
+	for value, want := range map[string]time.Time{
+		"2025-07-01T09:00:00Z": time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC),
+		"2025-07-01 09:00":     time.Date(2025, 7, 1, 9, 0, 0, 0, loc),
+		"2025-07-01 09:00:30":  time.Date(2025, 7, 1, 9, 0, 30, 0, loc),
+		"2025-07-01":           time.Date(2025, 7, 1, 0, 0, 0, 0, loc),
+	} {
+		got, err := parseInspectTime(value, loc)
+		require.NoError(t, err, value)
+		assert.True(t, want.Equal(got), "%s: expected %s, got %s", value, want, got)
+	}
+
+	_, err := parseInspectTime("yesterday", loc)
+	assert.Error(t, err)
+}
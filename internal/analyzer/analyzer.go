@@ -1,7 +1,11 @@
 package analyzer
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -10,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
 	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
 	"github.com/penwyp/go-claude-monitor/internal/data/cache"
 	"github.com/penwyp/go-claude-monitor/internal/data/parser"
@@ -18,19 +23,84 @@ import (
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
+var (
+	// ErrNoFilesFound is returned when the data directory contains no JSONL files
+	ErrNoFilesFound = errors.New("No JSONL files found")
+	// ErrNoUsageData is returned when no API usage records could be parsed
+	ErrNoUsageData = errors.New("No valid API usage data found")
+)
+
+// NoBranch is the --group-by branch group of usage logged outside a git
+// repository or without a branch
+const NoBranch = "(no branch)"
+
 type Config struct {
 	DataDir      string
 	CacheDir     string
 	OutputFormat string
+	OutputFile   string // Write formatted output here instead of stdout
 	Timezone     string
+	TimeFormat   string // Layout of hour groups: 12h, 24h (default), iso or a Go layout
 	Duration     string
 	GroupBy      string
 	Limit        int
 	Breakdown    bool
 	Concurrency  int
+	InputFormat  string // code (default) or desktop
+	// SinceLast reports only the complete hours after the watermark left by
+	// the previous --since-last run, then advances it to the hour in
+	// progress. Without a watermark Duration applies.
+	SinceLast bool
+	// Since and Until report usage in hours starting in [Since, Until)
+	// instead of Duration; a zero bound leaves that side open
+	Since time.Time
+	Until time.Time
+	// DisambiguateProjects qualifies project names shared by different
+	// directories with their parent path instead of merging their usage
+	DisambiguateProjects bool
+	// Recost reports the aggregation already in the cache with the current
+	// pricing without scanning or reading any source logs
+	Recost bool
+	// CacheCompress writes aggregation cache entries gzip-compressed; entries
+	// are read in either form
+	CacheCompress bool
+	// SplitByProject writes each project's analysis to its own file in
+	// OutputDir instead of a single report
+	SplitByProject bool
+	OutputDir      string
+	// StrictPricing reports models without pricing as unpriced (cost 0)
+	// instead of billing them at default rates
+	StrictPricing bool
+	// ExcludeUnpriced drops models without pricing from the results so they
+	// do not distort cost totals and rankings
+	ExcludeUnpriced bool
+	// UnknownModel selects how usage logged without a model name is handled
+	// (keep, drop, price, warn); UnknownModelPricing is the model it is billed
+	// as in price mode
+	UnknownModel        string
+	UnknownModelPricing string
+	// BusinessDaysOnly drops usage on weekends and Holidays from day and week
+	// rollups and their averages; days are taken in Timezone
+	BusinessDaysOnly bool
+	Holidays         []string // YYYY-MM-DD dates excluded with BusinessDaysOnly
+	// ShowExcludedDays keeps excluded days in day rollups as zero rows
+	ShowExcludedDays bool
+	// Models are globs of the models to report, matched against the raw
+	// model ID or its simplified name (empty = all)
+	Models []string
+	// Projects and ExcludeProjects select files by project name: globs or
+	// substrings, include applied first (empty = all). Excluded project
+	// directories are never scanned or parsed.
+	Projects        []string
+	ExcludeProjects []string
+	// Meta is embedded in JSON output together with the rows when set
+	Meta *formatter.Meta
 	// Pricing configuration
-	PricingSource      string // default, litellm
-	PricingOfflineMode bool   // Enable offline pricing mode
+	PricingSource      string                          // default, litellm
+	PricingOfflineMode bool                            // Enable offline pricing mode
+	CacheReadDiscount  float64                         // Multiplier on the cache-read rate, 0-1 (1 = list price, 0 = free)
+	PricingOverrides   map[string]pricing.ModelPricing // Per-model rates layered over the pricing source
+	PricingMaxAge      time.Duration                   // Reuse cached remote pricing younger than this (0 = 24h)
 }
 
 type Analyzer struct {
@@ -39,20 +109,19 @@ type Analyzer struct {
 	scanner    *scanner.FileScanner
 	parser     *parser.Parser
 	aggregator *aggregator.Aggregator
+	location   *time.Location // Timezone used to assign hours to groups
+	holidays   map[string]bool
+	warnings   io.Writer // Destination of warnings meant for the user
+
+	// Business days, or weeks holding one, in the reported range; the
+	// summary averages over them with BusinessDaysOnly
+	businessPeriods int
 }
 
 // extractSessionId extracts the session ID from a file path.
 // For example: "/path/to/00aec530-0614-436f-a53b-faaa0b32f123.jsonl" -> "00aec530-0614-436f-a53b-faaa0b32f123"
 func extractSessionId(filePath string) string {
-	// Handle both Unix and Windows path separators
-	filename := filePath
-	if idx := strings.LastIndex(filename, "/"); idx != -1 {
-		filename = filename[idx+1:]
-	}
-	if idx := strings.LastIndex(filename, "\\"); idx != -1 {
-		filename = filename[idx+1:]
-	}
-	return strings.TrimSuffix(filename, filepath.Ext(filename))
+	return scanner.SessionID(filePath)
 }
 
 func New(config *Config) *Analyzer {
@@ -61,11 +130,15 @@ func New(config *Config) *Analyzer {
 	}
 
 	fileCache, _ := cache.NewFileCache(config.CacheDir)
+	if fileCache != nil {
+		fileCache.SetCompress(config.CacheCompress)
+	}
 
 	// Create aggregator with pricing configuration
 	agg, err := aggregator.NewAggregatorWithConfig(
 		config.PricingSource,
 		config.PricingOfflineMode,
+		config.PricingMaxAge,
 		config.CacheDir,
 		config.Timezone,
 	)
@@ -74,20 +147,80 @@ func New(config *Config) *Analyzer {
 		// Fallback to default aggregator
 		agg = aggregator.NewAggregatorWithTimezone(config.Timezone)
 	}
+	agg.SetCacheReadDiscount(config.CacheReadDiscount)
+	agg.SetPricingOverrides(config.PricingOverrides)
+	agg.SetStrictPricing(config.StrictPricing)
+
+	adapter, err := parser.AdapterForFormat(config.InputFormat)
+	if err != nil {
+		util.LogError("Failed to select input adapter: " + err.Error())
+		// Fallback to Claude Code format
+		adapter, _ = parser.AdapterForFormat(parser.FormatCode)
+	}
+
+	holidays, err := ParseHolidays(config.Holidays)
+	if err != nil {
+		util.LogError("Failed to parse holidays: " + err.Error())
+	}
+
+	fileScanner := scanner.NewFileScanner(config.DataDir)
+	fileScanner.SetProjectFilter(config.Projects, config.ExcludeProjects)
 
 	return &Analyzer{
 		config:     config,
 		cache:      fileCache,
-		scanner:    scanner.NewFileScanner(config.DataDir),
-		parser:     parser.NewParser(config.Concurrency),
+		scanner:    fileScanner,
+		parser:     parser.NewParserWithAdapter(config.Concurrency, adapter),
 		aggregator: agg,
+		location:   loadLocation(config.Timezone),
+		holidays:   holidays,
+		warnings:   os.Stderr,
+	}
+}
+
+// loadLocation resolves the configured timezone, falling back to local time
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.Local
 	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
 }
 
-func (a *Analyzer) Run() error {
+// Run analyzes the data directory and writes the report. Cancelling ctx stops
+// parsing; files parsed until then stay cached and ctx's error is returned.
+func (a *Analyzer) Run(ctx context.Context) error {
 	startTime := time.Now()
 	util.LogInfo("Starting analysis of Claude usage...")
 
+	if a.config.Recost {
+		return a.recost(startTime)
+	}
+
+	allHourlyData, err := a.collectHourlyData(ctx)
+	if err != nil {
+		return err
+	}
+	// Files written before --since are skipped, so an explicit range with
+	// no usage reports nothing instead of failing
+	if len(allHourlyData) == 0 && !a.hasTimeRange() {
+		return ErrNoUsageData
+	}
+
+	if err := a.report(allHourlyData); err != nil {
+		return err
+	}
+
+	util.LogDebug(fmt.Sprintf("Total duration: %v", time.Since(startTime)))
+	return nil
+}
+
+// collectHourlyData scans the data directory and returns the hourly usage of
+// every log file, from the cache where it is still valid and parsed otherwise
+func (a *Analyzer) collectHourlyData(ctx context.Context) ([]aggregator.HourlyData, error) {
 	// Phase 1: Preload cache into memory
 	preloadStart := time.Now()
 	if err := a.cache.Preload(); err != nil {
@@ -100,22 +233,29 @@ func (a *Analyzer) Run() error {
 	scanStart := time.Now()
 	files, err := a.scanner.Scan()
 	if err != nil {
-		return fmt.Errorf("Failed to scan files: %w", err)
+		return nil, fmt.Errorf("Failed to scan files: %w", err)
 	}
 	scanDuration := time.Since(scanStart)
 	util.LogDebug(fmt.Sprintf("Phase 2 - File scan duration: %v, found %d files", scanDuration, len(files)))
 
 	if len(files) == 0 {
-		return fmt.Errorf("No JSONL files found")
+		return nil, ErrNoFilesFound
 	}
 
 	util.LogInfo(fmt.Sprintf("Found %d JSONL files", len(files)))
+	files = a.filterFilesBySince(files)
 
 	// Phase 3: Batch validate cache and process files
 	parseStart := time.Now()
 	stats := NewCacheStats()
 	var allHourlyData []aggregator.HourlyData
 
+	// Resolve colliding project names across directories
+	var projectNames map[string]string
+	if a.config.DisambiguateProjects {
+		projectNames = aggregator.DisambiguateProjectNames(files)
+	}
+
 	// Create session ID mapping
 	sessionIdMap := make(map[string]string, len(files))
 	sessionIds := make([]string, 0, len(files))
@@ -158,7 +298,11 @@ func (a *Analyzer) Run() error {
 		cacheResult := a.cache.Get(sessionId)
 		if cacheResult.Found && cacheResult.Data != nil {
 			stats.IncrementHit()
-			allHourlyData = append(allHourlyData, cacheResult.Data.HourlyStats...)
+			hourlyData := cacheResult.Data.HourlyStats
+			if name, ok := projectNames[file]; ok {
+				hourlyData = withProjectName(hourlyData, name)
+			}
+			allHourlyData = append(allHourlyData, hourlyData...)
 		}
 		stats.IncrementTotal()
 	}
@@ -168,7 +312,7 @@ func (a *Analyzer) Run() error {
 	// Concurrently parse files that need processing
 	if len(filesToParse) > 0 {
 		parseFileStart := time.Now()
-		parseResults := a.parser.ParseFiles(filesToParse)
+		parseResults := a.parser.ParseFilesContext(ctx, filesToParse)
 
 		processed := int64(len(cachedFiles)) // Number of cache files already processed
 		cacheMisses := int64(0)
@@ -189,7 +333,7 @@ func (a *Analyzer) Run() error {
 			stats.IncrementMiss(result.File, missReason)
 			cacheMisses++
 
-			projectName := aggregator.ExtractProjectName(result.File)
+			projectName := scanner.ExtractProjectName(result.File)
 			hourlyData := a.aggregator.AggregateByHourAndModel(result.Logs, projectName)
 
 			aggregatedData := &aggregator.AggregatedData{
@@ -204,6 +348,10 @@ func (a *Analyzer) Run() error {
 				util.LogWarn(fmt.Sprintf("Failed to save cache for %s: %v", result.File, err))
 			}
 
+			// The cache keeps the plain name so toggling the option needs no reparse
+			if name, ok := projectNames[result.File]; ok {
+				hourlyData = withProjectName(hourlyData, name)
+			}
 			allHourlyData = append(allHourlyData, hourlyData...)
 
 			if processed%100 == 0 {
@@ -214,6 +362,11 @@ func (a *Analyzer) Run() error {
 
 		parseFilesDuration := time.Since(parseFileStart)
 		util.LogDebug(fmt.Sprintf("File parsing duration: %v", parseFilesDuration))
+
+		// Files parsed before cancellation are already cached
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("analysis interrupted after parsing %d of %d files: %w", processed-int64(len(cachedFiles)), len(filesToParse), err)
+		}
 	}
 
 	parseDuration := time.Since(parseStart)
@@ -223,45 +376,160 @@ func (a *Analyzer) Run() error {
 	stats.PrintPeriodicStats()
 	stats.PrintFinalStats()
 
+	util.LogDebug(fmt.Sprintf("Collection duration (preload:%v scan:%v parse:%v)", preloadDuration, scanDuration, parseDuration))
+	return allHourlyData, nil
+}
+
+// recost reports every cached file under the data directory with the
+// configured pricing. Logs written or changed since they were cached are not
+// picked up until a regular run refreshes the cache.
+func (a *Analyzer) recost(startTime time.Time) error {
+	loadStart := time.Now()
+	entries, err := a.cache.LoadAll()
+	if err != nil {
+		return fmt.Errorf("Failed to load cache: %w", err)
+	}
+
+	var cachedEntries []*aggregator.AggregatedData
+	var files []string
+	for _, entry := range entries {
+		if isWithinDir(entry.FilePath, a.config.DataDir) && !a.scanner.IgnoresFile(entry.FilePath) {
+			cachedEntries = append(cachedEntries, entry)
+			files = append(files, entry.FilePath)
+		}
+	}
+
+	var projectNames map[string]string
+	if a.config.DisambiguateProjects {
+		projectNames = aggregator.DisambiguateProjectNames(files)
+	}
+
+	var allHourlyData []aggregator.HourlyData
+	for _, entry := range cachedEntries {
+		hourlyData := entry.HourlyStats
+		if name, ok := projectNames[entry.FilePath]; ok {
+			hourlyData = withProjectName(hourlyData, name)
+		}
+		allHourlyData = append(allHourlyData, hourlyData...)
+	}
+	util.LogInfo(fmt.Sprintf("Recosting %d cached files without reading source logs", len(cachedEntries)))
+	util.LogDebug(fmt.Sprintf("Cache load duration: %v, total records: %d", time.Since(loadStart), len(allHourlyData)))
+
 	if len(allHourlyData) == 0 {
-		return fmt.Errorf("No valid API usage data found")
+		return ErrNoUsageData
 	}
 
-	// Phase 4: Filter by date range
+	if err := a.report(allHourlyData); err != nil {
+		return err
+	}
+
+	util.LogDebug(fmt.Sprintf("Total duration: %v", time.Since(startTime)))
+	return nil
+}
+
+// isWithinDir reports whether path lies inside dir, comparing paths only
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// report filters, groups, sorts and writes out the hourly data. Costs are
+// calculated here from token counts, never taken from the cache.
+func (a *Analyzer) report(allHourlyData []aggregator.HourlyData) error {
+	// Phase 4: Filter by the watermark of the last run, the explicit time
+	// range or the date range, then by model
 	filterStart := time.Now()
-	filteredData := a.filterByDateRange(allHourlyData)
+	var watermark int64
+	hasWatermark := false
+	if a.config.SinceLast {
+		var err error
+		watermark, hasWatermark, err = loadWatermark(a.watermarkPath())
+		if err != nil {
+			return fmt.Errorf("failed to read watermark: %w", err)
+		}
+	}
+	currentHour := currentHourStart(time.Now())
+	var filteredData []aggregator.HourlyData
+	if hasWatermark {
+		util.LogInfo(fmt.Sprintf("Reporting usage since last run at %s", time.Unix(watermark, 0).In(a.location).Format(time.RFC3339)))
+		filteredData = filterSinceWatermark(allHourlyData, watermark, currentHour)
+	} else if a.config.SinceLast {
+		// The first run reports --duration up to the hour in progress
+		filteredData = filterSinceWatermark(a.filterByDateRange(allHourlyData), 0, currentHour)
+	} else if a.hasTimeRange() {
+		filteredData = a.filterByTimeRange(allHourlyData)
+	} else {
+		filteredData = a.filterByDateRange(allHourlyData)
+	}
+	filteredData = a.filterByModel(filteredData)
+	if a.config.BusinessDaysOnly {
+		a.businessPeriods = a.countBusinessPeriods(filteredData)
+	}
 	filterDuration := time.Since(filterStart)
 	util.LogDebug(fmt.Sprintf("Phase 4 - Date filtering duration: %v, records after filtering: %d", filterDuration, len(filteredData)))
 
+	// Phases 5-7: Group, sort, format and output
+	outputStart := time.Now()
+	var err error
+	if a.config.SplitByProject {
+		err = a.outputByProject(filteredData)
+	} else {
+		err = a.formatAndOutput(a.groupAndSort(filteredData))
+	}
+	outputDuration := time.Since(outputStart)
+	util.LogDebug(fmt.Sprintf("Phases 5-7 - Grouping and output duration: %v", outputDuration))
+
+	// Advance the watermark only once the report has been written
+	if err == nil && a.config.SinceLast {
+		if currentHour > watermark {
+			if err := saveWatermark(a.watermarkPath(), currentHour); err != nil {
+				return fmt.Errorf("failed to save watermark: %w", err)
+			}
+		}
+	}
+
+	util.LogDebug(fmt.Sprintf("Report duration (filter:%v output:%v)", filterDuration, outputDuration))
+
+	return err
+}
+
+// groupAndSort groups and sorts the data, then applies the result limit
+func (a *Analyzer) groupAndSort(data []aggregator.HourlyData) []formatter.GroupedData {
 	// Phase 5: Group data
 	groupStart := time.Now()
-	groupedData := a.groupData(filteredData)
-	groupDuration := time.Since(groupStart)
-	util.LogDebug(fmt.Sprintf("Phase 5 - Data grouping duration: %v, number of groups: %d", groupDuration, len(groupedData)))
+	groupedData := a.groupData(data)
+	util.LogDebug(fmt.Sprintf("Phase 5 - Data grouping duration: %v, number of groups: %d", time.Since(groupStart), len(groupedData)))
 
 	// Phase 6: Sort data
 	sortStart := time.Now()
 	sortedData := a.sortData(groupedData)
-	sortDuration := time.Since(sortStart)
-	util.LogDebug(fmt.Sprintf("Phase 6 - Data sorting duration: %v", sortDuration))
+	util.LogDebug(fmt.Sprintf("Phase 6 - Data sorting duration: %v", time.Since(sortStart)))
 
 	if a.config.Limit > 0 && len(sortedData) > a.config.Limit {
 		util.LogDebug(fmt.Sprintf("Applying result limit: %d -> %d", len(sortedData), a.config.Limit))
 		sortedData = sortedData[:a.config.Limit]
 	}
+	a.formatHourGroups(sortedData)
+	return sortedData
+}
 
-	// Phase 7: Format and output
-	outputStart := time.Now()
-	err = a.formatAndOutput(sortedData)
-	outputDuration := time.Since(outputStart)
-	util.LogDebug(fmt.Sprintf("Phase 7 - Formatting and output duration: %v", outputDuration))
-
-	totalDuration := time.Since(startTime)
-	util.LogDebug(fmt.Sprintf("Total duration: %v (preload:%v scan:%v parse:%v filter:%v group:%v sort:%v output:%v)",
-		totalDuration, preloadDuration, scanDuration, parseDuration,
-		filterDuration, groupDuration, sortDuration, outputDuration))
-
-	return err
+// formatHourGroups renders hour groups in the configured time format. Rows are
+// sorted by their canonical hour key first, which orders them in time.
+func (a *Analyzer) formatHourGroups(data []formatter.GroupedData) {
+	if a.config.GroupBy != "hour" || a.config.TimeFormat == "" {
+		return
+	}
+	layout := util.DateTimeLayout(a.config.TimeFormat, false)
+	for i := range data {
+		hour, err := time.ParseInLocation(hourGroupLayout, data[i].Date, a.location)
+		if err != nil {
+			continue
+		}
+		data[i].Date = hour.Format(layout)
+	}
 }
 
 func (a *Analyzer) filterByDateRange(data []aggregator.HourlyData) []aggregator.HourlyData {
@@ -293,23 +561,62 @@ func (a *Analyzer) filterByDateRange(data []aggregator.HourlyData) []aggregator.
 func (a *Analyzer) groupData(data []aggregator.HourlyData) []formatter.GroupedData {
 	groupMap := make(map[string]*formatter.GroupedData)
 	modelDetailsMap := make(map[string]map[string]*formatter.ModelDetail)
+	tierDetailsMap := make(map[string]map[string]*formatter.TierDetail)
+
+	unpricedModels := make(map[string]int)
+	var unknownTokens, totalTokens int
+	var unknownCost float64
 
 	for _, item := range data {
+		if item.Model == aggregator.UnknownModel && a.config.UnknownModel == UnknownModelDrop {
+			unknownTokens += item.TotalTokens
+			continue
+		}
+
+		if a.config.BusinessDaysOnly && !isBusinessDay(time.Unix(item.Hour, 0).In(a.location), a.holidays) {
+			// Excluded days contribute no usage, but may still be listed
+			if a.config.ShowExcludedDays && a.config.GroupBy == "day" {
+				period, project := a.getRowKey(item)
+				groupKey := period + "\x00" + project
+				if _, ok := groupMap[groupKey]; !ok {
+					groupMap[groupKey] = &formatter.GroupedData{Date: period, Project: project, Excluded: true}
+				}
+			}
+			continue
+		}
+
 		// Calculate cost in real-time instead of using cached cost
-		cost, err := a.aggregator.CalculateCost(&item)
-		if err != nil {
+		cost, err := a.aggregator.CalculateCost(a.pricedAs(item))
+		unpriced := errors.Is(err, aggregator.ErrUnpriced)
+		if unpriced {
+			unpricedModels[item.Model] += item.TotalTokens
+			if a.config.ExcludeUnpriced {
+				continue
+			}
+		} else if err != nil {
 			util.LogWarn(fmt.Sprintf("Failed to calculate cost for model %s: %v", item.Model, err))
+		}
+		if err != nil {
 			cost = 0 // Use 0 as the default value if calculation fails
 		}
 
-		groupKey := a.getGroupKey(item)
+		period, project := a.getRowKey(item)
+		groupKey := period + "\x00" + project
 
 		if _, ok := groupMap[groupKey]; !ok {
 			groupMap[groupKey] = &formatter.GroupedData{
-				Date:          groupKey,
-				ShowBreakdown: a.config.Breakdown || a.config.OutputFormat == "summary",
+				Date:          period,
+				Project:       project,
+				ShowBreakdown: a.showBreakdown(),
 			}
 			modelDetailsMap[groupKey] = make(map[string]*formatter.ModelDetail)
+			tierDetailsMap[groupKey] = make(map[string]*formatter.TierDetail)
+		}
+
+		totalTokens += item.TotalTokens
+		if item.Model == aggregator.UnknownModel {
+			unknownTokens += item.TotalTokens
+			unknownCost += cost
 		}
 
 		group := groupMap[groupKey]
@@ -323,11 +630,15 @@ func (a *Analyzer) groupData(data []aggregator.HourlyData) []formatter.GroupedDa
 		if !contains(group.Models, item.Model) {
 			group.Models = append(group.Models, item.Model)
 		}
+		if unpriced && !contains(group.UnpricedModels, item.Model) {
+			group.UnpricedModels = append(group.UnpricedModels, item.Model)
+		}
 
-		if a.config.Breakdown || a.config.OutputFormat == "summary" {
+		if a.showBreakdown() {
 			if _, ok := modelDetailsMap[groupKey][item.Model]; !ok {
 				modelDetailsMap[groupKey][item.Model] = &formatter.ModelDetail{
-					Model: item.Model,
+					Model:    item.Model,
+					Unpriced: unpriced,
 				}
 			}
 			detail := modelDetailsMap[groupKey][item.Model]
@@ -337,72 +648,207 @@ func (a *Analyzer) groupData(data []aggregator.HourlyData) []formatter.GroupedDa
 			detail.CacheRead += item.CacheRead
 			detail.TotalTokens += item.TotalTokens
 			detail.Cost += cost // Use real-time calculated cost
+
+			tier := pricing.NormalizeServiceTier(item.ServiceTier)
+			if _, ok := tierDetailsMap[groupKey][tier]; !ok {
+				tierDetailsMap[groupKey][tier] = &formatter.TierDetail{
+					ServiceTier: tier,
+				}
+			}
+			tierDetail := tierDetailsMap[groupKey][tier]
+			tierDetail.InputTokens += item.InputTokens
+			tierDetail.OutputTokens += item.OutputTokens
+			tierDetail.CacheCreation += item.CacheCreation
+			tierDetail.CacheRead += item.CacheRead
+			tierDetail.TotalTokens += item.TotalTokens
+			tierDetail.Cost += cost
+		}
+	}
+
+	for model, tokens := range unpricedModels {
+		action := "cost reported as 0"
+		if a.config.ExcludeUnpriced {
+			action = "excluded from results"
 		}
+		util.LogWarn(fmt.Sprintf("Model %s has no pricing (%d tokens), %s", model, tokens, action))
+	}
+	if a.config.UnknownModel == UnknownModelDrop && unknownTokens > 0 {
+		util.LogWarn(fmt.Sprintf("Dropped %d tokens logged without a model name", unknownTokens))
 	}
+	a.warnUnknownModel(unknownTokens, totalTokens, unknownCost)
 
 	var result []formatter.GroupedData
 	for key, group := range groupMap {
 		// Sort models by specified order
 		group.Models = util.SortModels(group.Models)
+		sort.Strings(group.UnpricedModels)
 
-		if a.config.Breakdown || a.config.OutputFormat == "summary" {
+		if a.showBreakdown() {
 			for _, detail := range modelDetailsMap[key] {
 				group.ModelDetails = append(group.ModelDetails, *detail)
 			}
 			sort.Slice(group.ModelDetails, func(i, j int) bool {
 				return util.GetModelOrder(group.ModelDetails[i].Model) < util.GetModelOrder(group.ModelDetails[j].Model)
 			})
+
+			for _, detail := range tierDetailsMap[key] {
+				group.TierDetails = append(group.TierDetails, *detail)
+			}
+			sort.Slice(group.TierDetails, func(i, j int) bool {
+				return group.TierDetails[i].ServiceTier < group.TierDetails[j].ServiceTier
+			})
 		}
 
 		result = append(result, *group)
 	}
 
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Date < result[j].Date
-	})
+	sortRows(result)
 
 	return result
 }
 
+// showBreakdown reports whether rows carry per-model and per-tier details
+func (a *Analyzer) showBreakdown() bool {
+	return a.config.Breakdown || a.config.OutputFormat == "summary" || a.config.OutputFormat == "ndjson"
+}
+
+// getRowKey returns the group of an hourly item and, for ndjson output, its
+// project, which splits every group into one row per project. Grouped by
+// project, ndjson rows have a project and no period.
+func (a *Analyzer) getRowKey(item aggregator.HourlyData) (period, project string) {
+	if a.config.OutputFormat != "ndjson" {
+		return a.getGroupKey(item), ""
+	}
+	if a.config.GroupBy == "project" {
+		return "", item.ProjectName
+	}
+	return a.getGroupKey(item), item.ProjectName
+}
+
+// sortRows orders rows by group, then by project
+func sortRows(data []formatter.GroupedData) {
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].Date != data[j].Date {
+			return data[i].Date < data[j].Date
+		}
+		return data[i].Project < data[j].Project
+	})
+}
+
+// hourGroupLayout is the key of hour groups, which sorts in time order
+const hourGroupLayout = "2006-01-02 15:00"
+
+// getGroupKey returns the group for an hourly item. Time-based groups are
+// computed per hour in the configured timezone, so a session that crosses
+// midnight contributes each hour's usage to the day it falls on.
 func (a *Analyzer) getGroupKey(item aggregator.HourlyData) string {
+	t := time.Unix(item.Hour, 0).In(a.location)
 	switch a.config.GroupBy {
 	case "model":
 		return item.Model
 	case "project":
 		return item.ProjectName
+	case "label":
+		return item.Label
+	case "branch":
+		if item.GitBranch == "" {
+			return NoBranch
+		}
+		return item.GitBranch
 	case "hour":
-		return time.Unix(item.Hour, 0).Format("2006-01-02 15:00")
+		return t.Format(hourGroupLayout)
 	case "week":
-		t := time.Unix(item.Hour, 0)
 		year, week := t.ISOWeek()
 		return fmt.Sprintf("%d-W%02d", year, week)
 	case "month":
-		return time.Unix(item.Hour, 0).Format("2006-01")
+		return t.Format("2006-01")
 	default:
-		return time.Unix(item.Hour, 0).Format("2006-01-02")
+		return t.Format("2006-01-02")
 	}
 }
 
 func (a *Analyzer) sortData(data []formatter.GroupedData) []formatter.GroupedData {
-	sort.Slice(data, func(i, j int) bool {
-		return data[i].Date < data[j].Date
-	})
+	sortRows(data)
 	return data
 }
 
 func (a *Analyzer) formatAndOutput(data []formatter.GroupedData) error {
+	if a.config.OutputFile == "" {
+		return a.newFormatter().Format(data)
+	}
+	return a.writeOutputFile(a.config.OutputFile, data)
+}
+
+// writeOutputFile formats data into the file at path, creating its directory
+func (a *Analyzer) writeOutputFile(path string, data []formatter.GroupedData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	f := a.newFormatter()
+	f.SetWriter(file)
+	if err := f.Format(data); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	util.LogInfo(fmt.Sprintf("Output written to %s", path))
+	return nil
+}
+
+func (a *Analyzer) newFormatter() formatter.Formatter {
 	switch a.config.OutputFormat {
 	case "json":
-		return formatter.NewJSONFormatter().Format(data)
+		f := formatter.NewJSONFormatter()
+		if a.config.Meta != nil {
+			f.SetMeta(a.config.Meta)
+		}
+		// Costs stay in USD; a display currency adds the converted total
+		if currency := util.DisplayCurrency(); currency.Code != util.USD.Code {
+			f.SetCurrency(currency)
+		}
+		return f
+	case "jsonl":
+		return formatter.NewJSONLFormatter()
+	case "ndjson":
+		return formatter.NewNDJSONFormatter()
+	case "yaml":
+		return formatter.NewYAMLFormatter()
 	case "csv":
-		return formatter.NewCSVFormatter().Format(data)
+		return formatter.NewCSVFormatter()
 	case "summary":
-		return formatter.NewSummaryFormatter().Format(data)
+		f := formatter.NewSummaryFormatter()
+		if a.config.BusinessDaysOnly {
+			f.SetAveragePeriod(a.config.GroupBy, a.businessPeriods)
+		}
+		return f
+	default:
+		return formatter.NewTableFormatter()
+	}
+}
+
+// ValidateOutputFormat reports whether format is an accepted --output value
+func ValidateOutputFormat(format string) error {
+	switch format {
+	case "table", "json", "jsonl", "ndjson", "yaml", "csv", "summary":
+		return nil
 	default:
-		return formatter.NewTableFormatter().Format(data)
+		return fmt.Errorf("invalid output format '%s': must be table, json, jsonl, ndjson, yaml, csv or summary", format)
 	}
 }
 
+// ValidateDuration reports whether durationStr is an accepted --duration value
+func ValidateDuration(durationStr string) error {
+	_, err := parseDuration(durationStr, time.UTC)
+	return err
+}
+
 func parseDuration(durationStr string, loc *time.Location) (time.Time, error) {
 	if durationStr == "" {
 		return time.Time{}, nil
@@ -410,12 +856,23 @@ func parseDuration(durationStr string, loc *time.Location) (time.Time, error) {
 
 	now := time.Now().In(loc)
 
+	totalDuration, err := ParseDurationSpan(durationStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return now.Add(-totalDuration), nil
+}
+
+// ParseDurationSpan parses a --duration style value such as 30d or 1w2d into
+// the span it covers. Months are 30 days and years 365 days.
+func ParseDurationSpan(durationStr string) (time.Duration, error) {
 	// Regular expression to match duration components
 	re := regexp.MustCompile(`(\d+)([hymwd])`)
 	matches := re.FindAllStringSubmatch(durationStr, -1)
 
 	if len(matches) == 0 {
-		return time.Time{}, fmt.Errorf("invalid duration format: %s", durationStr)
+		return 0, fmt.Errorf("invalid duration format: %s", durationStr)
 	}
 
 	var totalDuration time.Duration
@@ -423,7 +880,7 @@ func parseDuration(durationStr string, loc *time.Location) (time.Time, error) {
 	for _, match := range matches {
 		value, err := strconv.Atoi(match[1])
 		if err != nil {
-			return time.Time{}, fmt.Errorf("invalid number in duration: %s", match[1])
+			return 0, fmt.Errorf("invalid number in duration: %s", match[1])
 		}
 
 		unit := match[2]
@@ -441,11 +898,21 @@ func parseDuration(durationStr string, loc *time.Location) (time.Time, error) {
 			// For years, we approximate as 365 days
 			totalDuration += time.Duration(value) * 365 * 24 * time.Hour
 		default:
-			return time.Time{}, fmt.Errorf("unsupported time unit: %s", unit)
+			return 0, fmt.Errorf("unsupported time unit: %s", unit)
 		}
 	}
 
-	return now.Add(-totalDuration), nil
+	return totalDuration, nil
+}
+
+// withProjectName returns a copy of data attributed to the given project
+func withProjectName(data []aggregator.HourlyData, name string) []aggregator.HourlyData {
+	renamed := make([]aggregator.HourlyData, len(data))
+	for i, item := range data {
+		item.ProjectName = name
+		renamed[i] = item
+	}
+	return renamed
 }
 
 func contains(slice []string, item string) bool {
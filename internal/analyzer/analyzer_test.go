@@ -1,10 +1,19 @@
 package analyzer
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
 	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
 	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
 	"github.com/stretchr/testify/assert"
@@ -270,6 +279,21 @@ func TestContains(t *testing.T) {
 	}
 }
 
+func TestGroupAndSortFormatsHourGroups(t *testing.T) {
+	analyzer := New(&Config{Timezone: "UTC", GroupBy: "hour", TimeFormat: "12h"})
+	morning := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC).Unix()
+	afternoon := time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC).Unix()
+
+	rows := analyzer.groupAndSort([]aggregator.HourlyData{
+		{Hour: afternoon, Model: "claude-3-sonnet", TotalTokens: 200},
+		{Hour: morning, Model: "claude-3-sonnet", TotalTokens: 100},
+	})
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, "2024-01-15 9:00 AM", rows[0].Date, "rows stay in time order")
+	assert.Equal(t, "2024-01-15 1:00 PM", rows[1].Date)
+}
+
 func TestAnalyzerFilterByDateRange(t *testing.T) {
 	config := &Config{
 		DataDir:      "/tmp/data",
@@ -345,7 +369,7 @@ func TestAnalyzerGetGroupKey(t *testing.T) {
 			name:    "group by hour",
 			groupBy: "hour",
 			item:    aggregator.HourlyData{Hour: time.Date(2023, 10, 15, 14, 0, 0, 0, time.UTC).Unix()},
-			expected: time.Unix(time.Date(2023, 10, 15, 14, 0, 0, 0, time.UTC).Unix(), 0).Format("2006-01-02 15:00"), // Use actual timezone conversion
+			expected: "2023-10-15 14:00",
 		},
 		{
 			name:    "group by day",
@@ -441,6 +465,210 @@ func TestAnalyzerGroupData(t *testing.T) {
 	assert.Len(t, sonnetGroup.ModelDetails, 1, "Should have model details for breakdown")
 }
 
+func TestAnalyzerGroupDataSplitsSessionAcrossMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	analyzer := New(&Config{GroupBy: "day", Timezone: "Asia/Shanghai"})
+
+	// One session active from 22:00 to 02:00 local time
+	start := time.Date(2023, 10, 15, 22, 0, 0, 0, loc)
+	var testData []aggregator.HourlyData
+	for i := 0; i < 4; i++ {
+		testData = append(testData, aggregator.HourlyData{
+			Hour:        start.Add(time.Duration(i) * time.Hour).Unix(),
+			Model:       "claude-3-sonnet",
+			InputTokens: 100 * (i + 1),
+			TotalTokens: 100 * (i + 1),
+		})
+	}
+
+	grouped := analyzer.groupData(testData)
+
+	require.Len(t, grouped, 2, "Session crossing midnight should split into two days")
+	assert.Equal(t, "2023-10-15", grouped[0].Date)
+	assert.Equal(t, 300, grouped[0].TotalTokens, "22:00 and 23:00 belong to the first day")
+	assert.Equal(t, "2023-10-16", grouped[1].Date)
+	assert.Equal(t, 700, grouped[1].TotalTokens, "00:00 and 01:00 belong to the second day")
+}
+
+func TestAnalyzerGroupDataNDJSONSplitsRowsByProject(t *testing.T) {
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	testData := []aggregator.HourlyData{
+		{Hour: day.Unix(), Model: "claude-3-sonnet", ProjectName: "web", InputTokens: 100, TotalTokens: 100},
+		{Hour: day.Unix(), Model: "claude-3-haiku", ProjectName: "api", InputTokens: 200, TotalTokens: 200},
+		{Hour: day.Add(time.Hour).Unix(), Model: "claude-3-haiku", ProjectName: "web", InputTokens: 300, TotalTokens: 300},
+		{Hour: day.Add(24 * time.Hour).Unix(), Model: "claude-3-sonnet", ProjectName: "web", InputTokens: 400, TotalTokens: 400},
+	}
+
+	grouped := New(&Config{GroupBy: "day", Timezone: "UTC", OutputFormat: "ndjson"}).groupData(testData)
+	require.Len(t, grouped, 3, "Each day should have one row per project")
+	assert.Equal(t, []string{"2024-03-01", "api"}, []string{grouped[0].Date, grouped[0].Project})
+	assert.Equal(t, []string{"2024-03-01", "web"}, []string{grouped[1].Date, grouped[1].Project})
+	assert.Equal(t, []string{"2024-03-02", "web"}, []string{grouped[2].Date, grouped[2].Project})
+	assert.Equal(t, 400, grouped[1].TotalTokens)
+	assert.Len(t, grouped[1].ModelDetails, 2, "ndjson rows always carry the model breakdown")
+
+	// Grouped by project, rows have no period
+	grouped = New(&Config{GroupBy: "project", Timezone: "UTC", OutputFormat: "ndjson"}).groupData(testData)
+	require.Len(t, grouped, 2)
+	assert.Equal(t, "", grouped[0].Date)
+	assert.Equal(t, "api", grouped[0].Project)
+	assert.Equal(t, 800, grouped[1].TotalTokens)
+
+	// Other formats keep one row per group
+	grouped = New(&Config{GroupBy: "day", Timezone: "UTC", OutputFormat: "json"}).groupData(testData)
+	require.Len(t, grouped, 2)
+	assert.Empty(t, grouped[0].Project)
+	assert.Empty(t, grouped[0].ModelDetails)
+}
+
+// knownModelsProvider prices only the models in its map
+type knownModelsProvider struct {
+	pricings map[string]pricing.ModelPricing
+}
+
+func (p *knownModelsProvider) GetPricing(ctx context.Context, modelName string) (pricing.ModelPricing, error) {
+	if mp, ok := p.pricings[modelName]; ok {
+		return mp, nil
+	}
+	return pricing.ModelPricing{}, pricing.ErrPricingNotFound
+}
+
+func (p *knownModelsProvider) GetAllPricings(ctx context.Context) (map[string]pricing.ModelPricing, error) {
+	return p.pricings, nil
+}
+
+func (p *knownModelsProvider) RefreshPricing(ctx context.Context) error { return nil }
+
+func (p *knownModelsProvider) GetProviderName() string { return "test" }
+
+func TestAnalyzerGroupDataUnpricedModels(t *testing.T) {
+	provider := &knownModelsProvider{pricings: map[string]pricing.ModelPricing{
+		"claude-3-sonnet": {Input: 3.0, Output: 15.0},
+	}}
+	testData := []aggregator.HourlyData{
+		{Model: "claude-3-sonnet", InputTokens: 1_000_000, TotalTokens: 1_000_000},
+		{Model: "claude-next", InputTokens: 5_000_000, TotalTokens: 5_000_000},
+	}
+
+	t.Run("flagged when included", func(t *testing.T) {
+		analyzer := New(&Config{GroupBy: "model", Breakdown: true})
+		analyzer.aggregator = aggregator.NewAggregatorWithProvider(provider, "UTC")
+		analyzer.aggregator.SetStrictPricing(true)
+
+		grouped := analyzer.groupData(testData)
+
+		require.Len(t, grouped, 2)
+		var unpriced *formatter.GroupedData
+		for i := range grouped {
+			if grouped[i].Date == "claude-next" {
+				unpriced = &grouped[i]
+			}
+		}
+		require.NotNil(t, unpriced)
+		assert.Equal(t, []string{"claude-next"}, unpriced.UnpricedModels)
+		assert.Equal(t, 0.0, unpriced.Cost)
+		require.Len(t, unpriced.ModelDetails, 1)
+		assert.True(t, unpriced.ModelDetails[0].Unpriced)
+	})
+
+	t.Run("excluded from cost rankings", func(t *testing.T) {
+		analyzer := New(&Config{GroupBy: "model", ExcludeUnpriced: true})
+		analyzer.aggregator = aggregator.NewAggregatorWithProvider(provider, "UTC")
+		analyzer.aggregator.SetStrictPricing(true)
+
+		grouped := analyzer.groupData(testData)
+
+		require.Len(t, grouped, 1)
+		assert.Equal(t, "claude-3-sonnet", grouped[0].Date)
+		assert.Empty(t, grouped[0].UnpricedModels)
+		assert.InDelta(t, 3.0, grouped[0].Cost, 0.0001)
+	})
+
+	t.Run("billed at default rates without strict pricing", func(t *testing.T) {
+		analyzer := New(&Config{GroupBy: "model", ExcludeUnpriced: true})
+		analyzer.aggregator = aggregator.NewAggregatorWithProvider(provider, "UTC")
+
+		grouped := analyzer.groupData(testData)
+
+		require.Len(t, grouped, 2)
+		for _, group := range grouped {
+			assert.Empty(t, group.UnpricedModels)
+			if group.Date == "claude-next" {
+				assert.InDelta(t, 15.0, group.Cost, 0.0001) // 5M input tokens at the $3 default
+			}
+		}
+	})
+}
+
+func TestAnalyzerGroupDataUnknownModel(t *testing.T) {
+	provider := &knownModelsProvider{pricings: map[string]pricing.ModelPricing{
+		"claude-3-sonnet": {Input: 3.0, Output: 15.0},
+		"claude-3-opus":   {Input: 15.0, Output: 75.0},
+	}}
+	testData := []aggregator.HourlyData{
+		{Model: "claude-3-sonnet", InputTokens: 1_000_000, TotalTokens: 1_000_000},
+		{Model: aggregator.UnknownModel, InputTokens: 1_000_000, TotalTokens: 1_000_000},
+	}
+
+	tests := []struct {
+		mode         string
+		wantTokens  int
+		wantCost    float64
+		wantUnknown bool
+		wantWarning bool
+	}{
+		{UnknownModelKeep, 2_000_000, 3.0, true, false}, // Unknown is unpriced offline
+		{UnknownModelDrop, 1_000_000, 3.0, false, false},
+		{UnknownModelPrice, 2_000_000, 18.0, true, false}, // Billed at opus rates
+		{UnknownModelWarn, 2_000_000, 3.0, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			analyzer := New(&Config{GroupBy: "model", UnknownModel: tt.mode, UnknownModelPricing: "claude-3-opus"})
+			analyzer.aggregator = aggregator.NewAggregatorWithProvider(provider, "UTC")
+			analyzer.aggregator.SetStrictPricing(true)
+			var warnings bytes.Buffer
+			analyzer.warnings = &warnings
+
+			grouped := analyzer.groupData(testData)
+
+			var tokens int
+			var cost float64
+			var hasUnknown bool
+			for _, row := range grouped {
+				tokens += row.TotalTokens
+				cost += row.Cost
+				hasUnknown = hasUnknown || row.Date == aggregator.UnknownModel
+			}
+			assert.Equal(t, tt.wantTokens, tokens)
+			assert.InDelta(t, tt.wantCost, cost, 0.0001)
+			assert.Equal(t, tt.wantUnknown, hasUnknown)
+			if tt.wantWarning {
+				assert.Contains(t, warnings.String(), "1.0M tokens (50.0% of usage")
+			} else {
+				assert.Empty(t, warnings.String())
+			}
+		})
+	}
+}
+
+func TestValidateUnknownModel(t *testing.T) {
+	assert.NoError(t, ValidateUnknownModel("", ""))
+	assert.NoError(t, ValidateUnknownModel(UnknownModelDrop, ""))
+	assert.NoError(t, ValidateUnknownModel(UnknownModelPrice, "claude-3-opus"))
+	assert.Error(t, ValidateUnknownModel(UnknownModelPrice, ""))
+	assert.Error(t, ValidateUnknownModel("ignore", ""))
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, format := range []string{"table", "json", "jsonl", "yaml", "csv", "summary"} {
+		assert.NoError(t, ValidateOutputFormat(format), format)
+	}
+	assert.Error(t, ValidateOutputFormat("xml"))
+}
+
 func TestAnalyzerSortData(t *testing.T) {
 	config := &Config{}
 	analyzer := New(config)
@@ -496,6 +724,100 @@ func TestAnalyzerFormatAndOutput(t *testing.T) {
 	}
 }
 
+func TestAnalyzerFormatAndOutputToFile(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "reports", "usage.json")
+	analyzer := New(&Config{OutputFormat: "json", OutputFile: outputFile})
+
+	testData := []formatter.GroupedData{
+		{
+			Date:         "2023-10-15",
+			InputTokens:  100,
+			OutputTokens: 50,
+			TotalTokens:  150,
+			Models:       []string{"claude-3-sonnet"},
+		},
+	}
+
+	// Capture stdout to make sure nothing leaks into it
+	old := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+	formatErr := analyzer.formatAndOutput(testData)
+	w.Close()
+	os.Stdout = old
+	stdout, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	require.NoError(t, formatErr)
+	assert.Empty(t, stdout)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var decoded []formatter.GroupedData
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	assert.Equal(t, testData[0].Date, decoded[0].Date)
+	assert.Equal(t, testData[0].TotalTokens, decoded[0].TotalTokens)
+}
+
+func TestAnalyzerFormatAndOutputToFileOverwrites(t *testing.T) {
+	testData := []formatter.GroupedData{
+		{Date: "2023-10-15", TotalTokens: 150, Models: []string{"claude-3-sonnet"}},
+	}
+
+	for _, format := range []string{"table", "json", "jsonl", "yaml", "csv", "summary"} {
+		t.Run(format, func(t *testing.T) {
+			outputFile := filepath.Join(t.TempDir(), "usage.out")
+			stale := bytes.Repeat([]byte("stale "), 10000)
+			require.NoError(t, os.WriteFile(outputFile, stale, 0644))
+
+			analyzer := New(&Config{OutputFormat: format, OutputFile: outputFile})
+			require.NoError(t, analyzer.formatAndOutput(testData))
+
+			content, err := os.ReadFile(outputFile)
+			require.NoError(t, err)
+			assert.NotEmpty(t, content)
+			assert.NotContains(t, string(content), "stale")
+		})
+	}
+}
+
+func TestAnalyzerFormatAndOutputToUnwritableFile(t *testing.T) {
+	// A regular file in place of a directory is unwritable even for root
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0644))
+	testData := []formatter.GroupedData{{Date: "2023-10-15"}}
+
+	t.Run("parent is a file", func(t *testing.T) {
+		analyzer := New(&Config{OutputFormat: "json", OutputFile: filepath.Join(blocker, "usage.json")})
+		err := analyzer.formatAndOutput(testData)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create output directory")
+		assert.Contains(t, err.Error(), blocker)
+	})
+
+	t.Run("path is a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		analyzer := New(&Config{OutputFormat: "json", OutputFile: dir})
+		err := analyzer.formatAndOutput(testData)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create output file")
+		assert.Contains(t, err.Error(), dir)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced for root")
+		}
+		dir := filepath.Join(t.TempDir(), "readonly")
+		require.NoError(t, os.Mkdir(dir, 0555))
+		analyzer := New(&Config{OutputFormat: "json", OutputFile: filepath.Join(dir, "nested", "usage.json")})
+		err := analyzer.formatAndOutput(testData)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, os.ErrPermission)
+	})
+}
+
 func TestAnalyzerConfigDefaults(t *testing.T) {
 	config := &Config{
 		DataDir:  "/tmp/data",
@@ -581,6 +903,19 @@ func TestParseDurationEdgeCases(t *testing.T) {
 	}
 }
 
+func TestParseDurationSpan(t *testing.T) {
+	span, err := ParseDurationSpan("30d")
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, span)
+
+	span, err = ParseDurationSpan("1w12h")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour+12*time.Hour, span)
+
+	_, err = ParseDurationSpan("soon")
+	assert.Error(t, err)
+}
+
 func TestExtractSessionIdEdgeCases(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -593,9 +928,14 @@ func TestExtractSessionIdEdgeCases(t *testing.T) {
 			expected: "session-id",
 		},
 		{
-			name:     "multiple extensions",
+			name:     "backup copy shares the session id",
 			filePath: "/path/to/session.backup.jsonl",
-			expected: "session.backup",
+			expected: "session",
+		},
+		{
+			name:     "dotted session name",
+			filePath: "/path/to/2024.01.01-session.jsonl",
+			expected: "2024.01.01-session",
 		},
 		{
 			name:     "empty filename",
@@ -621,3 +961,352 @@ func TestExtractSessionIdEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzerDisambiguateProjects(t *testing.T) {
+	dataDir := t.TempDir()
+	ts := time.Now().Add(-time.Hour)
+	writeUsageLog(t, filepath.Join(dataDir, "work", "api", "a.jsonl"), ts, 100)
+	writeUsageLog(t, filepath.Join(dataDir, "personal", "api", "b.jsonl"), ts, 200)
+
+	run := func(disambiguate bool) map[string]int {
+		outputFile := filepath.Join(t.TempDir(), "report.json")
+		a := New(&Config{
+			DataDir:              dataDir,
+			CacheDir:             t.TempDir(),
+			OutputFormat:         "json",
+			OutputFile:           outputFile,
+			GroupBy:              "project",
+			DisambiguateProjects: disambiguate,
+		})
+		require.NoError(t, a.Run(context.Background()))
+
+		content, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		var groups []formatter.GroupedData
+		require.NoError(t, json.Unmarshal(content, &groups))
+		tokens := make(map[string]int)
+		for _, g := range groups {
+			tokens[g.Date] = g.TotalTokens
+		}
+		return tokens
+	}
+
+	assert.Equal(t, map[string]int{"api": 320}, run(false))
+	assert.Equal(t, map[string]int{"work/api": 110, "personal/api": 210}, run(true))
+}
+
+func TestAnalyzerExcludedProjectsAreNotParsed(t *testing.T) {
+	dataDir := t.TempDir()
+	cacheDir := t.TempDir()
+	ts := time.Now().Add(-time.Hour)
+	writeUsageLog(t, filepath.Join(dataDir, "app", "kept.jsonl"), ts, 100)
+	writeUsageLog(t, filepath.Join(dataDir, "scratch-tmp", "ignored.jsonl"), ts, 200)
+
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	a := New(&Config{
+		DataDir:         dataDir,
+		CacheDir:        cacheDir,
+		OutputFormat:    "json",
+		OutputFile:      outputFile,
+		GroupBy:         "project",
+		ExcludeProjects: []string{"scratch-*"},
+	})
+	require.NoError(t, a.Run(context.Background()))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var groups []formatter.GroupedData
+	require.NoError(t, json.Unmarshal(content, &groups))
+	require.Len(t, groups, 1)
+	assert.Equal(t, "app", groups[0].Date)
+
+	// The excluded file was never parsed, so it never reached the cache
+	assert.FileExists(t, filepath.Join(cacheDir, "kept.json"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, "ignored.json"))
+}
+
+func TestAnalyzerRecostUsesCachedDataOnly(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dataDir := t.TempDir()
+	cacheDir := t.TempDir()
+	writeUsageLog(t, filepath.Join(dataDir, "project", "a.jsonl"), time.Now().Add(-time.Hour), 1000000)
+
+	run := func(config *Config) formatter.GroupedData {
+		outputFile := filepath.Join(t.TempDir(), "report.json")
+		config.DataDir = dataDir
+		config.CacheDir = cacheDir
+		config.OutputFormat = "json"
+		config.OutputFile = outputFile
+		config.GroupBy = "project"
+		require.NoError(t, New(config).Run(context.Background()))
+
+		content, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		var groups []formatter.GroupedData
+		require.NoError(t, json.Unmarshal(content, &groups))
+		require.Len(t, groups, 1)
+		return groups[0]
+	}
+
+	// A regular run parses the log and fills the cache
+	baseline := run(&Config{PricingSource: "default"})
+	require.Greater(t, baseline.Cost, 0.0)
+
+	// Offline pricing for another source, priced differently from the default
+	pricingDir := filepath.Join(home, ".go-claude-monitor")
+	require.NoError(t, os.MkdirAll(pricingDir, 0755))
+	pricingCache, err := json.Marshal(pricing.PricingCache{
+		Source:    "litellm",
+		UpdatedAt: time.Now(),
+		Pricing: map[string]pricing.ModelPricing{
+			"claude-3-5-sonnet-20241022": {Input: 100, Output: 100},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(pricingDir, "pricing.json"), pricingCache, 0644))
+
+	// Without any source files left, only the cache can supply the usage
+	require.NoError(t, os.RemoveAll(filepath.Join(dataDir, "project")))
+
+	recosted := run(&Config{PricingSource: "litellm", PricingOfflineMode: true, Recost: true})
+	assert.Equal(t, baseline.TotalTokens, recosted.TotalTokens)
+	assert.InDelta(t, 100.001, recosted.Cost, 1e-9)
+	assert.NotEqual(t, baseline.Cost, recosted.Cost)
+}
+
+func TestAnalyzerBusinessDaysOnly(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// 100 tokens at noon local time on every day from Monday 2024-03-04 to Sunday 2024-03-10
+	var testData []aggregator.HourlyData
+	monday := time.Date(2024, 3, 4, 12, 0, 0, 0, loc)
+	for i := 0; i < 7; i++ {
+		testData = append(testData, aggregator.HourlyData{
+			Hour:        monday.AddDate(0, 0, i).Unix(),
+			Model:       "claude-3-sonnet",
+			InputTokens: 100,
+			TotalTokens: 100,
+		})
+	}
+
+	analyzer := New(&Config{
+		GroupBy:          "day",
+		Timezone:         "Asia/Shanghai",
+		OutputFormat:     "summary",
+		BusinessDaysOnly: true,
+		ShowExcludedDays: true,
+	})
+	grouped := analyzer.groupData(testData)
+
+	require.Len(t, grouped, 7, "Weekend days should still be listed")
+	for _, row := range grouped[:5] {
+		assert.False(t, row.Excluded, row.Date)
+		assert.Equal(t, 100, row.TotalTokens, row.Date)
+	}
+	for _, row := range grouped[5:] {
+		assert.True(t, row.Excluded, row.Date)
+		assert.Equal(t, 0, row.TotalTokens, "Weekend usage should be excluded on %s", row.Date)
+	}
+
+	analyzer.businessPeriods = analyzer.countBusinessPeriods(testData)
+	var buf bytes.Buffer
+	f := analyzer.newFormatter()
+	f.SetWriter(&buf)
+	require.NoError(t, f.Format(grouped))
+	assert.Contains(t, buf.String(), "Average per Day (5 days):")
+	assert.Contains(t, buf.String(), "  Tokens: 100\n", "Weekend days should not dilute the average")
+
+	// Without ShowExcludedDays weekends are omitted; holidays are excluded too
+	analyzer = New(&Config{
+		GroupBy:          "day",
+		Timezone:         "Asia/Shanghai",
+		BusinessDaysOnly: true,
+		Holidays:         []string{"2024-03-06"},
+	})
+	grouped = analyzer.groupData(testData)
+	var dates []string
+	for _, row := range grouped {
+		dates = append(dates, row.Date)
+	}
+	assert.Equal(t, []string{"2024-03-04", "2024-03-05", "2024-03-07", "2024-03-08"}, dates)
+}
+
+func TestAnalyzerBusinessDaysAverageCountsIdleDays(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// Usage on Monday, Tuesday, Thursday and Friday of the week of 2024-03-04;
+	// Wednesday is a business day without usage
+	var testData []aggregator.HourlyData
+	monday := time.Date(2024, 3, 4, 12, 0, 0, 0, loc)
+	for _, i := range []int{0, 1, 3, 4} {
+		testData = append(testData, aggregator.HourlyData{
+			Hour:        monday.AddDate(0, 0, i).Unix(),
+			Model:       "claude-3-sonnet",
+			InputTokens: 100,
+			TotalTokens: 100,
+		})
+	}
+	summary := func(config *Config) string {
+		t.Helper()
+		config.Timezone = "Asia/Shanghai"
+		config.OutputFormat = "summary"
+		analyzer := New(config)
+		if config.BusinessDaysOnly {
+			analyzer.businessPeriods = analyzer.countBusinessPeriods(testData)
+		}
+		var buf bytes.Buffer
+		f := analyzer.newFormatter()
+		f.SetWriter(&buf)
+		require.NoError(t, f.Format(analyzer.groupData(testData)))
+		return buf.String()
+	}
+
+	out := summary(&Config{
+		GroupBy:          "day",
+		BusinessDaysOnly: true,
+		Since:            monday.Add(-12 * time.Hour),
+		Until:            monday.AddDate(0, 0, 7).Add(-12 * time.Hour),
+	})
+	assert.Contains(t, out, "Average per Day (5 days):")
+	assert.Contains(t, out, "  Tokens: 80\n", "The idle Wednesday should count toward the average")
+
+	out = summary(&Config{GroupBy: "week", BusinessDaysOnly: true})
+	assert.Contains(t, out, "Average per Week (1 week):")
+
+	// Averages are only part of the summary with --business-days-only
+	assert.NotContains(t, summary(&Config{GroupBy: "day"}), "Average per")
+}
+
+func TestParseHolidays(t *testing.T) {
+	holidays, err := ParseHolidays([]string{"2024-12-25"})
+	require.NoError(t, err)
+	assert.True(t, holidays["2024-12-25"])
+
+	_, err = ParseHolidays([]string{"25/12/2024"})
+	assert.Error(t, err)
+
+	assert.NoError(t, ValidateBusinessDays("week"))
+	assert.Error(t, ValidateBusinessDays("model"))
+}
+
+func TestAnalyzerProjectFilter(t *testing.T) {
+	dataDir := t.TempDir()
+	ts := time.Now().Add(-time.Hour)
+	for i, project := range []string{"acme-api", "acme-web", "acme-sandbox", "blog"} {
+		writeUsageLog(t, filepath.Join(dataDir, project, project+".jsonl"), ts, 100*(i+1))
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	a := New(&Config{
+		DataDir:         dataDir,
+		CacheDir:        t.TempDir(),
+		OutputFormat:    "json",
+		OutputFile:      outputFile,
+		GroupBy:         "project",
+		Projects:        []string{"acme"},
+		ExcludeProjects: []string{"*-sandbox"},
+	})
+	require.NoError(t, a.Run(context.Background()))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var groups []formatter.GroupedData
+	require.NoError(t, json.Unmarshal(content, &groups))
+	var projects []string
+	for _, group := range groups {
+		projects = append(projects, group.Date)
+	}
+	assert.ElementsMatch(t, []string{"acme-api", "acme-web"}, projects)
+}
+
+func TestAnalyzerGroupDataByBranch(t *testing.T) {
+	provider := &knownModelsProvider{pricings: map[string]pricing.ModelPricing{
+		"claude-3-sonnet": {Input: 3.0, Output: 15.0},
+	}}
+	analyzer := New(&Config{GroupBy: "branch", Timezone: "UTC"})
+	analyzer.aggregator = aggregator.NewAggregatorWithProvider(provider, "UTC")
+	analyzer.aggregator.SetStrictPricing(true)
+
+	testData := []aggregator.HourlyData{
+		{Hour: 0, Model: "claude-3-sonnet", GitBranch: "main", InputTokens: 1_000_000, TotalTokens: 1_000_000},
+		{Hour: 3600, Model: "claude-3-sonnet", GitBranch: "main", OutputTokens: 100_000, TotalTokens: 100_000},
+		{Hour: 0, Model: "claude-3-sonnet", GitBranch: "feature/login", InputTokens: 2_000_000, TotalTokens: 2_000_000},
+		{Hour: 0, Model: "claude-3-sonnet", InputTokens: 500_000, TotalTokens: 500_000},
+	}
+
+	grouped := analyzer.sortData(analyzer.groupData(testData))
+	require.Len(t, grouped, 3)
+
+	byBranch := make(map[string]formatter.GroupedData)
+	var totalTokens int
+	var totalCost float64
+	for _, row := range grouped {
+		byBranch[row.Date] = row
+		totalTokens += row.TotalTokens
+		totalCost += row.Cost
+	}
+	assert.Equal(t, 1_100_000, byBranch["main"].TotalTokens)
+	assert.InDelta(t, 4.5, byBranch["main"].Cost, 0.0001) // $3 input + $1.5 output
+	assert.Equal(t, 2_000_000, byBranch["feature/login"].TotalTokens)
+	assert.InDelta(t, 6.0, byBranch["feature/login"].Cost, 0.0001)
+	assert.Equal(t, 500_000, byBranch[NoBranch].TotalTokens)
+	assert.InDelta(t, 1.5, byBranch[NoBranch].Cost, 0.0001)
+
+	assert.Equal(t, 3_600_000, totalTokens)
+	assert.InDelta(t, 12.0, totalCost, 0.0001)
+}
+
+func TestAnalyzerGroupByBranchFromLogs(t *testing.T) {
+	dataDir := t.TempDir()
+	ts := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	var lines strings.Builder
+	for i, branch := range []string{"main", "feature/login", "main", ""} {
+		fmt.Fprintf(&lines, `{"type":"assistant","timestamp":%q,"gitBranch":%q,"requestId":"req-%d","sessionId":"s","uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":"claude-3-5-sonnet-20241022","role":"assistant","usage":{"input_tokens":%d,"output_tokens":0}}}`+"\n",
+			ts, branch, i, i, i, 100*(i+1))
+	}
+	path := filepath.Join(dataDir, "app", "session.jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(lines.String()), 0644))
+
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	a := New(&Config{
+		DataDir:      dataDir,
+		CacheDir:     t.TempDir(),
+		OutputFormat: "json",
+		OutputFile:   outputFile,
+		GroupBy:      "branch",
+	})
+	require.NoError(t, a.Run(context.Background()))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var groups []formatter.GroupedData
+	require.NoError(t, json.Unmarshal(content, &groups))
+	tokens := make(map[string]int)
+	for _, group := range groups {
+		tokens[group.Date] = group.InputTokens
+	}
+	assert.Equal(t, map[string]int{"main": 400, "feature/login": 200, NoBranch: 400}, tokens)
+}
+
+func TestAnalyzerCacheReadDiscountAppliedAsGiven(t *testing.T) {
+	testData := []aggregator.HourlyData{
+		{Model: "claude-3-5-sonnet-20241022", CacheRead: 1_000_000, TotalTokens: 1_000_000},
+	}
+	cost := func(discount float64) float64 {
+		analyzer := New(&Config{GroupBy: "model", Timezone: "UTC", PricingOfflineMode: true, CacheReadDiscount: discount})
+		grouped := analyzer.groupData(testData)
+		require.Len(t, grouped, 1)
+		return grouped[0].Cost
+	}
+
+	listPrice := cost(1)
+	assert.Greater(t, listPrice, 0.0)
+	assert.InDelta(t, listPrice/2, cost(0.5), 1e-9)
+	// A discount of 0 makes cache reads free rather than falling back to list price
+	assert.Equal(t, 0.0, cost(0))
+}
@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+)
+
+// holidayLayout is the date format accepted by --holidays
+const holidayLayout = "2006-01-02"
+
+// ParseHolidays parses YYYY-MM-DD dates into a set keyed by date
+func ParseHolidays(dates []string) (map[string]bool, error) {
+	holidays := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		if _, err := time.Parse(holidayLayout, date); err != nil {
+			return nil, fmt.Errorf("invalid holiday '%s': must be YYYY-MM-DD", date)
+		}
+		holidays[date] = true
+	}
+	return holidays, nil
+}
+
+// ValidateBusinessDays reports whether --business-days-only can be applied to
+// the given grouping; only day and week rollups are restricted to business days
+func ValidateBusinessDays(groupBy string) error {
+	switch groupBy {
+	case "day", "week":
+		return nil
+	default:
+		return fmt.Errorf("--business-days-only requires --group-by day or week, got '%s'", groupBy)
+	}
+}
+
+// isBusinessDay reports whether t, already in the configured timezone, falls
+// on a weekday that is not a holiday
+func isBusinessDay(t time.Time, holidays map[string]bool) bool {
+	switch t.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return !holidays[t.Format(holidayLayout)]
+}
+
+// countBusinessPeriods returns the number of business days in the reported
+// range, or with week rollups the number of weeks holding one. Business days
+// without usage count too, so they lower the average instead of dropping out.
+func (a *Analyzer) countBusinessPeriods(data []aggregator.HourlyData) int {
+	from, to, ok := a.reportRange(data)
+	if !ok {
+		return 0
+	}
+	weeks := make(map[string]bool)
+	days := 0
+	// Noon steps clear of DST changes
+	day := time.Date(from.Year(), from.Month(), from.Day(), 12, 0, 0, 0, a.location)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 12, 0, 0, 0, a.location)
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !isBusinessDay(day, a.holidays) {
+			continue
+		}
+		days++
+		year, week := day.ISOWeek()
+		weeks[fmt.Sprintf("%d-W%02d", year, week)] = true
+	}
+	if a.config.GroupBy == "week" {
+		return len(weeks)
+	}
+	return days
+}
+
+// reportRange returns the first and last instant of the reported range in
+// the configured timezone: the time range or duration up to now when given,
+// otherwise the hours holding usage. ok is false when the range is unknown.
+func (a *Analyzer) reportRange(data []aggregator.HourlyData) (from, to time.Time, ok bool) {
+	var first, last int64
+	for i, item := range data {
+		if i == 0 || item.Hour < first {
+			first = item.Hour
+		}
+		if i == 0 || item.Hour > last {
+			last = item.Hour
+		}
+	}
+	from, to, ok = time.Unix(first, 0), time.Unix(last, 0), len(data) > 0
+
+	now := time.Now()
+	switch {
+	case a.hasTimeRange():
+		if !a.config.Since.IsZero() {
+			from, ok = a.config.Since, true
+		}
+		to = now
+		if !a.config.Until.IsZero() && a.config.Until.Before(now) {
+			to = a.config.Until.Add(-time.Nanosecond)
+		}
+	case a.config.Duration != "" && !a.config.SinceLast:
+		start, err := parseDuration(a.config.Duration, a.location)
+		if err != nil {
+			return from, to, false
+		}
+		from, to, ok = start, now, true
+	}
+	return from.In(a.location), to.In(a.location), ok
+}
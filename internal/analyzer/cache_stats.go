@@ -28,6 +28,8 @@ func cacheMissReasonString(r cache.CacheMissReason) string {
 		return "Cached file has no fingerprint"
 	case cache.MissReasonNotFound:
 		return "Cache not found"
+	case cache.MissReasonSchema:
+		return "Cache schema outdated"
 	default:
 		return "Unknown reason"
 	}
@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// ExportVersion is the version of the usage export format. Bump it when a
+// change would make older readers misinterpret an export.
+const ExportVersion = 1
+
+// UsageExport is the stable format written by the export command and read
+// back by import. It carries token counts only; costs are recalculated with
+// the importer's pricing.
+type UsageExport struct {
+	Version     int            `json:"version"`
+	Label       string         `json:"label"` // Machine or user the usage was recorded by
+	ExportedAt  int64          `json:"exportedAt"`
+	HourlyStats []ExportRecord `json:"hourlyStats"`
+}
+
+// ExportRecord is the usage of one model in one project and hour of an
+// export. It is part of the versioned format and deliberately separate from
+// aggregator.HourlyData, whose fields follow the cache instead.
+type ExportRecord struct {
+	Hour           int64  `json:"hour"` // Unix timestamp (truncated to hour)
+	Model          string `json:"model"`
+	ServiceTier    string `json:"serviceTier,omitempty"`
+	ProjectName    string `json:"projectName"`
+	GitBranch      string `json:"gitBranch,omitempty"`
+	InputTokens    int    `json:"inputTokens"`
+	OutputTokens   int    `json:"outputTokens"`
+	CacheCreation  int    `json:"cacheCreation"`
+	CacheRead      int    `json:"cacheRead"`
+	TotalTokens    int    `json:"totalTokens"`
+	MessageCount   int    `json:"messageCount"`
+	UserTurns      int    `json:"userTurns,omitempty"`
+	FirstEntryTime int64  `json:"firstEntryTime"`
+	LastEntryTime  int64  `json:"lastEntryTime"`
+}
+
+// newExportRecord returns the export record of an hourly item
+func newExportRecord(item aggregator.HourlyData) ExportRecord {
+	return ExportRecord{
+		Hour:           item.Hour,
+		Model:          item.Model,
+		ServiceTier:    item.ServiceTier,
+		ProjectName:    item.ProjectName,
+		GitBranch:      item.GitBranch,
+		InputTokens:    item.InputTokens,
+		OutputTokens:   item.OutputTokens,
+		CacheCreation:  item.CacheCreation,
+		CacheRead:      item.CacheRead,
+		TotalTokens:    item.TotalTokens,
+		MessageCount:   item.MessageCount,
+		UserTurns:      item.UserTurns,
+		FirstEntryTime: item.FirstEntryTime,
+		LastEntryTime:  item.LastEntryTime,
+	}
+}
+
+// hourlyData returns the record as an hourly item recorded by label
+func (r ExportRecord) hourlyData(label string) aggregator.HourlyData {
+	return aggregator.HourlyData{
+		Hour:           r.Hour,
+		Model:          r.Model,
+		ServiceTier:    r.ServiceTier,
+		ProjectName:    r.ProjectName,
+		GitBranch:      r.GitBranch,
+		InputTokens:    r.InputTokens,
+		OutputTokens:   r.OutputTokens,
+		CacheCreation:  r.CacheCreation,
+		CacheRead:      r.CacheRead,
+		TotalTokens:    r.TotalTokens,
+		MessageCount:   r.MessageCount,
+		UserTurns:      r.UserTurns,
+		FirstEntryTime: r.FirstEntryTime,
+		LastEntryTime:  r.LastEntryTime,
+		Label:          label,
+	}
+}
+
+// Export collects the usage in the data directory, limited to Duration, as
+// an export tagged with label
+func (a *Analyzer) Export(label string) (*UsageExport, error) {
+	allHourlyData, err := a.collectHourlyData(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	hourlyData := a.filterByDateRange(allHourlyData)
+	if len(hourlyData) == 0 {
+		return nil, ErrNoUsageData
+	}
+
+	records := make([]ExportRecord, 0, len(hourlyData))
+	for _, item := range hourlyData {
+		records = append(records, newExportRecord(item))
+	}
+	return &UsageExport{
+		Version:     ExportVersion,
+		Label:       label,
+		ExportedAt:  time.Now().Unix(),
+		HourlyStats: records,
+	}, nil
+}
+
+// WriteExport encodes export as indented JSON
+func WriteExport(w io.Writer, export *UsageExport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// ReadExport reads an export file. An export without a label is labelled
+// with the file name so that its usage can still be told apart.
+func ReadExport(path string) (*UsageExport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export UsageExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid export %s: %w", path, err)
+	}
+	if export.Version < 1 || export.Version > ExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d in %s", export.Version, path)
+	}
+	if export.Label == "" {
+		export.Label = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &export, nil
+}
+
+// MergeExports combines the usage of several exports, tagging every hourly
+// item with the label of the export it came from
+func MergeExports(exports []*UsageExport) []aggregator.HourlyData {
+	var merged []aggregator.HourlyData
+	for _, export := range exports {
+		for _, record := range export.HourlyStats {
+			merged = append(merged, record.hourlyData(export.Label))
+		}
+	}
+	return merged
+}
+
+// RunImport reports the merged usage of exports from other machines as a
+// regular analysis. Group by "label" to compare machines.
+func (a *Analyzer) RunImport(exports []*UsageExport) error {
+	startTime := time.Now()
+	allHourlyData := MergeExports(exports)
+	util.LogInfo(fmt.Sprintf("Importing %d exports with %d records", len(exports), len(allHourlyData)))
+	if len(allHourlyData) == 0 {
+		return ErrNoUsageData
+	}
+
+	if err := a.report(allHourlyData); err != nil {
+		return err
+	}
+
+	util.LogDebug(fmt.Sprintf("Total duration: %v", time.Since(startTime)))
+	return nil
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportMachine exports the usage of a single log written at ts to a file
+func exportMachine(t *testing.T, label string, ts time.Time, inputTokens int) string {
+	t.Helper()
+	dataDir := t.TempDir()
+	writeUsageLog(t, filepath.Join(dataDir, "api", label+".jsonl"), ts, inputTokens)
+
+	export, err := New(&Config{DataDir: dataDir, CacheDir: t.TempDir()}).Export(label)
+	require.NoError(t, err)
+	assert.Equal(t, ExportVersion, export.Version)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteExport(&buf, export))
+	path := filepath.Join(t.TempDir(), label+".json")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return path
+}
+
+func TestAnalyzerImportMergesExports(t *testing.T) {
+	ts := time.Now().Add(-time.Hour)
+	paths := []string{
+		exportMachine(t, "alice", ts, 100),
+		exportMachine(t, "bob", ts, 200),
+	}
+
+	var exports []*UsageExport
+	for _, path := range paths {
+		export, err := ReadExport(path)
+		require.NoError(t, err)
+		exports = append(exports, export)
+	}
+
+	run := func(groupBy string) map[string]int {
+		outputFile := filepath.Join(t.TempDir(), "report.json")
+		a := New(&Config{
+			CacheDir:     t.TempDir(),
+			OutputFormat: "json",
+			OutputFile:   outputFile,
+			GroupBy:      groupBy,
+		})
+		require.NoError(t, a.RunImport(exports))
+
+		content, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		var groups []formatter.GroupedData
+		require.NoError(t, json.Unmarshal(content, &groups))
+		tokens := make(map[string]int)
+		for _, g := range groups {
+			tokens[g.Date] = g.TotalTokens
+		}
+		return tokens
+	}
+
+	assert.Equal(t, map[string]int{"api": 320}, run("project"))
+	assert.Equal(t, map[string]int{"alice": 110, "bob": 210}, run("label"))
+}
+
+func TestReadExportRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":99,"label":"x","hourlyStats":[]}`), 0644))
+
+	_, err := ReadExport(path)
+	assert.ErrorContains(t, err, "unsupported export version 99")
+}
+
+func TestReadExportDefaultsLabelToFileName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "carol.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":1,"hourlyStats":[]}`), 0644))
+
+	export, err := ReadExport(path)
+	require.NoError(t, err)
+	assert.Equal(t, "carol", export.Label)
+}
+
+func TestExportRecordFields(t *testing.T) {
+	path := exportMachine(t, "alice", time.Now().Add(-time.Hour), 100)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var raw struct {
+		HourlyStats []map[string]interface{} `json:"hourlyStats"`
+	}
+	require.NoError(t, json.Unmarshal(content, &raw))
+	require.Len(t, raw.HourlyStats, 1)
+
+	// Records hold the documented fields only, whatever the cache stores
+	var keys []string
+	for key := range raw.HourlyStats[0] {
+		keys = append(keys, key)
+	}
+	assert.ElementsMatch(t, []string{
+		"hour", "model", "serviceTier", "projectName", "inputTokens", "outputTokens", "cacheCreation",
+		"cacheRead", "totalTokens", "messageCount", "firstEntryTime", "lastEntryTime",
+	}, keys)
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// ValidateModelPatterns reports whether every --model pattern is a valid glob
+func ValidateModelPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid model pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesModel reports whether model matches one of patterns, by its raw ID
+// (claude-3-opus*) or its simplified name (opus-4*), ignoring case
+func matchesModel(model string, patterns []string) bool {
+	names := []string{strings.ToLower(model), strings.ToLower(util.SimplifyModelName(model))}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterByModel keeps the usage of models matching the configured patterns,
+// or all usage when there are none
+func (a *Analyzer) filterByModel(data []aggregator.HourlyData) []aggregator.HourlyData {
+	if len(a.config.Models) == 0 {
+		return data
+	}
+	filtered := make([]aggregator.HourlyData, 0, len(data))
+	for _, item := range data {
+		if matchesModel(item.Model, a.config.Models) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
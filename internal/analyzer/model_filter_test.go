@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeMultiModelLog writes one assistant message per model, each with its
+// own input token count
+func writeMultiModelLog(t *testing.T, path string, ts time.Time, tokensByModel map[string]int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	var lines strings.Builder
+	i := 0
+	for model, tokens := range tokensByModel {
+		i++
+		fmt.Fprintf(&lines, `{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":"s-1","uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":%q,"role":"assistant","usage":{"input_tokens":%d,"output_tokens":0}}}`+"\n",
+			ts.UTC().Format(time.RFC3339), i, i, i, model, tokens)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(lines.String()), 0644))
+}
+
+func runModelFilter(t *testing.T, dataDir string, models []string) []formatter.GroupedData {
+	t.Helper()
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	a := New(&Config{
+		DataDir:      dataDir,
+		CacheDir:     t.TempDir(),
+		OutputFormat: "json",
+		OutputFile:   outputFile,
+		Timezone:     "UTC",
+		GroupBy:      "project",
+		Breakdown:    true,
+		Models:       models,
+	})
+	require.NoError(t, a.Run(context.Background()))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.NotEqual(t, "null", strings.TrimSpace(string(content)), "no rows must be an empty array")
+	var groups []formatter.GroupedData
+	require.NoError(t, json.Unmarshal(content, &groups), "output must be valid JSON: %s", content)
+	return groups
+}
+
+func TestAnalyzerModelFilter(t *testing.T) {
+	dataDir := t.TempDir()
+	writeMultiModelLog(t, filepath.Join(dataDir, "app", "session.jsonl"), time.Now().Add(-time.Hour), map[string]int{
+		"claude-3-opus-20240229":     1000,
+		"claude-3-5-sonnet-20241022": 200,
+		"claude-3-haiku-20240307":    30,
+	})
+
+	all := runModelFilter(t, dataDir, nil)
+	require.Len(t, all, 1)
+	assert.Equal(t, 1230, all[0].InputTokens)
+	assert.Len(t, all[0].Models, 3)
+
+	tests := []struct {
+		name       string
+		models     []string
+		wantTokens int
+		wantModels []string
+	}{
+		{"raw id glob", []string{"claude-3-opus*"}, 1000, []string{"claude-3-opus-20240229"}},
+		{"simplified name", []string{"3-OPUS"}, 1000, []string{"claude-3-opus-20240229"}},
+		{"repeated", []string{"*opus*", "*haiku*"}, 1030, []string{"claude-3-opus-20240229", "claude-3-haiku-20240307"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := runModelFilter(t, dataDir, tt.models)
+			require.Len(t, groups, 1)
+			assert.Equal(t, tt.wantTokens, groups[0].InputTokens)
+			assert.Equal(t, tt.wantTokens, groups[0].TotalTokens)
+			assert.ElementsMatch(t, tt.wantModels, groups[0].Models)
+			assert.Len(t, groups[0].ModelDetails, len(tt.wantModels))
+			assert.Less(t, groups[0].Cost, all[0].Cost)
+		})
+	}
+
+	// A filter matching nothing reports no rows, not an error
+	assert.Empty(t, runModelFilter(t, dataDir, []string{"gpt-*"}))
+}
+
+func TestMatchesModel(t *testing.T) {
+	assert.True(t, matchesModel("claude-3-opus-20240229", []string{"claude-3-opus*"}))
+	assert.True(t, matchesModel("claude-opus-4-20250514", []string{"opus-4"}))
+	assert.True(t, matchesModel("claude-sonnet-4-20250514", []string{"opus*", "sonnet*"}))
+	assert.False(t, matchesModel("claude-sonnet-4-20250514", []string{"opus*"}))
+
+	// The documented *opus* covers every generation; opus* misses 3-opus
+	assert.True(t, matchesModel("claude-3-opus-20240229", []string{"*opus*"}))
+	assert.True(t, matchesModel("claude-opus-4-1-20250805", []string{"*opus*"}))
+	assert.False(t, matchesModel("claude-3-opus-20240229", []string{"opus*"}))
+}
+
+func TestValidateModelPatterns(t *testing.T) {
+	assert.NoError(t, ValidateModelPatterns(nil))
+	assert.NoError(t, ValidateModelPatterns([]string{"claude-3-opus*", "sonnet-?"}))
+	assert.Error(t, ValidateModelPatterns([]string{"opus["}))
+}
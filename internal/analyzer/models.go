@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"context"
+	"sort"
+
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// ModelUsage describes one model seen in the usage data
+type ModelUsage struct {
+	Model      string // Raw model ID from the logs
+	Simplified string // Display name, e.g. "Sonnet-4"
+	Messages   int
+	Tokens     int
+	Priced     bool // Whether the pricing source has an entry for the model
+}
+
+// Models returns every model in the data directory with its usage and
+// whether it is priced under the configured pricing source, most used first
+func (a *Analyzer) Models() ([]ModelUsage, error) {
+	allHourlyData, err := a.collectHourlyData(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if len(allHourlyData) == 0 {
+		return nil, ErrNoUsageData
+	}
+
+	byModel := make(map[string]*ModelUsage)
+	for _, item := range allHourlyData {
+		usage, ok := byModel[item.Model]
+		if !ok {
+			usage = &ModelUsage{
+				Model:      item.Model,
+				Simplified: util.SimplifyModelName(item.Model),
+				Priced:     a.aggregator.HasPricing(item.Model),
+			}
+			byModel[item.Model] = usage
+		}
+		usage.Messages += item.MessageCount
+		usage.Tokens += item.TotalTokens
+	}
+
+	result := make([]ModelUsage, 0, len(byModel))
+	for _, usage := range byModel {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Messages != result[j].Messages {
+			return result[i].Messages > result[j].Messages
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result, nil
+}
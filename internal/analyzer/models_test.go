@@ -0,0 +1,45 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzerModelsMarksUnpricedModels(t *testing.T) {
+	dataDir := t.TempDir()
+	ts := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	var lines []string
+	for i, modelName := range []string{"claude-sonnet-4-20250514", "claude-sonnet-4-20250514", "claude-nova-9-20300101"} {
+		lines = append(lines, fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":"s","uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":%q,"role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`,
+			ts, i, i, i, modelName))
+	}
+	path := filepath.Join(dataDir, "project", "models.jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	a := New(&Config{DataDir: dataDir, CacheDir: t.TempDir(), PricingSource: "default"})
+	models, err := a.Models()
+	require.NoError(t, err)
+	require.Len(t, models, 2)
+
+	assert.Equal(t, ModelUsage{
+		Model:      "claude-sonnet-4-20250514",
+		Simplified: "Sonnet-4",
+		Messages:   2,
+		Tokens:     220,
+		Priced:     true,
+	}, models[0])
+	assert.Equal(t, "claude-nova-9-20300101", models[1].Model)
+	assert.Equal(t, "Nova-9", models[1].Simplified)
+	assert.Equal(t, 1, models[1].Messages)
+	assert.False(t, models[1].Priced, "unknown model must be reported as unpriced")
+}
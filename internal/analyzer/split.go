@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// outputByProject writes the analysis of each project to
+// OutputDir/<project>.<ext>, with the project name made safe for use as a
+// file name
+func (a *Analyzer) outputByProject(data []aggregator.HourlyData) error {
+	byProject := make(map[string][]aggregator.HourlyData)
+	for _, item := range data {
+		byProject[item.ProjectName] = append(byProject[item.ProjectName], item)
+	}
+
+	projects := make([]string, 0, len(byProject))
+	for project := range byProject {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+
+	usedNames := make(map[string]bool, len(projects))
+	for _, project := range projects {
+		name := uniqueFileName(sanitizeFileName(project), usedNames)
+		path := filepath.Join(a.config.OutputDir, name+"."+outputExtension(a.config.OutputFormat))
+		if err := a.writeOutputFile(path, a.groupAndSort(byProject[project])); err != nil {
+			return fmt.Errorf("failed to write report for project %s: %w", project, err)
+		}
+	}
+
+	util.LogInfo(fmt.Sprintf("Wrote %d project reports to %s", len(projects), a.config.OutputDir))
+	return nil
+}
+
+// outputExtension returns the file extension for an output format
+func outputExtension(format string) string {
+	switch format {
+	case "json":
+		return "json"
+	case "jsonl":
+		return "jsonl"
+	case "ndjson":
+		return "ndjson"
+	case "yaml":
+		return "yaml"
+	case "csv":
+		return "csv"
+	default:
+		return "txt"
+	}
+}
+
+// sanitizeFileName replaces every character outside [A-Za-z0-9._-] with an
+// underscore and rejects names that would refer to a directory
+func sanitizeFileName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	if strings.Trim(sanitized, ".") == "" {
+		return "project"
+	}
+	return sanitized
+}
+
+// uniqueFileName returns name, suffixed with a counter if sanitizing made it
+// collide with a name already in used, and records the result
+func uniqueFileName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
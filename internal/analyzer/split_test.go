@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzerSplitByProject(t *testing.T) {
+	dataDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "reports")
+	ts := time.Now().Add(-time.Hour)
+	writeUsageLog(t, filepath.Join(dataDir, "api", "a.jsonl"), ts, 100)
+	writeUsageLog(t, filepath.Join(dataDir, "web", "b.jsonl"), ts, 200)
+
+	a := New(&Config{
+		DataDir:        dataDir,
+		CacheDir:       t.TempDir(),
+		OutputFormat:   "json",
+		GroupBy:        "day",
+		SplitByProject: true,
+		OutputDir:      outputDir,
+	})
+	require.NoError(t, a.Run(context.Background()))
+
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"api.json", "web.json"}, names)
+
+	for file, tokens := range map[string]int{"api.json": 110, "web.json": 210} {
+		content, err := os.ReadFile(filepath.Join(outputDir, file))
+		require.NoError(t, err)
+		var groups []formatter.GroupedData
+		require.NoError(t, json.Unmarshal(content, &groups))
+		require.Len(t, groups, 1, file)
+		assert.Equal(t, tokens, groups[0].TotalTokens, file)
+	}
+}
+
+func TestOutputByProjectSanitizesNames(t *testing.T) {
+	outputDir := t.TempDir()
+	a := New(&Config{
+		CacheDir:       t.TempDir(),
+		OutputFormat:   "csv",
+		GroupBy:        "project",
+		SplitByProject: true,
+		OutputDir:      outputDir,
+	})
+
+	hour := time.Now().Truncate(time.Hour).Unix()
+	data := []aggregator.HourlyData{
+		{Hour: hour, Model: "claude-3-5-sonnet-20241022", ProjectName: "work/api", InputTokens: 10, TotalTokens: 10},
+		{Hour: hour, Model: "claude-3-5-sonnet-20241022", ProjectName: "work_api", InputTokens: 20, TotalTokens: 20},
+		{Hour: hour, Model: "claude-3-5-sonnet-20241022", ProjectName: "..", InputTokens: 30, TotalTokens: 30},
+		{Hour: hour, Model: "claude-3-5-sonnet-20241022", ProjectName: "a:b*c?", InputTokens: 40, TotalTokens: 40},
+	}
+	require.NoError(t, a.outputByProject(data))
+
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"project.csv", "a_b_c_.csv", "work_api.csv", "work_api-2.csv"}, names)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "a_b_c_.csv"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "a:b*c?")
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"my-project":  "my-project",
+		"work/api":    "work_api",
+		`C:\repo`:     "C__repo",
+		"":            "project",
+		"..":          "project",
+		"v1.2 (beta)": "v1.2__beta_",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, sanitizeFileName(input), input)
+	}
+}
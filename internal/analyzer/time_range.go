@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// ParseTimeBound parses a --since or --until value, RFC3339 or YYYY-MM-DD.
+// A bare date is midnight in loc; as an end bound it covers the whole day,
+// so it is the following midnight. Usage is stored per UTC hour, so a bound
+// that falls inside an hour is rejected rather than rounded.
+func ParseTimeBound(value string, loc *time.Location, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if t.Unix()%3600 != 0 {
+			return time.Time{}, fmt.Errorf("time '%s' is not on the hour: usage is recorded per hour", value)
+		}
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s': use RFC3339 (2024-06-04T09:00:00Z) or YYYY-MM-DD", value)
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	if day.Unix()%3600 != 0 {
+		return time.Time{}, fmt.Errorf("midnight of '%s' in %s is not on a UTC hour: usage is recorded per UTC hour, pass an RFC3339 time instead", value, loc)
+	}
+	return day, nil
+}
+
+// hasTimeRange reports whether an absolute --since/--until range is set
+func (a *Analyzer) hasTimeRange() bool {
+	return !a.config.Since.IsZero() || !a.config.Until.IsZero()
+}
+
+// inTimeRange reports whether an hour starting at hour lies in the
+// configured range; an unset bound leaves that side open
+func (a *Analyzer) inTimeRange(hour int64) bool {
+	t := time.Unix(hour, 0)
+	if !a.config.Since.IsZero() && t.Before(a.config.Since) {
+		return false
+	}
+	return a.config.Until.IsZero() || t.Before(a.config.Until)
+}
+
+// filterByTimeRange keeps hourly items whose hour starts in the range
+func (a *Analyzer) filterByTimeRange(data []aggregator.HourlyData) []aggregator.HourlyData {
+	var filtered []aggregator.HourlyData
+	for _, item := range data {
+		if a.inTimeRange(item.Hour) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// filterFilesBySince drops files last written before --since; logs are
+// append-only, so such files hold no entries in the range
+func (a *Analyzer) filterFilesBySince(files []string) []string {
+	if a.config.Since.IsZero() {
+		return files
+	}
+	since := a.config.Since.Unix()
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		info, err := util.GetFileInfo(file)
+		if err == nil && info.ModTime < since {
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept
+}
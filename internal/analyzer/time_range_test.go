@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	start, err := ParseTimeBound("2024-06-04", tokyo, false)
+	require.NoError(t, err)
+	assert.True(t, start.Equal(time.Date(2024, 6, 4, 0, 0, 0, 0, tokyo)))
+
+	end, err := ParseTimeBound("2024-06-04", tokyo, true)
+	require.NoError(t, err)
+	assert.True(t, end.Equal(time.Date(2024, 6, 5, 0, 0, 0, 0, tokyo)))
+
+	// RFC3339 carries its own offset
+	exact, err := ParseTimeBound("2024-06-04T09:00:00+02:00", tokyo, true)
+	require.NoError(t, err)
+	assert.True(t, exact.Equal(time.Date(2024, 6, 4, 7, 0, 0, 0, time.UTC)))
+
+	_, err = ParseTimeBound("06/04/2024", tokyo, false)
+	assert.Error(t, err)
+
+	// Usage is recorded per hour, so bounds inside an hour are rejected
+	_, err = ParseTimeBound("2024-06-04T09:30:00Z", tokyo, false)
+	assert.Error(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	_, err = ParseTimeBound("2024-06-04", kolkata, true)
+	assert.Error(t, err)
+	_, err = ParseTimeBound("2024-06-04T09:00:00+05:30", kolkata, false)
+	assert.Error(t, err)
+}
+
+func TestAnalyzerTimeRange(t *testing.T) {
+	dataDir := t.TempDir()
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 12, 0, 0, 0, time.UTC) }
+	for d := 1; d <= 5; d++ {
+		path := filepath.Join(dataDir, "app", "day"+string(rune('0'+d))+".jsonl")
+		writeUsageLog(t, path, day(d), 100*d)
+		// Logs are written as they happen
+		require.NoError(t, os.Chtimes(path, day(d), day(d)))
+	}
+
+	run := func(since, until time.Time) []formatter.GroupedData {
+		t.Helper()
+		outputFile := filepath.Join(t.TempDir(), "report.json")
+		a := New(&Config{
+			DataDir:      dataDir,
+			CacheDir:     t.TempDir(),
+			OutputFormat: "json",
+			OutputFile:   outputFile,
+			Timezone:     "UTC",
+			GroupBy:      "day",
+			Since:        since,
+			Until:        until,
+		})
+		require.NoError(t, a.Run(context.Background()))
+		content, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		require.NotEqual(t, "null", strings.TrimSpace(string(content)))
+		var groups []formatter.GroupedData
+		require.NoError(t, json.Unmarshal(content, &groups))
+		return groups
+	}
+	days := func(groups []formatter.GroupedData) []string {
+		var result []string
+		for _, group := range groups {
+			result = append(result, group.Date)
+		}
+		return result
+	}
+
+	// --since 2024-06-02 --until 2024-06-03 covers the 2nd and 3rd
+	since := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2024-06-02", "2024-06-03"}, days(run(since, until)))
+
+	// Open-ended on either side
+	assert.Equal(t, []string{"2024-06-04", "2024-06-05"}, days(run(time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC), time.Time{})))
+	assert.Equal(t, []string{"2024-06-01"}, days(run(time.Time{}, since)))
+
+	// A range without usage reports nothing rather than failing
+	assert.Empty(t, run(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), time.Time{}))
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// Handling of usage logged without a model name, aggregated as
+// aggregator.UnknownModel
+const (
+	UnknownModelKeep  = "keep"  // Report it like any other model
+	UnknownModelDrop  = "drop"  // Leave it out of the results
+	UnknownModelPrice = "price" // Bill it at the rates of UnknownModelPricing
+	UnknownModelWarn  = "warn"  // Report it and print a warning with its share
+)
+
+// ValidateUnknownModel reports whether mode is a valid --unknown-model value
+// and, for the price mode, that a model to bill at was given
+func ValidateUnknownModel(mode, pricingModel string) error {
+	switch mode {
+	case "", UnknownModelKeep, UnknownModelDrop, UnknownModelWarn:
+		return nil
+	case UnknownModelPrice:
+		if pricingModel == "" {
+			return fmt.Errorf("--unknown-model %s requires --unknown-model-pricing", UnknownModelPrice)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid unknown model handling '%s': must be %s, %s, %s or %s",
+			mode, UnknownModelKeep, UnknownModelDrop, UnknownModelPrice, UnknownModelWarn)
+	}
+}
+
+// pricedAs returns the item to calculate the cost of item with. Usage
+// without a model name is billed as the configured model in price mode.
+func (a *Analyzer) pricedAs(item aggregator.HourlyData) *aggregator.HourlyData {
+	if item.Model == aggregator.UnknownModel && a.config.UnknownModel == UnknownModelPrice {
+		item.Model = a.config.UnknownModelPricing
+	}
+	return &item
+}
+
+// warnUnknownModel prints how much of the reported usage has no model name
+func (a *Analyzer) warnUnknownModel(tokens, totalTokens int, cost float64) {
+	if a.config.UnknownModel != UnknownModelWarn || tokens == 0 {
+		return
+	}
+	share := float64(tokens) / float64(totalTokens) * 100
+	fmt.Fprintf(a.warnings, "Warning: %s tokens (%.1f%% of usage, %s) were logged without a model name and are reported as '%s'. "+
+		"Check the source logs, or use --unknown-model drop or price.\n",
+		util.FormatNumber(tokens), share, util.FormatCurrency(cost), aggregator.UnknownModel)
+}
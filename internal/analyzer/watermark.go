@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+)
+
+// WatermarkFileName stores the start of the first hour not yet reported by a
+// --since-last run. It deliberately does not use the .json extension so that
+// cache preloading and --reset leave it alone.
+const WatermarkFileName = "since_last.watermark"
+
+func (a *Analyzer) watermarkPath() string {
+	return filepath.Join(a.config.CacheDir, WatermarkFileName)
+}
+
+// loadWatermark returns the stored watermark. ok is false when no previous
+// run has recorded one. A watermark not on the hour would split an hour
+// of usage, so it is rejected.
+func loadWatermark(path string) (watermark int64, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	watermark, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid watermark in %s: %w", path, err)
+	}
+	if watermark%3600 != 0 {
+		return 0, false, fmt.Errorf("invalid watermark in %s: %d is not on the hour", path, watermark)
+	}
+	return watermark, true, nil
+}
+
+// saveWatermark atomically replaces the stored watermark
+func saveWatermark(path string, watermark int64) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strconv.FormatInt(watermark, 10)+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// currentHourStart returns the start of the hour in progress at now, which
+// is where a --since-last run stops reporting
+func currentHourStart(now time.Time) int64 {
+	return now.Unix() - now.Unix()%3600
+}
+
+// filterSinceWatermark keeps hourly items starting at or after the watermark
+// and before currentHour. Usage is aggregated per hour, so only complete
+// hours are reported: the hour in progress is left to the next run and no
+// hour is reported twice.
+func filterSinceWatermark(data []aggregator.HourlyData, watermark, currentHour int64) []aggregator.HourlyData {
+	var filtered []aggregator.HourlyData
+	for _, item := range data {
+		if item.Hour >= watermark && item.Hour < currentHour {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeUsageLog writes a Claude Code log with one assistant entry at ts
+func writeUsageLog(t *testing.T, path string, ts time.Time, inputTokens int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	id := filepath.Base(path)
+	line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%s","sessionId":"s-%s","uuid":"u-%s",`+
+		`"message":{"id":"msg-%s","model":"claude-3-5-sonnet-20241022","role":"assistant","usage":{"input_tokens":%d,"output_tokens":10}}}`+"\n",
+		ts.UTC().Format(time.RFC3339), id, id, id, id, inputTokens)
+	require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+}
+
+func runSinceLast(t *testing.T, dataDir, cacheDir string) []formatter.GroupedData {
+	t.Helper()
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	a := New(&Config{
+		DataDir:      dataDir,
+		CacheDir:     cacheDir,
+		OutputFormat: "json",
+		OutputFile:   outputFile,
+		Timezone:     "UTC",
+		Duration:     "1d",
+		GroupBy:      "hour",
+		SinceLast:    true,
+	})
+	require.NoError(t, a.Run(context.Background()))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var groups []formatter.GroupedData
+	require.NoError(t, json.Unmarshal(content, &groups))
+	return groups
+}
+
+func TestAnalyzerSinceLastReportsOnlyNewUsage(t *testing.T) {
+	dataDir := t.TempDir()
+	cacheDir := t.TempDir()
+	watermarkPath := filepath.Join(cacheDir, WatermarkFileName)
+	now := time.Now().UTC()
+	currentHour := currentHourStart(now)
+
+	writeUsageLog(t, filepath.Join(dataDir, "project", "old.jsonl"), now.Add(-48*time.Hour), 500)
+	writeUsageLog(t, filepath.Join(dataDir, "project", "first.jsonl"), now.Add(-3*time.Hour), 100)
+	writeUsageLog(t, filepath.Join(dataDir, "project", "current.jsonl"), now, 1000)
+
+	// First run has no watermark and falls back to --duration, leaving out
+	// the hour in progress
+	groups := runSinceLast(t, dataDir, cacheDir)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 110, groups[0].TotalTokens)
+
+	watermark, ok, err := loadWatermark(watermarkPath)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, currentHour, watermark)
+
+	// Pretend the first run happened two hours ago: the next run reports the
+	// complete hours since then and still leaves out the hour in progress
+	require.NoError(t, saveWatermark(watermarkPath, currentHour-2*3600))
+	latest := now.Add(-time.Hour)
+	writeUsageLog(t, filepath.Join(dataDir, "project", "third.jsonl"), latest, 200)
+
+	groups = runSinceLast(t, dataDir, cacheDir)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 210, groups[0].TotalTokens)
+	assert.Equal(t, time.Unix(latest.Unix(), 0).UTC().Format("2006-01-02 15:00"), groups[0].Date)
+
+	watermark, ok, err = loadWatermark(watermarkPath)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, currentHour, watermark)
+}
+
+func TestFilterSinceWatermarkReportsEachHourOnce(t *testing.T) {
+	hour := int64(1717495200) // 2024-06-04 10:00 UTC
+	data := []aggregator.HourlyData{
+		{Hour: hour - 3600, TotalTokens: 10},
+		{Hour: hour, TotalTokens: 20, LastEntryTime: hour + 1800},
+	}
+
+	// At 10:30 the 10:00 hour is still in progress
+	first := filterSinceWatermark(data, 0, hour)
+	require.Len(t, first, 1)
+	assert.Equal(t, hour-3600, first[0].Hour)
+
+	// By 11:00 more usage landed in the 10:00 hour; it is reported once, in full
+	data[1].TotalTokens = 30
+	second := filterSinceWatermark(data, hour, hour+3600)
+	require.Len(t, second, 1)
+	assert.Equal(t, 30, second[0].TotalTokens)
+}
+
+func TestLoadWatermarkRejectsUnalignedWatermark(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WatermarkFileName)
+
+	require.NoError(t, saveWatermark(path, 1717495200+1234))
+	_, ok, err := loadWatermark(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not on the hour")
+	assert.False(t, ok)
+}
+
+func TestLoadWatermarkMissingFile(t *testing.T) {
+	_, ok, err := loadWatermark(filepath.Join(t.TempDir(), WatermarkFileName))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -1,6 +1,31 @@
 package top
 
-import "time"
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"runtime"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/constants"
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/data/parser"
+	"github.com/penwyp/go-claude-monitor/internal/data/scanner"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// Refresh bounds. The UI ticker fires every 1000/UIRefreshRate milliseconds and
+// every data refresh rescans the data directory, so both must stay in range.
+const (
+	MinDataRefreshInterval = 1 * time.Second
+	MaxDataRefreshInterval = 1 * time.Hour
+	MinUIRefreshRate       = 0.1
+	MaxUIRefreshRate       = 20.0
+)
+
+// DefaultMaxCachedRawLogs bounds how many sessions keep raw logs in memory
+const DefaultMaxCachedRawLogs = 1000
 
 // TopConfig contains configuration for the top command
 type TopConfig struct {
@@ -11,6 +36,8 @@ type TopConfig struct {
 	// Plan configuration
 	Plan              string
 	CustomLimitTokens int
+	LimitTokenTypes   string // Comma-separated token types counted toward the limit (empty = all)
+	WeeklyTokenLimit  int    // Rolling 7-day token cap across all sessions (0 = none)
 
 	// Display settings
 	Timezone   string
@@ -19,13 +46,70 @@ type TopConfig struct {
 	// Refresh settings
 	DataRefreshInterval time.Duration
 	UIRefreshRate       float64
+	PollInterval        time.Duration // Rescan for changed files instead of watching them (0 = file watcher)
+	WatchDebounce       time.Duration // Collect file change events this long before processing them (0 = default)
+	IdleExit            time.Duration // Exit after this long without keyboard input (0 = never)
+
+	// Notify shows a desktop notification when a window gets within
+	// NotifyThreshold of its reset or a new limit window starts
+	Notify          bool
+	NotifyThreshold time.Duration
+
+	// MetricsAddr serves Prometheus metrics of the sessions on /metrics at
+	// this address, e.g. ":9090" (empty = off)
+	MetricsAddr string
+
+	// LimitWebhook receives a JSON POST for each new unexpired rate limit
+	// (empty = off)
+	LimitWebhook string
+
+	// ClampResetTime caps the displayed reset time at one session duration
+	ClampResetTime bool
+
+	// CollapseModels shows only the dominant model per session in the distribution
+	CollapseModels bool
+
+	// ShowDailyUsage shows the cumulative usage of the current day next to
+	// the active window
+	ShowDailyUsage bool
+
+	// FollowProject renders only the active window of this project full-screen
+	FollowProject string
+
+	// MaxSessionAge hides sessions that ended longer ago than this (0 = show all)
+	MaxSessionAge time.Duration
+
+	// Session detection settings
+	NoFutureWindows     bool          // Suppress sessions lying entirely in the future with no activity
+	NoSpeculativeActive bool          // Skip the synthetic active window when the current period has no logs
+	SessionDuration     time.Duration // Length of a session window, 1h-24h (0 = 5h)
+	MinGapDuration      time.Duration // Minimum idle period shown as a gap row (0 = session gap)
+	SessionGap          time.Duration // Idle period after which activity starts a new window (0 = session duration)
+	WindowAnchor        string        // HH:MM that continuous activity windows align to (empty = hour)
+	WindowAnchorTZ      string        // Timezone window boundaries and WindowAnchor are computed in, e.g. UTC or the server's (empty = Timezone)
+	BurnRateSmoothing   float64       // Alpha of the smoothed per-minute rate used for projections (0 = session average)
+
+	// Input settings
+	InputFormat string // code (default) or desktop
+
+	// Projects and ExcludeProjects select files by project name: globs or
+	// substrings, include applied first (empty = all). Excluded project
+	// directories are never scanned.
+	Projects        []string
+	ExcludeProjects []string
 
 	// Performance settings
-	Concurrency int
+	Concurrency      int
+	MaxCachedRawLogs int  // Sessions whose raw logs stay in memory (0 = default, negative = unlimited)
+	PreloadWorkers   int  // Workers loading the file cache at startup (0 = CPU count)
+	CacheCompress    bool // Write file cache entries gzip-compressed
 
 	// Pricing configuration
-	PricingSource      string // default, litellm
-	PricingOfflineMode bool   // Enable offline pricing mode
+	PricingSource      string                          // default, litellm
+	PricingOfflineMode bool                            // Enable offline pricing mode
+	CacheReadDiscount  float64                         // Multiplier on the cache-read rate, 0-1 (1 = list price, 0 = free)
+	PricingOverrides   map[string]pricing.ModelPricing // Per-model rates layered over the pricing source
+	PricingMaxAge      time.Duration                   // Reuse cached remote pricing younger than this (0 = 24h)
 }
 
 // Validate checks if the configuration is valid
@@ -40,19 +124,114 @@ func (c *TopConfig) Validate() error {
 		c.Timezone = "Local"
 	}
 	if c.TimeFormat == "" {
-		c.TimeFormat = "24h"
+		c.TimeFormat = util.TimeFormat24h
+	}
+	if err := util.ValidateTimeFormat(c.TimeFormat); err != nil {
+		return err
+	}
+	if c.DataRefreshInterval < MinDataRefreshInterval || c.DataRefreshInterval > MaxDataRefreshInterval {
+		return fmt.Errorf("refresh interval %s is out of range: must be between %s and %s",
+			c.DataRefreshInterval, MinDataRefreshInterval, MaxDataRefreshInterval)
+	}
+	if c.UIRefreshRate < MinUIRefreshRate || c.UIRefreshRate > MaxUIRefreshRate {
+		return fmt.Errorf("UI refresh rate %g is out of range: must be between %g and %g Hz",
+			c.UIRefreshRate, MinUIRefreshRate, MaxUIRefreshRate)
+	}
+	if c.PollInterval < 0 {
+		return fmt.Errorf("poll interval %s must not be negative", c.PollInterval)
+	}
+	if c.WatchDebounce < 0 {
+		return fmt.Errorf("watch debounce %s must not be negative", c.WatchDebounce)
+	}
+	if c.WatchDebounce == 0 {
+		c.WatchDebounce = DefaultWatchDebounce
+	}
+	if c.IdleExit < 0 {
+		return fmt.Errorf("idle exit %s must not be negative", c.IdleExit)
 	}
-	if c.DataRefreshInterval == 0 {
-		c.DataRefreshInterval = 10 * time.Second
+	if c.NotifyThreshold == 0 {
+		c.NotifyThreshold = DefaultNotifyThreshold
 	}
-	if c.UIRefreshRate == 0 {
-		c.UIRefreshRate = 0.75
+	if c.NotifyThreshold < 0 {
+		return fmt.Errorf("notify threshold %s must not be negative", c.NotifyThreshold)
+	}
+	if c.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(c.MetricsAddr); err != nil {
+			return fmt.Errorf("invalid metrics address %q: %w", c.MetricsAddr, err)
+		}
+	}
+	if c.LimitWebhook != "" {
+		u, err := url.Parse(c.LimitWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid limit webhook %q: use an http or https URL", c.LimitWebhook)
+		}
+	}
+	if err := scanner.ValidateProjectPatterns(c.Projects); err != nil {
+		return err
+	}
+	if err := scanner.ValidateProjectPatterns(c.ExcludeProjects); err != nil {
+		return err
+	}
+	if c.WeeklyTokenLimit < 0 {
+		return fmt.Errorf("weekly token limit %d must not be negative", c.WeeklyTokenLimit)
+	}
+	if c.MaxSessionAge < 0 {
+		return fmt.Errorf("max session age %s must not be negative", c.MaxSessionAge)
+	}
+	if c.SessionDuration != 0 && (c.SessionDuration < constants.MinSessionDuration || c.SessionDuration > constants.MaxSessionDuration) {
+		return fmt.Errorf("session duration %s must be between %s and %s",
+			c.SessionDuration, constants.MinSessionDuration, constants.MaxSessionDuration)
+	}
+	if c.MinGapDuration < 0 {
+		return fmt.Errorf("minimum gap duration %s must not be negative", c.MinGapDuration)
+	}
+	if c.SessionGap < 0 {
+		return fmt.Errorf("session gap %s must be positive", c.SessionGap)
+	}
+	if c.WindowAnchor != "" {
+		if _, err := session.ParseWindowAnchor(c.WindowAnchor); err != nil {
+			return err
+		}
+	}
+	if c.WindowAnchorTZ != "" {
+		if _, err := time.LoadLocation(c.WindowAnchorTZ); err != nil {
+			return fmt.Errorf("invalid window anchor timezone '%s': %w", c.WindowAnchorTZ, err)
+		}
+	}
+	if err := session.ValidateBurnRateSmoothing(c.BurnRateSmoothing); err != nil {
+		return err
+	}
+	if c.LimitTokenTypes != "" {
+		if _, err := session.ParseTokenComponents(c.LimitTokenTypes); err != nil {
+			return err
+		}
+	}
+	if c.InputFormat == "" {
+		c.InputFormat = parser.FormatCode
+	}
+	if _, err := parser.AdapterForFormat(c.InputFormat); err != nil {
+		return err
 	}
 	if c.Concurrency == 0 {
 		c.Concurrency = 4
 	}
+	if c.PreloadWorkers < 0 {
+		return fmt.Errorf("preload workers %d must not be negative", c.PreloadWorkers)
+	}
+	if c.PreloadWorkers == 0 {
+		c.PreloadWorkers = runtime.NumCPU()
+	}
+	if c.MaxCachedRawLogs == 0 {
+		c.MaxCachedRawLogs = DefaultMaxCachedRawLogs
+	}
 	if c.PricingSource == "" {
 		c.PricingSource = "default"
 	}
+	if err := pricing.ValidateCacheReadDiscount(c.CacheReadDiscount); err != nil {
+		return err
+	}
+	if c.PricingMaxAge < 0 {
+		return fmt.Errorf("pricing max age %s must not be negative", c.PricingMaxAge)
+	}
 	return nil
-}
\ No newline at end of file
+}
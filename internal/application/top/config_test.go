@@ -0,0 +1,230 @@
+package top
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validTopConfig() *TopConfig {
+	return &TopConfig{
+		DataDir:             "/tmp/test_data",
+		CacheDir:            "/tmp/test_cache",
+		Plan:                "pro",
+		DataRefreshInterval: 10 * time.Second,
+		UIRefreshRate:       0.75,
+		PricingSource:       "default",
+		PricingOfflineMode:  true,
+	}
+}
+
+func TestTopConfigValidateRefreshBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(c *TopConfig)
+		errorMsg string
+	}{
+		{
+			name:   "valid defaults",
+			modify: func(c *TopConfig) {},
+		},
+		{
+			name:   "bounds are inclusive",
+			modify: func(c *TopConfig) { c.DataRefreshInterval = MinDataRefreshInterval; c.UIRefreshRate = MaxUIRefreshRate },
+		},
+		{
+			name:     "zero ui rate",
+			modify:   func(c *TopConfig) { c.UIRefreshRate = 0 },
+			errorMsg: "UI refresh rate 0 is out of range",
+		},
+		{
+			name:     "negative ui rate",
+			modify:   func(c *TopConfig) { c.UIRefreshRate = -1 },
+			errorMsg: "UI refresh rate -1 is out of range",
+		},
+		{
+			name:     "ui rate too high",
+			modify:   func(c *TopConfig) { c.UIRefreshRate = 50 },
+			errorMsg: "UI refresh rate 50 is out of range",
+		},
+		{
+			name:     "zero refresh interval",
+			modify:   func(c *TopConfig) { c.DataRefreshInterval = 0 },
+			errorMsg: "refresh interval 0s is out of range",
+		},
+		{
+			name:     "refresh interval too short",
+			modify:   func(c *TopConfig) { c.DataRefreshInterval = 10 * time.Millisecond },
+			errorMsg: "refresh interval 10ms is out of range",
+		},
+		{
+			name:     "refresh interval too long",
+			modify:   func(c *TopConfig) { c.DataRefreshInterval = 2 * time.Hour },
+			errorMsg: "refresh interval 2h0m0s is out of range",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validTopConfig()
+			tt.modify(config)
+
+			err := config.Validate()
+			if tt.errorMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestNewOrchestratorRejectsZeroUIRate(t *testing.T) {
+	config := validTopConfig()
+	config.UIRefreshRate = 0
+
+	assert.NotPanics(t, func() {
+		orchestrator, err := NewOrchestrator(config)
+		require.Error(t, err)
+		assert.Nil(t, orchestrator)
+		assert.Contains(t, err.Error(), "UI refresh rate")
+	})
+}
+
+func TestTopConfigValidateCacheReadDiscount(t *testing.T) {
+	for _, discount := range []float64{0, 0.1, 1} {
+		config := validTopConfig()
+		config.CacheReadDiscount = discount
+		require.NoError(t, config.Validate())
+		assert.Equal(t, discount, config.CacheReadDiscount, "discount %g should be kept", discount)
+	}
+
+	var config *TopConfig
+
+	for _, discount := range []float64{-0.1, 1.5} {
+		config = validTopConfig()
+		config.CacheReadDiscount = discount
+		assert.Error(t, config.Validate(), "discount %g should be rejected", discount)
+	}
+}
+
+func TestTopConfigValidateWatchDebounce(t *testing.T) {
+	config := validTopConfig()
+	require.NoError(t, config.Validate())
+	assert.Equal(t, DefaultWatchDebounce, config.WatchDebounce)
+
+	config = validTopConfig()
+	config.WatchDebounce = -time.Second
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidatePricingMaxAge(t *testing.T) {
+	config := validTopConfig()
+	config.PricingMaxAge = time.Hour
+	require.NoError(t, config.Validate())
+
+	config = validTopConfig()
+	config.PricingMaxAge = -time.Hour
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateSessionGap(t *testing.T) {
+	config := validTopConfig()
+	config.SessionGap = 2 * time.Hour
+	require.NoError(t, config.Validate())
+
+	config = validTopConfig()
+	config.SessionGap = -time.Hour
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateMetricsAddr(t *testing.T) {
+	for _, addr := range []string{"", ":9090", "127.0.0.1:9090"} {
+		config := validTopConfig()
+		config.MetricsAddr = addr
+		assert.NoError(t, config.Validate(), "address %q should be accepted", addr)
+	}
+
+	config := validTopConfig()
+	config.MetricsAddr = "9090"
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateMaxSessionAge(t *testing.T) {
+	config := validTopConfig()
+	config.MaxSessionAge = 24 * time.Hour
+	require.NoError(t, config.Validate())
+
+	config = validTopConfig()
+	config.MaxSessionAge = -time.Hour
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateWindowAnchorTimezone(t *testing.T) {
+	config := validTopConfig()
+	config.WindowAnchor = "03:00"
+	config.WindowAnchorTZ = "UTC"
+	require.NoError(t, config.Validate())
+
+	// Without an anchor it still sets where windows start on the hour
+	config = validTopConfig()
+	config.WindowAnchorTZ = "UTC"
+	require.NoError(t, config.Validate())
+
+	config = validTopConfig()
+	config.WindowAnchor = "03:00"
+	config.WindowAnchorTZ = "Mars/Olympus"
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateIdleExit(t *testing.T) {
+	config := validTopConfig()
+	config.IdleExit = 30 * time.Minute
+	require.NoError(t, config.Validate())
+	assert.Equal(t, 30*time.Minute, config.IdleExit)
+
+	config = validTopConfig()
+	config.IdleExit = -time.Minute
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateNotifyThreshold(t *testing.T) {
+	config := validTopConfig()
+	require.NoError(t, config.Validate())
+	assert.Equal(t, DefaultNotifyThreshold, config.NotifyThreshold)
+
+	config = validTopConfig()
+	config.NotifyThreshold = -time.Minute
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateSessionDuration(t *testing.T) {
+	config := validTopConfig()
+	config.SessionDuration = 3 * time.Hour
+	require.NoError(t, config.Validate())
+
+	config = validTopConfig()
+	config.SessionDuration = 30 * time.Minute
+	assert.Error(t, config.Validate())
+
+	config = validTopConfig()
+	config.SessionDuration = 25 * time.Hour
+	assert.Error(t, config.Validate())
+}
+
+func TestTopConfigValidateLimitWebhook(t *testing.T) {
+	for _, hook := range []string{"", "http://localhost:8080/hook", "https://example.com/alerts"} {
+		config := validTopConfig()
+		config.LimitWebhook = hook
+		assert.NoError(t, config.Validate(), "webhook %q should be accepted", hook)
+	}
+
+	for _, hook := range []string{"example.com/hook", "ftp://example.com", "http://"} {
+		config := validTopConfig()
+		config.LimitWebhook = hook
+		assert.Error(t, config.Validate(), "webhook %q should be rejected", hook)
+	}
+}
@@ -25,6 +25,8 @@ func convertSessionsForDisplay(sessions []*session.Session) []*display.Session {
 			WindowSource:     s.WindowSource,
 			FirstEntryTime:   s.FirstEntryTime,
 			TotalTokens:      s.TotalTokens,
+			LimitTokens:      s.LimitTokens,
+			HasLimitTokens:   s.HasLimitTokens,
 			TotalCost:        s.TotalCost,
 			ProjectTokens:    s.ProjectTokens,
 			ProjectCost:      s.ProjectCost,
@@ -40,6 +42,12 @@ func convertSessionsForDisplay(sessions []*session.Session) []*display.Session {
 			CostPerMinute:     s.CostPerMinute,
 			TokensPerMinute:   s.TokensPerMinute,
 			PredictedEndTime:  s.PredictedEndTime,
+			ProjectedTokens:   s.ProjectedTokens,
+			ProjectedCost:     s.ProjectedCost,
+			PacingLead:        s.PacingLead,
+		}
+		if confidence, ok := s.ProjectionData["confidence"].(string); ok {
+			result[i].ProjectionConfidence = confidence
 		}
 		// Copy projects map
 		if s.Projects != nil {
@@ -1,9 +1,8 @@
 package top
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/cache"
@@ -17,6 +16,16 @@ import (
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
+// Loading stages reported to a ProgressFunc
+const (
+	ProgressStageParsing   = "parsing"
+	ProgressStageDetecting = "detecting"
+)
+
+// ProgressFunc is called as data loading advances. done and total count files
+// in the parsing stage and are zero in the detecting stage.
+type ProgressFunc func(stage string, done, total int)
+
 // DataLoader handles all data loading and caching operations
 type DataLoader struct {
 	config        *TopConfig
@@ -26,6 +35,7 @@ type DataLoader struct {
 	scanner       *scanner.FileScanner
 	parser        *parser.Parser
 	aggregator    *aggregator.Aggregator
+	progress      ProgressFunc
 }
 
 // NewDataLoader creates a new DataLoader instance
@@ -35,11 +45,14 @@ func NewDataLoader(config *TopConfig) (*DataLoader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file cache: %w", err)
 	}
+	fileCache.SetPreloadWorkers(config.PreloadWorkers)
+	fileCache.SetCompress(config.CacheCompress)
 
 	// Create aggregator with pricing configuration
 	agg, err := aggregator.NewAggregatorWithConfig(
 		config.PricingSource,
 		config.PricingOfflineMode,
+		config.PricingMaxAge,
 		config.CacheDir,
 		config.Timezone,
 	)
@@ -48,23 +61,45 @@ func NewDataLoader(config *TopConfig) (*DataLoader, error) {
 		// Fallback to default aggregator
 		agg = aggregator.NewAggregatorWithTimezone(config.Timezone)
 	}
+	agg.SetCacheReadDiscount(config.CacheReadDiscount)
+	agg.SetPricingOverrides(config.PricingOverrides)
+
+	adapter, err := parser.AdapterForFormat(config.InputFormat)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get session configuration
 	sessionConfig := session.GetSessionConfig()
 
+	fileScanner := scanner.NewFileScanner(config.DataDir)
+	fileScanner.SetProjectFilter(config.Projects, config.ExcludeProjects)
+
 	return &DataLoader{
 		config:        config,
 		sessionConfig: sessionConfig,
 		fileCache:     fileCache,
-		memoryCache:   cache.NewMemoryCache(),
-		scanner:       scanner.NewFileScanner(config.DataDir),
-		parser:        parser.NewParser(config.Concurrency),
+		memoryCache:   cache.NewMemoryCacheWithLimit(config.MaxCachedRawLogs),
+		scanner:       fileScanner,
+		parser:        parser.NewParserWithAdapter(config.Concurrency, adapter),
 		aggregator:    agg,
 	}, nil
 }
 
-// Preload loads cache and recent data
-func (dl *DataLoader) Preload() error {
+// SetProgressFunc registers a callback for file loading progress
+func (dl *DataLoader) SetProgressFunc(fn ProgressFunc) {
+	dl.progress = fn
+}
+
+func (dl *DataLoader) reportProgress(stage string, done, total int) {
+	if dl.progress != nil {
+		dl.progress(stage, done, total)
+	}
+}
+
+// Preload loads cache and recent data. Cancelling ctx stops parsing; files
+// parsed until then stay cached and ctx's error is returned.
+func (dl *DataLoader) Preload(ctx context.Context) error {
 	util.LogInfo("Preloading cache and recent data...")
 
 	// 1. Preload file cache to memory
@@ -81,7 +116,45 @@ func (dl *DataLoader) Preload() error {
 	util.LogInfo(fmt.Sprintf("Found %d files to process", len(files)))
 
 	// 3. Load data in parallel
-	return dl.LoadFiles(files)
+	return dl.LoadFiles(ctx, files)
+}
+
+// DryRunReport describes what loading would do, as found by DryRun
+type DryRunReport struct {
+	TotalFiles  int
+	CacheHits   int                               // Files served from the cache
+	ToParse     int                               // Files that would be parsed
+	MissReasons map[datacache.CacheMissReason]int // Files to parse by cache miss reason
+}
+
+// DryRun scans for files and validates them against the cache like Preload
+// does, without parsing files or touching the memory cache
+func (dl *DataLoader) DryRun() (*DryRunReport, error) {
+	files, err := dl.ScanRecentFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionIds := make([]string, 0, len(files))
+	for _, file := range files {
+		sessionIds = append(sessionIds, extractSessionId(file))
+	}
+	validCache := dl.fileCache.BatchValidate(sessionIds)
+
+	report := &DryRunReport{
+		TotalFiles:  len(files),
+		MissReasons: make(map[datacache.CacheMissReason]int),
+	}
+	for _, sessionId := range sessionIds {
+		result := validCache[sessionId]
+		if result.Valid {
+			report.CacheHits++
+			continue
+		}
+		report.ToParse++
+		report.MissReasons[result.MissReason]++
+	}
+	return report, nil
 }
 
 // ScanRecentFiles scans for recent files based on configuration
@@ -134,8 +207,19 @@ func (dl *DataLoader) ScanRecentFiles() ([]string, error) {
 	}
 }
 
-// LoadFiles loads and processes the specified files
-func (dl *DataLoader) LoadFiles(files []string) error {
+// LoadFiles loads and processes the specified files until ctx is done
+func (dl *DataLoader) LoadFiles(ctx context.Context, files []string) error {
+	// Changed files reported by the watcher were not scanned, so the
+	// project filters and backup deduplication have not been applied to
+	// them yet. A backup shares its original's session id and would
+	// overwrite the original's cached data.
+	kept := files[:0:0]
+	for _, file := range files {
+		if !dl.scanner.IgnoresFile(file) && !scanner.SupersededBackup(file) {
+			kept = append(kept, file)
+		}
+	}
+	files = kept
 	if len(files) == 0 {
 		return nil
 	}
@@ -172,20 +256,33 @@ func (dl *DataLoader) LoadFiles(files []string) error {
 		}
 	}
 
+	// Cache hits count as already parsed
+	total := len(files)
+	done := total - len(filesToParse)
+	dl.reportProgress(ProgressStageParsing, done, total)
+
 	// Parse files that need processing
 	if len(filesToParse) > 0 {
 		util.LogInfo(fmt.Sprintf("Parsing %d files...", len(filesToParse)))
-		dl.parseAndCacheFiles(filesToParse, sessionIdMap)
+		return dl.parseAndCacheFiles(ctx, filesToParse, sessionIdMap, func() {
+			done++
+			dl.reportProgress(ProgressStageParsing, done, total)
+		})
 	}
 
 	return nil
 }
 
-// parseAndCacheFiles parses files and updates caches
-func (dl *DataLoader) parseAndCacheFiles(files []string, sessionIdMap map[string]string) {
-	parseResults := dl.parser.ParseFiles(files)
+// parseAndCacheFiles parses files and updates caches, calling onParsed once per
+// file. Once ctx is done no new files are parsed; the results of files already
+// being parsed are still cached before ctx's error is returned.
+func (dl *DataLoader) parseAndCacheFiles(ctx context.Context, files []string, sessionIdMap map[string]string, onParsed func()) error {
+	parseResults := dl.parser.ParseFilesContext(ctx, files)
+	parsed := 0
 
 	for result := range parseResults {
+		parsed++
+		onParsed()
 		if result.Error != nil {
 			util.LogWarn(fmt.Sprintf("Failed to parse %s: %v", result.File, result.Error))
 			continue
@@ -198,7 +295,7 @@ func (dl *DataLoader) parseAndCacheFiles(files []string, sessionIdMap map[string
 		}
 
 		// Aggregate data
-		projectName := aggregator.ExtractProjectName(result.File)
+		projectName := scanner.ExtractProjectName(result.File)
 		hourlyData := dl.aggregator.AggregateByHourAndModel(recentLogs, projectName)
 
 		// Extract limit messages
@@ -238,6 +335,11 @@ func (dl *DataLoader) parseAndCacheFiles(files []string, sessionIdMap map[string
 			RawLogs:        recentLogs,
 		})
 	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("loading interrupted after parsing %d of %d files: %w", parsed, len(files), err)
+	}
+	return nil
 }
 
 // filterRecentLogs filters logs based on retention configuration
@@ -271,6 +373,24 @@ func (dl *DataLoader) GetGlobalTimeline(secondsBack int64) []timeline.Timestampe
 	return dl.memoryCache.GetGlobalTimeline(secondsBack)
 }
 
+// DailyUsage returns the tokens and cost of every window on the calendar day
+// containing now, in now's location. Usage is bucketed by hour, so an hour is
+// counted on the day it starts.
+func (dl *DataLoader) DailyUsage(now time.Time) (int, float64) {
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	tokens := 0
+	cost := 0.0
+	for _, item := range dl.memoryCache.GetHourlyStats(dayStart.Unix(), dayEnd.Unix()) {
+		tokens += item.TotalTokens
+		if itemCost, err := dl.aggregator.CalculateCost(&item); err == nil {
+			cost += itemCost
+		}
+	}
+	return tokens, cost
+}
+
 // GetCachedWindowInfo returns cached window detection information
 func (dl *DataLoader) GetCachedWindowInfo() map[string]*session.WindowDetectionInfo {
 	return dl.memoryCache.GetCachedWindowInfo()
@@ -281,7 +401,7 @@ func (dl *DataLoader) UpdateWindowInfo(sessionID string, info *session.WindowDet
 	dl.memoryCache.UpdateWindowInfo(sessionID, info)
 }
 
-// IdentifyChangedFiles returns files that have changed since last load
+// IdentifyChangedFiles returns the session IDs of files that have changed since last load
 func (dl *DataLoader) IdentifyChangedFiles(files []string) []string {
 	changedFiles := make([]string, 0)
 
@@ -290,8 +410,8 @@ func (dl *DataLoader) IdentifyChangedFiles(files []string) []string {
 
 		// Check if file is in memory cache
 		if entry, exists := dl.memoryCache.Get(sessionId); exists {
-			// File exists, check if it's marked as dirty (changed)
-			if entry.IsDirty {
+			// File exists, check whether it differs from the loaded version
+			if fileChangedSinceLoad(file, entry.AggregatedData) {
 				changedFiles = append(changedFiles, sessionId)
 			}
 		} else {
@@ -304,6 +424,19 @@ func (dl *DataLoader) IdentifyChangedFiles(files []string) []string {
 }
 
 
+// fileChangedSinceLoad reports whether the file's modification time or size no
+// longer match the ones recorded when data was loaded from it
+func fileChangedSinceLoad(file string, data *aggregator.AggregatedData) bool {
+	if data == nil {
+		return true
+	}
+	info, err := util.GetFileInfo(file)
+	if err != nil {
+		return true
+	}
+	return info.ModTime != data.LastModified || info.Size != data.FileSize
+}
+
 // PersistDirtyEntries persists dirty cache entries to file cache
 func (dl *DataLoader) PersistDirtyEntries() error {
 	dirtyEntries := dl.memoryCache.GetDirtyEntries()
@@ -329,6 +462,5 @@ func (dl *DataLoader) GetAggregator() *aggregator.Aggregator {
 
 // extractSessionId extracts the session ID from a file path
 func extractSessionId(filePath string) string {
-	filename := filepath.Base(filePath)
-	return strings.TrimSuffix(filename, filepath.Ext(filename))
+	return scanner.SessionID(filePath)
 }
\ No newline at end of file
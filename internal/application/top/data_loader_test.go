@@ -0,0 +1,187 @@
+package top
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/cache"
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	datacache "github.com/penwyp/go-claude-monitor/internal/data/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataLoaderDryRunCountsInvalidCacheEntries(t *testing.T) {
+	dataDir := t.TempDir()
+	ts := time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	writeLog := func(name string, i int) string {
+		path := filepath.Join(dataDir, "project", name+".jsonl")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":%q,"uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+			ts, i, name, i, i)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = f.WriteString(line)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		return path
+	}
+	cached := writeLog("cached", 1)
+	changed := writeLog("changed", 2)
+
+	dl, err := NewDataLoader(&TopConfig{
+		DataDir:            dataDir,
+		CacheDir:           t.TempDir(),
+		Timezone:           "UTC",
+		Concurrency:        1,
+		PricingOfflineMode: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, dl.LoadFiles(context.Background(), []string{cached, changed}))
+
+	// One cached file changes on disk and one file was never loaded
+	writeLog("changed", 3)
+	writeLog("fresh", 4)
+
+	report, err := dl.DryRun()
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.TotalFiles)
+	assert.Equal(t, 1, report.CacheHits)
+	assert.Equal(t, 2, report.ToParse)
+	assert.Equal(t, map[datacache.CacheMissReason]int{
+		datacache.MissReasonSize:     1,
+		datacache.MissReasonNotFound: 1,
+	}, report.MissReasons)
+
+	// Nothing was parsed into memory
+	_, exists := dl.GetMemoryCache().Get("fresh")
+	assert.False(t, exists)
+}
+
+func TestDataLoaderDailyUsageIncludesEarlierWindows(t *testing.T) {
+	dl, err := NewDataLoader(&TopConfig{
+		DataDir:            t.TempDir(),
+		CacheDir:           t.TempDir(),
+		Timezone:           "Asia/Shanghai",
+		Concurrency:        1,
+		PricingOfflineMode: true,
+	})
+	require.NoError(t, err)
+
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	now := time.Date(2025, 7, 1, 16, 30, 0, 0, loc)
+	hour := func(h int) aggregator.HourlyData {
+		return aggregator.HourlyData{
+			Hour:         time.Date(2025, 7, 1, h, 0, 0, 0, loc).Unix(),
+			Model:        "claude-sonnet-4-20250514",
+			InputTokens:  1000,
+			TotalTokens:  1000,
+			MessageCount: 1,
+		}
+	}
+	set := func(id string, stats ...aggregator.HourlyData) {
+		dl.GetMemoryCache().Set(id, &cache.MemoryCacheEntry{
+			AggregatedData: &aggregator.AggregatedData{SessionId: id, HourlyStats: stats},
+		})
+	}
+
+	// A morning window, the active afternoon window and usage from the
+	// evening before, which falls on the previous local day
+	set("morning", hour(2), hour(5))
+	set("afternoon", hour(15), hour(16))
+	set("yesterday", hour(-1))
+
+	tokens, cost := dl.DailyUsage(now)
+	assert.Equal(t, 4000, tokens)
+	assert.InDelta(t, 0.012, cost, 1e-9)
+}
+
+func TestDataLoaderLoadFilesStopsWhenCancelled(t *testing.T) {
+	dataDir := t.TempDir()
+	cacheDir := t.TempDir()
+	ts := time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	var files []string
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("session-%02d", i)
+		path := filepath.Join(dataDir, "project", name+".jsonl")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":%q,"uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+			ts, i, name, i, i)
+		require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+		files = append(files, path)
+	}
+
+	dl, err := NewDataLoader(&TopConfig{
+		DataDir:            dataDir,
+		CacheDir:           cacheDir,
+		Timezone:           "UTC",
+		Concurrency:        1,
+		PricingOfflineMode: true,
+	})
+	require.NoError(t, err)
+
+	// Cancel as soon as the first file has been parsed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dl.SetProgressFunc(func(stage string, done, total int) {
+		if done > 0 {
+			cancel()
+		}
+	})
+
+	start := time.Now()
+	err = dl.LoadFiles(ctx, files)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// Files parsed before cancellation are cached completely, the rest are not
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries)
+	assert.Less(t, len(entries), len(files))
+	tmp, err := filepath.Glob(filepath.Join(cacheDir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmp)
+}
+
+func TestDataLoaderLoadFilesSkipsSupersededBackup(t *testing.T) {
+	dataDir := t.TempDir()
+	ts := time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	writeLog := func(name string, inputTokens int) string {
+		path := filepath.Join(dataDir, "project", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%s","sessionId":"session","uuid":"u-%s",`+
+			`"message":{"id":"msg-%s","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":%d,"output_tokens":10}}}`+"\n",
+			ts, name, name, name, inputTokens)
+		require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+		return path
+	}
+	original := writeLog("session.jsonl", 100)
+	backup := writeLog("session.backup.jsonl", 5000)
+
+	dl, err := NewDataLoader(&TopConfig{
+		DataDir:            dataDir,
+		CacheDir:           t.TempDir(),
+		Timezone:           "UTC",
+		Concurrency:        1,
+		PricingOfflineMode: true,
+	})
+	require.NoError(t, err)
+
+	// The watcher reports both files after a backup is written; the backup
+	// must not replace the original's data under the shared session id
+	require.NoError(t, dl.LoadFiles(context.Background(), []string{original, backup}))
+
+	entry, exists := dl.GetMemoryCache().Get("session")
+	require.True(t, exists)
+	require.Len(t, entry.AggregatedData.HourlyStats, 1)
+	assert.Equal(t, 110, entry.AggregatedData.HourlyStats[0].TotalTokens)
+}
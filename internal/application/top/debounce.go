@@ -0,0 +1,57 @@
+package top
+
+import "time"
+
+// DefaultWatchDebounce is how long file change events are collected before
+// they are processed as one batch
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// eventDebouncer collects the paths of file change events and releases them
+// as a single batch one window after the first event of the batch. The window
+// is not extended by later events, so a steady stream of writes still gets
+// processed regularly.
+type eventDebouncer struct {
+	window  time.Duration
+	pending map[string]bool
+	order   []string // Pending paths in the order they first changed
+	timer   *time.Timer
+}
+
+func newEventDebouncer(window time.Duration) *eventDebouncer {
+	return &eventDebouncer{
+		window:  window,
+		pending: make(map[string]bool),
+	}
+}
+
+// Add records a changed path, starting the window if none is running
+func (d *eventDebouncer) Add(path string) {
+	if !d.pending[path] {
+		d.pending[path] = true
+		d.order = append(d.order, path)
+	}
+	if d.timer == nil {
+		d.timer = time.NewTimer(d.window)
+	}
+}
+
+// C returns the channel that fires when the pending batch is due, or nil
+// when nothing is pending
+func (d *eventDebouncer) C() <-chan time.Time {
+	if d.timer == nil {
+		return nil
+	}
+	return d.timer.C
+}
+
+// Flush returns the pending paths and starts a new batch
+func (d *eventDebouncer) Flush() []string {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	batch := d.order
+	d.order = nil
+	d.pending = make(map[string]bool)
+	return batch
+}
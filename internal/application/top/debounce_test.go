@@ -0,0 +1,44 @@
+package top
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventDebouncerCoalescesRapidEvents(t *testing.T) {
+	d := newEventDebouncer(50 * time.Millisecond)
+	assert.Nil(t, d.C(), "no window runs before the first event")
+
+	// A burst of writes to the same file, as Claude Code produces while streaming
+	for i := 0; i < 5; i++ {
+		d.Add("/data/project/session.jsonl")
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var batches [][]string
+	idle := time.After(300 * time.Millisecond)
+loop:
+	for {
+		select {
+		case <-d.C():
+			batches = append(batches, d.Flush())
+		case <-idle:
+			break loop
+		}
+	}
+
+	assert.Equal(t, [][]string{{"/data/project/session.jsonl"}}, batches, "one detection cycle for the burst")
+	assert.Nil(t, d.C())
+}
+
+func TestEventDebouncerKeepsFirstChangeOrder(t *testing.T) {
+	d := newEventDebouncer(time.Hour)
+	d.Add("b.jsonl")
+	d.Add("a.jsonl")
+	d.Add("b.jsonl")
+
+	assert.Equal(t, []string{"b.jsonl", "a.jsonl"}, d.Flush())
+	assert.Empty(t, d.Flush())
+}
@@ -0,0 +1,128 @@
+package top
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+)
+
+// ExportDirName is the cache subdirectory holding views exported with 'e'
+const ExportDirName = "exports"
+
+// exportStatusDuration is how long the export confirmation stays on screen
+const exportStatusDuration = 5 * time.Second
+
+// projectSummary is the exported view of one project within a session
+type projectSummary struct {
+	TotalTokens          int     `json:"total_tokens"`
+	ThinkingOutputTokens int     `json:"thinking_output_tokens,omitempty"`
+	TotalCost            float64 `json:"total_cost"`
+	MessageCount         int     `json:"message_count"`
+}
+
+// sessionSummary is the exported view of one session. Every time is given
+// both as a formatted string and as raw epoch seconds for tooling.
+type sessionSummary struct {
+	ID                   string                    `json:"id"`
+	StartTime            string                    `json:"start_time"`
+	StartTimeUnix        int64                     `json:"start_time_unix"`
+	EndTime              string                    `json:"end_time"`
+	EndTimeUnix          int64                     `json:"end_time_unix"`
+	ResetTime            string                    `json:"reset_time,omitempty"`
+	ResetTimeUnix        int64                     `json:"reset_time_unix,omitempty"`
+	ActualEndTime        string                    `json:"actual_end_time,omitempty"`
+	ActualEndTimeUnix    int64                     `json:"actual_end_time_unix,omitempty"`
+	IsActive             bool                      `json:"is_active"`
+	IsGap                bool                      `json:"is_gap,omitempty"`
+	WindowSource         string                    `json:"window_source"`
+	DetectionConfidence  string                    `json:"detection_confidence,omitempty"`
+	IsAccountLevel       bool                      `json:"is_account_level"` // Usage spans several projects
+	TotalTokens          int                       `json:"total_tokens"`
+	ThinkingOutputTokens int                       `json:"thinking_output_tokens,omitempty"` // Output tokens of requests with extended thinking
+	TotalCost            float64                   `json:"total_cost"`
+	MessageCount         int                       `json:"message_count"`
+	BurnRate             float64                   `json:"burn_rate"`
+	LimitPercentage      float64                   `json:"limit_percentage"`
+	LimitBound           string                    `json:"limit_bound,omitempty"` // Plan limit the percentage is of
+	Models               map[string]int            `json:"models,omitempty"`      // Tokens by model
+	Projects             map[string]projectSummary `json:"projects,omitempty"`
+}
+
+// summarizeSessions converts sessions into their JSON summary form, keeping
+// their order. Timestamps are formatted as RFC 3339 in the given location.
+func summarizeSessions(sessions []*session.Session, loc *time.Location) []sessionSummary {
+	summaries := make([]sessionSummary, 0, len(sessions))
+	for _, s := range sessions {
+		summary := sessionSummary{
+			ID:                   s.ID,
+			StartTime:            time.Unix(s.StartTime, 0).In(loc).Format(time.RFC3339),
+			StartTimeUnix:        s.StartTime,
+			EndTime:              time.Unix(s.EndTime, 0).In(loc).Format(time.RFC3339),
+			EndTimeUnix:          s.EndTime,
+			IsActive:             s.IsActive,
+			IsGap:                s.IsGap,
+			WindowSource:         s.WindowSource,
+			DetectionConfidence:  s.DetectionConfidence,
+			IsAccountLevel:       s.IsAccountLevel(),
+			TotalTokens:          s.TotalTokens,
+			ThinkingOutputTokens: s.ThinkingOutputTokens,
+			TotalCost:            s.TotalCost,
+			MessageCount:         s.MessageCount,
+			BurnRate:             s.BurnRate,
+			LimitPercentage:      s.LimitPercentage,
+			LimitBound:           s.LimitBound,
+		}
+		if s.ResetTime > 0 {
+			summary.ResetTime = time.Unix(s.ResetTime, 0).In(loc).Format(time.RFC3339)
+			summary.ResetTimeUnix = s.ResetTime
+		}
+		if s.ActualEndTime != nil {
+			summary.ActualEndTime = time.Unix(*s.ActualEndTime, 0).In(loc).Format(time.RFC3339)
+			summary.ActualEndTimeUnix = *s.ActualEndTime
+		}
+		if len(s.ModelDistribution) > 0 {
+			summary.Models = make(map[string]int, len(s.ModelDistribution))
+			for name, stats := range s.ModelDistribution {
+				if stats != nil {
+					summary.Models[name] = stats.Tokens
+				}
+			}
+		}
+		if len(s.Projects) > 0 {
+			summary.Projects = make(map[string]projectSummary, len(s.Projects))
+			for name, p := range s.Projects {
+				summary.Projects[name] = projectSummary{
+					TotalTokens:          p.TotalTokens,
+					ThinkingOutputTokens: p.ThinkingOutputTokens,
+					TotalCost:            p.TotalCost,
+					MessageCount:         p.MessageCount,
+				}
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// exportSessions writes the JSON summary of sessions to a timestamped file
+// under dir and returns its path
+func exportSessions(dir string, sessions []*session.Session, loc *time.Location, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(summarizeSessions(sessions, loc), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sessions: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("top-%s.json", now.In(loc).Format("20060102-150405")))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write export file: %w", err)
+	}
+	return path, nil
+}
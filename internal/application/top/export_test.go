@@ -0,0 +1,164 @@
+package top
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportSessionsWritesParseableFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ExportDirName)
+	now := time.Date(2025, 7, 1, 15, 30, 0, 0, time.UTC)
+	start := now.Add(-2 * time.Hour).Unix()
+
+	sessions := []*session.Session{
+		{
+			ID:           "active",
+			StartTime:    start,
+			EndTime:      start + 5*3600,
+			ResetTime:    start + 5*3600,
+			IsActive:     true,
+			WindowSource: "first_message",
+			TotalTokens:  1500,
+			TotalCost:    0.75,
+			MessageCount: 3,
+			ModelDistribution: map[string]*model.ModelStats{
+				"claude-sonnet-4": {Model: "claude-sonnet-4", Tokens: 1500},
+			},
+			Projects: map[string]*session.ProjectStats{
+				"api": {ProjectName: "api", TotalTokens: 1500, TotalCost: 0.75, MessageCount: 3},
+			},
+			DetectionConfidence: model.DetectionConfidenceLow,
+		},
+		{ID: "gap", IsGap: true, StartTime: start - 3600, EndTime: start},
+	}
+
+	path, err := exportSessions(dir, sessions, time.UTC, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "top-20250701-153000.json"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var exported []sessionSummary
+	require.NoError(t, json.Unmarshal(data, &exported))
+
+	require.Len(t, exported, 2)
+	assert.Equal(t, "active", exported[0].ID)
+	assert.Equal(t, time.Unix(start, 0).UTC().Format(time.RFC3339), exported[0].StartTime)
+	assert.True(t, exported[0].IsActive)
+	assert.Equal(t, model.DetectionConfidenceLow, exported[0].DetectionConfidence)
+	assert.Equal(t, 1500, exported[0].Models["claude-sonnet-4"])
+	assert.Equal(t, 0.75, exported[0].Projects["api"].TotalCost)
+	assert.Equal(t, "gap", exported[1].ID)
+	assert.True(t, exported[1].IsGap)
+	assert.Empty(t, exported[1].ResetTime)
+}
+
+func TestExportSessionsIncludesEpochTimes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ExportDirName)
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	now := time.Date(2025, 7, 1, 15, 30, 0, 0, loc)
+	start := now.Add(-2 * time.Hour).Unix()
+	lastEntry := start + 1800
+
+	sessions := []*session.Session{
+		{
+			ID:            "active",
+			StartTime:     start,
+			EndTime:       start + 5*3600,
+			ResetTime:     start + 5*3600,
+			ActualEndTime: &lastEntry,
+			TotalTokens:   100,
+		},
+	}
+
+	path, err := exportSessions(dir, sessions, loc, now)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var raw []map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Len(t, raw, 1)
+
+	for _, field := range []string{"start_time", "end_time", "reset_time", "actual_end_time"} {
+		formatted, ok := raw[0][field].(string)
+		require.True(t, ok, "missing %s", field)
+		epoch, ok := raw[0][field+"_unix"].(float64)
+		require.True(t, ok, "missing %s_unix", field)
+
+		parsed, err := time.Parse(time.RFC3339, formatted)
+		require.NoError(t, err)
+		assert.Equal(t, int64(epoch), parsed.Unix(), field)
+	}
+	assert.Equal(t, float64(lastEntry), raw[0]["actual_end_time_unix"])
+}
+
+func TestExportSessionsLabelsAccountLevelSessions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ExportDirName)
+	now := time.Date(2025, 7, 1, 15, 30, 0, 0, time.UTC)
+	start := now.Add(-2 * time.Hour).Unix()
+
+	sessions := []*session.Session{
+		{
+			ID:        "shared",
+			StartTime: start,
+			EndTime:   start + 5*3600,
+			Projects: map[string]*session.ProjectStats{
+				"api": {ProjectName: "api", TotalTokens: 1000},
+				"web": {ProjectName: "web", TotalTokens: 500},
+			},
+		},
+		{
+			ID:        "solo",
+			StartTime: start - 5*3600,
+			EndTime:   start,
+			Projects: map[string]*session.ProjectStats{
+				"api": {ProjectName: "api", TotalTokens: 800},
+			},
+		},
+	}
+
+	path, err := exportSessions(dir, sessions, time.UTC, now)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var raw []map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Len(t, raw, 2)
+	assert.Equal(t, true, raw[0]["is_account_level"])
+	assert.Equal(t, false, raw[1]["is_account_level"])
+}
+
+func TestExportSessionsIncludesLimitPercentage(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Unix()
+	s := &session.Session{
+		ID:          "active",
+		StartTime:   start,
+		EndTime:     start + 5*3600,
+		IsActive:    true,
+		TotalTokens: 30000,
+		TotalCost:   1.5,
+	}
+	plan := pricing.Plan{Name: "tokens-only", TokenLimit: 120000}
+	session.NewMetricsCalculator(plan).Calculate(s)
+
+	data, err := json.Marshal(summarizeSessions([]*session.Session{s}, time.UTC))
+	require.NoError(t, err)
+	var exported []map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &exported))
+
+	require.Len(t, exported, 1)
+	assert.InDelta(t, float64(30000)/float64(120000)*100, exported[0]["limit_percentage"], 0.0001)
+	assert.Equal(t, session.LimitBoundTokens, exported[0]["limit_bound"])
+}
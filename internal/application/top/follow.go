@@ -0,0 +1,26 @@
+package top
+
+import "github.com/penwyp/go-claude-monitor/internal/core/session"
+
+// followSessions returns the active window the project contributed to, or
+// nothing when it has none. Windows are account-wide, so the window may hold
+// other projects' usage too. Should several active windows contain the
+// project, the earliest one is followed, matching the dashboard.
+func followSessions(sessions []*session.Session, project string) []*session.Session {
+	var followed *session.Session
+	for _, s := range sessions {
+		if s.IsGap || !s.IsActive {
+			continue
+		}
+		if _, ok := s.Projects[project]; !ok {
+			continue
+		}
+		if followed == nil || s.StartTime < followed.StartTime {
+			followed = s
+		}
+	}
+	if followed == nil {
+		return nil
+	}
+	return []*session.Session{followed}
+}
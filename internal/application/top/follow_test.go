@@ -0,0 +1,39 @@
+package top
+
+import (
+	"testing"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowSessionsSelectsActiveWindowOfProject(t *testing.T) {
+	projects := func(names ...string) map[string]*session.ProjectStats {
+		m := make(map[string]*session.ProjectStats)
+		for _, name := range names {
+			m[name] = &session.ProjectStats{ProjectName: name}
+		}
+		return m
+	}
+	sessions := []*session.Session{
+		{ID: "past", StartTime: 1000, IsActive: false, Projects: projects("api")},
+		{ID: "gap", StartTime: 2000, IsGap: true, Projects: projects()},
+		{ID: "other", StartTime: 3000, IsActive: true, Projects: projects("web")},
+		{ID: "shared", StartTime: 4000, IsActive: true, Projects: projects("web", "api")},
+		{ID: "later", StartTime: 5000, IsActive: true, Projects: projects("api")},
+	}
+
+	// The earliest active account window containing the project
+	followed := followSessions(sessions, "api")
+	require.Len(t, followed, 1)
+	assert.Equal(t, "shared", followed[0].ID)
+
+	followed = followSessions(sessions, "web")
+	require.Len(t, followed, 1)
+	assert.Equal(t, "other", followed[0].ID)
+
+	// Projects without an active window show nothing rather than another window
+	assert.Empty(t, followSessions(sessions, "docs"))
+	assert.Empty(t, followSessions(sessions[:2], "api"))
+}
@@ -0,0 +1,48 @@
+package top
+
+import "time"
+
+// idleTimer fires once no key event has been seen for the configured timeout.
+// A zero timeout disables it.
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newIdleTimer(timeout time.Duration) *idleTimer {
+	t := &idleTimer{timeout: timeout}
+	if timeout > 0 {
+		t.timer = time.NewTimer(timeout)
+	}
+	return t
+}
+
+// Reset restarts the idle period, typically on a key event
+func (t *idleTimer) Reset() {
+	if t.timer == nil {
+		return
+	}
+	if !t.timer.Stop() {
+		select {
+		case <-t.timer.C:
+		default:
+		}
+	}
+	t.timer.Reset(t.timeout)
+}
+
+// C returns the channel that fires when the idle period ends, or nil when
+// the timer is disabled
+func (t *idleTimer) C() <-chan time.Time {
+	if t.timer == nil {
+		return nil
+	}
+	return t.timer.C
+}
+
+// Stop releases the timer
+func (t *idleTimer) Stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
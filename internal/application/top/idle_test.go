@@ -0,0 +1,66 @@
+package top
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdleTimerFiresAfterTimeout(t *testing.T) {
+	idle := newIdleTimer(30 * time.Millisecond)
+	defer idle.Stop()
+
+	start := time.Now()
+	select {
+	case <-idle.C():
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("idle timer did not fire")
+	}
+}
+
+func TestIdleTimerResetByKeyEvent(t *testing.T) {
+	idle := newIdleTimer(60 * time.Millisecond)
+	defer idle.Stop()
+
+	// A key event just before the deadline postpones the exit
+	time.Sleep(40 * time.Millisecond)
+	idle.Reset()
+	reset := time.Now()
+
+	select {
+	case <-idle.C():
+		t.Fatal("idle timer fired before the reset idle period ended")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	select {
+	case <-idle.C():
+		assert.GreaterOrEqual(t, time.Since(reset), 60*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("idle timer did not fire after reset")
+	}
+}
+
+func TestIdleTimerResetAfterExpiry(t *testing.T) {
+	idle := newIdleTimer(10 * time.Millisecond)
+	defer idle.Stop()
+
+	// An expiry nobody received must not leak into the next period
+	time.Sleep(30 * time.Millisecond)
+	idle.Reset()
+
+	select {
+	case <-idle.C():
+		t.Fatal("stale expiry was delivered after reset")
+	case <-time.After(5 * time.Millisecond):
+	}
+}
+
+func TestIdleTimerDisabled(t *testing.T) {
+	idle := newIdleTimer(0)
+	idle.Reset()
+	idle.Stop()
+	assert.Nil(t, idle.C())
+}
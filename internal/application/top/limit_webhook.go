@@ -0,0 +1,187 @@
+package top
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// limitWebhookTimeout bounds each POST of the limit webhook
+const limitWebhookTimeout = 10 * time.Second
+
+// LimitWebhookStateFileName stores the reset times of unexpired limits
+// already posted, one per line, so a restarted top does not post them
+// again. It deliberately does not use the .json extension so that cache
+// preloading and --reset leave it alone.
+const LimitWebhookStateFileName = "limit_webhook.sent"
+
+// limitWebhookPayload is the JSON body posted for a detected limit
+type limitWebhookPayload struct {
+	ResetTime  time.Time `json:"reset_time"`
+	DetectedAt time.Time `json:"detected_at"`
+	LimitType  string    `json:"limit_type"`
+	Projects   []string  `json:"projects"`
+}
+
+// limitWebhook posts detected rate limits to a URL, once per reset time.
+// Posts run in the background so they never hold up detection; a limit
+// counts as posted only once the URL accepted it, so a failed post is
+// tried again on the next detection.
+type limitWebhook struct {
+	url       string
+	client    *http.Client
+	statePath string // Where posted reset times persist; empty keeps them in memory
+
+	mu      sync.Mutex
+	sent    map[int64]bool // Reset times already posted
+	pending map[int64]bool // Reset times being posted
+	wg      sync.WaitGroup
+}
+
+// newLimitWebhook creates a webhook posting to url that remembers posted
+// reset times in statePath across runs
+func newLimitWebhook(url, statePath string) *limitWebhook {
+	w := &limitWebhook{
+		url:       url,
+		client:    &http.Client{Timeout: limitWebhookTimeout},
+		statePath: statePath,
+		sent:      make(map[int64]bool),
+		pending:   make(map[int64]bool),
+	}
+	if err := w.loadSent(); err != nil {
+		util.LogWarn(fmt.Sprintf("Failed to load posted limits: %v", err))
+	}
+	return w
+}
+
+// HandleLimit posts event unless its reset time was posted before
+func (w *limitWebhook) HandleLimit(event session.LimitEvent) {
+	w.mu.Lock()
+	if w.sent[event.ResetTime] || w.pending[event.ResetTime] {
+		w.mu.Unlock()
+		return
+	}
+	w.pending[event.ResetTime] = true
+	w.mu.Unlock()
+
+	projects := event.Projects
+	if projects == nil {
+		projects = []string{}
+	}
+	payload := limitWebhookPayload{
+		ResetTime:  time.Unix(event.ResetTime, 0).UTC(),
+		DetectedAt: time.Unix(event.DetectedAt, 0).UTC(),
+		LimitType:  event.Type,
+		Projects:   projects,
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		// Retry once; a limit is worth a second try but not a backlog
+		err := w.post(payload)
+		if err != nil {
+			util.LogDebug(fmt.Sprintf("Limit webhook failed, retrying: %v", err))
+			err = w.post(payload)
+		}
+		if err != nil {
+			util.LogWarn(fmt.Sprintf("Limit webhook failed: %v", err))
+		}
+		w.finish(event.ResetTime, err == nil)
+	}()
+}
+
+// finish records the outcome of posting the limit with resetTime; only an
+// accepted post is remembered, across runs too
+func (w *limitWebhook) finish(resetTime int64, posted bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pending, resetTime)
+	if !posted {
+		return
+	}
+	w.sent[resetTime] = true
+	if err := w.saveSent(time.Now().Unix()); err != nil {
+		util.LogWarn(fmt.Sprintf("Failed to save posted limits: %v", err))
+	}
+}
+
+// post sends payload once
+func (w *limitWebhook) post(payload limitWebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// loadSent reads the reset times posted by earlier runs
+func (w *limitWebhook) loadSent() error {
+	if w.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(w.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, line := range strings.Fields(string(data)) {
+		resetTime, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid reset time in %s: %w", w.statePath, err)
+		}
+		w.sent[resetTime] = true
+	}
+	return nil
+}
+
+// saveSent atomically replaces the state file with the posted reset times
+// that have not passed at now; expired limits are never posted again.
+// The caller holds w.mu.
+func (w *limitWebhook) saveSent(now int64) error {
+	if w.statePath == "" {
+		return nil
+	}
+	resetTimes := make([]int64, 0, len(w.sent))
+	for resetTime := range w.sent {
+		if resetTime > now {
+			resetTimes = append(resetTimes, resetTime)
+		}
+	}
+	sort.Slice(resetTimes, func(i, j int) bool { return resetTimes[i] < resetTimes[j] })
+
+	var content strings.Builder
+	for _, resetTime := range resetTimes {
+		content.WriteString(strconv.FormatInt(resetTime, 10) + "\n")
+	}
+	tmpPath := w.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(content.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, w.statePath)
+}
+
+// Wait blocks until posts in flight are done
+func (w *limitWebhook) Wait() {
+	w.wg.Wait()
+}
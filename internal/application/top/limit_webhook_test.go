@@ -0,0 +1,146 @@
+package top
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookRecorder is a test server recording the payloads posted to it
+type webhookRecorder struct {
+	mu       sync.Mutex
+	payloads []limitWebhookPayload
+	failures int // Requests to answer with 500 before succeeding
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var payload limitWebhookPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.payloads = append(r.payloads, payload)
+}
+
+func (r *webhookRecorder) Payloads() []limitWebhookPayload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]limitWebhookPayload(nil), r.payloads...)
+}
+
+func TestLimitWebhookPostsRepeatedLimitOnce(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	webhook := newLimitWebhook(server.URL, "")
+	event := session.LimitEvent{
+		Type:       "general_limit",
+		ResetTime:  1_700_018_000,
+		DetectedAt: 1_700_010_000,
+		Projects:   []string{"api", "web"},
+	}
+
+	// Every refresh detects the limit again
+	for i := 0; i < 3; i++ {
+		webhook.HandleLimit(event)
+	}
+	webhook.Wait()
+
+	payloads := recorder.Payloads()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, time.Unix(1_700_018_000, 0).UTC(), payloads[0].ResetTime)
+	assert.Equal(t, time.Unix(1_700_010_000, 0).UTC(), payloads[0].DetectedAt)
+	assert.Equal(t, "general_limit", payloads[0].LimitType)
+	assert.Equal(t, []string{"api", "web"}, payloads[0].Projects)
+
+	// A limit with another reset time is new
+	event.ResetTime += 5 * 3600
+	webhook.HandleLimit(event)
+	webhook.Wait()
+	assert.Len(t, recorder.Payloads(), 2)
+}
+
+func TestLimitWebhookRetriesOnce(t *testing.T) {
+	recorder := &webhookRecorder{failures: 1}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	webhook := newLimitWebhook(server.URL, "")
+	webhook.HandleLimit(session.LimitEvent{Type: "opus_limit", ResetTime: 1_700_018_000})
+	webhook.Wait()
+	assert.Len(t, recorder.Payloads(), 1)
+
+	// A second failure is given up on
+	recorder.failures = 2
+	webhook.HandleLimit(session.LimitEvent{Type: "opus_limit", ResetTime: 1_700_036_000})
+	webhook.Wait()
+	assert.Len(t, recorder.Payloads(), 1)
+}
+
+func TestLimitWebhookRetriesFailedLimitLater(t *testing.T) {
+	recorder := &webhookRecorder{failures: 2}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	statePath := filepath.Join(t.TempDir(), LimitWebhookStateFileName)
+	event := session.LimitEvent{Type: "general_limit", ResetTime: time.Now().Add(2 * time.Hour).Unix()}
+
+	// Both tries fail, so the limit is not recorded as posted
+	webhook := newLimitWebhook(server.URL, statePath)
+	webhook.HandleLimit(event)
+	webhook.Wait()
+	assert.Empty(t, recorder.Payloads())
+	_, err := os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// The next detection of the same limit posts it
+	webhook.HandleLimit(event)
+	webhook.Wait()
+	require.Len(t, recorder.Payloads(), 1)
+	assert.Equal(t, time.Unix(event.ResetTime, 0).UTC(), recorder.Payloads()[0].ResetTime)
+}
+
+func TestLimitWebhookRemembersPostsAcrossRuns(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	statePath := filepath.Join(t.TempDir(), LimitWebhookStateFileName)
+	resetTime := time.Now().Add(2 * time.Hour).Unix()
+	expired := time.Now().Add(-time.Hour).Unix()
+
+	first := newLimitWebhook(server.URL, statePath)
+	first.HandleLimit(session.LimitEvent{Type: "general_limit", ResetTime: expired})
+	first.HandleLimit(session.LimitEvent{Type: "general_limit", ResetTime: resetTime})
+	first.Wait()
+	require.Len(t, recorder.Payloads(), 2)
+
+	// Only the unexpired reset time is kept
+	content, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(resetTime, 10)+"\n", string(content))
+
+	// A restarted top does not post the limit again
+	second := newLimitWebhook(server.URL, statePath)
+	second.HandleLimit(session.LimitEvent{Type: "general_limit", ResetTime: resetTime})
+	second.Wait()
+	assert.Len(t, recorder.Payloads(), 2)
+}
@@ -0,0 +1,66 @@
+package top
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/presentation/formatter"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// metricsShutdownTimeout bounds how long in-flight scrapes may delay exit
+const metricsShutdownTimeout = 5 * time.Second
+
+// metricsServer serves the sessions of the state manager on /metrics in the
+// Prometheus text exposition format
+type metricsServer struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// startMetricsServer listens on addr and serves /metrics in the background.
+// The listener is opened before returning so an unusable address fails
+// startup instead of being logged from the goroutine.
+func startMetricsServer(addr string, state *StateManager) (*metricsServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		// Refreshes swap in a new session slice, so a scrape during one
+		// renders the previous sessions
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := formatter.WritePrometheus(w, state.GetSessionsForDisplay()); err != nil {
+			util.LogDebug(fmt.Sprintf("Metrics scrape failed: %v", err))
+		}
+	})
+
+	s := &metricsServer{
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		listener: listener,
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			util.LogWarn(fmt.Sprintf("Metrics server stopped: %v", err))
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *metricsServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Shutdown stops the server, waiting for in-flight scrapes to finish
+func (s *metricsServer) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,68 @@
+package top
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsServerServesSessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dataDir := t.TempDir()
+	path := filepath.Join(dataDir, "project", "metrics.jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-1","sessionId":"s","uuid":"u-1",`+
+		`"message":{"id":"msg-1","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+		time.Now().Add(-30*time.Minute).UTC().Format(time.RFC3339))
+	require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+
+	o, err := NewOrchestrator(&TopConfig{
+		DataDir:             dataDir,
+		CacheDir:            t.TempDir(),
+		Plan:                "max5",
+		Timezone:            "UTC",
+		DataRefreshInterval: 10 * time.Second,
+		UIRefreshRate:       1,
+		PricingOfflineMode:  true,
+		MetricsAddr:         "127.0.0.1:0",
+	})
+	require.NoError(t, err)
+	defer o.Close()
+
+	server, err := startMetricsServer(o.config.MetricsAddr, o.stateManager)
+	require.NoError(t, err)
+	url := fmt.Sprintf("http://%s/metrics", server.Addr())
+
+	scrape := func() string {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// Before the first detection there are no samples, only declarations
+	assert.NotContains(t, scrape(), "claude_session_total_tokens{")
+
+	sessions, err := o.LoadAndAnalyzeData(context.Background())
+	require.NoError(t, err)
+	o.stateManager.SetSessions(sessions)
+
+	body := scrape()
+	assert.Contains(t, body, "# TYPE claude_session_total_tokens gauge")
+	assert.Regexp(t, `(?m)^claude_session_total_tokens\{project="project",session_id="[^"]+",active="true"\} 110$`, body)
+
+	require.NoError(t, server.Shutdown())
+	_, err = http.Get(url)
+	assert.Error(t, err, "server should be closed after shutdown")
+}
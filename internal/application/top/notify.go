@@ -0,0 +1,149 @@
+package top
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// DefaultNotifyThreshold is how long before a window resets --notify warns
+const DefaultNotifyThreshold = 15 * time.Minute
+
+// notifyTimeout bounds a notification command, e.g. notify-send waiting on a
+// missing D-Bus session
+const notifyTimeout = 5 * time.Second
+
+// Notifier shows a notification to the user
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// desktopNotifier shows notifications through the platform's notification
+// command: osascript on macOS, notify-send elsewhere
+type desktopNotifier struct {
+	command string
+	args    func(title, message string) []string
+}
+
+// newDesktopNotifier returns the notifier for this platform, or an error when
+// none is available
+func newDesktopNotifier() (Notifier, error) {
+	var notifier *desktopNotifier
+	switch runtime.GOOS {
+	case "darwin":
+		notifier = &desktopNotifier{
+			command: "osascript",
+			args: func(title, message string) []string {
+				return []string{"-e", fmt.Sprintf("display notification %s with title %s",
+					strconv.Quote(message), strconv.Quote(title))}
+			},
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		notifier = &desktopNotifier{
+			command: "notify-send",
+			args: func(title, message string) []string {
+				return []string{title, message}
+			},
+		}
+	default:
+		return nil, fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath(notifier.command); err != nil {
+		return nil, fmt.Errorf("desktop notifications need %s: %w", notifier.command, err)
+	}
+	return notifier, nil
+}
+
+func (n *desktopNotifier) Notify(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, n.command, n.args(title, message)...).Run()
+}
+
+// nopNotifier drops notifications, used when no desktop notifier is available
+type nopNotifier struct{}
+
+func (nopNotifier) Notify(title, message string) error { return nil }
+
+// notification is an event --notify reports
+type notification struct {
+	Title   string
+	Message string
+}
+
+// notificationTracker turns session updates into notifications, each event
+// at most once: a window getting within threshold of its reset, and a new
+// window started by a limit message.
+type notificationTracker struct {
+	threshold time.Duration
+	layout    string          // Layout of reset times in messages
+	warned    map[string]bool // Session IDs already warned about their reset
+	limits    map[int64]bool  // Start times of limit windows already seen
+	primed    bool            // Whether limit windows of the first check were recorded
+}
+
+func newNotificationTracker(threshold time.Duration, timeFormat string) *notificationTracker {
+	return &notificationTracker{
+		threshold: threshold,
+		layout:    util.ClockLayout(timeFormat, false),
+		warned:    make(map[string]bool),
+		limits:    make(map[int64]bool),
+	}
+}
+
+// Check returns the notifications due for sessions at now. Limit windows
+// present on the first check are recorded without notifying, so starting
+// the monitor does not report limits that were already hit.
+func (t *notificationTracker) Check(sessions []*session.Session, now int64) []notification {
+	var due []notification
+	for _, s := range sessions {
+		if s.IsGap || !s.IsActive {
+			continue
+		}
+		if isLimitWindow(s) && !t.limits[s.StartTime] {
+			t.limits[s.StartTime] = true
+			if t.primed {
+				due = append(due, notification{
+					Title:   "Claude usage limit reached",
+					Message: fmt.Sprintf("Window resets at %s", t.formatTime(resetTimeOf(s))),
+				})
+			}
+		}
+
+		remaining := time.Duration(resetTimeOf(s)-now) * time.Second
+		if remaining > 0 && remaining <= t.threshold && !t.warned[s.ID] {
+			t.warned[s.ID] = true
+			due = append(due, notification{
+				Title:   "Claude session resets soon",
+				Message: fmt.Sprintf("%s remaining, resets at %s", remaining.Round(time.Minute), t.formatTime(resetTimeOf(s))),
+			})
+		}
+	}
+	t.primed = true
+	return due
+}
+
+// isLimitWindow reports whether s was started by a limit message
+func isLimitWindow(s *session.Session) bool {
+	return s.WindowSource == "limit_message" || s.WindowSource == "history_limit"
+}
+
+// resetTimeOf returns when the window of s resets
+func resetTimeOf(s *session.Session) int64 {
+	if s.ResetTime > 0 {
+		return s.ResetTime
+	}
+	return s.EndTime
+}
+
+// formatTime formats ts as a clock time in the configured timezone and
+// time format
+func (t *notificationTracker) formatTime(ts int64) string {
+	return util.GetTimeProvider().Format(time.Unix(ts, 0), t.layout)
+}
@@ -0,0 +1,78 @@
+package top
+
+import (
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func notifySession(start int64, source string) *session.Session {
+	return &session.Session{
+		ID:           "s",
+		StartTime:    start,
+		EndTime:      start + 5*3600,
+		ResetTime:    start + 5*3600,
+		IsActive:     true,
+		WindowSource: source,
+	}
+}
+
+func TestNotificationTrackerThresholdCrossing(t *testing.T) {
+	tracker := newNotificationTracker(15*time.Minute, "24h")
+	s := notifySession(1_700_000_000, "first_message")
+	reset := s.ResetTime
+
+	// Twenty minutes left: not yet within the threshold
+	assert.Empty(t, tracker.Check([]*session.Session{s}, reset-20*60))
+
+	// Crossing the threshold fires once
+	due := tracker.Check([]*session.Session{s}, reset-14*60)
+	require.Len(t, due, 1)
+	assert.Contains(t, due[0].Message, "14m0s remaining")
+
+	// Later ticks in the same window do not fire again
+	assert.Empty(t, tracker.Check([]*session.Session{s}, reset-10*60))
+	assert.Empty(t, tracker.Check([]*session.Session{s}, reset-60))
+}
+
+func TestNotificationTrackerSkipsExpiredAndGaps(t *testing.T) {
+	tracker := newNotificationTracker(15*time.Minute, "24h")
+	expired := notifySession(1_700_000_000, "first_message")
+	gap := notifySession(1_700_000_000, "gap")
+	gap.IsGap = true
+
+	assert.Empty(t, tracker.Check([]*session.Session{expired}, expired.ResetTime+60))
+	assert.Empty(t, tracker.Check([]*session.Session{gap}, gap.ResetTime-60))
+}
+
+func TestNotificationTrackerNewLimitWindow(t *testing.T) {
+	tracker := newNotificationTracker(15*time.Minute, "24h")
+	start := int64(1_700_000_000)
+	existing := notifySession(start, "limit_message")
+	existing.ID = "existing"
+
+	// A limit window already there when monitoring starts is not reported
+	assert.Empty(t, tracker.Check([]*session.Session{existing}, start+3600))
+
+	// A new one is reported on the tick it appears, and only then
+	limit := notifySession(start+5*3600, "limit_message")
+	limit.ID = "limit"
+	due := tracker.Check([]*session.Session{limit}, start+5*3600+60)
+	require.Len(t, due, 1)
+	assert.Equal(t, "Claude usage limit reached", due[0].Title)
+	assert.Empty(t, tracker.Check([]*session.Session{limit}, start+5*3600+120))
+}
+
+func TestNotificationTrackerHonorsTimeFormat(t *testing.T) {
+	tracker := newNotificationTracker(15*time.Minute, "12h")
+	s := notifySession(1_700_000_000, "first_message")
+
+	due := tracker.Check([]*session.Session{s}, s.ResetTime-60)
+	require.Len(t, due, 1)
+	want := util.GetTimeProvider().Format(time.Unix(s.ResetTime, 0), "3:04 PM")
+	assert.Contains(t, due[0].Message, "resets at "+want)
+}
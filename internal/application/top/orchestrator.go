@@ -3,6 +3,7 @@ package top
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
@@ -11,6 +12,7 @@ import (
 	"github.com/penwyp/go-claude-monitor/internal/core/session"
 	"github.com/penwyp/go-claude-monitor/internal/presentation/display"
 	"github.com/penwyp/go-claude-monitor/internal/presentation/interaction"
+	"github.com/penwyp/go-claude-monitor/internal/presentation/layout"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
@@ -35,9 +37,15 @@ type Orchestrator struct {
 	
 	// Monitoring
 	watcher *monitoring.FileWatcher
+
+	// Posts detected rate limits, nil without --limit-webhook
+	limitWebhook *limitWebhook
 	
 	// Cache management
 	lastCacheSave int64
+
+	// Loading progress callback, used by detect
+	progress ProgressFunc
 }
 
 // NewOrchestrator creates a new Orchestrator instance
@@ -55,12 +63,41 @@ func NewOrchestrator(config *TopConfig) (*Orchestrator, error) {
 	
 	// Determine plan limits
 	planLimits := pricing.GetPlanWithDefault(config.Plan, config.CustomLimitTokens)
+	if config.WeeklyTokenLimit > 0 {
+		planLimits.WeeklyTokenLimit = config.WeeklyTokenLimit
+	}
 	
 	// Create session detector with aggregator from data loader
 	detector := session.NewSessionDetectorWithAggregator(dataLoader.GetAggregator(), config.Timezone, config.CacheDir)
+	detector.SetSuppressFutureWindows(config.NoFutureWindows)
+	detector.SetSuppressSpeculativeActive(config.NoSpeculativeActive)
+	detector.SetSessionDuration(config.SessionDuration)
+	detector.SetMinGapDuration(config.MinGapDuration)
+	detector.SetSessionGap(config.SessionGap)
+	detector.SetBurnRateSmoothing(config.BurnRateSmoothing)
+	if err := detector.SetWindowAnchor(config.WindowAnchor); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := detector.SetWindowAnchorTimezone(config.WindowAnchorTZ); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	
+	var webhook *limitWebhook
+	if config.LimitWebhook != "" {
+		webhook = newLimitWebhook(config.LimitWebhook, filepath.Join(config.CacheDir, LimitWebhookStateFileName))
+		detector.SetLimitHandler(webhook.HandleLimit)
+	}
 	
 	// Create metrics calculator
 	calculator := session.NewMetricsCalculator(planLimits)
+	calculator.SetSessionDuration(config.SessionDuration)
+	if config.LimitTokenTypes != "" {
+		components, err := session.ParseTokenComponents(config.LimitTokenTypes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		calculator.SetLimitTokenComponents(components)
+	}
 	
 	// Create state manager
 	stateManager := NewStateManager()
@@ -70,9 +107,13 @@ func NewOrchestrator(config *TopConfig) (*Orchestrator, error) {
 	
 	// Create display
 	displayConfig := &display.DisplayConfig{
-		Plan:       config.Plan,
-		Timezone:   config.Timezone,
-		TimeFormat: config.TimeFormat,
+		Plan:            config.Plan,
+		Timezone:        config.Timezone,
+		TimeFormat:      config.TimeFormat,
+		SessionDuration: config.SessionDuration,
+		ClampResetTime:  config.ClampResetTime,
+		CollapseModels:  config.CollapseModels,
+		FollowProject:   config.FollowProject,
 	}
 	termDisplay := display.NewTerminalDisplay(displayConfig)
 	
@@ -89,6 +130,7 @@ func NewOrchestrator(config *TopConfig) (*Orchestrator, error) {
 		calculator:   calculator,
 		display:      termDisplay,
 		sorter:       sorter,
+		limitWebhook: webhook,
 	}, nil
 }
 
@@ -103,6 +145,17 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 	if err := util.InitializeTimeProvider(o.config.Timezone); err != nil {
 		return fmt.Errorf("failed to initialize timezone: %w", err)
 	}
+
+	// Serve /metrics for the whole run; scrapes before the first detection
+	// see no sessions
+	if o.config.MetricsAddr != "" {
+		metrics, err := startMetricsServer(o.config.MetricsAddr, o.stateManager)
+		if err != nil {
+			return fmt.Errorf("failed to start metrics server: %w", err)
+		}
+		defer metrics.Shutdown()
+		util.LogInfo(fmt.Sprintf("Serving metrics on %s/metrics", metrics.Addr()))
+	}
 	
 	// Phase 1: Initialize keyboard
 	keyboard, err := interaction.NewKeyboardReader()
@@ -129,7 +182,7 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 	o.stateManager.SetLoadingState(true, "Loading data files...")
 	o.updateDisplay()
 	
-	if err := o.dataLoader.Preload(); err != nil {
+	if err := o.dataLoader.Preload(ctx); err != nil {
 		return fmt.Errorf("preload failed: %w", err)
 	}
 	
@@ -149,8 +202,19 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 	o.stateManager.SetLoadingState(true, "Starting file monitoring...")
 	o.updateDisplay()
 	
-	if err := o.startWatcher(ctx); err != nil {
-		return fmt.Errorf("failed to start file watcher: %w", err)
+	// On network filesystems file events are unreliable, so poll instead
+	var fileEvents <-chan model.FileEvent
+	var pollTick <-chan time.Time
+	if o.config.PollInterval > 0 {
+		util.LogInfo(fmt.Sprintf("File watcher disabled, polling for changes every %s", o.config.PollInterval))
+		pollTicker := time.NewTicker(o.config.PollInterval)
+		defer pollTicker.Stop()
+		pollTick = pollTicker.C
+	} else {
+		if err := o.startWatcher(ctx); err != nil {
+			return fmt.Errorf("failed to start file watcher: %w", err)
+		}
+		fileEvents = o.watcher.Events()
 	}
 	
 	// Clear loading state only after everything is ready
@@ -166,6 +230,21 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 	cacheTicker := time.NewTicker(1 * time.Minute)
 	defer cacheTicker.Stop()
 	
+	// Coalesce bursts of writes into one load and detection
+	debouncer := newEventDebouncer(o.config.WatchDebounce)
+	defer debouncer.Flush()
+
+	// Exit on shared terminals nobody is watching
+	idle := newIdleTimer(o.config.IdleExit)
+	defer idle.Stop()
+
+	var notifier Notifier
+	var notifications *notificationTracker
+	if o.config.Notify {
+		notifier = o.newNotifier()
+		notifications = newNotificationTracker(o.config.NotifyThreshold, o.config.TimeFormat)
+	}
+	
 	// Initial display with loaded data
 	o.updateDisplay()
 	
@@ -176,6 +255,10 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 			return nil
 			
 		case <-uiTicker.C:
+			if notifications != nil {
+				o.sendNotifications(notifier, notifications)
+			}
+
 			// UI refresh
 			state := o.stateManager.GetInteractionState()
 			if !state.IsPaused {
@@ -196,15 +279,31 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 			// Persist cache
 			o.persistCache()
 			
-		case event := <-o.watcher.Events():
-			// Handle file changes
+		case event := <-fileEvents:
+			// Collect file changes until the debounce window ends
 			state := o.stateManager.GetInteractionState()
 			if !state.IsPaused {
-				o.handleFileChange(event)
+				util.LogDebug(fmt.Sprintf("File changed: %s (%s)", event.Path, event.Operation))
+				debouncer.Add(event.Path)
+			}
+
+		case <-debouncer.C():
+			o.handleFileChanges(debouncer.Flush())
+
+		case <-pollTick:
+			// Look for file changes the watcher would have reported
+			state := o.stateManager.GetInteractionState()
+			if !state.IsPaused {
+				o.pollFileChanges()
 			}
 			
+		case <-idle.C():
+			util.LogInfo(fmt.Sprintf("No keyboard input for %s, exiting", o.config.IdleExit))
+			return nil
+
 		case keyEvent := <-o.keyboard.Events():
 			// Handle keyboard input
+			idle.Reset()
 			if o.handleKeyboard(keyEvent) {
 				return nil // Exit requested
 			}
@@ -213,19 +312,47 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 	}
 }
 
-// LoadAndAnalyzeData performs the core session detection workflow without UI
-func (o *Orchestrator) LoadAndAnalyzeData() ([]*session.Session, error) {
+// newNotifier returns the desktop notifier, or one that drops notifications
+// when the platform has none
+func (o *Orchestrator) newNotifier() Notifier {
+	notifier, err := newDesktopNotifier()
+	if err != nil {
+		util.LogWarn(fmt.Sprintf("Notifications disabled: %v", err))
+		return nopNotifier{}
+	}
+	return notifier
+}
+
+// sendNotifications shows the notifications due for the current sessions.
+// They are shown in the background so a slow notifier never stalls the UI.
+func (o *Orchestrator) sendNotifications(notifier Notifier, tracker *notificationTracker) {
+	now := o.refreshCtrl.clock.Now().Unix()
+	for _, n := range tracker.Check(o.stateManager.GetCurrentSessions(), now) {
+		go func(n notification) {
+			if err := notifier.Notify(n.Title, n.Message); err != nil {
+				util.LogWarn(fmt.Sprintf("Failed to send notification: %v", err))
+			}
+		}(n)
+	}
+}
+
+// LoadAndAnalyzeData performs the core session detection workflow without UI.
+// Cancelling ctx stops loading before detection starts.
+func (o *Orchestrator) LoadAndAnalyzeData(ctx context.Context) ([]*session.Session, error) {
 	// Initialize global time provider
 	if err := util.InitializeTimeProvider(o.config.Timezone); err != nil {
 		return nil, fmt.Errorf("failed to initialize timezone: %w", err)
 	}
 	
 	// Preload data
-	if err := o.dataLoader.Preload(); err != nil {
+	if err := o.dataLoader.Preload(ctx); err != nil {
 		return nil, fmt.Errorf("preload failed: %w", err)
 	}
 	
 	// Detect sessions
+	if o.progress != nil {
+		o.progress(ProgressStageDetecting, 0, 0)
+	}
 	sessions, err := o.refreshCtrl.FullDetect()
 	if err != nil {
 		return nil, fmt.Errorf("session detection failed: %w", err)
@@ -234,12 +361,23 @@ func (o *Orchestrator) LoadAndAnalyzeData() ([]*session.Session, error) {
 	return sessions, nil
 }
 
+// DryRun reports how many files would be served from the cache or parsed
+func (o *Orchestrator) DryRun() (*DryRunReport, error) {
+	return o.dataLoader.DryRun()
+}
+
 // GetAggregatedMetrics calculates aggregated metrics from sessions
 func (o *Orchestrator) GetAggregatedMetrics(sessions []*session.Session) *model.AggregatedMetrics {
 	displaySessions := convertSessionsForDisplay(sessions)
 	return o.display.CalculateAggregatedMetrics(displaySessions)
 }
 
+// SetProgressFunc registers a callback for loading and detection progress
+func (o *Orchestrator) SetProgressFunc(fn ProgressFunc) {
+	o.progress = fn
+	o.dataLoader.SetProgressFunc(fn)
+}
+
 // GetDetector returns the session detector instance
 func (o *Orchestrator) GetDetector() *session.SessionDetector {
 	return o.detector
@@ -250,6 +388,16 @@ func (o *Orchestrator) updateDisplay() {
 	isLoading, loadingMessage := o.stateManager.GetLoadingState()
 	sessions := o.stateManager.GetSessionsForDisplay()
 	
+	// The weekly cap spans every session, so measure it before filtering
+	if o.planLimits.WeeklyTokenLimit > 0 {
+		weekly := o.calculator.CalculateWeeklyUsage(sessions, util.GetTimeProvider().Now().Unix())
+		o.display.SetWeeklyUsage(weekly.Tokens, weekly.Limit, weekly.ResetTime)
+	}
+	sessions = recentSessions(sessions, util.GetTimeProvider().Now().Unix(), o.config.MaxSessionAge)
+	if o.config.FollowProject != "" {
+		sessions = followSessions(sessions, o.config.FollowProject)
+	}
+	
 	// Convert for sorting
 	sortingSessions := convertSessionsForSorting(sessions)
 	o.sorter.Sort(sortingSessions)
@@ -258,6 +406,10 @@ func (o *Orchestrator) updateDisplay() {
 	// Convert for display
 	displaySessions := convertSessionsForDisplay(sessions)
 	
+	if o.config.ShowDailyUsage {
+		o.display.SetDailyUsage(o.dataLoader.DailyUsage(util.GetTimeProvider().Now()))
+	}
+	
 	// Update state with loading information
 	state := o.stateManager.GetInteractionState()
 	state.IsLoading = isLoading
@@ -272,6 +424,9 @@ func (o *Orchestrator) refreshData() {
 	// Get current state for comparison
 	currentSessions := o.stateManager.GetCurrentSessions()
 	currentCount := len(currentSessions)
+
+	// Warn if the system timezone moved; display keeps the configured one
+	util.GetTimeProvider().CheckSystemTimezone()
 	
 	// Set refreshing state to keep data visible
 	o.stateManager.SetDisplayStatus(model.StatusRefreshing, "Refreshing data...")
@@ -382,8 +537,11 @@ func (o *Orchestrator) handleKeyboard(event interaction.KeyEvent) bool {
 		case 't', 'T':
 			// Cycle through layout styles
 			o.stateManager.UpdateInteractionState(func(s *model.InteractionState) {
-				s.LayoutStyle = (s.LayoutStyle + 1) % 2
+				s.LayoutStyle = (s.LayoutStyle + 1) % layout.StyleCount
 			})
+		case 'e', 'E':
+			// Export current view
+			o.exportView()
 		}
 	case interaction.KeyEscape:
 		// If help is shown, close it; otherwise quit
@@ -401,6 +559,36 @@ func (o *Orchestrator) handleKeyboard(event interaction.KeyEvent) bool {
 }
 
 
+// exportView writes the displayed sessions to a JSON file in the cache dir
+// and shows the outcome as a transient status message
+func (o *Orchestrator) exportView() {
+	sessions := o.stateManager.GetSessionsForDisplay()
+	sortingSessions := convertSessionsForSorting(sessions)
+	o.sorter.Sort(sortingSessions)
+	applySortingToOriginal(sessions, sortingSessions)
+
+	now := util.GetTimeProvider().Now()
+	path, err := exportSessions(filepath.Join(o.config.CacheDir, ExportDirName), sessions, now.Location(), now)
+	message := fmt.Sprintf("Exported %d sessions to %s", len(sessions), path)
+	if err != nil {
+		util.LogError(fmt.Sprintf("Failed to export view: %v", err))
+		message = fmt.Sprintf("Export failed: %v", err)
+	} else {
+		util.LogInfo(message)
+	}
+
+	o.stateManager.UpdateInteractionState(func(s *model.InteractionState) {
+		s.StatusMessage = message
+	})
+	time.AfterFunc(exportStatusDuration, func() {
+		o.stateManager.UpdateInteractionState(func(s *model.InteractionState) {
+			if s.StatusMessage == message {
+				s.StatusMessage = ""
+			}
+		})
+	})
+}
+
 // clearCache clears memory cache with confirmation
 func (o *Orchestrator) clearCache() {
 	o.stateManager.UpdateInteractionState(func(s *model.InteractionState) {
@@ -435,7 +623,7 @@ func (o *Orchestrator) clearCache() {
 							return
 						}
 						
-						if err := o.dataLoader.LoadFiles(files); err != nil {
+						if err := o.dataLoader.LoadFiles(context.Background(), files); err != nil {
 							util.LogError(fmt.Sprintf("Failed to load files during cache clear: %v", err))
 							memoryCache.CancelClear() // Cancel the pending clear
 							o.stateManager.SetDisplayStatus(model.StatusNormal, "")
@@ -509,15 +697,15 @@ func (o *Orchestrator) startWatcher(ctx context.Context) error {
 	return nil
 }
 
-// handleFileChange handles file change events
-func (o *Orchestrator) handleFileChange(event model.FileEvent) {
-	util.LogDebug(fmt.Sprintf("File changed: %s (%s)", event.Path, event.Operation))
+// handleFileChanges loads a batch of changed files and runs one detection
+// cycle for all of them
+func (o *Orchestrator) handleFileChanges(changedFiles []string) {
+	util.LogDebug(fmt.Sprintf("Processing %d changed files", len(changedFiles)))
 	
-	// Parse and update the changed file
-	o.dataLoader.LoadFiles([]string{event.Path})
+	// Parse and update the changed files
+	o.dataLoader.LoadFiles(context.Background(), changedFiles)
 	
 	// Use incremental detection for better performance
-	changedFiles := []string{event.Path}
 	sessions, err := o.refreshCtrl.IncrementalDetect(changedFiles)
 	if err != nil {
 		util.LogError(fmt.Sprintf("Failed to handle file change with incremental detection: %v", err))
@@ -536,11 +724,54 @@ func (o *Orchestrator) handleFileChange(event model.FileEvent) {
 		util.LogDebug(fmt.Sprintf("File change handled, updated with %d sessions", len(sessions)))
 	} else if len(currentSessions) > 0 {
 		// If we have existing data and new detection returns empty, keep existing
-		util.LogWarn(fmt.Sprintf("File change for %s returned no sessions, keeping existing %d sessions", event.Path, len(currentSessions)))
+		util.LogWarn(fmt.Sprintf("File changes for %v returned no sessions, keeping existing %d sessions", changedFiles, len(currentSessions)))
 	} else {
 		// No existing data and no new data - this might be normal for initial empty state
-		util.LogInfo(fmt.Sprintf("File change for %s processed, no sessions detected", event.Path))
+		util.LogInfo(fmt.Sprintf("File changes for %v processed, no sessions detected", changedFiles))
+	}
+}
+
+// pollFileChanges rescans the data directory, reloads the files that changed
+// since they were last loaded and returns their paths
+func (o *Orchestrator) pollFileChanges() []string {
+	files, err := o.dataLoader.ScanRecentFiles()
+	if err != nil {
+		util.LogError(fmt.Sprintf("Failed to scan files while polling: %v", err))
+		return nil
+	}
+
+	changedIds := o.dataLoader.IdentifyChangedFiles(files)
+	if len(changedIds) == 0 {
+		return nil
+	}
+	changed := make(map[string]bool, len(changedIds))
+	for _, id := range changedIds {
+		changed[id] = true
+	}
+	var changedFiles []string
+	for _, file := range files {
+		if changed[extractSessionId(file)] {
+			changedFiles = append(changedFiles, file)
+		}
+	}
+	util.LogDebug(fmt.Sprintf("Poll found %d changed files", len(changedFiles)))
+
+	if err := o.dataLoader.LoadFiles(context.Background(), changedFiles); err != nil {
+		util.LogError(fmt.Sprintf("Failed to load changed files: %v", err))
+		return changedFiles
+	}
+
+	sessions, err := o.refreshCtrl.IncrementalDetect(changedIds)
+	if err != nil {
+		util.LogError(fmt.Sprintf("Failed to detect sessions after poll: %v", err))
+		return changedFiles
+	}
+	if len(sessions) > 0 {
+		o.stateManager.SetSessions(sessions)
+	} else if current := o.stateManager.GetCurrentSessions(); len(current) > 0 {
+		util.LogWarn(fmt.Sprintf("Poll returned no sessions, keeping existing %d sessions", len(current)))
 	}
+	return changedFiles
 }
 
 // Close cleans up all resources
@@ -557,6 +788,11 @@ func (o *Orchestrator) Close() error {
 		}
 	}
 	
+	// Let limit posts in flight finish; each is bounded by its timeout
+	if o.limitWebhook != nil {
+		o.limitWebhook.Wait()
+	}
+	
 	// Close file watcher
 	if o.watcher != nil {
 		if err := o.watcher.Close(); err != nil {
@@ -0,0 +1,110 @@
+package top
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollFileChangesDetectsModifiedFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dataDir := t.TempDir()
+	logLine := func(i int, ts time.Time) string {
+		return fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":"s","uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+			ts.UTC().Format(time.RFC3339), i, i, i)
+	}
+	path := filepath.Join(dataDir, "project", "poll.jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(logLine(1, time.Now().Add(-30*time.Minute))), 0644))
+
+	o, err := NewOrchestrator(&TopConfig{
+		DataDir:             dataDir,
+		CacheDir:            t.TempDir(),
+		Plan:                "max5",
+		Timezone:            "UTC",
+		DataRefreshInterval: 10 * time.Second,
+		UIRefreshRate:       1,
+		PollInterval:        time.Second,
+		PricingOfflineMode:  true,
+	})
+	require.NoError(t, err)
+	defer o.Close()
+
+	sessions, err := o.LoadAndAnalyzeData(context.Background())
+	require.NoError(t, err)
+	o.stateManager.SetSessions(sessions)
+	assert.Equal(t, 110, totalTokens(o), "initial tokens")
+
+	// Polling never starts the watcher, and an untouched file is not reloaded
+	assert.Nil(t, o.watcher)
+	assert.Empty(t, o.pollFileChanges())
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(logLine(2, time.Now().Add(-10*time.Minute)))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{path}, o.pollFileChanges())
+	assert.Equal(t, 220, totalTokens(o), "tokens after poll")
+}
+
+func totalTokens(o *Orchestrator) int {
+	total := 0
+	for _, s := range o.stateManager.GetCurrentSessions() {
+		total += s.TotalTokens
+	}
+	return total
+}
+
+func TestLoadAndAnalyzeDataProjectFilter(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dataDir := t.TempDir()
+	ts := time.Now().Add(-30 * time.Minute)
+	for i, project := range []string{"acme-api", "acme-web", "acme-sandbox", "blog"} {
+		path := filepath.Join(dataDir, project, project+".jsonl")
+		line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":"s-%d","uuid":"u-%d",`+
+			`"message":{"id":"msg-%d","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+			ts.UTC().Format(time.RFC3339), i, i, i, i)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+	}
+
+	o, err := NewOrchestrator(&TopConfig{
+		DataDir:             dataDir,
+		CacheDir:            t.TempDir(),
+		Plan:                "max5",
+		Timezone:            "UTC",
+		DataRefreshInterval: 10 * time.Second,
+		UIRefreshRate:       1,
+		PricingOfflineMode:  true,
+		Projects:            []string{"acme"},
+		ExcludeProjects:     []string{"sandbox"},
+	})
+	require.NoError(t, err)
+	defer o.Close()
+
+	sessions, err := o.LoadAndAnalyzeData(context.Background())
+	require.NoError(t, err)
+	var projects []string
+	for _, s := range sessions {
+		for name := range s.Projects {
+			projects = append(projects, name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"acme-api", "acme-web"}, projects)
+
+	// A change reported by the watcher in a filtered project is not loaded
+	require.NoError(t, o.dataLoader.LoadFiles(context.Background(), []string{filepath.Join(dataDir, "blog", "blog.jsonl")}))
+	_, loaded := o.dataLoader.GetMemoryCache().Get("acme-api")
+	assert.True(t, loaded)
+	_, loaded = o.dataLoader.GetMemoryCache().Get("blog")
+	assert.False(t, loaded)
+}
@@ -1,6 +1,7 @@
 package top
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ type RefreshController struct {
 	calculator    *session.MetricsCalculator
 	stateManager  *StateManager
 	sessionConfig session.SessionConfig
+	clock         util.Clock
 	
 	mu           sync.RWMutex
 	refreshMutex sync.Mutex // Prevent concurrent refreshes
@@ -31,6 +33,31 @@ func NewRefreshController(dataLoader *DataLoader, detector *session.SessionDetec
 		calculator:    calculator,
 		stateManager:  stateManager,
 		sessionConfig: session.GetSessionConfig(),
+		clock:         util.SystemClock,
+	}
+}
+
+// SetClock sets the clock refreshes read the time from, for the detector,
+// calculator and state manager as well. Nil restores the system clock.
+func (rc *RefreshController) SetClock(clock util.Clock) {
+	if clock == nil {
+		clock = util.SystemClock
+	}
+	rc.clock = clock
+	rc.detector.SetClock(clock)
+	rc.calculator.SetClock(clock)
+	rc.stateManager.SetClock(clock)
+}
+
+// holdClock fixes the detector and calculator clocks at now, so a whole
+// detection measures against one time, and returns a func that releases them
+func (rc *RefreshController) holdClock(now int64) func() {
+	held := util.ClockFunc(func() time.Time { return time.Unix(now, 0) })
+	rc.detector.SetClock(held)
+	rc.calculator.SetClock(held)
+	return func() {
+		rc.detector.SetClock(rc.clock)
+		rc.calculator.SetClock(rc.clock)
 	}
 }
 
@@ -41,6 +68,7 @@ func (rc *RefreshController) RefreshData() ([]*session.Session, error) {
 	defer rc.refreshMutex.Unlock()
 
 	util.LogDebug("RefreshData: Starting refresh operation")
+	now := rc.referenceTime()
 
 	// Rescan recent files and update
 	files, err := rc.dataLoader.ScanRecentFiles()
@@ -55,7 +83,7 @@ func (rc *RefreshController) RefreshData() ([]*session.Session, error) {
 	util.LogDebug(fmt.Sprintf("RefreshData: %d files have changed", len(changedFiles)))
 	
 	// Load files
-	if err := rc.dataLoader.LoadFiles(files); err != nil {
+	if err := rc.dataLoader.LoadFiles(context.Background(), files); err != nil {
 		return nil, fmt.Errorf("failed to load files: %w", err)
 	}
 	
@@ -63,10 +91,10 @@ func (rc *RefreshController) RefreshData() ([]*session.Session, error) {
 	var newSessions []*session.Session
 	if rc.sessionConfig.EnableIncrementalDetection && len(changedFiles) > 0 {
 		util.LogInfo(fmt.Sprintf("RefreshData: Using incremental detection for %d changed files", len(changedFiles)))
-		newSessions, err = rc.IncrementalDetect(changedFiles)
+		newSessions, err = rc.incrementalDetect(changedFiles, now)
 	} else {
 		util.LogInfo("RefreshData: Using full detection")
-		newSessions, err = rc.FullDetect()
+		newSessions, err = rc.fullDetect(now)
 	}
 	
 	if err != nil {
@@ -89,11 +117,42 @@ func (rc *RefreshController) RefreshData() ([]*session.Session, error) {
 	return newSessions, nil
 }
 
+// referenceTime returns the Unix time a refresh measures windows against.
+// When the clock is behind the last data update, e.g. after an NTP correction
+// or a VM resume, it logs a warning and records the skew in the interaction
+// state. The corrected time is still used, so remaining time and burn rate
+// keep moving; elapsed times that turn negative are clamped where measured.
+func (rc *RefreshController) referenceTime() int64 {
+	now := rc.clock.Now().Unix()
+	lastUpdate := rc.stateManager.LastDataUpdate()
+
+	var skew time.Duration
+	if lastUpdate > 0 && now < lastUpdate {
+		skew = time.Duration(lastUpdate-now) * time.Second
+	}
+	previous := rc.stateManager.GetInteractionState().ClockSkew
+	rc.stateManager.UpdateInteractionState(func(state *model.InteractionState) {
+		state.ClockSkew = skew
+	})
+	// Warn once per jump rather than on every refresh until the clock catches up
+	if skew > 0 && previous == 0 {
+		util.LogWarn(fmt.Sprintf("System clock moved back %s since the last data update at %s",
+			skew, time.Unix(lastUpdate, 0).Format("2006-01-02 15:04:05")))
+	}
+	return now
+}
+
 // IncrementalDetect performs incremental session detection for changed files
 func (rc *RefreshController) IncrementalDetect(changedFiles []string) ([]*session.Session, error) {
+	return rc.incrementalDetect(changedFiles, rc.referenceTime())
+}
+
+// incrementalDetect performs incremental session detection for changed files
+// as of now
+func (rc *RefreshController) incrementalDetect(changedFiles []string, now int64) ([]*session.Session, error) {
 	if len(changedFiles) == 0 {
 		// No changes, use full detection
-		return rc.FullDetect()
+		return rc.fullDetect(now)
 	}
 
 	// Get current sessions from state manager
@@ -105,6 +164,7 @@ func (rc *RefreshController) IncrementalDetect(changedFiles []string) ([]*sessio
 	// Get time range of changed files
 	minTime := int64(^uint64(0) >> 1) // Max int64
 	maxTime := int64(0)
+	var timestamps []int64
 	
 	for _, file := range changedFiles {
 		logs := memCache.GetLogsForFile(file)
@@ -114,6 +174,7 @@ func (rc *RefreshController) IncrementalDetect(changedFiles []string) ([]*sessio
 				continue
 			}
 			timestamp := ts.Unix()
+			timestamps = append(timestamps, timestamp)
 			if timestamp < minTime {
 				minTime = timestamp
 			}
@@ -123,6 +184,14 @@ func (rc *RefreshController) IncrementalDetect(changedFiles []string) ([]*sessio
 		}
 	}
 
+	// Activity outside every window, e.g. past the end of the last one, needs
+	// a window that only full detection can create
+	if ts, found := uncoveredTimestamp(currentSessions, timestamps); found {
+		util.LogInfo(fmt.Sprintf("Changed logs at %s fall outside all existing windows, performing full detection",
+			time.Unix(ts, 0).Format("2006-01-02 15:04:05")))
+		return rc.fullDetect(now)
+	}
+
 	// Check if we have existing windows that cover this time range
 	existingWindows := make(map[string]*session.Session)
 	for _, sess := range currentSessions {
@@ -134,9 +203,18 @@ func (rc *RefreshController) IncrementalDetect(changedFiles []string) ([]*sessio
 	if len(existingWindows) > 0 && rc.detector.GetWindowHistory() != nil {
 		// We have existing windows, just update the statistics
 		util.LogInfo(fmt.Sprintf("Incremental update for %d existing windows", len(existingWindows)))
-		
-		// Get updated global timeline
-		globalTimeline := rc.dataLoader.GetGlobalTimeline(6 * 3600)
+
+		// Get updated global timeline, reaching back to the earliest affected
+		// window so none is recalculated from part of its logs
+		secondsBack := int64(6 * 3600)
+		for _, sess := range existingWindows {
+			if back := now - sess.StartTime + 3600; back > secondsBack {
+				secondsBack = back
+			}
+		}
+		globalTimeline := rc.dataLoader.GetGlobalTimeline(secondsBack)
+		release := rc.holdClock(now)
+		defer release()
 		
 		// Prepare new session list
 		newSessions := make([]*session.Session, 0, len(currentSessions))
@@ -151,19 +229,21 @@ func (rc *RefreshController) IncrementalDetect(changedFiles []string) ([]*sessio
 			
 			// Create new session with same window
 			newSession := &session.Session{
-				ID:                oldSession.ID,
-				StartTime:         oldSession.StartTime,
-				EndTime:           oldSession.EndTime,
-				Projects:          make(map[string]*session.ProjectStats),
-				ModelDistribution: make(map[string]*model.ModelStats),
-				PerModelStats:     make(map[string]map[string]interface{}),
-				HourlyMetrics:     make([]*model.HourlyMetric, 0),
-				LimitMessages:     make([]map[string]interface{}, 0),
-				ProjectionData:    make(map[string]interface{}),
-				WindowStartTime:   oldSession.WindowStartTime,
-				IsWindowDetected:  oldSession.IsWindowDetected,
-				WindowSource:      oldSession.WindowSource,
-				ResetTime:         oldSession.ResetTime,
+				ID:                  oldSession.ID,
+				StartTime:           oldSession.StartTime,
+				EndTime:             oldSession.EndTime,
+				Projects:            make(map[string]*session.ProjectStats),
+				ModelDistribution:   make(map[string]*model.ModelStats),
+				TierDistribution:    make(map[string]*model.TierStats),
+				PerModelStats:       make(map[string]map[string]interface{}),
+				HourlyMetrics:       make([]*model.HourlyMetric, 0),
+				LimitMessages:       make([]map[string]interface{}, 0),
+				ProjectionData:      make(map[string]interface{}),
+				WindowStartTime:     oldSession.WindowStartTime,
+				IsWindowDetected:    oldSession.IsWindowDetected,
+				WindowSource:        oldSession.WindowSource,
+				ResetTime:           oldSession.ResetTime,
+				DetectionConfidence: oldSession.DetectionConfidence,
 			}
 			
 			// Add logs that belong to this window
@@ -175,22 +255,45 @@ func (rc *RefreshController) IncrementalDetect(changedFiles []string) ([]*sessio
 			
 			// Finalize and calculate metrics
 			rc.detector.FinalizeSession(newSession)
-			rc.detector.CalculateMetrics(newSession, time.Now().Unix())
+			rc.detector.CalculateMetrics(newSession, now)
 			rc.calculator.Calculate(newSession)
 			
 			newSessions = append(newSessions, newSession)
 		}
 		
-		return newSessions, nil
+		return rc.detector.FilterFutureWindows(newSessions, now), nil
 	} else {
 		// No existing windows or window history, do full detection
 		util.LogInfo("No existing windows found, performing full detection")
-		return rc.FullDetect()
+		return rc.fullDetect(now)
 	}
 }
 
+// uncoveredTimestamp returns the first of timestamps that lies in no non-gap
+// session window, using the same half-open ranges logs are assigned with
+func uncoveredTimestamp(sessions []*session.Session, timestamps []int64) (int64, bool) {
+	for _, ts := range timestamps {
+		covered := false
+		for _, sess := range sessions {
+			if !sess.IsGap && ts >= sess.StartTime && ts < sess.EndTime {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return ts, true
+		}
+	}
+	return 0, false
+}
+
 // FullDetect performs full session detection
 func (rc *RefreshController) FullDetect() ([]*session.Session, error) {
+	return rc.fullDetect(rc.referenceTime())
+}
+
+// fullDetect performs full session detection as of now
+func (rc *RefreshController) fullDetect(now int64) ([]*session.Session, error) {
 	memCache := rc.dataLoader.GetMemoryCache()
 	
 	// First, load historical limit windows from the past 1 day
@@ -216,10 +319,12 @@ func (rc *RefreshController) FullDetect() ([]*session.Session, error) {
 		GlobalTimeline:   globalTimeline,
 		CachedWindowInfo: cachedWindowInfo,
 	}
+	release := rc.holdClock(now)
+	defer release()
 	newSessions := rc.detector.DetectSessionsWithLimits(input)
 
 	// Calculate metrics for each session and store window info
-	currentTime := time.Now().Unix()
+	currentTime := now
 	maxFutureTime := currentTime + constants.MaxFutureWindowSeconds
 	
 	for _, sess := range newSessions {
@@ -228,7 +333,7 @@ func (rc *RefreshController) FullDetect() ([]*session.Session, error) {
 		// Store window detection info back to cache if detected
 		if sess.IsWindowDetected && sess.WindowStartTime != nil {
 			// Check if window end time is not too far in the future
-			windowEndTime := *sess.WindowStartTime + constants.SessionDurationSeconds
+			windowEndTime := *sess.WindowStartTime + int64(rc.detector.SessionDuration().Seconds())
 			if windowEndTime <= maxFutureTime {
 				windowInfo := &session.WindowDetectionInfo{
 					WindowStartTime:  sess.WindowStartTime,
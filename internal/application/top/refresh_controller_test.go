@@ -1,12 +1,17 @@
 package top
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
 	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
 	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -89,4 +94,204 @@ func TestIncrementalDetection(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, sessions)
 	})
-}
\ No newline at end of file
+}
+// refreshTestEnv is a refresh controller over a temporary data directory
+type refreshTestEnv struct {
+	dataDir      string
+	dataLoader   *DataLoader
+	stateManager *StateManager
+	refreshCtrl  *RefreshController
+	requests     int
+}
+
+func newRefreshTestEnv(t *testing.T) *refreshTestEnv {
+	t.Setenv("HOME", t.TempDir())
+	config := &TopConfig{
+		DataDir:            t.TempDir(),
+		CacheDir:           t.TempDir(),
+		Plan:               "max5",
+		Timezone:           "UTC",
+		Concurrency:        1,
+		PricingOfflineMode: true,
+	}
+	dataLoader, err := NewDataLoader(config)
+	require.NoError(t, err)
+	detector := session.NewSessionDetectorWithAggregator(nil, config.Timezone, config.CacheDir)
+	calculator := session.NewMetricsCalculator(pricing.GetPlanWithDefault(config.Plan, 0))
+	stateManager := NewStateManager()
+	return &refreshTestEnv{
+		dataDir:      config.DataDir,
+		dataLoader:   dataLoader,
+		stateManager: stateManager,
+		refreshCtrl:  NewRefreshController(dataLoader, detector, calculator, stateManager),
+	}
+}
+
+// appendLog appends an assistant entry of 110 tokens at ts to the log of
+// sessionId and returns the log's path
+func (e *refreshTestEnv) appendLog(t *testing.T, sessionId string, ts time.Time) string {
+	e.requests++
+	i := e.requests
+	path := filepath.Join(e.dataDir, "project", sessionId+".jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	line := fmt.Sprintf(`{"type":"assistant","timestamp":%q,"requestId":"req-%d","sessionId":%q,"uuid":"u-%d",`+
+		`"message":{"id":"msg-%d","model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":100,"output_tokens":10}}}`+"\n",
+		ts.UTC().Format(time.RFC3339), i, sessionId, i, i)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(line)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return path
+}
+
+// fullDetect loads files and makes the detected sessions current
+func (e *refreshTestEnv) fullDetect(t *testing.T, files []string) []*session.Session {
+	require.NoError(t, e.dataLoader.LoadFiles(context.Background(), files))
+	sessions, err := e.refreshCtrl.FullDetect()
+	require.NoError(t, err)
+	e.stateManager.SetSessions(sessions)
+	return sessions
+}
+
+// incrementalDetect reloads files and runs incremental detection on the ones
+// that changed
+func (e *refreshTestEnv) incrementalDetect(t *testing.T, files []string) []*session.Session {
+	changed := e.dataLoader.IdentifyChangedFiles(files)
+	require.NotEmpty(t, changed)
+	require.NoError(t, e.dataLoader.LoadFiles(context.Background(), files))
+	sessions, err := e.refreshCtrl.IncrementalDetect(changed)
+	require.NoError(t, err)
+	return sessions
+}
+
+// windowAt returns the non-gap session whose window contains ts
+func windowAt(sessions []*session.Session, ts time.Time) *session.Session {
+	for _, sess := range sessions {
+		if !sess.IsGap && ts.Unix() >= sess.StartTime && ts.Unix() < sess.EndTime {
+			return sess
+		}
+	}
+	return nil
+}
+
+func TestIncrementalDetectUpdatesExistingWindows(t *testing.T) {
+	env := newRefreshTestEnv(t)
+	now := time.Now()
+
+	// An unrelated early window, and a log spanning an older window and the
+	// active one
+	early := env.appendLog(t, "early", now.Add(-20*time.Hour))
+	path := env.appendLog(t, "log", now.Add(-9*time.Hour))
+	env.appendLog(t, "log", now.Add(-2*time.Hour))
+	files := []string{early, path}
+	before := env.fullDetect(t, files)
+
+	earlyWindow := windowAt(before, now.Add(-20*time.Hour))
+	oldWindow := windowAt(before, now.Add(-9*time.Hour))
+	activeWindow := windowAt(before, now.Add(-2*time.Hour))
+	require.NotNil(t, earlyWindow)
+	require.NotNil(t, oldWindow)
+	require.NotNil(t, activeWindow)
+	require.NotEqual(t, oldWindow.ID, activeWindow.ID)
+
+	// More activity inside the active window
+	env.appendLog(t, "log", now.Add(-time.Hour))
+	after := env.incrementalDetect(t, files)
+
+	// The fast path keeps unaffected sessions as they were
+	assert.Same(t, earlyWindow, windowAt(after, now.Add(-20*time.Hour)))
+	assert.Len(t, after, len(before))
+
+	// Affected windows are recalculated in place from all of their logs
+	updatedOld := windowAt(after, now.Add(-9*time.Hour))
+	updatedActive := windowAt(after, now.Add(-time.Hour))
+	require.NotNil(t, updatedOld)
+	require.NotNil(t, updatedActive)
+	assert.Equal(t, oldWindow.ID, updatedOld.ID)
+	assert.Equal(t, oldWindow.TotalTokens, updatedOld.TotalTokens)
+	assert.Equal(t, activeWindow.ID, updatedActive.ID)
+	assert.Equal(t, activeWindow.TotalTokens+110, updatedActive.TotalTokens)
+}
+
+func TestIncrementalDetectCreatesWindowPastLastEnd(t *testing.T) {
+	env := newRefreshTestEnv(t)
+	now := time.Now()
+
+	path := env.appendLog(t, "log", now.Add(-9*time.Hour))
+	sessions := env.fullDetect(t, []string{path})
+
+	var lastEnd int64
+	for _, sess := range sessions {
+		if !sess.IsGap && sess.EndTime > lastEnd {
+			lastEnd = sess.EndTime
+		}
+	}
+	require.NotZero(t, lastEnd)
+
+	// New activity after the only window has ended
+	late := now.Add(-30 * time.Minute)
+	require.Greater(t, late.Unix(), lastEnd)
+	env.appendLog(t, "log", late)
+	sessions = env.incrementalDetect(t, []string{path})
+
+	covering := windowAt(sessions, late)
+	require.NotNil(t, covering, "no window covers the new activity")
+	assert.GreaterOrEqual(t, covering.StartTime, lastEnd)
+	assert.Positive(t, covering.TotalTokens)
+}
+
+func TestUncoveredTimestamp(t *testing.T) {
+	sessions := []*session.Session{
+		{ID: "a", StartTime: 0, EndTime: 100},
+		{ID: "gap", IsGap: true, StartTime: 100, EndTime: 200},
+		{ID: "b", StartTime: 200, EndTime: 300},
+	}
+
+	_, found := uncoveredTimestamp(sessions, []int64{0, 99, 200, 299})
+	assert.False(t, found)
+
+	ts, found := uncoveredTimestamp(sessions, []int64{50, 150, 350})
+	assert.True(t, found)
+	assert.Equal(t, int64(150), ts)
+
+	ts, found = uncoveredTimestamp(sessions, []int64{300})
+	assert.True(t, found)
+	assert.Equal(t, int64(300), ts)
+}
+
+func TestFullDetectUsesCorrectedTimeWhenClockMovesBack(t *testing.T) {
+	env := newRefreshTestEnv(t)
+	now := time.Now()
+
+	// The clock runs an hour fast while the active window is detected
+	fast := now.Add(time.Hour)
+	env.refreshCtrl.SetClock(util.ClockFunc(func() time.Time { return fast }))
+	path := env.appendLog(t, "log", now.Add(-2*time.Hour))
+	before := env.fullDetect(t, []string{path})
+	require.NotNil(t, windowAt(before, now.Add(-2*time.Hour)))
+	assert.Equal(t, fast.Unix(), env.stateManager.LastDataUpdate())
+	assert.Zero(t, env.stateManager.GetInteractionState().ClockSkew)
+
+	// Once corrected, windows are measured against the corrected time and
+	// keep counting down instead of freezing until the clock catches up
+	for _, elapsed := range []time.Duration{0, 10 * time.Minute} {
+		corrected := now.Add(elapsed)
+		env.refreshCtrl.SetClock(util.ClockFunc(func() time.Time { return corrected }))
+		after, err := env.refreshCtrl.FullDetect()
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Hour-elapsed, env.stateManager.GetInteractionState().ClockSkew)
+		window := windowAt(after, now.Add(-2*time.Hour))
+		require.NotNil(t, window)
+		assert.True(t, window.IsActive)
+		assert.Equal(t, time.Duration(window.EndTime-corrected.Unix())*time.Second, window.TimeRemaining)
+		assert.Greater(t, window.TokensPerMinute, 0.0)
+	}
+
+	// The skew clears once the clock is back in step
+	env.refreshCtrl.SetClock(util.ClockFunc(func() time.Time { return fast.Add(time.Minute) }))
+	_, err := env.refreshCtrl.FullDetect()
+	require.NoError(t, err)
+	assert.Zero(t, env.stateManager.GetInteractionState().ClockSkew)
+}
@@ -0,0 +1,28 @@
+package top
+
+import (
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+)
+
+// recentSessions returns the sessions that ended within maxAge of now. Active
+// sessions are always kept. A zero maxAge keeps every session. Only the list
+// is trimmed; detection and window history still see all sessions.
+func recentSessions(sessions []*session.Session, now int64, maxAge time.Duration) []*session.Session {
+	if maxAge <= 0 {
+		return sessions
+	}
+	cutoff := now - int64(maxAge.Seconds())
+	recent := make([]*session.Session, 0, len(sessions))
+	for _, s := range sessions {
+		end := s.EndTime
+		if s.ActualEndTime != nil {
+			end = *s.ActualEndTime
+		}
+		if s.IsActive || end >= cutoff {
+			recent = append(recent, s)
+		}
+	}
+	return recent
+}
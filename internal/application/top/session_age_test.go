@@ -0,0 +1,57 @@
+package top
+
+import (
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/core/timeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentSessionsHidesOldSessionsButKeepsHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	detector := session.NewSessionDetectorWithAggregator(nil, "UTC", t.TempDir())
+
+	now := time.Now().Unix()
+	var entries []timeline.TimestampedLog
+	for _, ts := range []int64{now - 72*3600, now - 72*3600 + 600, now - 3600, now - 600} {
+		entries = append(entries, timeline.TimestampedLog{
+			Timestamp: ts,
+			Log: model.ConversationLog{
+				Type:    "synthetic",
+				Message: model.Message{Usage: model.Usage{InputTokens: 100}},
+			},
+		})
+	}
+	sessions := detector.DetectSessionsWithLimits(session.SessionDetectionInput{GlobalTimeline: entries})
+
+	var old *session.Session
+	for _, s := range sessions {
+		if !s.IsGap && s.StartTime <= now-72*3600 {
+			old = s
+		}
+	}
+	require.NotNil(t, old, "Expected a session for the activity three days ago")
+
+	recent := recentSessions(sessions, now, 24*time.Hour)
+	assert.NotContains(t, recent, old, "Sessions older than the max age should be hidden")
+	assert.NotEmpty(t, recent, "Recent sessions should stay listed")
+	for _, s := range recent {
+		assert.True(t, s.IsActive || s.EndTime >= now-24*3600, "Unexpected old session %s", s.ID)
+	}
+
+	// Window history still learned the hidden window
+	var learned bool
+	for _, record := range detector.GetWindowHistory().GetRecentWindows(96 * time.Hour) {
+		if record.StartTime == old.StartTime {
+			learned = true
+		}
+	}
+	assert.True(t, learned, "Hidden sessions should still be recorded in window history")
+
+	// Without a max age nothing is hidden
+	assert.Len(t, recentSessions(sessions, now, 0), len(sessions))
+}
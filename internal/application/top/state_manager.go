@@ -2,10 +2,10 @@ package top
 
 import (
 	"sync"
-	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
 	"github.com/penwyp/go-claude-monitor/internal/core/session"
+	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
 // StateManager manages application state in a thread-safe manner
@@ -27,6 +27,7 @@ type StateManager struct {
 	lastDataUpdate int64 // Timestamp of last successful data update
 	hasInitialData bool  // Flag to track if initial data has been loaded
 	lastValidCount int   // Track last valid session count for integrity check
+	clock          util.Clock // Clock data updates are timed with
 }
 
 // NewStateManager creates a new StateManager instance
@@ -37,9 +38,21 @@ func NewStateManager() *StateManager {
 		interactionState: model.InteractionState{},
 		hasInitialData:   false,
 		lastValidCount:   0,
+		clock:            util.SystemClock,
 	}
 }
 
+// SetClock sets the clock data updates are timed with. Nil restores the
+// system clock.
+func (sm *StateManager) SetClock(clock util.Clock) {
+	if clock == nil {
+		clock = util.SystemClock
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.clock = clock
+}
+
 
 // SetSessions updates active sessions (thread-safe)
 func (sm *StateManager) SetSessions(sessions []*session.Session) {
@@ -69,7 +82,7 @@ func (sm *StateManager) SetSessions(sessions []*session.Session) {
 		if !sm.hasInitialData && len(sessions) == 0 {
 			// Don't mark as having initial data yet
 			sm.activeSessions = sessions
-			sm.lastDataUpdate = time.Now().Unix()
+			sm.markDataUpdated()
 			return
 		}
 	}
@@ -92,7 +105,7 @@ func (sm *StateManager) SetSessions(sessions []*session.Session) {
 	
 	// Update active sessions
 	sm.activeSessions = sessions
-	sm.lastDataUpdate = time.Now().Unix()
+	sm.markDataUpdated()
 	
 	// Update tracking flags
 	if newCount > 0 {
@@ -101,6 +114,22 @@ func (sm *StateManager) SetSessions(sessions []*session.Session) {
 	}
 }
 
+// markDataUpdated records a data update at the current time. The update time
+// never moves back, so a clock that jumped backwards is still detected on
+// later refreshes until it catches up. Callers must hold sm.mu.
+func (sm *StateManager) markDataUpdated() {
+	if now := sm.clock.Now().Unix(); now > sm.lastDataUpdate {
+		sm.lastDataUpdate = now
+	}
+}
+
+// LastDataUpdate returns the Unix time of the last data update (0 = none)
+func (sm *StateManager) LastDataUpdate() int64 {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	
+	return sm.lastDataUpdate
+}
 
 // GetLoadingState returns current loading state and message
 func (sm *StateManager) GetLoadingState() (bool, string) {
@@ -3,6 +3,7 @@ package cache
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
@@ -27,12 +28,18 @@ type MemoryCacheEntry struct {
 	IsDirty      bool                    // Marks if needs persistence
 	RawLogs      []model.ConversationLog // Raw logs for limit detection
 	WindowInfo   *WindowDetectionInfo    // Sliding window detection state
+
+	accessSeq uint64 // Breaks LastAccessed ties, which only has second resolution
 }
 
 type MemoryCache struct {
 	mu      sync.RWMutex
 	entries map[string]*MemoryCacheEntry
 
+	// Raw log eviction: at most maxRawLogEntries entries keep their RawLogs (0 = unlimited)
+	maxRawLogEntries int
+	accessClock      atomic.Uint64
+
 	// Double buffering support
 	pendingClear      bool                         // Flag indicating cache is pending clear
 	shadowEntries     map[string]*MemoryCacheEntry // Shadow buffer for atomic swap
@@ -48,34 +55,95 @@ func NewMemoryCache() *MemoryCache {
 	}
 }
 
+// NewMemoryCacheWithLimit creates a MemoryCache that keeps raw logs for at most
+// maxRawLogEntries sessions. Aggregated data is never evicted.
+func NewMemoryCacheWithLimit(maxRawLogEntries int) *MemoryCache {
+	mc := NewMemoryCache()
+	mc.maxRawLogEntries = maxRawLogEntries
+	return mc
+}
+
 func (mc *MemoryCache) Set(sessionId string, entry *MemoryCacheEntry) {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
 	if entry != nil {
-		entry.LastAccessed = time.Now().Unix()
+		mc.touch(entry)
 		entry.IsDirty = true
 	}
 
 	// If pending clear, add to shadow buffer instead
 	if mc.pendingClear && mc.shadowEntries != nil {
 		mc.shadowEntries[sessionId] = entry
+		mc.evictRawLogs(mc.shadowEntries)
 	} else {
 		mc.entries[sessionId] = entry
+		mc.evictRawLogs(mc.entries)
 	}
 }
 
 func (mc *MemoryCache) Get(sessionId string) (*MemoryCacheEntry, bool) {
-	mc.mu.RLock()
-	defer mc.mu.RUnlock()
+	// A write lock, since recording the access updates the entry
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
 
 	entry, ok := mc.entries[sessionId]
 	if ok && entry != nil {
-		entry.LastAccessed = time.Now().Unix()
+		mc.touch(entry)
 	}
 	return entry, ok
 }
 
+// touch records an access to entry for LRU ordering. Callers must hold
+// mc.mu for writing.
+func (mc *MemoryCache) touch(entry *MemoryCacheEntry) {
+	entry.LastAccessed = time.Now().Unix()
+	entry.accessSeq = mc.accessClock.Add(1)
+}
+
+// evictRawLogs drops the raw logs of the least recently used entries until at
+// most maxRawLogEntries entries hold raw logs. Each Set adds at most one
+// entry over the limit, so the oldest entry is found by a scan rather than
+// by sorting. Callers must hold mc.mu for writing.
+func (mc *MemoryCache) evictRawLogs(entries map[string]*MemoryCacheEntry) {
+	if mc.maxRawLogEntries <= 0 {
+		return
+	}
+
+	withRawLogs := 0
+	for _, entry := range entries {
+		if entry != nil && entry.RawLogs != nil {
+			withRawLogs++
+		}
+	}
+	excess := withRawLogs - mc.maxRawLogEntries
+	if excess <= 0 {
+		return
+	}
+
+	evictedLogs := 0
+	for i := 0; i < excess; i++ {
+		var oldest *MemoryCacheEntry
+		for _, entry := range entries {
+			if entry != nil && entry.RawLogs != nil && (oldest == nil || entry.usedBefore(oldest)) {
+				oldest = entry
+			}
+		}
+		evictedLogs += len(oldest.RawLogs)
+		oldest.RawLogs = nil
+	}
+	util.LogDebug(fmt.Sprintf("MemoryCache: Evicted raw logs from %d entries (%d logs), limit %d",
+		excess, evictedLogs, mc.maxRawLogEntries))
+}
+
+// usedBefore reports whether e was last accessed before other
+func (e *MemoryCacheEntry) usedBefore(other *MemoryCacheEntry) bool {
+	if e.LastAccessed != other.LastAccessed {
+		return e.LastAccessed < other.LastAccessed
+	}
+	return e.accessSeq < other.accessSeq
+}
+
 func (mc *MemoryCache) GetDirtyEntries() map[string]*aggregator.AggregatedData {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
@@ -189,6 +257,26 @@ func (mc *MemoryCache) GetHistoricalLogs(duration int64) []model.ConversationLog
 	return rawLogs
 }
 
+// GetHourlyStats returns the hourly usage of every cached file for the hours
+// starting within [from, to)
+func (mc *MemoryCache) GetHourlyStats(from, to int64) []aggregator.HourlyData {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	var result []aggregator.HourlyData
+	for _, entry := range mc.entries {
+		if entry == nil || entry.AggregatedData == nil {
+			continue
+		}
+		for _, item := range entry.HourlyStats {
+			if item.Hour >= from && item.Hour < to {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
 // GetGlobalTimeline returns all logs from all projects sorted by timestamp
 // GetLogsForFile returns all logs for a specific file/session
 func (mc *MemoryCache) GetLogsForFile(sessionId string) []model.ConversationLog {
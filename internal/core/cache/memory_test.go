@@ -2,6 +2,7 @@ package cache
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -467,3 +468,81 @@ func TestMemoryCacheEdgeCases(t *testing.T) {
 }
 
 // Helper function
+
+func TestMemoryCacheEvictsLeastRecentlyUsedRawLogs(t *testing.T) {
+	cache := NewMemoryCacheWithLimit(2)
+
+	newEntry := func() *MemoryCacheEntry {
+		return &MemoryCacheEntry{
+			AggregatedData: &aggregator.AggregatedData{
+				HourlyStats: []aggregator.HourlyData{{Hour: time.Now().Unix(), TotalTokens: 100}},
+			},
+			RawLogs: []model.ConversationLog{{Type: "assistant", Timestamp: time.Now().Format(time.RFC3339)}},
+		}
+	}
+
+	cache.Set("session-1", newEntry())
+	cache.Set("session-2", newEntry())
+
+	// Touch session-1 so session-2 becomes the least recently used
+	cache.Get("session-1")
+
+	cache.Set("session-3", newEntry())
+
+	for id, wantRawLogs := range map[string]bool{"session-1": true, "session-2": false, "session-3": true} {
+		entry, ok := cache.Get(id)
+		if !ok {
+			t.Fatalf("Expected %s to remain cached", id)
+		}
+		if hasRawLogs := entry.RawLogs != nil; hasRawLogs != wantRawLogs {
+			t.Errorf("%s: expected raw logs present=%v, got %v", id, wantRawLogs, hasRawLogs)
+		}
+		if entry.AggregatedData == nil || len(entry.AggregatedData.HourlyStats) != 1 {
+			t.Errorf("%s: expected aggregated data to be kept", id)
+		}
+	}
+
+	if logs := cache.GetHistoricalLogs(0); len(logs) != 2 {
+		t.Errorf("Expected 2 raw logs after eviction, got %d", len(logs))
+	}
+}
+
+func TestMemoryCacheUnlimitedByDefault(t *testing.T) {
+	cache := NewMemoryCache()
+	for i := 0; i < 50; i++ {
+		cache.Set(fmt.Sprintf("session-%d", i), &MemoryCacheEntry{
+			RawLogs: []model.ConversationLog{{Type: "assistant"}},
+		})
+	}
+
+	if logs := cache.GetHistoricalLogs(0); len(logs) != 50 {
+		t.Errorf("Expected all 50 raw logs to be kept, got %d", len(logs))
+	}
+}
+
+func TestMemoryCacheConcurrentGetsOfOneEntry(t *testing.T) {
+	cache := NewMemoryCacheWithLimit(1)
+	cache.Set("session-1", &MemoryCacheEntry{RawLogs: []model.ConversationLog{{Type: "assistant"}}})
+
+	// Every Get records its access on the shared entry; run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cache.Get("session-1")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The most recently used entry keeps its raw logs
+	cache.Set("session-2", &MemoryCacheEntry{RawLogs: []model.ConversationLog{{Type: "assistant"}}})
+	if entry, _ := cache.Get("session-1"); entry.RawLogs != nil {
+		t.Error("Expected session-1 to lose its raw logs to the newer session-2")
+	}
+	if entry, _ := cache.Get("session-2"); entry.RawLogs == nil {
+		t.Error("Expected session-2 to keep its raw logs")
+	}
+}
@@ -7,6 +7,10 @@ const (
 	SessionDuration        = 5 * time.Hour
 	SessionDurationSeconds = int64(5 * 3600)
 
+	// Bounds of a configured session duration
+	MinSessionDuration = time.Hour
+	MaxSessionDuration = 24 * time.Hour
+
 	// Limit window retention
 	LimitWindowRetentionDays    = 3
 	LimitWindowRetentionSeconds = int64(LimitWindowRetentionDays * 24 * 3600)
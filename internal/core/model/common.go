@@ -14,6 +14,35 @@ const (
 const (
 	EntryMessage   = "message"
 	EntryAssistant = "assistant"
+	EntryUser      = "user"
+)
+
+// Message count modes for message-capped plans
+const (
+	MessageCountUserTurns      = "user_turns"      // Count prompts typed by the user
+	MessageCountAssistantTurns = "assistant_turns" // Count assistant responses
+)
+
+// Projection confidence levels, based on how much of the window has elapsed
+const (
+	ProjectionConfidenceLow    = "low"
+	ProjectionConfidenceMedium = "medium"
+	ProjectionConfidenceHigh   = "high"
+)
+
+// Detection confidence levels, based on what a session's window was derived from
+const (
+	DetectionConfidenceHigh        = "high"        // Backed by a limit message
+	DetectionConfidenceMedium      = "medium"      // Aligned to continuous activity
+	DetectionConfidenceLow         = "low"         // Inferred from a gap or the first message
+	DetectionConfidenceSpeculative = "speculative" // No activity backs the window yet
+)
+
+// Service tier identifiers
+const (
+	ServiceTierStandard = "standard"
+	ServiceTierPriority = "priority"
+	ServiceTierBatch    = "batch"
 )
 
 // Plan identifiers
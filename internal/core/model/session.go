@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"github.com/penwyp/go-claude-monitor/internal/core/constants"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 	"time"
 )
@@ -47,13 +48,14 @@ type InteractionState struct {
 	IsPaused       bool
 	ShowHelp       bool
 	ForceRefresh   bool
-	LayoutStyle    int           // 0: Full Dashboard, 1: Minimal
+	LayoutStyle    int           // 0: Full Dashboard, 1: Minimal, 2: Compact Gauge
 	StatusMessage  string        // Status message to display
 	ConfirmDialog  *ConfirmDialog
 	IsLoading      bool          // Whether data is currently being loaded (deprecated, use DisplayStatus)
 	LoadingMessage string        // Loading status message (deprecated, use StatusMessage)
 	DisplayStatus  DisplayStatus // Current display status
 	StatusIndicator string       // Status indicator text for bottom-right corner
+	ClockSkew      time.Duration // How far the clock is behind the last data update (0 = none)
 }
 
 // ConfirmDialog represents a confirmation dialog
@@ -68,6 +70,8 @@ type ConfirmDialog struct {
 type AggregatedMetrics struct {
 	TotalCost           float64
 	TotalTokens         int
+	LimitTokens         int  // Tokens counted toward TokenLimit, when HasLimitTokens
+	HasLimitTokens      bool // Whether LimitTokens was calculated; TotalTokens counts otherwise
 	TotalMessages       int
 	ActiveSessions      int
 	TotalSessions       int
@@ -83,9 +87,37 @@ type AggregatedMetrics struct {
 	LimitExceeded       bool
 	LimitExceededReason string
 	ResetTime           int64 // Unix timestamp
+	ResetTimeClamped    bool  // Whether ResetTime was capped at one session duration
+	SessionDuration     time.Duration // Length of a session window (0 = 5h)
 	PredictedEndTime    int64 // Unix timestamp
 	CostPerMinute       float64
 
+	// Window-end projections and how far they can be trusted
+	ProjectedTokens      int
+	ProjectedCost        float64
+	ProjectionConfidence string // low, medium or high; empty when unknown
+
+	// How long before reset the window's opening burst pace would hit the
+	// token limit; 0 when the pace is sustainable
+	PacingLead time.Duration
+
+	// Usage of the whole calendar day in the configured timezone, across
+	// every window; only set when HasDailyUsage is true
+	HasDailyUsage bool
+	DailyTokens   int
+	DailyCost     float64
+
+	// Usage of the trailing 7 days across every session against the plan's
+	// weekly token cap; only set when HasWeeklyUsage is true
+	HasWeeklyUsage   bool
+	WeeklyTokens     int
+	WeeklyTokenLimit int
+	WeeklyResetTime  int64 // When the oldest usage in the week ages out (0 = no usage)
+
+	// Usage of the followed project within the window (top --follow)
+	FollowedProjectTokens int
+	FollowedProjectCost   float64
+
 	// Sliding window information
 	WindowSource     string // Source of window detection: "limit_message", "gap", "first_message", "rounded_hour"
 	IsWindowDetected bool   // Whether window timing was explicitly detected
@@ -97,28 +129,58 @@ type AggregatedMetrics struct {
 	StatusIndicator string // Status message to show in bottom-right corner (e.g., "Refreshing...")
 }
 
+// GetLimitTokens returns the tokens counted toward the token limit, which may
+// leave out token types that the plan does not enforce. Metrics built
+// without a limit calculation count every token.
+func (aggregated AggregatedMetrics) GetLimitTokens() int {
+	if aggregated.HasLimitTokens {
+		return aggregated.LimitTokens
+	}
+	return aggregated.TotalTokens
+}
+
 func (aggregated AggregatedMetrics) GetTokenPercentage() float64 {
 	if aggregated.TokenLimit < 0 {
 		return 0
 	}
 
-	percentage := (float64(aggregated.TotalTokens) / float64(aggregated.TokenLimit)) * 100
+	percentage := (float64(aggregated.GetLimitTokens()) / float64(aggregated.TokenLimit)) * 100
 	if percentage > 100 {
 		percentage = 100
 	}
 	return percentage
 }
 
+// GetWeeklyPercentage returns the share of the weekly token cap used, which is
+// independent of the window's token percentage
+func (aggregated AggregatedMetrics) GetWeeklyPercentage() float64 {
+	if aggregated.WeeklyTokenLimit <= 0 {
+		return 0
+	}
+
+	percentage := (float64(aggregated.WeeklyTokens) / float64(aggregated.WeeklyTokenLimit)) * 100
+	if percentage > 100 {
+		percentage = 100
+	}
+	return percentage
+}
+
+// GetSessionDuration returns the length of a session window, five hours unless
+// configured otherwise
+func (aggregated AggregatedMetrics) GetSessionDuration() time.Duration {
+	if aggregated.SessionDuration <= 0 {
+		return constants.SessionDuration
+	}
+	return aggregated.SessionDuration
+}
+
 func (aggregated AggregatedMetrics) GetTokensRunOut(param LayoutParam) string {
 	tp := util.GetTimeProvider()
 	tokensRunOut := "Unknown"
 	if aggregated.PredictedEndTime != 0 {
 		predictedTime := time.Unix(aggregated.PredictedEndTime, 0).UTC()
 		predictedTimeLocal := tp.In(predictedTime)
-		tokensRunOut = predictedTimeLocal.Format("15:04")
-		if param.TimeFormat == "12h" {
-			tokensRunOut = predictedTimeLocal.Format("3:04 PM")
-		}
+		tokensRunOut = predictedTimeLocal.Format(util.ClockLayout(param.TimeFormat, false))
 	}
 	return tokensRunOut
 }
@@ -153,10 +215,7 @@ func (aggregated AggregatedMetrics) FormatResetTime(param LayoutParam) string {
 		param.TimeFormat,
 		aggregated.WindowSource))
 
-	if param.TimeFormat == "12h" {
-		return resetTimeLocal.Format("3:04 PM")
-	}
-	return resetTimeLocal.Format("15:04")
+	return resetTimeLocal.Format(util.ClockLayout(param.TimeFormat, false))
 }
 
 func (aggregated AggregatedMetrics) AppendWindowIndicator(resetTimeStr string) string {
@@ -207,6 +266,44 @@ func (aggregated AggregatedMetrics) FormatRemainingTime() string {
 	return util.FormatDuration(remaining)
 }
 
+// FormatProjection formats the window-end projection with its confidence level
+func (aggregated AggregatedMetrics) FormatProjection() string {
+	if aggregated.ProjectionConfidence == "" || aggregated.ProjectedTokens <= 0 {
+		return "N/A"
+	}
+
+	return fmt.Sprintf("%s tokens / %s (%s confidence)",
+		util.FormatNumber(aggregated.ProjectedTokens),
+		util.FormatCurrency(aggregated.ProjectedCost),
+		aggregated.ProjectionConfidence)
+}
+
+// FormatPacingWarning formats the warning about a burst pace that would hit
+// the limit before reset, or returns an empty string without one
+func (aggregated AggregatedMetrics) FormatPacingWarning() string {
+	if aggregated.PacingLead <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("At this pace you'll hit the limit ~%s before reset", util.FormatDuration(aggregated.PacingLead))
+}
+
+// FormatDailyUsage formats the cumulative usage of the current day
+func (aggregated AggregatedMetrics) FormatDailyUsage() string {
+	return fmt.Sprintf("%s tokens / %s",
+		util.FormatNumber(aggregated.DailyTokens),
+		util.FormatCurrency(aggregated.DailyCost))
+}
+
+// FormatWeeklyReset formats when usage next ages out of the weekly cap
+func (aggregated AggregatedMetrics) FormatWeeklyReset(param LayoutParam) string {
+	if aggregated.WeeklyResetTime == 0 {
+		return "N/A"
+	}
+
+	layout := "Mon " + util.ClockLayout(param.TimeFormat, false)
+	return util.GetTimeProvider().In(time.Unix(aggregated.WeeklyResetTime, 0).UTC()).Format(layout)
+}
+
 // ModelStats contains statistics for a specific model
 type ModelStats struct {
 	Model  string
@@ -215,6 +312,34 @@ type ModelStats struct {
 	Count  int
 }
 
+// CollapseModelDistribution returns the model with the most tokens and the
+// number of other models in the distribution. Ties are broken by model name.
+func CollapseModelDistribution(distribution map[string]*ModelStats) (string, int) {
+	dominant := ""
+	dominantTokens := -1
+	for name, stats := range distribution {
+		if stats == nil {
+			continue
+		}
+		if stats.Tokens > dominantTokens || (stats.Tokens == dominantTokens && name < dominant) {
+			dominant = name
+			dominantTokens = stats.Tokens
+		}
+	}
+	if dominant == "" {
+		return "", 0
+	}
+	return dominant, len(distribution) - 1
+}
+
+// TierStats contains statistics for a specific service tier
+type TierStats struct {
+	Tier   string
+	Tokens int
+	Cost   float64
+	Count  int
+}
+
 // BurnRate represents the token/cost consumption rate
 type BurnRate struct {
 	TokensPerMinute float64
@@ -223,7 +348,9 @@ type BurnRate struct {
 }
 
 type LayoutParam struct {
-	Timezone   string
-	TimeFormat string
-	Plan       string
+	Timezone       string
+	TimeFormat     string
+	Plan           string
+	CollapseModels bool // Show only the dominant model in the distribution
+	FollowProject  string // Project followed full-screen by top --follow
 }
@@ -67,6 +67,19 @@ func TestAggregatedMetricsGetTokenPercentage(t *testing.T) {
 	}
 }
 
+func TestAggregatedMetricsGetLimitTokens(t *testing.T) {
+	// Without a limit calculation every token counts
+	assert.Equal(t, 1000, AggregatedMetrics{TotalTokens: 1000}.GetLimitTokens())
+
+	// A calculated count is used even when none of the counted types were used
+	none := AggregatedMetrics{TotalTokens: 1000, HasLimitTokens: true, TokenLimit: 1000}
+	assert.Equal(t, 0, none.GetLimitTokens())
+	assert.Equal(t, 0.0, none.GetTokenPercentage())
+
+	some := AggregatedMetrics{TotalTokens: 1000, LimitTokens: 250, HasLimitTokens: true}
+	assert.Equal(t, 250, some.GetLimitTokens())
+}
+
 func TestAggregatedMetricsGetMessagePercentage(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -224,6 +237,18 @@ func TestAggregatedMetricsFormatResetTime(t *testing.T) {
 			timeFormat: "12h",
 			expected:   "12:00 AM",
 		},
+		{
+			name:       "iso_format",
+			resetTime:  time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC).Unix(),
+			timeFormat: "iso",
+			expected:   "2024-01-01T15:30Z",
+		},
+		{
+			name:       "custom_layout",
+			resetTime:  time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC).Unix(),
+			timeFormat: "Jan 2 15h04",
+			expected:   "Jan 1 15h30",
+		},
 	}
 
 	for _, tt := range tests {
@@ -691,4 +716,30 @@ func TestAggregatedMetricsEdgeCases(t *testing.T) {
 		assert.NotNil(t, aggregated.ModelDistribution)
 		assert.Empty(t, aggregated.ModelDistribution)
 	})
-}
\ No newline at end of file
+}
+func TestCollapseModelDistribution(t *testing.T) {
+	dominant, more := CollapseModelDistribution(map[string]*ModelStats{
+		"claude-3-5-sonnet": {Tokens: 1000},
+		"claude-3-opus":     {Tokens: 5000},
+		"claude-3-haiku":    {Tokens: 200},
+	})
+	assert.Equal(t, "claude-3-opus", dominant)
+	assert.Equal(t, 2, more)
+
+	dominant, more = CollapseModelDistribution(map[string]*ModelStats{
+		"claude-3-opus": {Tokens: 10},
+	})
+	assert.Equal(t, "claude-3-opus", dominant)
+	assert.Equal(t, 0, more)
+
+	// Ties resolve by name so the result is stable across refreshes
+	dominant, _ = CollapseModelDistribution(map[string]*ModelStats{
+		"b-model": {Tokens: 10},
+		"a-model": {Tokens: 10},
+	})
+	assert.Equal(t, "a-model", dominant)
+
+	dominant, more = CollapseModelDistribution(nil)
+	assert.Empty(t, dominant)
+	assert.Equal(t, 0, more)
+}
@@ -28,6 +28,31 @@ type ConversationLog struct {
 	Version           string  `json:"version"`
 }
 
+// IsUserTurn reports whether the log is a prompt sent by the user, as opposed
+// to a tool result, meta entry or sidechain message that also uses the user role.
+func (l ConversationLog) IsUserTurn() bool {
+	if l.Type != EntryUser || l.IsMeta || l.IsSidechain {
+		return false
+	}
+	for _, item := range l.Message.Content {
+		if item.Type == "tool_result" {
+			return false
+		}
+	}
+	return true
+}
+
+// HasThinking reports whether the log carries an extended-thinking block. Such
+// output is billed as regular output tokens; the logs give no separate count.
+func (l ConversationLog) HasThinking() bool {
+	for _, item := range l.Message.Content {
+		if item.Type == "thinking" || item.Type == "redacted_thinking" {
+			return true
+		}
+	}
+	return false
+}
+
 type Message struct {
 	Content      FlexibleContent `json:"content"`
 	Id           string          `json:"id,omitempty"`
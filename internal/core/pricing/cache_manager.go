@@ -12,6 +12,10 @@ import (
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
+// DefaultPricingMaxAge is how long fetched pricing is reused before it is
+// fetched again
+const DefaultPricingMaxAge = 24 * time.Hour
+
 // CacheManager handles caching of pricing data for offline use
 type CacheManager struct {
 	mu        sync.RWMutex
@@ -80,6 +84,11 @@ func (m *CacheManager) SavePricing(ctx context.Context, source string, pricing m
 	return nil
 }
 
+// CacheFile returns the path of the pricing cache
+func (m *CacheManager) CacheFile() string {
+	return m.cacheFile
+}
+
 // LoadPricing loads pricing data from cache
 func (m *CacheManager) LoadPricing(ctx context.Context) (*PricingCache, error) {
 	m.mu.RLock()
@@ -2,12 +2,25 @@ package pricing
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 	"sync"
 	"time"
 )
 
+// ErrNoPricingCache is returned in offline mode when a remote pricing source
+// has no cached pricing to use
+var ErrNoPricingCache = errors.New("no cached pricing available")
+
+// cacheableProvider is implemented by remote providers that can start from
+// cached pricing instead of fetching it
+type cacheableProvider interface {
+	SetMaxAge(maxAge time.Duration)
+	SeedPricing(pricing map[string]ModelPricing, fetchedAt time.Time)
+	LastFetchTime() time.Time
+}
+
 // CachedProvider wraps another provider with caching capabilities
 type CachedProvider struct {
 	provider     PricingProvider
@@ -18,6 +31,11 @@ type CachedProvider struct {
 	updateMu       sync.Mutex
 	lastUpdateTime time.Time
 	updateInterval time.Duration
+
+	// Cached pricing younger than maxAge is reused instead of fetched
+	maxAge   time.Duration
+	seedOnce sync.Once
+	seededAt time.Time // Fetch time of the cached pricing the provider started from
 }
 
 // NewCachedProvider creates a new cached pricing provider
@@ -27,6 +45,19 @@ func NewCachedProvider(provider PricingProvider, cacheManager *CacheManager, use
 		cacheManager:   cacheManager,
 		useOffline:     useOffline,
 		updateInterval: 1 * time.Minute, // Default: update cache at most once per minute
+		maxAge:         DefaultPricingMaxAge,
+	}
+}
+
+// SetMaxAge sets how long cached pricing is reused before it is fetched again
+// (0 = DefaultPricingMaxAge). Offline mode uses the cache regardless of age.
+func (p *CachedProvider) SetMaxAge(maxAge time.Duration) {
+	if maxAge <= 0 {
+		maxAge = DefaultPricingMaxAge
+	}
+	p.maxAge = maxAge
+	if seeder, ok := p.provider.(cacheableProvider); ok {
+		seeder.SetMaxAge(maxAge)
 	}
 }
 
@@ -45,7 +76,16 @@ func (p *CachedProvider) GetPricing(ctx context.Context, modelName string) (Mode
 				return pricing, nil
 			}
 		}
+		// A remote source must not be fetched in offline mode
+		if _, remote := p.provider.(cacheableProvider); remote {
+			if err != nil {
+				return ModelPricing{}, p.noCacheError()
+			}
+			return ModelPricing{}, fmt.Errorf("%w: %s", ErrPricingNotFound, modelName)
+		}
 		util.LogDebugf("Cached pricing not found for model %s, falling back to provider", modelName)
+	} else {
+		p.seedFromCache(ctx)
 	}
 
 	// Get pricing from the underlying provider
@@ -83,6 +123,11 @@ func (p *CachedProvider) GetAllPricings(ctx context.Context) (map[string]ModelPr
 			return cache.Pricing, nil
 		}
 		util.LogDebugf("Failed to load cached pricing: %v", err)
+		if _, remote := p.provider.(cacheableProvider); remote {
+			return nil, p.noCacheError()
+		}
+	} else {
+		p.seedFromCache(ctx)
 	}
 
 	// Get pricing from the underlying provider
@@ -136,11 +181,45 @@ func (p *CachedProvider) GetProviderName() string {
 	return fmt.Sprintf("%s-cached", p.provider.GetProviderName())
 }
 
+// seedFromCache starts a remote provider from the pricing cache once, when
+// the cache holds pricing of the same source younger than the max age, so
+// startup skips the fetch
+func (p *CachedProvider) seedFromCache(ctx context.Context) {
+	p.seedOnce.Do(func() {
+		seeder, ok := p.provider.(cacheableProvider)
+		if !ok || !p.cacheManager.HasCache() {
+			return
+		}
+		cache, err := p.cacheManager.LoadPricing(ctx)
+		if err != nil || cache.Source != p.provider.GetProviderName() || len(cache.Pricing) == 0 {
+			return
+		}
+		if age := time.Since(cache.UpdatedAt); age > p.maxAge {
+			util.LogDebugf("Cached %s pricing is %s old, fetching it again", cache.Source, age.Round(time.Second))
+			return
+		}
+		util.LogDebugf("Reusing cached %s pricing from %s", cache.Source, cache.UpdatedAt.Format("2006-01-02 15:04:05"))
+		seeder.SeedPricing(cache.Pricing, cache.UpdatedAt)
+		p.seededAt = cache.UpdatedAt
+	})
+}
+
+// noCacheError describes a missing cache in offline mode
+func (p *CachedProvider) noCacheError() error {
+	return fmt.Errorf("%w for %s at %s: run once without --pricing-offline to create it",
+		ErrNoPricingCache, p.provider.GetProviderName(), p.cacheManager.CacheFile())
+}
+
 // updateCacheIfNeeded updates the cache if enough time has passed since last update
 func (p *CachedProvider) updateCacheIfNeeded() {
 	p.updateMu.Lock()
 	defer p.updateMu.Unlock()
 
+	// Pricing read from the cache is not saved back, which would reset its age
+	if seeder, ok := p.provider.(cacheableProvider); ok && !seeder.LastFetchTime().After(p.seededAt) {
+		return
+	}
+
 	// Check if enough time has passed since last update
 	now := time.Now()
 	if !p.lastUpdateTime.IsZero() && now.Sub(p.lastUpdateTime) < p.updateInterval {
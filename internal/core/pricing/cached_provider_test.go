@@ -0,0 +1,112 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLiteLLMServer serves LiteLLM pricing data and counts the fetches
+func newTestLiteLLMServer(t *testing.T, fetches *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"claude-3-sonnet": map[string]interface{}{
+				"input_cost_per_token":  0.000004,
+				"output_cost_per_token": 0.00002,
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writePricingCache writes a litellm pricing cache of the given age
+func writePricingCache(t *testing.T, cm *CacheManager, age time.Duration) {
+	data, err := json.Marshal(PricingCache{
+		Source:    "litellm",
+		UpdatedAt: time.Now().Add(-age),
+		Pricing:   map[string]ModelPricing{"claude-3-sonnet": {Input: 1, Output: 5}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cm.CacheFile(), data, 0644))
+}
+
+func newTestCachedProvider(t *testing.T, url string, offline bool) (*CachedProvider, *CacheManager) {
+	cm, err := NewCacheManager(t.TempDir())
+	require.NoError(t, err)
+	lite := NewLiteLLMProvider()
+	lite.url = url
+	provider := NewCachedProvider(lite, cm, offline)
+	provider.SetMaxAge(time.Hour)
+	return provider, cm
+}
+
+func TestCachedProviderReusesFreshCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var fetches int32
+	server := newTestLiteLLMServer(t, &fetches)
+	provider, cm := newTestCachedProvider(t, server.URL, false)
+	writePricingCache(t, cm, 10*time.Minute)
+
+	pricing, err := provider.GetPricing(context.Background(), "claude-3-sonnet")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, pricing.Input, "pricing should come from the cache")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&fetches))
+}
+
+func TestCachedProviderFetchesStaleCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var fetches int32
+	server := newTestLiteLLMServer(t, &fetches)
+	provider, cm := newTestCachedProvider(t, server.URL, false)
+	writePricingCache(t, cm, 2*time.Hour)
+
+	pricing, err := provider.GetPricing(context.Background(), "claude-3-sonnet")
+	require.NoError(t, err)
+	assert.InDelta(t, 4.0, pricing.Input, 1e-9, "pricing should be fetched")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// The fetched pricing replaces the stale cache
+	require.Eventually(t, func() bool {
+		cache, err := cm.LoadPricing(context.Background())
+		return err == nil && time.Since(cache.UpdatedAt) < time.Minute
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCachedProviderOfflineUsesStaleCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var fetches int32
+	server := newTestLiteLLMServer(t, &fetches)
+	provider, cm := newTestCachedProvider(t, server.URL, true)
+	writePricingCache(t, cm, 30*24*time.Hour)
+
+	pricing, err := provider.GetPricing(context.Background(), "claude-3-sonnet")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, pricing.Input)
+
+	// Models missing from the cache are not fetched either
+	_, err = provider.GetPricing(context.Background(), "claude-opus-4")
+	assert.ErrorIs(t, err, ErrPricingNotFound)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&fetches))
+}
+
+func TestCreatePricingProviderOfflineWithoutCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := CreatePricingProvider(&SourceConfig{PricingSource: "litellm", PricingOfflineMode: true}, t.TempDir())
+	require.ErrorIs(t, err, ErrNoPricingCache)
+	assert.Contains(t, err.Error(), "pricing.json")
+
+	// The built-in rates need no cache
+	_, err = CreatePricingProvider(&SourceConfig{PricingSource: "default", PricingOfflineMode: true}, t.TempDir())
+	assert.NoError(t, err)
+}
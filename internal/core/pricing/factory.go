@@ -38,6 +38,10 @@ func CreatePricingProvider(cfg *SourceConfig, cacheDir string) (PricingProvider,
 		}
 
 		cachedProvider := NewCachedProvider(baseProvider, cacheManager, cfg.PricingOfflineMode)
+		cachedProvider.SetMaxAge(cfg.PricingMaxAge)
+		if cfg.PricingOfflineMode && source != "default" && !cacheManager.HasCache() {
+			return nil, cachedProvider.noCacheError()
+		}
 		return cachedProvider, nil
 	}
 
@@ -16,7 +16,7 @@ import (
 
 const (
 	liteLLMPricingURL = "https://raw.githubusercontent.com/BerriAI/litellm/main/model_prices_and_context_window.json"
-	cacheExpiration   = 24 * time.Hour // Cache pricing data for 24 hours
+	cacheExpiration   = DefaultPricingMaxAge
 )
 
 // LiteLLMProvider implements PricingProvider by fetching pricing from LiteLLM's repository
@@ -24,6 +24,8 @@ type LiteLLMProvider struct {
 	mu            sync.RWMutex
 	pricing       map[string]ModelPricing
 	lastFetchTime time.Time
+	maxAge        time.Duration // Refetch pricing older than this (0 = cacheExpiration)
+	url           string
 	httpClient    *http.Client
 }
 
@@ -39,6 +41,7 @@ type liteLLMModel struct {
 func NewLiteLLMProvider() *LiteLLMProvider {
 	return &LiteLLMProvider{
 		pricing: make(map[string]ModelPricing),
+		url:     liteLLMPricingURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -115,10 +118,38 @@ func (p *LiteLLMProvider) GetProviderName() string {
 	return "litellm"
 }
 
+// SetMaxAge sets how old fetched pricing may get before it is fetched again
+func (p *LiteLLMProvider) SetMaxAge(maxAge time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxAge = maxAge
+}
+
+// SeedPricing starts the provider from pricing fetched at fetchedAt, e.g. read
+// from the pricing cache. It is fetched again once older than the max age.
+func (p *LiteLLMProvider) SeedPricing(pricing map[string]ModelPricing, fetchedAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pricing = pricing
+	p.lastFetchTime = fetchedAt
+}
+
+// LastFetchTime returns when the pricing was fetched, or was fetched before
+// being seeded
+func (p *LiteLLMProvider) LastFetchTime() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.lastFetchTime
+}
+
 // ensurePricingLoaded checks if pricing data needs to be loaded or refreshed
 func (p *LiteLLMProvider) ensurePricingLoaded(ctx context.Context) error {
 	p.mu.RLock()
-	needsRefresh := time.Since(p.lastFetchTime) > cacheExpiration || len(p.pricing) == 0
+	maxAge := p.maxAge
+	if maxAge <= 0 {
+		maxAge = cacheExpiration
+	}
+	needsRefresh := time.Since(p.lastFetchTime) > maxAge || len(p.pricing) == 0
 	currentCount := len(p.pricing)
 	lastFetch := p.lastFetchTime
 	p.mu.RUnlock()
@@ -140,9 +171,9 @@ func (p *LiteLLMProvider) ensurePricingLoaded(ctx context.Context) error {
 
 // fetchPricing fetches the latest pricing data from LiteLLM
 func (p *LiteLLMProvider) fetchPricing(ctx context.Context) error {
-	util.LogDebug(fmt.Sprintf("Starting to fetch pricing data from LiteLLM: %s", liteLLMPricingURL))
+	util.LogDebug(fmt.Sprintf("Starting to fetch pricing data from LiteLLM: %s", p.url))
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, liteLLMPricingURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -0,0 +1,121 @@
+package pricing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tierRates are the per-million-token rates of one model in a pricing file.
+// Pointers tell a missing rate apart from a free one.
+type tierRates struct {
+	Input         *float64 `json:"input"`
+	Output        *float64 `json:"output"`
+	CacheCreation *float64 `json:"cache_creation"`
+	CacheRead     *float64 `json:"cache_read"`
+}
+
+// overrideRates are the rates of one model in a pricing file, with optional
+// rates per service tier
+type overrideRates struct {
+	tierRates
+	ServiceTiers map[string]tierRates `json:"service_tiers"`
+}
+
+// LoadPricingOverrides reads a JSON pricing file mapping model names to their
+// input, output, cache_creation and cache_read rates per million tokens, e.g.
+//
+//	{"claude-3-sonnet": {"input": 2.5, "output": 12, "cache_creation": 3, "cache_read": 0.25,
+//	  "service_tiers": {"priority": {"input": 4, "output": 20, "cache_creation": 5, "cache_read": 0.4}}}}
+//
+// Every model and service tier must set all four rates and none may be
+// negative. Tiers without rates keep the tier multiplier, see
+// ApplyServiceTier. A name also applies to the dated IDs logs carry, see
+// LookupOverride.
+func LoadPricingOverrides(path string) (map[string]ModelPricing, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+
+	var raw map[string]overrideRates
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid pricing file %s: %w", path, err)
+	}
+
+	overrides := make(map[string]ModelPricing, len(raw))
+	for model, rates := range raw {
+		pricing, err := rates.tierRates.pricing(fmt.Sprintf("model %q", model))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pricing file %s: %w", path, err)
+		}
+		for tier, tierRates := range rates.ServiceTiers {
+			tierPricing, err := tierRates.pricing(fmt.Sprintf("model %q service tier %q", model, tier))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pricing file %s: %w", path, err)
+			}
+			if pricing.ServiceTiers == nil {
+				pricing.ServiceTiers = make(map[string]ModelPricing, len(rates.ServiceTiers))
+			}
+			pricing.ServiceTiers[NormalizeServiceTier(tier)] = tierPricing
+		}
+		overrides[model] = pricing
+	}
+	return overrides, nil
+}
+
+// pricing converts the rates to a ModelPricing, requiring all four rates to
+// be set and not negative; owner names them in errors
+func (r tierRates) pricing(owner string) (ModelPricing, error) {
+	fields := []struct {
+		name  string
+		value *float64
+	}{
+		{"input", r.Input},
+		{"output", r.Output},
+		{"cache_creation", r.CacheCreation},
+		{"cache_read", r.CacheRead},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			return ModelPricing{}, fmt.Errorf("%s has no %s rate", owner, field.name)
+		}
+		if *field.value < 0 {
+			return ModelPricing{}, fmt.Errorf("%s has a negative %s rate %g", owner, field.name, *field.value)
+		}
+	}
+	return ModelPricing{
+		Input:         *r.Input,
+		Output:        *r.Output,
+		CacheCreation: *r.CacheCreation,
+		CacheRead:     *r.CacheRead,
+	}, nil
+}
+
+// LookupOverride returns the override rates of modelName. Names match
+// case-insensitively, exactly or as a prefix ending at a '-', so
+// "claude-3-sonnet" applies to "claude-3-sonnet-20240229"; the longest
+// matching name wins.
+func LookupOverride(overrides map[string]ModelPricing, modelName string) (ModelPricing, bool) {
+	if rates, ok := overrides[modelName]; ok {
+		return rates, true
+	}
+	modelLower := strings.ToLower(modelName)
+	var best string
+	var bestRates ModelPricing
+	found := false
+	for name, rates := range overrides {
+		nameLower := strings.ToLower(name)
+		if modelLower != nameLower && !strings.HasPrefix(modelLower, nameLower+"-") {
+			continue
+		}
+		if !found || len(name) > len(best) {
+			best, bestRates, found = name, rates, true
+		}
+	}
+	return bestRates, found
+}
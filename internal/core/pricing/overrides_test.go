@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePricingFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadPricingOverrides(t *testing.T) {
+	path := writePricingFile(t, `{"claude-3-sonnet": {"input": 2.5, "output": 12, "cache_creation": 3, "cache_read": 0}}`)
+
+	overrides, err := LoadPricingOverrides(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ModelPricing{
+		"claude-3-sonnet": {Input: 2.5, Output: 12, CacheCreation: 3, CacheRead: 0},
+	}, overrides)
+}
+
+func TestLoadPricingOverridesServiceTiers(t *testing.T) {
+	path := writePricingFile(t, `{"claude-3-sonnet": {"input": 3, "output": 15, "cache_creation": 3.75, "cache_read": 0.3,
+		"service_tiers": {"priority": {"input": 4.5, "output": 22.5, "cache_creation": 5.6, "cache_read": 0.45}}}}`)
+
+	overrides, err := LoadPricingOverrides(path)
+	require.NoError(t, err)
+	sonnet := overrides["claude-3-sonnet"]
+	priority := ModelPricing{Input: 4.5, Output: 22.5, CacheCreation: 5.6, CacheRead: 0.45}
+	assert.Equal(t, map[string]ModelPricing{"priority": priority}, sonnet.ServiceTiers)
+
+	assert.Equal(t, priority, sonnet.ApplyServiceTier("priority"))
+	assert.Equal(t, 3.0, sonnet.ApplyServiceTier("standard").Input)
+	assert.Equal(t, 1.5, sonnet.ApplyServiceTier("batch").Input)
+}
+
+func TestLoadPricingOverridesRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"malformed", `{"claude-3-sonnet": {"input": 2.5,`, "invalid pricing file"},
+		{"not a map", `[1, 2]`, "invalid pricing file"},
+		{"unknown rate", `{"m": {"input": 1, "output": 1, "cache_creation": 1, "cache_read": 1, "imput": 1}}`, "imput"},
+		{"missing rate", `{"m": {"input": 1, "output": 1, "cache_creation": 1}}`, `model "m" has no cache_read rate`},
+		{"negative rate", `{"m": {"input": 1, "output": -2, "cache_creation": 1, "cache_read": 1}}`, `model "m" has a negative output rate -2`},
+		{"missing tier rate", `{"m": {"input": 1, "output": 1, "cache_creation": 1, "cache_read": 1, "service_tiers": {"priority": {"input": 1}}}}`,
+			`model "m" service tier "priority" has no output rate`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPricingOverrides(writePricingFile(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err := LoadPricingOverrides(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLookupOverride(t *testing.T) {
+	overrides := map[string]ModelPricing{
+		"claude-3":                 {Input: 1},
+		"claude-3-sonnet":          {Input: 2},
+		"claude-3-sonnet-20240229": {Input: 3},
+	}
+
+	tests := []struct {
+		model     string
+		wantInput float64
+		wantFound bool
+	}{
+		{"claude-3-sonnet-20240229", 3, true},
+		{"claude-3-sonnet-20250101", 2, true}, // Dated ID of an undated name
+		{"Claude-3-Sonnet-20250101", 2, true},
+		{"claude-3-opus-20240229", 1, true},
+		{"claude-3-sonnetx", 1, true}, // Prefixes end at a '-'
+		{"claude-sonnet-4-20250514", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			rates, found := LookupOverride(overrides, tt.model)
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantInput, rates.Input)
+		})
+	}
+}
@@ -1,10 +1,20 @@
 package pricing
 
-import "github.com/penwyp/go-claude-monitor/internal/core/model"
+import (
+	"fmt"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+)
 
 type SourceConfig struct {
 	PricingSource      string `json:"pricingSource"`
 	PricingOfflineMode bool   `json:"pricingOfflineMode"`
+
+	// PricingMaxAge is how long cached pricing of a remote source is reused
+	// before it is fetched again (0 = DefaultPricingMaxAge). Offline mode uses
+	// the cache regardless of its age.
+	PricingMaxAge time.Duration `json:"pricingMaxAge,omitempty"`
 }
 
 // ModelPricing defines token pricing for different Claude models
@@ -13,6 +23,9 @@ type ModelPricing struct {
 	Output        float64 // Per million tokens
 	CacheCreation float64 // Per million tokens
 	CacheRead     float64 // Per million tokens
+	// ServiceTiers holds rates that replace the tier multiplier for a
+	// service tier, e.g. negotiated priority rates from a pricing file
+	ServiceTiers map[string]ModelPricing `json:"ServiceTiers,omitempty"`
 }
 
 // Plan represents a subscription plan with token and cost limits
@@ -21,6 +34,14 @@ type Plan struct {
 	TokenLimit   int     `json:"token_limit"`
 	CostLimit    float64 `json:"cost_limit"`
 	MessageLimit int     `json:"message_limit"`
+
+	// WeeklyTokenLimit caps the tokens used across all sessions in any
+	// trailing 7 days (0 = no weekly cap)
+	WeeklyTokenLimit int `json:"weekly_token_limit,omitempty"`
+
+	// MessageCountMode selects what counts toward MessageLimit:
+	// model.MessageCountUserTurns (default) or model.MessageCountAssistantTurns
+	MessageCountMode string `json:"message_count_mode,omitempty"`
 }
 
 // modelPricingMap stores pricing for all Claude models
@@ -63,6 +84,20 @@ var modelPricingMap = map[string]ModelPricing{
 	},
 }
 
+// serviceTierMultipliers scales model pricing for non-standard service tiers
+// whose model has no ServiceTiers rates. Batch requests are billed at 50% of
+// the standard rates
+// (https://docs.anthropic.com/en/docs/build-with-claude/batch-processing).
+// Priority Tier is bought as committed capacity with no public per-token
+// rate (https://docs.anthropic.com/en/api/service-tiers), so it defaults to
+// standard rates; set the negotiated rates with "service_tiers" in a
+// pricing file.
+var serviceTierMultipliers = map[string]float64{
+	model.ServiceTierStandard: 1.0,
+	model.ServiceTierPriority: 1.0,
+	model.ServiceTierBatch:    0.5,
+}
+
 // planMap stores all available subscription plans
 var planMap = map[string]Plan{
 	model.PlanPro: {
@@ -100,6 +135,54 @@ func GetPricing(modelName string) ModelPricing {
 	return modelPricingMap[model.ModelDefault]
 }
 
+// NormalizeServiceTier maps empty and legacy tier names to the standard tier
+func NormalizeServiceTier(tier string) string {
+	if tier == "" || tier == "default" {
+		return model.ServiceTierStandard
+	}
+	return tier
+}
+
+// GetServiceTierMultiplier returns the price multiplier for a service tier.
+// Unknown tiers are billed at standard rates.
+func GetServiceTierMultiplier(tier string) float64 {
+	if multiplier, ok := serviceTierMultipliers[NormalizeServiceTier(tier)]; ok {
+		return multiplier
+	}
+	return 1.0
+}
+
+// ApplyServiceTier returns the pricing of the given service tier: its own
+// ServiceTiers rates when set, else the rates scaled by the tier multiplier
+func (p ModelPricing) ApplyServiceTier(tier string) ModelPricing {
+	if rates, ok := p.ServiceTiers[NormalizeServiceTier(tier)]; ok {
+		return rates
+	}
+	multiplier := GetServiceTierMultiplier(tier)
+	return ModelPricing{
+		Input:         p.Input * multiplier,
+		Output:        p.Output * multiplier,
+		CacheCreation: p.CacheCreation * multiplier,
+		CacheRead:     p.CacheRead * multiplier,
+	}
+}
+
+// ValidateCacheReadDiscount reports whether discount is a usable cache-read
+// cost multiplier
+func ValidateCacheReadDiscount(discount float64) error {
+	if discount < 0 || discount > 1 {
+		return fmt.Errorf("cache read discount %g is out of range: must be between 0 and 1", discount)
+	}
+	return nil
+}
+
+// ApplyCacheReadDiscount returns the pricing with the cache-read rate scaled
+// by discount, leaving the other rates unchanged
+func (p ModelPricing) ApplyCacheReadDiscount(discount float64) ModelPricing {
+	p.CacheRead *= discount
+	return p
+}
+
 // GetPlan returns a specific subscription plan
 func GetPlan(planName string) Plan {
 	if plan, ok := planMap[planName]; ok {
@@ -0,0 +1,85 @@
+package session
+
+import (
+	"sort"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+)
+
+// ActivitySession is a block of contiguous activity for reporting. Unlike a
+// Session it is not bounded by the 5-hour limit window and may span several.
+type ActivitySession struct {
+	StartTime         int64    // Timestamp of the first message in the block
+	EndTime           int64    // Timestamp of the last message in the block
+	WindowIDs         []string // Limit windows covered by the block, oldest first
+	TotalTokens       int
+	TotalCost         float64
+	MessageCount      int
+	ModelDistribution map[string]*model.ModelStats
+}
+
+// GroupActivitySessions merges limit windows whose activity is separated by
+// less than the gap threshold into activity sessions, oldest first. Gap
+// sessions are skipped; the windows themselves are left untouched.
+func (d *SessionDetector) GroupActivitySessions(sessions []*Session) []*ActivitySession {
+	windows := make([]*Session, 0, len(sessions))
+	for _, s := range sessions {
+		if !s.IsGap && s.MessageCount > 0 {
+			windows = append(windows, s)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].StartTime < windows[j].StartTime
+	})
+
+	threshold := d.gapThreshold()
+	var result []*ActivitySession
+	var current *ActivitySession
+	for _, w := range windows {
+		start, end := activityBounds(w)
+		if current == nil || start-current.EndTime >= threshold {
+			current = &ActivitySession{
+				StartTime:         start,
+				ModelDistribution: make(map[string]*model.ModelStats),
+			}
+			result = append(result, current)
+		}
+
+		if end > current.EndTime {
+			current.EndTime = end
+		}
+		current.WindowIDs = append(current.WindowIDs, w.ID)
+		current.TotalTokens += w.TotalTokens
+		current.TotalCost += w.TotalCost
+		current.MessageCount += w.MessageCount
+		for name, stats := range w.ModelDistribution {
+			if stats == nil {
+				continue
+			}
+			merged, ok := current.ModelDistribution[name]
+			if !ok {
+				merged = &model.ModelStats{Model: name}
+				current.ModelDistribution[name] = merged
+			}
+			merged.Tokens += stats.Tokens
+			merged.Cost += stats.Cost
+			merged.Count += stats.Count
+		}
+	}
+
+	return result
+}
+
+// activityBounds returns the first and last message times of a window,
+// falling back to the window bounds when they are unknown
+func activityBounds(s *Session) (int64, int64) {
+	start := s.StartTime
+	if s.FirstEntryTime > 0 {
+		start = s.FirstEntryTime
+	}
+	end := s.EndTime
+	if s.ActualEndTime != nil {
+		end = *s.ActualEndTime
+	}
+	return start, end
+}
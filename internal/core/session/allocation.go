@@ -0,0 +1,76 @@
+package session
+
+import "sort"
+
+// ProjectAllocation is one project's share of a window's cost
+type ProjectAllocation struct {
+	ProjectName string
+	Tokens      int
+	Cost        float64 // Allocated cost; the allocations of a window sum to its TotalCost
+	Share       float64 // Fraction of the window cost, 0-1
+}
+
+// WindowAllocation splits the cost of one account-level window across the
+// projects that used it
+type WindowAllocation struct {
+	SessionID string
+	StartTime int64
+	EndTime   int64
+	TotalCost float64
+	Projects  []ProjectAllocation // Largest share first
+}
+
+// AllocateWindowCosts returns the per-project cost allocation of every
+// non-gap window, oldest first. Shares follow each project's own cost and fall
+// back to token counts when no project cost is known.
+func AllocateWindowCosts(sessions []*Session) []WindowAllocation {
+	var result []WindowAllocation
+	for _, s := range sessions {
+		if s.IsGap || len(s.Projects) == 0 {
+			continue
+		}
+
+		var totalProjectCost float64
+		var totalProjectTokens int
+		for _, p := range s.Projects {
+			totalProjectCost += p.TotalCost
+			totalProjectTokens += p.TotalTokens
+		}
+
+		allocation := WindowAllocation{
+			SessionID: s.ID,
+			StartTime: s.StartTime,
+			EndTime:   s.EndTime,
+			TotalCost: s.TotalCost,
+		}
+		for name, p := range s.Projects {
+			var share float64
+			switch {
+			case totalProjectCost > 0:
+				share = p.TotalCost / totalProjectCost
+			case totalProjectTokens > 0:
+				share = float64(p.TotalTokens) / float64(totalProjectTokens)
+			default:
+				share = 1 / float64(len(s.Projects))
+			}
+			allocation.Projects = append(allocation.Projects, ProjectAllocation{
+				ProjectName: name,
+				Tokens:      p.TotalTokens,
+				Cost:        s.TotalCost * share,
+				Share:       share,
+			})
+		}
+		sort.Slice(allocation.Projects, func(i, j int) bool {
+			if allocation.Projects[i].Share != allocation.Projects[j].Share {
+				return allocation.Projects[i].Share > allocation.Projects[j].Share
+			}
+			return allocation.Projects[i].ProjectName < allocation.Projects[j].ProjectName
+		})
+		result = append(result, allocation)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartTime < result[j].StartTime
+	})
+	return result
+}
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateWindowCostsSplitsSharedWindow(t *testing.T) {
+	start := int64(1700000000)
+	sessions := []*Session{
+		{
+			ID:        "shared",
+			StartTime: start,
+			EndTime:   start + 5*3600,
+			TotalCost: 4.5,
+			Projects: map[string]*ProjectStats{
+				"api": {ProjectName: "api", TotalTokens: 3000, TotalCost: 3.0},
+				"web": {ProjectName: "web", TotalTokens: 1000, TotalCost: 1.5},
+			},
+		},
+		{ID: "gap", IsGap: true, StartTime: start + 5*3600, EndTime: start + 6*3600},
+	}
+
+	allocations := AllocateWindowCosts(sessions)
+	require.Len(t, allocations, 1)
+
+	allocation := allocations[0]
+	assert.Equal(t, "shared", allocation.SessionID)
+	require.Len(t, allocation.Projects, 2)
+	assert.Equal(t, "api", allocation.Projects[0].ProjectName)
+	assert.InDelta(t, 2.0/3.0, allocation.Projects[0].Share, 1e-9)
+	assert.Equal(t, "web", allocation.Projects[1].ProjectName)
+
+	var sum float64
+	for _, p := range allocation.Projects {
+		sum += p.Cost
+	}
+	assert.InDelta(t, allocation.TotalCost, sum, 1e-9)
+}
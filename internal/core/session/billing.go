@@ -0,0 +1,122 @@
+package session
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// billingDayLayout is the date format of a billing day
+const billingDayLayout = "2006-01-02"
+
+// DailyBillingEntry is the part of one window's cost billed to one day
+type DailyBillingEntry struct {
+	Date            string // Billing day, YYYY-MM-DD in the given location
+	SessionID       string
+	Tokens          int
+	CostCents       int64   // Prorated cost; the entries of a window sum to its total cents
+	SessionCost     int64   // The window's total cost in cents
+	LimitPercentage float64 // Plan limit usage of the window, repeated on each of its days
+	LimitBound      string  // Empty without plan limits
+}
+
+// ProrateDailyBilling splits the cost of every non-gap window across the days
+// its hourly metrics fall on, oldest window first. Days are weighted by their
+// hourly cost, or by tokens when no hourly cost is known. Costs are prorated in
+// whole cents with the largest remainder method so a window's daily entries sum
+// exactly to its rounded total.
+func ProrateDailyBilling(sessions []*Session, loc *time.Location) []DailyBillingEntry {
+	ordered := make([]*Session, 0, len(sessions))
+	for _, s := range sessions {
+		if !s.IsGap {
+			ordered = append(ordered, s)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].StartTime < ordered[j].StartTime
+	})
+
+	var result []DailyBillingEntry
+	for _, s := range ordered {
+		result = append(result, prorateSession(s, loc)...)
+	}
+	return result
+}
+
+// prorateSession returns the daily entries of one window in date order
+func prorateSession(s *Session, loc *time.Location) []DailyBillingEntry {
+	totalCents := int64(math.Round(s.TotalCost * 100))
+
+	costs := make(map[string]float64)
+	tokens := make(map[string]int)
+	var totalCost float64
+	var totalTokens int
+	for _, metric := range s.HourlyMetrics {
+		day := metric.Hour.In(loc).Format(billingDayLayout)
+		costs[day] += metric.Cost
+		tokens[day] += metric.Tokens
+		totalCost += metric.Cost
+		totalTokens += metric.Tokens
+	}
+	if len(tokens) == 0 {
+		// Without hourly metrics the whole window is billed to its first day
+		day := time.Unix(s.StartTime, 0).In(loc).Format(billingDayLayout)
+		return []DailyBillingEntry{{
+			Date:            day,
+			SessionID:       s.ID,
+			Tokens:          s.TotalTokens,
+			CostCents:       totalCents,
+			SessionCost:     totalCents,
+			LimitPercentage: s.LimitPercentage,
+			LimitBound:      s.LimitBound,
+		}}
+	}
+
+	days := make([]string, 0, len(tokens))
+	for day := range tokens {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	entries := make([]DailyBillingEntry, len(days))
+	remainders := make([]float64, len(days))
+	var allocated int64
+	for i, day := range days {
+		var share float64
+		switch {
+		case totalCost > 0:
+			share = costs[day] / totalCost
+		case totalTokens > 0:
+			share = float64(tokens[day]) / float64(totalTokens)
+		default:
+			share = 1 / float64(len(days))
+		}
+		exact := float64(totalCents) * share
+		cents := int64(math.Floor(exact))
+		entries[i] = DailyBillingEntry{
+			Date:            day,
+			SessionID:       s.ID,
+			Tokens:          tokens[day],
+			CostCents:       cents,
+			SessionCost:     totalCents,
+			LimitPercentage: s.LimitPercentage,
+			LimitBound:      s.LimitBound,
+		}
+		remainders[i] = exact - float64(cents)
+		allocated += cents
+	}
+
+	// Hand the cents lost to flooring to the days with the largest remainders
+	order := make([]int, len(days))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; allocated < totalCents; i = (i + 1) % len(order) {
+		entries[order[i]].CostCents++
+		allocated++
+	}
+	return entries
+}
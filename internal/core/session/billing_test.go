@@ -0,0 +1,67 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProrateDailyBillingSplitsAcrossMidnight(t *testing.T) {
+	start := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	hour := func(i int) time.Time { return start.Add(time.Duration(i) * time.Hour) }
+	sessions := []*Session{
+		{
+			ID:              "late",
+			StartTime:       start.Unix(),
+			EndTime:         start.Add(5 * time.Hour).Unix(),
+			TotalCost:       1.00,
+			TotalTokens:     3000,
+			LimitPercentage: 60,
+			LimitBound:      LimitBoundCost,
+			HourlyMetrics: []*model.HourlyMetric{
+				{Hour: hour(0), Tokens: 1000, Cost: 1.0 / 3},
+				{Hour: hour(2), Tokens: 1000, Cost: 1.0 / 3},
+				{Hour: hour(3), Tokens: 1000, Cost: 1.0 / 3},
+			},
+		},
+		{ID: "gap", IsGap: true, StartTime: start.Add(5 * time.Hour).Unix(), EndTime: start.Add(6 * time.Hour).Unix()},
+	}
+
+	entries := ProrateDailyBilling(sessions, time.UTC)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "2024-03-01", entries[0].Date)
+	assert.Equal(t, 1000, entries[0].Tokens)
+	assert.Equal(t, "2024-03-02", entries[1].Date)
+	assert.Equal(t, 2000, entries[1].Tokens)
+
+	// 33.33 and 66.67 cents: the leftover cent goes to the larger remainder
+	assert.Equal(t, int64(33), entries[0].CostCents)
+	assert.Equal(t, int64(67), entries[1].CostCents)
+
+	var sum int64
+	for _, e := range entries {
+		assert.Equal(t, "late", e.SessionID)
+		assert.Equal(t, int64(100), e.SessionCost)
+		assert.Equal(t, 60.0, e.LimitPercentage)
+		assert.Equal(t, LimitBoundCost, e.LimitBound)
+		sum += e.CostCents
+	}
+	assert.Equal(t, int64(100), sum)
+}
+
+func TestProrateDailyBillingWithoutHourlyMetrics(t *testing.T) {
+	start := time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)
+	sessions := []*Session{
+		{ID: "s", StartTime: start.Unix(), EndTime: start.Add(5 * time.Hour).Unix(), TotalCost: 2.34, TotalTokens: 10},
+	}
+
+	entries := ProrateDailyBilling(sessions, time.UTC)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "2024-03-01", entries[0].Date)
+	assert.Equal(t, int64(234), entries[0].CostCents)
+	assert.Equal(t, 10, entries[0].Tokens)
+}
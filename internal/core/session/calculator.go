@@ -1,21 +1,131 @@
 package session
 
 import (
+	"fmt"
+	"github.com/penwyp/go-claude-monitor/internal/core/constants"
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
 	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
+	"github.com/penwyp/go-claude-monitor/internal/util"
 	"sort"
+	"strings"
 	"time"
 )
 
+// Token component names accepted by ParseTokenComponents
+const (
+	TokenComponentInput         = "input"
+	TokenComponentOutput        = "output"
+	TokenComponentCacheCreation = "cache_creation"
+	TokenComponentCacheRead     = "cache_read"
+)
+
+// TokenComponents selects which token types count toward the plan's token limit
+type TokenComponents struct {
+	Input         bool
+	Output        bool
+	CacheCreation bool
+	CacheRead     bool
+}
+
+// AllTokenComponents counts every token type, matching the displayed total
+var AllTokenComponents = TokenComponents{Input: true, Output: true, CacheCreation: true, CacheRead: true}
+
+// ParseTokenComponents parses a comma-separated list of token component names,
+// e.g. "input,output,cache_read". At least one component is required.
+func ParseTokenComponents(spec string) (TokenComponents, error) {
+	var components TokenComponents
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(name) {
+		case TokenComponentInput:
+			components.Input = true
+		case TokenComponentOutput:
+			components.Output = true
+		case TokenComponentCacheCreation:
+			components.CacheCreation = true
+		case TokenComponentCacheRead:
+			components.CacheRead = true
+		case "":
+		default:
+			return TokenComponents{}, fmt.Errorf("invalid token component '%s': must be one of %s, %s, %s, %s",
+				strings.TrimSpace(name), TokenComponentInput, TokenComponentOutput, TokenComponentCacheCreation, TokenComponentCacheRead)
+		}
+	}
+	if components == (TokenComponents{}) {
+		return TokenComponents{}, fmt.Errorf("no token components in '%s': at least one is required", spec)
+	}
+	return components, nil
+}
+
+// Count returns the session tokens that count toward the limit. Sessions
+// without a per-type breakdown count their whole total.
+func (tc TokenComponents) Count(session *Session) int {
+	if session.InputTokens == 0 && session.OutputTokens == 0 &&
+		session.CacheCreationTokens == 0 && session.CacheReadTokens == 0 {
+		return session.TotalTokens
+	}
+	total := 0
+	if tc.Input {
+		total += session.InputTokens
+	}
+	if tc.Output {
+		total += session.OutputTokens
+	}
+	if tc.CacheCreation {
+		total += session.CacheCreationTokens
+	}
+	if tc.CacheRead {
+		total += session.CacheReadTokens
+	}
+	return total
+}
+
+// Plan limits a session's usage can be bound by
+const (
+	LimitBoundTokens   = "tokens"
+	LimitBoundCost     = "cost"
+	LimitBoundMessages = "messages"
+)
+
 type MetricsCalculator struct {
-	planLimits pricing.Plan
+	planLimits      pricing.Plan
+	limitComponents TokenComponents
+	sessionDuration time.Duration
+	clock           util.Clock // Clock elapsed and remaining time are measured with
 }
 
 func NewMetricsCalculator(limits pricing.Plan) *MetricsCalculator {
 	return &MetricsCalculator{
-		planLimits: limits,
+		planLimits:      limits,
+		limitComponents: AllTokenComponents,
+		sessionDuration: constants.SessionDuration,
+		clock:           util.SystemClock,
 	}
 }
 
+// SetClock sets the clock elapsed and remaining time are measured with. Nil
+// restores the system clock.
+func (c *MetricsCalculator) SetClock(clock util.Clock) {
+	if clock == nil {
+		clock = util.SystemClock
+	}
+	c.clock = clock
+}
+
+// SetSessionDuration sets the window length the plan limits are spread over
+// when rating utilization. Zero restores the default of five hours.
+func (c *MetricsCalculator) SetSessionDuration(duration time.Duration) {
+	if duration <= 0 {
+		duration = constants.SessionDuration
+	}
+	c.sessionDuration = duration
+}
+
+// SetLimitTokenComponents selects which token types count toward the token
+// limit. The displayed session total is unaffected.
+func (c *MetricsCalculator) SetLimitTokenComponents(components TokenComponents) {
+	c.limitComponents = components
+}
+
 func (c *MetricsCalculator) Calculate(session *Session) {
 	if session == nil {
 		return
@@ -27,14 +137,168 @@ func (c *MetricsCalculator) Calculate(session *Session) {
 	})
 
 	// Calculate additional metrics based on plan limits
+	c.calculateTokenUsage(session)
 	c.calculateUtilizationRate(session)
 	c.calculateTimeToLimit(session)
+	c.calculateMessageUsage(session)
+	c.calculateLimitUsage(session)
+	c.calculatePacing(session, c.clock.Now().Unix())
+}
+
+// calculatePacing warns about active windows whose opening burst would use up
+// the token limit well before the window resets, even when the current burn
+// rate has since slowed down
+func (c *MetricsCalculator) calculatePacing(session *Session, now int64) {
+	session.PacingLead = 0
+	if !session.IsActive || c.planLimits.TokenLimit <= 0 || session.ResetTime <= now {
+		return
+	}
+
+	start := session.StartTime
+	if session.WindowStartTime != nil {
+		start = *session.WindowStartTime
+	}
+	rate, ok := BurstTokensPerMinute(session.MinuteTokens, start, now)
+	if !ok || rate <= 0 {
+		return
+	}
+	// Only the counted share of the rate consumes the limit
+	if session.TotalTokens > 0 {
+		rate *= float64(session.LimitTokens) / float64(session.TotalTokens)
+	}
+	remainingTokens := c.planLimits.TokenLimit - session.LimitTokens
+	if remainingTokens <= 0 || rate <= 0 {
+		return
+	}
+
+	hitAt := now + int64(float64(remainingTokens)/rate*60)
+	if lead := time.Duration(session.ResetTime-hitAt) * time.Second; lead >= PacingWarningLead {
+		session.PacingLead = lead
+	}
+}
+
+// calculateLimitUsage records the highest share of any plan limit the session
+// has used and which limit that is. Sessions of plans without limits keep an
+// empty bound.
+func (c *MetricsCalculator) calculateLimitUsage(session *Session) {
+	session.LimitPercentage = 0
+	session.LimitBound = ""
+	if c.planLimits.TokenLimit > 0 {
+		session.LimitPercentage = session.TokenPercentage
+		session.LimitBound = LimitBoundTokens
+	}
+	if c.planLimits.CostLimit > 0 {
+		if percentage := session.TotalCost / c.planLimits.CostLimit * 100; session.LimitBound == "" || percentage > session.LimitPercentage {
+			session.LimitPercentage = percentage
+			session.LimitBound = LimitBoundCost
+		}
+	}
+	if c.planLimits.MessageLimit > 0 {
+		if session.LimitBound == "" || session.MessagePercentage > session.LimitPercentage {
+			session.LimitPercentage = session.MessagePercentage
+			session.LimitBound = LimitBoundMessages
+		}
+	}
+}
+
+// WeeklyCapWindow is the trailing period a plan's weekly token cap spans
+const WeeklyCapWindow = 7 * 24 * time.Hour
+
+// WeeklyUsage is the usage counted against a plan's rolling weekly token cap
+type WeeklyUsage struct {
+	Tokens     int
+	Limit      int
+	Percentage float64
+	ResetTime  int64 // When the oldest usage in the week ages out (0 = no usage)
+}
+
+// CalculateWeeklyUsage sums the tokens of all sessions used in the trailing
+// week ending at now. Unlike the window cap it spans sessions, so usage only
+// frees up as individual minutes age out of the week.
+func (c *MetricsCalculator) CalculateWeeklyUsage(sessions []*Session, now int64) WeeklyUsage {
+	usage := WeeklyUsage{Limit: c.planLimits.WeeklyTokenLimit}
+	cutoff := now - int64(WeeklyCapWindow.Seconds())
+	oldest := int64(0)
+
+	add := func(timestamp int64, tokens int) {
+		if timestamp <= cutoff || timestamp > now || tokens <= 0 {
+			return
+		}
+		usage.Tokens += tokens
+		if oldest == 0 || timestamp < oldest {
+			oldest = timestamp
+		}
+	}
+
+	for _, session := range sessions {
+		if session == nil || session.IsGap {
+			continue
+		}
+		if len(session.MinuteTokens) == 0 {
+			// Sessions restored without per-minute data count as a whole
+			add(session.StartTime, session.TotalTokens)
+			continue
+		}
+		for minute, tokens := range session.MinuteTokens {
+			add(minute, tokens)
+		}
+	}
+
+	if oldest > 0 {
+		usage.ResetTime = oldest + int64(WeeklyCapWindow.Seconds())
+	}
+	if usage.Limit > 0 {
+		usage.Percentage = float64(usage.Tokens) / float64(usage.Limit) * 100
+	}
+	return usage
+}
+
+// countMessages returns the number of messages that count toward the plan's
+// message limit according to its message count mode
+func (c *MetricsCalculator) countMessages(session *Session) int {
+	if c.planLimits.MessageCountMode == model.MessageCountAssistantTurns {
+		return session.MessageCount
+	}
+	return session.SentMessageCount
+}
+
+func (c *MetricsCalculator) calculateMessageUsage(session *Session) {
+	session.CountedMessages = c.countMessages(session)
+	if c.planLimits.MessageLimit <= 0 {
+		return
+	}
+
+	session.MessagePercentage = float64(session.CountedMessages) / float64(c.planLimits.MessageLimit) * 100
+	session.ProjectedMessages = session.CountedMessages
+
+	// Project the current message rate over the rest of the window
+	nowTimestamp := c.clock.Now().Unix()
+	elapsedMinutes := float64(nowTimestamp-session.StartTime) / 60.0
+	remainingMinutes := float64(session.EndTime-nowTimestamp) / 60.0
+	if elapsedMinutes > 0 && remainingMinutes > 0 {
+		messagesPerMinute := float64(session.CountedMessages) / elapsedMinutes
+		session.ProjectedMessages += int(messagesPerMinute * remainingMinutes)
+	}
+	if session.ProjectedMessages > c.planLimits.MessageLimit {
+		session.ProjectedMessages = c.planLimits.MessageLimit
+	}
+}
+
+// calculateTokenUsage derives the tokens counted toward the token limit and
+// their share of it
+func (c *MetricsCalculator) calculateTokenUsage(session *Session) {
+	session.LimitTokens = c.limitComponents.Count(session)
+	session.HasLimitTokens = true
+	session.TokenPercentage = 0
+	if c.planLimits.TokenLimit > 0 {
+		session.TokenPercentage = float64(session.LimitTokens) / float64(c.planLimits.TokenLimit) * 100
+	}
 }
 
 func (c *MetricsCalculator) calculateUtilizationRate(session *Session) {
 	// Calculate utilization rate based on elapsed time
 	startTime := time.Unix(session.StartTime, 0)
-	elapsed := time.Since(startTime)
+	elapsed := c.clock.Now().Sub(startTime)
 	if elapsed.Minutes() <= 0 {
 		return
 	}
@@ -42,14 +306,14 @@ func (c *MetricsCalculator) calculateUtilizationRate(session *Session) {
 	// Calculate actual vs expected usage
 	if c.planLimits.TokenLimit > 0 {
 		// Expected tokens per minute for full utilization
-		expectedTokensPerMinute := float64(c.planLimits.TokenLimit) / (5 * 60) // 5 hours
+		expectedTokensPerMinute := float64(c.planLimits.TokenLimit) / c.sessionDuration.Minutes()
 		utilizationRate := session.TokensPerMinute / expectedTokensPerMinute * 100
 
 		// Adjust burn rate based on utilization
 		session.BurnRate = session.TokensPerMinute * (utilizationRate / 100)
 	} else if c.planLimits.CostLimit > 0 {
 		// Expected cost per hour for full utilization
-		expectedCostPerHour := c.planLimits.CostLimit / 5 // 5 hours
+		expectedCostPerHour := c.planLimits.CostLimit / c.sessionDuration.Hours()
 		utilizationRate := session.CostPerHour / expectedCostPerHour * 100
 
 		// Adjust burn rate based on utilization
@@ -59,27 +323,34 @@ func (c *MetricsCalculator) calculateUtilizationRate(session *Session) {
 
 func (c *MetricsCalculator) calculateTimeToLimit(session *Session) {
 	// Calculate time remaining until hitting limits
-	if session.TokensPerMinute <= 0 && session.CostPerMinute <= 0 {
+	tokensPerMinute, costPerMinute := session.ProjectionRates()
+	if tokensPerMinute <= 0 && costPerMinute <= 0 {
 		return
 	}
 
-	nowTimestamp := time.Now().Unix()
+	nowTimestamp := c.clock.Now().Unix()
 	var predictedEndTimestamp int64
 
 	// Prioritize cost limit calculation for cost-based plans
-	if c.planLimits.CostLimit > 0 && session.CostPerMinute > 0 {
+	if c.planLimits.CostLimit > 0 && costPerMinute > 0 {
 		// Calculate based on cost limit
 		remainingCost := c.planLimits.CostLimit - session.TotalCost
 		if remainingCost > 0 {
-			minutesToLimit := remainingCost / session.CostPerMinute
+			minutesToLimit := remainingCost / costPerMinute
 			costEndTimestamp := nowTimestamp + int64(minutesToLimit*60)
 			predictedEndTimestamp = costEndTimestamp
 		}
-	} else if c.planLimits.TokenLimit > 0 && session.TokensPerMinute > 0 {
+	} else if c.planLimits.TokenLimit > 0 && tokensPerMinute > 0 {
 		// Fallback to token limit if no cost limit
-		remainingTokens := c.planLimits.TokenLimit - session.TotalTokens
-		if remainingTokens > 0 {
-			minutesToLimit := float64(remainingTokens) / session.TokensPerMinute
+		// Only the counted share of the token rate consumes the limit
+		limitTokens := c.limitComponents.Count(session)
+		limitTokensPerMinute := tokensPerMinute
+		if session.TotalTokens > 0 {
+			limitTokensPerMinute *= float64(limitTokens) / float64(session.TotalTokens)
+		}
+		remainingTokens := c.planLimits.TokenLimit - limitTokens
+		if remainingTokens > 0 && limitTokensPerMinute > 0 {
+			minutesToLimit := float64(remainingTokens) / limitTokensPerMinute
 			tokenEndTimestamp := nowTimestamp + int64(minutesToLimit*60)
 			predictedEndTimestamp = tokenEndTimestamp
 		}
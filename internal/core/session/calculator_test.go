@@ -6,6 +6,8 @@ import (
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
 	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
+	"github.com/penwyp/go-claude-monitor/internal/core/timeline"
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
 )
 
 func TestNewMetricsCalculator(t *testing.T) {
@@ -147,6 +149,311 @@ func TestCalculate(t *testing.T) {
 	}
 }
 
+func TestCalculateMessageLimitUsage(t *testing.T) {
+	start := time.Now().Add(-1 * time.Hour).Truncate(time.Minute)
+	at := func(minutes int) string {
+		return start.Add(time.Duration(minutes) * time.Minute).UTC().Format(time.RFC3339)
+	}
+	userPrompt := func(minutes int, text string) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryUser,
+			Timestamp: at(minutes),
+			Message: model.Message{
+				Role:    "user",
+				Content: model.FlexibleContent{{Type: "text", Text: text}},
+			},
+		}
+	}
+	toolResult := func(minutes int) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryUser,
+			Timestamp: at(minutes),
+			Message: model.Message{
+				Role:    "user",
+				Content: model.FlexibleContent{{Type: "tool_result", ToolUseId: "tool-1"}},
+			},
+		}
+	}
+	assistant := func(minutes int, requestId string) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryAssistant,
+			RequestId: requestId,
+			Timestamp: at(minutes),
+			Message: model.Message{
+				Id:    "msg-" + requestId,
+				Model: "claude-3-sonnet",
+				Role:  "assistant",
+				Usage: model.Usage{InputTokens: 100, OutputTokens: 50},
+			},
+		}
+	}
+
+	// Two typed prompts, one tool result, three assistant responses
+	logs := []model.ConversationLog{
+		userPrompt(1, "fix the build"),
+		assistant(2, "req-1"),
+		toolResult(3),
+		assistant(4, "req-2"),
+		userPrompt(10, "now add a test"),
+		assistant(11, "req-3"),
+	}
+
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	builder := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := builder.ConvertToTimestampedLogs(
+		builder.BuildFromHourlyData(agg.AggregateByHourAndModel(logs, "test-project")))
+
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", "/tmp")
+	newSession := func() *Session {
+		sess := &Session{
+			ID:                "message-session",
+			StartTime:         start.Unix(),
+			EndTime:           start.Add(5 * time.Hour).Unix(),
+			Projects:          make(map[string]*ProjectStats),
+			ModelDistribution: make(map[string]*model.ModelStats),
+		}
+		for _, tl := range globalTimeline {
+			detector.AddLogToSession(sess, tl)
+		}
+		return sess
+	}
+
+	t.Run("user_turns", func(t *testing.T) {
+		sess := newSession()
+		calc := NewMetricsCalculator(pricing.Plan{Name: "pro", MessageLimit: 4})
+		calc.Calculate(sess)
+
+		if sess.CountedMessages != 2 {
+			t.Errorf("Expected 2 counted user turns, got %d", sess.CountedMessages)
+		}
+		if sess.MessagePercentage != 50 {
+			t.Errorf("Expected 50%% of message limit, got %.1f%%", sess.MessagePercentage)
+		}
+		// 2 messages in the first hour projects to ~10 over the window, capped at the limit
+		if sess.ProjectedMessages != 4 {
+			t.Errorf("Expected projection capped at 4, got %d", sess.ProjectedMessages)
+		}
+	})
+
+	t.Run("assistant_turns", func(t *testing.T) {
+		sess := newSession()
+		calc := NewMetricsCalculator(pricing.Plan{
+			Name:             "custom",
+			MessageLimit:     4,
+			MessageCountMode: model.MessageCountAssistantTurns,
+		})
+		calc.Calculate(sess)
+
+		if sess.CountedMessages != 3 {
+			t.Errorf("Expected 3 counted assistant turns, got %d", sess.CountedMessages)
+		}
+		if sess.MessagePercentage != 75 {
+			t.Errorf("Expected 75%% of message limit, got %.1f%%", sess.MessagePercentage)
+		}
+	})
+
+	t.Run("no_message_limit", func(t *testing.T) {
+		sess := newSession()
+		NewMetricsCalculator(pricing.Plan{Name: "custom", TokenLimit: 1000}).Calculate(sess)
+
+		if sess.MessagePercentage != 0 || sess.ProjectedMessages != 0 {
+			t.Errorf("Expected no message usage without a limit, got %.1f%% / %d",
+				sess.MessagePercentage, sess.ProjectedMessages)
+		}
+	})
+}
+
+func TestCalculateLimitTokenComponents(t *testing.T) {
+	newSession := func() *Session {
+		return &Session{
+			ID:                  "cache-session",
+			StartTime:           time.Now().Add(-time.Hour).Unix(),
+			EndTime:             time.Now().Add(4 * time.Hour).Unix(),
+			TotalTokens:         10000,
+			InputTokens:         1000,
+			OutputTokens:        1000,
+			CacheCreationTokens: 6000,
+			CacheReadTokens:     2000,
+		}
+	}
+	plan := pricing.Plan{Name: "custom", TokenLimit: 20000}
+
+	withCache := newSession()
+	NewMetricsCalculator(plan).Calculate(withCache)
+	if withCache.LimitTokens != 10000 {
+		t.Errorf("Expected all 10000 tokens counted by default, got %d", withCache.LimitTokens)
+	}
+	if withCache.TokenPercentage != 50 {
+		t.Errorf("Expected 50%% of token limit with cache creation, got %.1f%%", withCache.TokenPercentage)
+	}
+
+	components, err := ParseTokenComponents("input,output,cache_read")
+	if err != nil {
+		t.Fatalf("ParseTokenComponents failed: %v", err)
+	}
+	if components.CacheCreation {
+		t.Fatal("Expected cache creation to be excluded")
+	}
+	withoutCache := newSession()
+	calc := NewMetricsCalculator(plan)
+	calc.SetLimitTokenComponents(components)
+	calc.Calculate(withoutCache)
+	if withoutCache.LimitTokens != 4000 {
+		t.Errorf("Expected 4000 tokens counted without cache creation, got %d", withoutCache.LimitTokens)
+	}
+	if withoutCache.TokenPercentage != 20 {
+		t.Errorf("Expected 20%% of token limit without cache creation, got %.1f%%", withoutCache.TokenPercentage)
+	}
+	if withoutCache.TotalTokens != 10000 {
+		t.Errorf("Expected displayed total to stay 10000, got %d", withoutCache.TotalTokens)
+	}
+
+	// A window that used only types left out counts nothing toward the limit
+	cacheOnly := &Session{
+		ID:                  "cache-only",
+		StartTime:           time.Now().Add(-time.Hour).Unix(),
+		EndTime:             time.Now().Add(4 * time.Hour).Unix(),
+		TotalTokens:         6000,
+		CacheCreationTokens: 6000,
+	}
+	calc.Calculate(cacheOnly)
+	if !cacheOnly.HasLimitTokens || cacheOnly.LimitTokens != 0 {
+		t.Errorf("Expected a calculated limit count of 0, got %d (calculated: %v)", cacheOnly.LimitTokens, cacheOnly.HasLimitTokens)
+	}
+
+	for _, spec := range []string{"", "input,cache", " , "} {
+		if _, err := ParseTokenComponents(spec); err == nil {
+			t.Errorf("Expected error for token components %q", spec)
+		}
+	}
+}
+
+func TestCalculateLimitBound(t *testing.T) {
+	newSession := func() *Session {
+		return &Session{
+			StartTime:   time.Now().Add(-time.Hour).Unix(),
+			EndTime:     time.Now().Add(4 * time.Hour).Unix(),
+			TotalTokens: 5000,
+			TotalCost:   6.0,
+		}
+	}
+
+	tokensOnly := newSession()
+	NewMetricsCalculator(pricing.Plan{TokenLimit: 20000}).Calculate(tokensOnly)
+	if tokensOnly.LimitBound != LimitBoundTokens || tokensOnly.LimitPercentage != 25 {
+		t.Errorf("Expected 25%% bound by tokens, got %.1f%% bound by %q", tokensOnly.LimitPercentage, tokensOnly.LimitBound)
+	}
+
+	// The limit closest to being reached binds the session
+	both := newSession()
+	NewMetricsCalculator(pricing.Plan{TokenLimit: 20000, CostLimit: 10}).Calculate(both)
+	if both.LimitBound != LimitBoundCost || both.LimitPercentage != 60 {
+		t.Errorf("Expected 60%% bound by cost, got %.1f%% bound by %q", both.LimitPercentage, both.LimitBound)
+	}
+
+	unlimited := newSession()
+	NewMetricsCalculator(pricing.Plan{}).Calculate(unlimited)
+	if unlimited.LimitBound != "" || unlimited.LimitPercentage != 0 {
+		t.Errorf("Expected no bound without limits, got %.1f%% bound by %q", unlimited.LimitPercentage, unlimited.LimitBound)
+	}
+}
+
+func TestCalculateWeeklyUsage(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC).Unix()
+	calc := NewMetricsCalculator(pricing.Plan{TokenLimit: 40000, WeeklyTokenLimit: 100000})
+
+	// One session a day for eight days, 10k tokens each over two minutes;
+	// the first one is more than a week old
+	var sessions []*Session
+	for day := 7; day >= 0; day-- {
+		start := now - int64(day)*86400 - 3600
+		sessions = append(sessions, &Session{
+			StartTime:    start,
+			EndTime:      start + 5*3600,
+			TotalTokens:  10000,
+			MinuteTokens: map[int64]int{start: 4000, start + 60: 6000},
+		})
+	}
+	sessions = append(sessions, &Session{IsGap: true, StartTime: now - 7200, TotalTokens: 5000})
+
+	weekly := calc.CalculateWeeklyUsage(sessions, now)
+	if weekly.Tokens != 70000 {
+		t.Errorf("Expected 70000 weekly tokens, got %d", weekly.Tokens)
+	}
+	if weekly.Limit != 100000 || weekly.Percentage != 70 {
+		t.Errorf("Expected 70%% of 100000, got %.1f%% of %d", weekly.Percentage, weekly.Limit)
+	}
+	oldest := now - 6*86400 - 3600
+	if expected := oldest + int64(WeeklyCapWindow.Seconds()); weekly.ResetTime != expected {
+		t.Errorf("Expected weekly reset at %d, got %d", expected, weekly.ResetTime)
+	}
+
+	// The window percentage only reflects the current session
+	current := sessions[len(sessions)-2]
+	calc.Calculate(current)
+	if current.TokenPercentage != 25 {
+		t.Errorf("Expected window token percentage 25, got %.1f", current.TokenPercentage)
+	}
+
+	// Without usage in the week nothing ages out
+	empty := calc.CalculateWeeklyUsage(sessions[:1], now)
+	if empty.Tokens != 0 || empty.ResetTime != 0 || empty.Percentage != 0 {
+		t.Errorf("Expected no weekly usage, got %+v", empty)
+	}
+}
+
+func TestCalculatePacing(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC).Unix()
+	start := now - 3600
+	calc := NewMetricsCalculator(pricing.Plan{TokenLimit: 1000000})
+
+	newSession := func(minuteTokens map[int64]int) *Session {
+		total := 0
+		for _, tokens := range minuteTokens {
+			total += tokens
+		}
+		return &Session{
+			IsActive:     true,
+			StartTime:    start,
+			ResetTime:    start + 5*3600,
+			TotalTokens:  total,
+			LimitTokens:  total,
+			MinuteTokens: minuteTokens,
+		}
+	}
+
+	// 200k tokens in the first 20 minutes, then nothing: at 6.7k/min the
+	// remaining 800k run out after two hours, two hours before reset
+	burst := make(map[int64]int)
+	for i := int64(0); i < 20; i++ {
+		burst[start+i*60] = 10000
+	}
+	front := newSession(burst)
+	calc.calculatePacing(front, now)
+	if front.PacingLead < 119*time.Minute || front.PacingLead > 121*time.Minute {
+		t.Errorf("Expected a pacing lead of about 2h, got %s", front.PacingLead)
+	}
+
+	// 2k tokens a minute lasts past the reset
+	steadyTokens := make(map[int64]int)
+	for i := int64(0); i < 60; i++ {
+		steadyTokens[start+i*60] = 2000
+	}
+	steady := newSession(steadyTokens)
+	calc.calculatePacing(steady, now)
+	if steady.PacingLead != 0 {
+		t.Errorf("Expected no pacing warning for steady usage, got %s", steady.PacingLead)
+	}
+
+	// The pace is not judged during the first minutes of a window
+	early := newSession(map[int64]int{now - 120: 500000})
+	early.StartTime = now - 120
+	calc.calculatePacing(early, now)
+	if early.PacingLead != 0 {
+		t.Errorf("Expected no pacing warning two minutes in, got %s", early.PacingLead)
+	}
+}
+
 func TestCalculateUtilizationRate(t *testing.T) {
 	tests := []struct {
 		name             string
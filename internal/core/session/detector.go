@@ -2,12 +2,12 @@ package session
 
 import (
 	"fmt"
-	"math"
 	"sort"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/constants"
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
 	"github.com/penwyp/go-claude-monitor/internal/core/session/internal"
 	"github.com/penwyp/go-claude-monitor/internal/core/timeline"
 	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
@@ -20,6 +20,47 @@ type SessionDetector struct {
 	aggregator      *aggregator.Aggregator // Add aggregator field for real-time cost calculation
 	limitParser     *LimitParser           // Parser for limit messages
 	windowHistory   *WindowHistoryManager  // Window history manager
+
+	// Phantom future window suppression
+	suppressFutureWindows bool // Drop sessions lying entirely in the future with no activity
+	suppressedCount       int  // Number of sessions dropped by the last detection
+
+	// Skip the synthetic active window when the current period has no logs
+	suppressSpeculativeActive bool
+
+	// Minimum idle period between sessions that produces a gap row (0 = session gap)
+	minGapDuration time.Duration
+
+	// Idle period after which activity starts a new window (0 = session duration)
+	sessionGap time.Duration
+
+	// Token mismatch found by the last detection, nil when totals agreed
+	lastDiscrepancy *TokenDiscrepancy
+
+	// Percentage difference between session and timeline totals tolerated
+	// before a mismatch is reported
+	tokenMismatchThreshold float64
+
+	// Window candidates and selection from the last detection
+	lastCandidates []WindowCandidate
+	lastSelected   []WindowCandidate
+
+	// Fixed time of day that continuous activity windows align to
+	hasWindowAnchor bool
+	windowAnchor    time.Duration // Offset from local midnight
+
+	// Timezone window boundaries and the window anchor are computed in, e.g.
+	// UTC or that of the server reset (nil = display timezone)
+	anchorLocation *time.Location
+
+	// Smoothing factor of the per-minute burn rate used for projections (0 = session average)
+	burnRateSmoothing float64
+
+	// Clock windows are measured against
+	clock util.Clock
+
+	// Called with every unexpired limit a detection finds (nil = none)
+	limitHandler func(LimitEvent)
 }
 
 // NewSessionDetectorWithAggregator creates a SessionDetector with a custom aggregator
@@ -36,11 +77,13 @@ func NewSessionDetectorWithAggregator(aggregator *aggregator.Aggregator, timezon
 	}
 	
 	return &SessionDetector{
-		sessionDuration: constants.SessionDuration,
-		timezone:        loc,
-		aggregator:      aggregator,
-		limitParser:     NewLimitParser(),
-		windowHistory:   windowHistory,
+		sessionDuration:        constants.SessionDuration,
+		timezone:               loc,
+		aggregator:             aggregator,
+		limitParser:            NewLimitParser(),
+		windowHistory:          windowHistory,
+		tokenMismatchThreshold: DefaultTokenMismatchThreshold,
+		clock:                  util.SystemClock,
 	}
 }
 
@@ -49,6 +92,218 @@ func (d *SessionDetector) GetWindowHistory() *WindowHistoryManager {
 	return d.windowHistory
 }
 
+// SetSuppressFutureWindows enables dropping sessions whose window lies entirely
+// in the future and has no activity (e.g. surfaced from cached window info)
+func (d *SessionDetector) SetSuppressFutureWindows(enabled bool) {
+	d.suppressFutureWindows = enabled
+}
+
+// SetSuppressSpeculativeActive stops active-window detection from creating a
+// window for the current time unless logs fall inside it
+func (d *SessionDetector) SetSuppressSpeculativeActive(enabled bool) {
+	d.suppressSpeculativeActive = enabled
+}
+
+// ParseWindowAnchor parses an "HH:MM" time of day into an offset from midnight
+func ParseWindowAnchor(anchor string) (time.Duration, error) {
+	t, err := time.Parse("15:04", anchor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window anchor '%s': must be HH:MM", anchor)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// SetWindowAnchor aligns continuous activity windows to a fixed "HH:MM" time of
+// day in the detector's timezone. An empty anchor restores hour alignment.
+// Limit-derived windows are unaffected.
+func (d *SessionDetector) SetWindowAnchor(anchor string) error {
+	if anchor == "" {
+		d.hasWindowAnchor = false
+		d.windowAnchor = 0
+		return nil
+	}
+	offset, err := ParseWindowAnchor(anchor)
+	if err != nil {
+		return err
+	}
+	d.hasWindowAnchor = true
+	d.windowAnchor = offset
+	return nil
+}
+
+// SetWindowAnchorTimezone computes window boundaries in the named timezone,
+// which the detector's timezone then only displays: windows start on its
+// hours and the window anchor is read in it, so windows can follow a server
+// reset at a fixed clock time elsewhere, or UTC hours. An empty name
+// restores the display timezone.
+func (d *SessionDetector) SetWindowAnchorTimezone(name string) error {
+	if name == "" {
+		d.anchorLocation = nil
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid window anchor timezone '%s': %w", name, err)
+	}
+	d.anchorLocation = loc
+	return nil
+}
+
+// windowLocation returns the location window boundaries are computed in
+func (d *SessionDetector) windowLocation() *time.Location {
+	if d.anchorLocation != nil {
+		return d.anchorLocation
+	}
+	return d.timezone
+}
+
+// truncateToHour returns the start of the hour containing timestamp on the
+// clock windows are computed in
+func (d *SessionDetector) truncateToHour(timestamp int64) int64 {
+	return internal.TruncateToHourIn(timestamp, d.windowLocation())
+}
+
+// alignWindowStart returns the start of the continuous activity window
+// containing timestamp
+func (d *SessionDetector) alignWindowStart(timestamp int64) int64 {
+	if !d.hasWindowAnchor {
+		return d.truncateToHour(timestamp)
+	}
+
+	// Step in whole windows from the anchor on the same day
+	loc := d.windowLocation()
+	t := time.Unix(timestamp, 0).In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	anchor := midnight.Add(d.windowAnchor).Unix()
+	step := int64(d.sessionDuration.Seconds())
+	if anchor > timestamp {
+		return anchor - ((anchor-timestamp+step-1)/step)*step
+	}
+	return anchor + ((timestamp-anchor)/step)*step
+}
+
+// GetTokenDiscrepancy returns the token mismatch detail from the last
+// detection, or nil if session and timeline totals agreed
+func (d *SessionDetector) GetTokenDiscrepancy() *TokenDiscrepancy {
+	return d.lastDiscrepancy
+}
+
+// SetTokenMismatchThreshold sets the percentage difference between session
+// and timeline totals above which a mismatch is reported. Zero reports any
+// difference.
+func (d *SessionDetector) SetTokenMismatchThreshold(percentage float64) {
+	d.tokenMismatchThreshold = percentage
+}
+
+// SetSessionDuration sets the length of every session window, for plans with a
+// different reset cadence. Zero restores the default of five hours.
+func (d *SessionDetector) SetSessionDuration(duration time.Duration) {
+	if duration <= 0 {
+		duration = constants.SessionDuration
+	}
+	d.sessionDuration = duration
+	if d.windowHistory != nil {
+		d.windowHistory.SetSessionDuration(duration)
+	}
+}
+
+// SessionDuration returns the length of a session window
+func (d *SessionDetector) SessionDuration() time.Duration {
+	return d.sessionDuration
+}
+
+// SetMinGapDuration sets the minimum idle period between sessions that is
+// reported as a gap session. Zero restores the default of one session gap.
+func (d *SessionDetector) SetMinGapDuration(minGap time.Duration) {
+	d.minGapDuration = minGap
+}
+
+// SetSessionGap sets the idle period after which activity starts a new window,
+// independently of the window length. A continuous activity window in which
+// activity resumes after such a gap ends at the hour the activity resumes.
+// Zero restores the default of one session duration.
+func (d *SessionDetector) SetSessionGap(gap time.Duration) {
+	d.sessionGap = gap
+}
+
+// sessionGapSeconds returns the idle period, in seconds, that starts a new window
+func (d *SessionDetector) sessionGapSeconds() int64 {
+	if d.sessionGap > 0 {
+		return int64(d.sessionGap.Seconds())
+	}
+	return int64(d.sessionDuration.Seconds())
+}
+
+// SetClock sets the clock detection measures windows against, e.g. to hold
+// the time of a refresh steady. Nil restores the system clock.
+func (d *SessionDetector) SetClock(clock util.Clock) {
+	if clock == nil {
+		clock = util.SystemClock
+	}
+	d.clock = clock
+	d.limitParser.SetClock(clock)
+	if d.windowHistory != nil {
+		d.windowHistory.SetClock(clock)
+	}
+}
+
+// now returns the current Unix time of the detection clock
+func (d *SessionDetector) now() int64 {
+	return d.clock.Now().Unix()
+}
+
+// SetLimitHandler sets a function called with every unexpired limit found by
+// a detection. The same limit is reported again by later detections, so the
+// handler deduplicates. It runs during detection and must not block.
+func (d *SessionDetector) SetLimitHandler(handler func(LimitEvent)) {
+	d.limitHandler = handler
+}
+
+// SetBurnRateSmoothing projects usage from an exponentially smoothed
+// per-minute token rate with the given alpha instead of the session average,
+// so an early burst weighs less. Zero restores the session average.
+func (d *SessionDetector) SetBurnRateSmoothing(alpha float64) {
+	d.burnRateSmoothing = alpha
+}
+
+// gapThreshold returns the minimum gap, in seconds, that produces a gap session
+func (d *SessionDetector) gapThreshold() int64 {
+	if d.minGapDuration > 0 {
+		return int64(d.minGapDuration.Seconds())
+	}
+	return d.sessionGapSeconds()
+}
+
+// GetSuppressedFutureWindowCount returns how many phantom future sessions the
+// last detection dropped
+func (d *SessionDetector) GetSuppressedFutureWindowCount() int {
+	return d.suppressedCount
+}
+
+// FilterFutureWindows removes sessions that start after nowTimestamp and have
+// no recorded activity. It is a no-op unless suppression is enabled.
+func (d *SessionDetector) FilterFutureWindows(sessions []*Session, nowTimestamp int64) []*Session {
+	d.suppressedCount = 0
+	if !d.suppressFutureWindows {
+		return sessions
+	}
+
+	filtered := make([]*Session, 0, len(sessions))
+	for _, s := range sessions {
+		if s.StartTime > nowTimestamp && s.MessageCount == 0 && s.TotalTokens == 0 {
+			util.LogInfo(fmt.Sprintf("Suppressing phantom future session %s (%s-%s, source: %s)",
+				s.ID,
+				time.Unix(s.StartTime, 0).Format("2006-01-02 15:04:05"),
+				time.Unix(s.EndTime, 0).Format("2006-01-02 15:04:05"),
+				s.WindowSource))
+			d.suppressedCount++
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
+
 // SessionDetectionInput contains all data needed for session detection
 type SessionDetectionInput struct {
 	CachedWindowInfo map[string]*WindowDetectionInfo // Cached window info by sessionId
@@ -68,11 +323,20 @@ type WindowCandidate struct {
 	Source    string
 	Priority  int  // Higher is better
 	IsLimit   bool // True if from limit message
+
+	// Set when activity resumed after a session gap ended the window before
+	// its full length
+	SplitByGap bool
+
+	// Set when window history moved the detected boundaries
+	AdjustedByHistory bool
+	OriginalStartTime int64 // Detected start before adjustment
+	OriginalEndTime   int64 // Detected end before adjustment
 }
 
 // detectSessionsFromGlobalTimeline detects sessions from a global timeline of logs
 func (d *SessionDetector) detectSessionsFromGlobalTimeline(input SessionDetectionInput) []*Session {
-	nowTimestamp := time.Now().Unix()
+	nowTimestamp := d.now()
 	
 	util.LogInfo(fmt.Sprintf("detectSessionsFromGlobalTimeline: Processing %d logs from global timeline", len(input.GlobalTimeline)))
 	
@@ -87,6 +351,8 @@ func (d *SessionDetector) detectSessionsFromGlobalTimeline(input SessionDetectio
 	// Step 2: Select best windows (non-overlapping, highest priority)
 	bestWindows := d.selectBestWindows(candidates)
 	util.LogInfo(fmt.Sprintf("Selected %d best windows from candidates", len(bestWindows)))
+	d.lastCandidates = candidates
+	d.lastSelected = bestWindows
 	
 	// Step 3: Create sessions for each window
 	sessions := make([]*Session, 0)
@@ -144,12 +410,20 @@ func (d *SessionDetector) detectSessionsFromGlobalTimeline(input SessionDetectio
 	
 	// Mark active sessions
 	d.markActiveSessions(sessions, nowTimestamp)
+	markSpeculativeSessions(sessions, nowTimestamp)
+
+	// Drop phantom sessions for windows that haven't started yet
+	sessions = d.FilterFutureWindows(sessions, nowTimestamp)
 	
 	// Sort by start time (most recent first)
 	sort.Slice(sessions, func(i, j int) bool {
 		return sessions[i].StartTime > sessions[j].StartTime
 	})
 	
+	if OnlyGaps(sessions) {
+		util.LogInfo(fmt.Sprintf("Session detection found only %d idle gaps and no usage", len(sessions)))
+	}
+
 	// Validate token counts
 	var totalSessionTokens int64
 	var timelineTokens int64
@@ -169,17 +443,29 @@ func (d *SessionDetector) detectSessionsFromGlobalTimeline(input SessionDetectio
 	util.LogInfo(fmt.Sprintf("Token validation: Sessions=%d tokens, Timeline=%d tokens (%d entries, %d synthetic)",
 		totalSessionTokens, timelineTokens, len(input.GlobalTimeline), syntheticCount))
 	
-	if totalSessionTokens != timelineTokens && timelineTokens > 0 {
-		discrepancy := float64(totalSessionTokens-timelineTokens) / float64(timelineTokens) * 100
-		if math.Abs(discrepancy) > 1 { // Only warn if discrepancy is more than 1%
-			util.LogWarn(fmt.Sprintf("Token count mismatch: %.1f%% difference (Sessions=%d, Timeline=%d)",
-				discrepancy, totalSessionTokens, timelineTokens))
-		}
+	d.lastDiscrepancy = analyzeTokenDiscrepancy(sessions, input.GlobalTimeline, d.tokenMismatchThreshold)
+	if d.lastDiscrepancy != nil {
+		logTokenDiscrepancy(d.lastDiscrepancy)
 	}
 	
 	return sessions
 }
 
+// projectsActiveBetween returns the sorted projects of timeline entries in
+// [start, end)
+func projectsActiveBetween(entries []timeline.TimestampedLog, start, end int64) []string {
+	seen := make(map[string]bool)
+	var projects []string
+	for _, tl := range entries {
+		if tl.Timestamp >= start && tl.Timestamp < end && tl.ProjectName != "" && !seen[tl.ProjectName] {
+			seen[tl.ProjectName] = true
+			projects = append(projects, tl.ProjectName)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
 // collectWindowCandidates collects all potential session windows from various sources
 func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) []WindowCandidate {
 	candidates := make([]WindowCandidate, 0)
@@ -229,12 +515,12 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 		
 		// Separate unexpired from expired limits
 		unexpiredCount := 0
-		currentTime := time.Now().Unix()
+		currentTime := d.now()
 		
 		for _, limit := range limits {
 			if limit.ResetTime != nil {
 				windowStart := *limit.ResetTime - int64(d.sessionDuration.Seconds())
-				isUnexpired := limit.IsUnexpired()
+				isUnexpired := limit.IsUnexpiredAt(currentTime)
 				
 				// Give unexpired limits the highest priority
 				priority := 9
@@ -258,6 +544,15 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 				if d.windowHistory != nil {
 					d.windowHistory.UpdateFromLimitMessage(*limit.ResetTime, limit.Timestamp, limit.Content)
 				}
+
+				if isUnexpired && d.limitHandler != nil {
+					d.limitHandler(LimitEvent{
+						Type:       limit.Type,
+						ResetTime:  *limit.ResetTime,
+						DetectedAt: limit.Timestamp,
+						Projects:   projectsActiveBetween(input.GlobalTimeline, windowStart, *limit.ResetTime),
+					})
+				}
 			}
 		}
 		
@@ -272,8 +567,8 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 		firstActivity := input.GlobalTimeline[0].Timestamp
 		lastActivity := input.GlobalTimeline[len(input.GlobalTimeline)-1].Timestamp
 		
-		// Start from the first activity's hour boundary
-		currentWindowStart := internal.TruncateToHour(firstActivity)
+		// Start from the first activity's hour boundary, or the configured anchor
+		currentWindowStart := d.alignWindowStart(firstActivity)
 		
 		util.LogInfo(fmt.Sprintf("Generating strict 5-hour windows from %s to %s",
 			time.Unix(firstActivity, 0).Format("2006-01-02 15:04:05"),
@@ -281,6 +576,11 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 		
 		for currentWindowStart <= lastActivity {
 			windowEnd := currentWindowStart + int64(d.sessionDuration.Seconds())
+			splitByGap := false
+			if resumed := d.resumeAfterSessionGap(input.GlobalTimeline, currentWindowStart, windowEnd); resumed > 0 {
+				windowEnd = resumed
+				splitByGap = true
+			}
 			
 			// Check if this window period has any activity
 			hasActivity := false
@@ -293,11 +593,12 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 			
 			if hasActivity {
 				candidates = append(candidates, WindowCandidate{
-					StartTime: currentWindowStart,
-					EndTime:   windowEnd,
-					Source:    "continuous_activity",
-					Priority:  8, // Higher than gap(5) and first_message(3), lower than limit(9-10)
-					IsLimit:   false,
+					StartTime:  currentWindowStart,
+					EndTime:    windowEnd,
+					Source:     "continuous_activity",
+					Priority:   8, // Higher than gap(5) and first_message(3), lower than limit(9-10)
+					IsLimit:    false,
+					SplitByGap: splitByGap,
 				})
 				util.LogDebug(fmt.Sprintf("Added continuous_activity window: %s to %s",
 					time.Unix(currentWindowStart, 0).Format("2006-01-02 15:04:05"),
@@ -313,9 +614,9 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 	if len(input.GlobalTimeline) > 1 {
 		for i := 1; i < len(input.GlobalTimeline); i++ {
 			gap := input.GlobalTimeline[i].Timestamp - input.GlobalTimeline[i-1].Timestamp
-			if gap >= int64(d.sessionDuration.Seconds()) {
+			if gap >= d.sessionGapSeconds() {
 				// Gap detected, new window starts at current message
-				windowStart := internal.TruncateToHour(input.GlobalTimeline[i].Timestamp)
+				windowStart := d.truncateToHour(input.GlobalTimeline[i].Timestamp)
 				candidates = append(candidates, WindowCandidate{
 					StartTime: windowStart,
 					EndTime:   windowStart + int64(d.sessionDuration.Seconds()),
@@ -330,7 +631,7 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 	// Priority 5: First message
 	if len(input.GlobalTimeline) > 0 {
 		firstTimestamp := input.GlobalTimeline[0].Timestamp
-		windowStart := internal.TruncateToHour(firstTimestamp)
+		windowStart := d.truncateToHour(firstTimestamp)
 		candidates = append(candidates, WindowCandidate{
 			StartTime: windowStart,
 			EndTime:   windowStart + int64(d.sessionDuration.Seconds()),
@@ -343,7 +644,7 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 	}
 	
 	// Priority 6: Active window detection - add current window if within bounds
-	currentTime := time.Now().Unix()
+	currentTime := d.now()
 	
 	// Check if we should add an active window
 	// This happens when current time is not covered by any existing window candidates
@@ -365,35 +666,25 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 		var activeWindowEnd int64
 		foundAlignment := false
 		
-		// Look for the most recent window to align with
-		if len(candidates) > 0 {
-			// Find the most recent window end time
-			var mostRecentEnd int64
-			for _, candidate := range candidates {
-				if candidate.EndTime > mostRecentEnd && candidate.EndTime <= currentTime {
-					mostRecentEnd = candidate.EndTime
-				}
-			}
+		// Chain from the end of the latest contiguous window before now
+		if anchorEnd := activeWindowAnchor(candidates, currentTime); anchorEnd > 0 {
+			// Check if current time would be in the next window
+			nextWindowStart := anchorEnd
+			nextWindowEnd := nextWindowStart + int64(d.sessionDuration.Seconds())
 			
-			// If we found a recent window, check if current time would be in the next window
-			if mostRecentEnd > 0 {
-				nextWindowStart := mostRecentEnd
-				nextWindowEnd := nextWindowStart + int64(d.sessionDuration.Seconds())
-				
-				if currentTime >= nextWindowStart && currentTime < nextWindowEnd {
-					activeWindowStart = nextWindowStart
-					activeWindowEnd = nextWindowEnd
-					foundAlignment = true
-					util.LogInfo(fmt.Sprintf("Active window aligned with previous window end: %s to %s",
-						time.Unix(activeWindowStart, 0).Format("2006-01-02 15:04:05"),
-						time.Unix(activeWindowEnd, 0).Format("2006-01-02 15:04:05")))
-				}
+			if currentTime >= nextWindowStart && currentTime < nextWindowEnd {
+				activeWindowStart = nextWindowStart
+				activeWindowEnd = nextWindowEnd
+				foundAlignment = true
+				util.LogInfo(fmt.Sprintf("Active window aligned with previous window end: %s to %s",
+					time.Unix(activeWindowStart, 0).Format("2006-01-02 15:04:05"),
+					time.Unix(activeWindowEnd, 0).Format("2006-01-02 15:04:05")))
 			}
 		}
 		
 		// If no alignment found, create a new window starting at current hour
 		if !foundAlignment {
-			activeWindowStart = internal.TruncateToHour(currentTime)
+			activeWindowStart = d.truncateToHour(currentTime)
 			activeWindowEnd = activeWindowStart + int64(d.sessionDuration.Seconds())
 			
 			// Make sure current time is within this window
@@ -405,6 +696,12 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 			}
 		}
 		
+		// Without logs in the current period the window is only speculative
+		if foundAlignment && d.suppressSpeculativeActive && !hasLogsInRange(input.GlobalTimeline, activeWindowStart, currentTime) {
+			foundAlignment = false
+			util.LogDebug("Skipped speculative active_window candidate: no logs in current period")
+		}
+		
 		// Add the active window candidate if we found a valid window
 		if foundAlignment {
 			candidates = append(candidates, WindowCandidate{
@@ -422,6 +719,59 @@ func (d *SessionDetector) collectWindowCandidates(input SessionDetectionInput) [
 	return candidates
 }
 
+// activeWindowAnchor returns the end of the latest window that an active
+// window can follow contiguously, or 0 when no candidate ended by currentTime.
+// Candidates are walked in start order as a chain of non-overlapping windows;
+// of two overlapping candidates only the higher priority one, or the earlier
+// one on a tie, stays in the chain, as selection would keep only one of them.
+// The result therefore does not depend on the order of candidates.
+func activeWindowAnchor(candidates []WindowCandidate, currentTime int64) int64 {
+	var prior []WindowCandidate
+	for _, candidate := range candidates {
+		if candidate.EndTime <= currentTime {
+			prior = append(prior, candidate)
+		}
+	}
+	sort.Slice(prior, func(i, j int) bool {
+		if prior[i].StartTime != prior[j].StartTime {
+			return prior[i].StartTime < prior[j].StartTime
+		}
+		if prior[i].Priority != prior[j].Priority {
+			return prior[i].Priority > prior[j].Priority
+		}
+		if prior[i].EndTime != prior[j].EndTime {
+			return prior[i].EndTime < prior[j].EndTime
+		}
+		return prior[i].Source < prior[j].Source
+	})
+
+	var last *WindowCandidate
+	for i := range prior {
+		candidate := &prior[i]
+		if last != nil && candidate.StartTime < last.EndTime {
+			if candidate.Priority > last.Priority {
+				last = candidate
+			}
+			continue
+		}
+		last = candidate
+	}
+	if last == nil {
+		return 0
+	}
+	return last.EndTime
+}
+
+// hasLogsInRange reports whether any log falls within [start, end]
+func hasLogsInRange(logs []timeline.TimestampedLog, start, end int64) bool {
+	for _, entry := range logs {
+		if entry.Timestamp >= start && entry.Timestamp <= end {
+			return true
+		}
+	}
+	return false
+}
+
 // selectBestWindows selects the best non-overlapping windows from candidates
 func (d *SessionDetector) selectBestWindows(candidates []WindowCandidate) []WindowCandidate {
 	util.LogDebug(fmt.Sprintf("selectBestWindows: Processing %d candidates", len(candidates)))
@@ -429,7 +779,7 @@ func (d *SessionDetector) selectBestWindows(candidates []WindowCandidate) []Wind
 		return []WindowCandidate{}
 	}
 	
-	currentTime := time.Now().Unix()
+	currentTime := d.now()
 	
 	// Phase 1: Separate unexpired limit messages from other candidates
 	var unexpiredLimits []WindowCandidate
@@ -503,8 +853,8 @@ func (d *SessionDetector) selectBestWindows(candidates []WindowCandidate) []Wind
 			time.Unix(candidate.StartTime, 0).Format("2006-01-02 15:04:05"),
 			candidate.Source, candidate.Priority))
 		
-		// Ensure window is exactly 5 hours
-		if candidate.EndTime-candidate.StartTime != int64(d.sessionDuration.Seconds()) {
+		// Ensure window is exactly 5 hours, unless a session gap ended it early
+		if !candidate.SplitByGap && candidate.EndTime-candidate.StartTime != int64(d.sessionDuration.Seconds()) {
 			candidate.EndTime = candidate.StartTime + int64(d.sessionDuration.Seconds())
 		}
 		
@@ -544,11 +894,20 @@ func (d *SessionDetector) selectBestWindows(candidates []WindowCandidate) []Wind
 					selected = append(selected, candidate)
 				} else {
 					validStart, validEnd, isValid := d.windowHistory.ValidateNewWindow(candidate.StartTime, candidate.EndTime)
-					if isValid {
-						if validStart != candidate.StartTime || validEnd != candidate.EndTime {
+					adjusted := validStart != candidate.StartTime || validEnd != candidate.EndTime
+					if isValid && adjusted && overlapsWindows(validStart, validEnd, selected) {
+						util.LogWarn(fmt.Sprintf("Window rejected by history: adjusted window %s-%s overlaps a selected window (source: %s)",
+							time.Unix(validStart, 0).Format("15:04:05"),
+							time.Unix(validEnd, 0).Format("15:04:05"),
+							candidate.Source))
+					} else if isValid {
+						if adjusted {
 							util.LogInfo(fmt.Sprintf("Window adjusted by history: %s->%s",
 								time.Unix(candidate.StartTime, 0).Format("15:04:05"),
 								time.Unix(validStart, 0).Format("15:04:05")))
+							candidate.AdjustedByHistory = true
+							candidate.OriginalStartTime = candidate.StartTime
+							candidate.OriginalEndTime = candidate.EndTime
 							candidate.StartTime = validStart
 							candidate.EndTime = validEnd
 						}
@@ -582,6 +941,40 @@ func (d *SessionDetector) selectBestWindows(candidates []WindowCandidate) []Wind
 	return selected
 }
 
+// resumeAfterSessionGap returns the hour at which activity resumes inside
+// start-end after an idle period of at least the session gap, or 0 if it does
+// not. Resumptions within the hour of the previous activity cannot start a new
+// window and are ignored.
+func (d *SessionDetector) resumeAfterSessionGap(entries []timeline.TimestampedLog, start, end int64) int64 {
+	gap := d.sessionGapSeconds()
+	var prev int64
+	for _, tl := range entries {
+		if tl.Timestamp < start {
+			continue
+		}
+		if tl.Timestamp >= end {
+			break
+		}
+		if prev > 0 && tl.Timestamp-prev >= gap {
+			if resumed := d.truncateToHour(tl.Timestamp); resumed > prev {
+				return resumed
+			}
+		}
+		prev = tl.Timestamp
+	}
+	return 0
+}
+
+// overlapsWindows reports whether start-end overlaps any of the windows
+func overlapsWindows(start, end int64, windows []WindowCandidate) bool {
+	for _, w := range windows {
+		if start < w.EndTime && end > w.StartTime {
+			return true
+		}
+	}
+	return false
+}
+
 // createSessionForWindow creates a session for a specific window
 func (d *SessionDetector) createSessionForWindow(window WindowCandidate, defaultProject string) *Session {
 	sessionID := fmt.Sprintf("%d", window.StartTime)
@@ -608,24 +1001,29 @@ func (d *SessionDetector) createSessionForWindow(window WindowCandidate, default
 	return &Session{
 		ID:                sessionID,
 		StartTime:         window.StartTime,
-		StartHour:         internal.TruncateToHour(window.StartTime),
+		StartHour:         d.truncateToHour(window.StartTime),
 		EndTime:           window.EndTime,
 		IsActive:          false,
 		IsGap:             window.Source == "gap",
 		ProjectName:       "", // Will be set in finalizeSession
 		Projects:          make(map[string]*ProjectStats),
 		ModelDistribution: make(map[string]*model.ModelStats),
+		TierDistribution:  make(map[string]*model.TierStats),
 		PerModelStats:     make(map[string]map[string]interface{}),
 		HourlyMetrics:     make([]*model.HourlyMetric, 0),
 		LimitMessages:     make([]map[string]interface{}, 0),
 		ProjectionData:    make(map[string]interface{}),
 		SentMessageCount:  0,
 		// Window fields
-		WindowStartTime:  &window.StartTime,
-		IsWindowDetected: true, // All windows are now explicitly detected
-		WindowSource:     window.Source,
-		ResetTime:        window.EndTime,
-		PredictedEndTime: window.EndTime,
+		WindowStartTime:     &window.StartTime,
+		IsWindowDetected:    true, // All windows are now explicitly detected
+		WindowSource:        window.Source,
+		ResetTime:           window.EndTime,
+		PredictedEndTime:    window.EndTime,
+		DetectionConfidence: DetectionConfidence(window.Source, window.IsLimit),
+		AdjustedByHistory:   window.AdjustedByHistory,
+		OriginalStartTime:   window.OriginalStartTime,
+		OriginalEndTime:     window.OriginalEndTime,
 	}
 }
 
@@ -661,17 +1059,49 @@ func (d *SessionDetector) AddLogToSession(session *Session, tl timeline.Timestam
 	if session.ActualEndTime == nil || tl.Timestamp > *session.ActualEndTime {
 		session.ActualEndTime = &tl.Timestamp
 	}
+
+	// Count user prompts (tool results are excluded when the timeline is built)
+	projectStats.SentMessageCount += tl.UserTurns
+	session.SentMessageCount += tl.UserTurns
+	projectStats.TurnaroundSeconds += tl.TurnaroundSeconds
+	projectStats.TurnaroundCount += tl.TurnaroundCount
+	session.TurnaroundSeconds += tl.TurnaroundSeconds
+	session.TurnaroundCount += tl.TurnaroundCount
+	projectStats.ThinkingOutputTokens += tl.ThinkingOutputTokens
+	session.ThinkingOutputTokens += tl.ThinkingOutputTokens
 	
 	// Process the log message if it has usage data
 	usage := tl.Log.Message.Usage
 	// Include all token types: input, output, cache creation, and cache read
 	totalTokens := internal.CalculateTotalTokens(usage)
+
+	// Record which branches and directories the work happened in
+	if tl.Log.GitBranch != "" {
+		tl.GitBranches = map[string]int{tl.Log.GitBranch: totalTokens}
+	}
+	if tl.Log.Cwd != "" {
+		tl.Cwds = map[string]int{tl.Log.Cwd: totalTokens}
+	}
+	session.GitBranches = mergeCounts(session.GitBranches, tl.GitBranches)
+	session.WorkingDirs = mergeCounts(session.WorkingDirs, tl.Cwds)
+	if tl.Log.SessionId != "" {
+		if session.Conversations == nil {
+			session.Conversations = make(map[string]int)
+		}
+		session.Conversations[tl.Log.SessionId] += totalTokens
+	}
 	if totalTokens > 0 {
 		modelName := util.SimplifyModelName(tl.Log.Message.Model)
+
+		// Synthetic entries from hourly data stand for several messages
+		messages := 1
+		if tl.Messages > 0 {
+			messages = tl.Messages
+		}
 		
 		// Update project stats
 		projectStats.TotalTokens += totalTokens
-		projectStats.MessageCount++
+		projectStats.MessageCount += messages
 		if tl.Log.Type == "message:sent" {
 			projectStats.SentMessageCount++
 		}
@@ -688,6 +1118,7 @@ func (d *SessionDetector) AddLogToSession(session *Session, tl timeline.Timestam
 		if d.aggregator != nil {
 			hourlyData := &aggregator.HourlyData{
 				Model:         tl.Log.Message.Model,
+				ServiceTier:   usage.ServiceTier,
 				InputTokens:   usage.InputTokens,
 				OutputTokens:  usage.OutputTokens,
 				CacheCreation: usage.CacheCreationInputTokens,
@@ -700,8 +1131,16 @@ func (d *SessionDetector) AddLogToSession(session *Session, tl timeline.Timestam
 		
 		// Update session-level stats
 		session.TotalTokens += totalTokens
+		if session.MinuteTokens == nil {
+			session.MinuteTokens = make(map[int64]int)
+		}
+		session.MinuteTokens[tl.Timestamp-tl.Timestamp%60] += totalTokens
+		session.InputTokens += usage.InputTokens
+		session.OutputTokens += usage.OutputTokens
+		session.CacheCreationTokens += usage.CacheCreationInputTokens
+		session.CacheReadTokens += usage.CacheReadInputTokens
 		session.TotalCost += cost
-		session.MessageCount++
+		session.MessageCount += messages
 		if tl.Log.Type == "message:sent" {
 			session.SentMessageCount++
 		}
@@ -712,6 +1151,19 @@ func (d *SessionDetector) AddLogToSession(session *Session, tl timeline.Timestam
 		}
 		sessionModelStats := session.ModelDistribution[modelName]
 		internal.UpdateModelStats(sessionModelStats, totalTokens, cost)
+
+		// Update session service tier distribution
+		tier := pricing.NormalizeServiceTier(usage.ServiceTier)
+		if session.TierDistribution == nil {
+			session.TierDistribution = make(map[string]*model.TierStats)
+		}
+		if _, ok := session.TierDistribution[tier]; !ok {
+			session.TierDistribution[tier] = &model.TierStats{Tier: tier}
+		}
+		tierStats := session.TierDistribution[tier]
+		tierStats.Tokens += totalTokens
+		tierStats.Cost += cost
+		tierStats.Count += messages
 	}
 }
 
@@ -719,6 +1171,20 @@ func (d *SessionDetector) AddLogToSession(session *Session, tl timeline.Timestam
 
 
 
+// mergeCounts adds src into dst, allocating dst when needed
+func mergeCounts(dst, src map[string]int) map[string]int {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]int, len(src))
+	}
+	for key, count := range src {
+		dst[key] += count
+	}
+	return dst
+}
+
 // CalculateMetrics calculates metrics for a session
 func (d *SessionDetector) CalculateMetrics(session *Session, nowTimestamp int64) {
 	// For burn rate calculation, prefer using WindowStartTime for detected windows
@@ -785,6 +1251,14 @@ func (d *SessionDetector) CalculateMetrics(session *Session, nowTimestamp int64)
 		}
 	}
 
+	// Smooth the rate used for projections so early bursts fade out
+	session.SmoothedTokensPerMinute = nil
+	if d.burnRateSmoothing > 0 && elapsedMinutes > 0 {
+		if rate, ok := SmoothedTokensPerMinute(session.MinuteTokens, startTimeForCalc, nowTimestamp, d.burnRateSmoothing); ok {
+			session.SmoothedTokensPerMinute = &rate
+		}
+	}
+
 	// Calculate burn rate (last hour)
 	session.BurnRate = d.calculateBurnRate(session, nowTimestamp)
 
@@ -815,12 +1289,12 @@ func (d *SessionDetector) CalculateMetrics(session *Session, nowTimestamp int64)
 		session.ActualEndTime))
 
 	// Projections
-	if session.TokensPerMinute > 0 {
+	if tokensPerMinute, costPerMinute := session.ProjectionRates(); tokensPerMinute > 0 {
 		remainingMinutes := float64(session.EndTime-nowTimestamp) / 60.0
 		session.ProjectedTokens = session.TotalTokens +
-			int(session.TokensPerMinute*remainingMinutes)
+			int(tokensPerMinute*remainingMinutes)
 		session.ProjectedCost = session.TotalCost +
-			(session.CostPerHour * (remainingMinutes / 60.0))
+			(costPerMinute * remainingMinutes)
 		
 		util.LogDebug(fmt.Sprintf("Session %s - Projections: RemainingMinutes=%.2f, CurrentTokens=%d, ProjectedTokens=%d, CurrentCost=%.2f, ProjectedCost=%.2f",
 			session.ID,
@@ -830,6 +1304,59 @@ func (d *SessionDetector) CalculateMetrics(session *Session, nowTimestamp int64)
 			session.TotalCost,
 			session.ProjectedCost))
 	}
+
+	// Linear projections made early in a window are unreliable
+	elapsedFraction := elapsed / duration
+	if elapsedFraction > 1 {
+		elapsedFraction = 1
+	}
+	if session.ProjectionData == nil {
+		session.ProjectionData = make(map[string]interface{})
+	}
+	session.ProjectionData["elapsed_fraction"] = elapsedFraction
+	session.ProjectionData["confidence"] = ProjectionConfidence(elapsedFraction)
+}
+
+// DetectionConfidence rates how reliably a window from the given source
+// reflects the real rate-limit window. limitBacked reports whether a limit
+// message, live or recorded in history, determined the window.
+func DetectionConfidence(source string, limitBacked bool) string {
+	if limitBacked {
+		return model.DetectionConfidenceHigh
+	}
+	switch source {
+	case "limit_message", "history_limit":
+		return model.DetectionConfidenceHigh
+	case "continuous_activity", "history_account":
+		return model.DetectionConfidenceMedium
+	case "active_window":
+		return model.DetectionConfidenceSpeculative
+	default:
+		return model.DetectionConfidenceLow
+	}
+}
+
+// markSpeculativeSessions downgrades sessions that start after nowTimestamp
+// without any activity, e.g. windows surfaced from cached history
+func markSpeculativeSessions(sessions []*Session, nowTimestamp int64) {
+	for _, s := range sessions {
+		if !s.IsGap && s.StartTime > nowTimestamp && s.MessageCount == 0 && s.TotalTokens == 0 {
+			s.DetectionConfidence = model.DetectionConfidenceSpeculative
+		}
+	}
+}
+
+// ProjectionConfidence rates how far a linear projection can be trusted given
+// the fraction of the session window that has elapsed
+func ProjectionConfidence(elapsedFraction float64) string {
+	switch {
+	case elapsedFraction < 0.2:
+		return model.ProjectionConfidenceLow
+	case elapsedFraction < 0.6:
+		return model.ProjectionConfidenceMedium
+	default:
+		return model.ProjectionConfidenceHigh
+	}
 }
 
 func (d *SessionDetector) calculateBurnRate(session *Session, nowTimestamp int64) float64 {
@@ -927,13 +1454,13 @@ func (d *SessionDetector) insertGapSessions(sessions []*Session) []*Session {
 		if prevSession.ActualEndTime != nil {
 			gapDuration := currSession.StartTime - *prevSession.ActualEndTime
 
-			if gapDuration >= int64(d.sessionDuration.Seconds()) {
+			if gapDuration >= d.gapThreshold() {
 				// Create gap session
 				gapID := fmt.Sprintf("gap-%d", *prevSession.ActualEndTime)
 				gapSession := &Session{
 					ID:                gapID,
 					StartTime:         *prevSession.ActualEndTime,
-					StartHour:         internal.TruncateToHour(*prevSession.ActualEndTime),
+					StartHour:         d.truncateToHour(*prevSession.ActualEndTime),
 					EndTime:           currSession.StartTime,
 					ActualEndTime:     nil,
 					IsGap:             true,
@@ -1000,6 +1527,9 @@ func (d *SessionDetector) deduplicateSessions(sessions []*Session) []*Session {
 					existingProject.TotalCost += projectStats.TotalCost
 					existingProject.MessageCount += projectStats.MessageCount
 					existingProject.SentMessageCount += projectStats.SentMessageCount
+					existingProject.TurnaroundSeconds += projectStats.TurnaroundSeconds
+					existingProject.TurnaroundCount += projectStats.TurnaroundCount
+					existingProject.ThinkingOutputTokens += projectStats.ThinkingOutputTokens
 					
 					// Update time bounds
 					if projectStats.FirstEntryTime < existingProject.FirstEntryTime {
@@ -1027,9 +1557,40 @@ func (d *SessionDetector) deduplicateSessions(sessions []*Session) []*Session {
 			
 			// Update session totals
 			existing.TotalTokens += session.TotalTokens
+			existing.InputTokens += session.InputTokens
+			existing.OutputTokens += session.OutputTokens
+			existing.CacheCreationTokens += session.CacheCreationTokens
+			existing.CacheReadTokens += session.CacheReadTokens
 			existing.TotalCost += session.TotalCost
 			existing.MessageCount += session.MessageCount
 			existing.SentMessageCount += session.SentMessageCount
+			existing.TurnaroundSeconds += session.TurnaroundSeconds
+			existing.TurnaroundCount += session.TurnaroundCount
+			existing.ThinkingOutputTokens += session.ThinkingOutputTokens
+
+			existing.GitBranches = mergeCounts(existing.GitBranches, session.GitBranches)
+			existing.WorkingDirs = mergeCounts(existing.WorkingDirs, session.WorkingDirs)
+			existing.Conversations = mergeCounts(existing.Conversations, session.Conversations)
+			for minute, tokens := range session.MinuteTokens {
+				if existing.MinuteTokens == nil {
+					existing.MinuteTokens = make(map[int64]int)
+				}
+				existing.MinuteTokens[minute] += tokens
+			}
+
+			// Merge service tier distributions
+			for tier, stats := range session.TierDistribution {
+				if existing.TierDistribution == nil {
+					existing.TierDistribution = make(map[string]*model.TierStats)
+				}
+				if existingStats, ok := existing.TierDistribution[tier]; ok {
+					existingStats.Tokens += stats.Tokens
+					existingStats.Cost += stats.Cost
+					existingStats.Count += stats.Count
+				} else {
+					existing.TierDistribution[tier] = stats
+				}
+			}
 			
 			// Keep the better window detection
 			if session.IsWindowDetected && !existing.IsWindowDetected ||
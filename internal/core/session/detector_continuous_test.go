@@ -224,4 +224,53 @@ func TestBoundaryActivityAssignment(t *testing.T) {
 	// Activity at 15:00:00 should go to second window (>= startTime rule)
 	// First window: 10:00-15:00, should have activities at 10:00:00 and 14:59:59
 	// Second window: 15:00-20:00, should have activities at 15:00:00 and 19:59:59
-}
\ No newline at end of file
+}
+// TestContinuousActivityWithConfiguredDuration tests that a configured session
+// duration drives window boundaries, reset times and the gap threshold
+func TestContinuousActivityWithConfiguredDuration(t *testing.T) {
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", t.TempDir())
+	detector.SetSessionDuration(3 * time.Hour)
+	assert.Equal(t, 3*time.Hour, detector.SessionDuration())
+
+	parseTime := func(timeStr string) int64 {
+		t, _ := time.Parse("2006-01-02 15:04:05", timeStr)
+		return t.Unix()
+	}
+
+	// Continuous activity from 08:30 to 13:30, then a 4 hour pause
+	timeline := []timeline.TimestampedLog{
+		{Timestamp: parseTime("2025-08-07 08:30:00"), ProjectName: "test"},
+		{Timestamp: parseTime("2025-08-07 10:00:00"), ProjectName: "test"},
+		{Timestamp: parseTime("2025-08-07 11:30:00"), ProjectName: "test"},
+		{Timestamp: parseTime("2025-08-07 13:30:00"), ProjectName: "test"},
+		{Timestamp: parseTime("2025-08-07 18:15:00"), ProjectName: "test"},
+	}
+	for i := range timeline {
+		timeline[i].Log = model.ConversationLog{Type: "user"}
+	}
+
+	sessions := detector.DetectSessionsWithLimits(SessionDetectionInput{GlobalTimeline: timeline})
+
+	windows := make(map[int64]*Session)
+	gaps := 0
+	for _, s := range sessions {
+		if s.IsGap {
+			gaps++
+			continue
+		}
+		windows[s.StartTime] = s
+	}
+
+	// 08:00-11:00, 11:00-14:00 and, after the gap, 17:00-20:00 on the same 3 hour grid
+	for _, start := range []string{"2025-08-07 08:00:00", "2025-08-07 11:00:00", "2025-08-07 17:00:00"} {
+		s, ok := windows[parseTime(start)]
+		if !assert.True(t, ok, "Should have a window starting at %s", start) {
+			continue
+		}
+		assert.Equal(t, parseTime(start)+3*3600, s.EndTime, "Window starting at %s should last 3 hours", start)
+		assert.Equal(t, s.EndTime, s.ResetTime, "Window starting at %s should reset at its end", start)
+	}
+	assert.Len(t, windows, 3)
+	assert.Equal(t, 1, gaps, "The 4 hour pause exceeds the 3 hour gap threshold")
+}
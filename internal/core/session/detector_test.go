@@ -1,12 +1,17 @@
 package session
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
 	"github.com/penwyp/go-claude-monitor/internal/core/timeline"
 	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
 func TestSlidingWindowDetection(t *testing.T) {
@@ -220,3 +225,1149 @@ func TestGapDetection(t *testing.T) {
 		t.Errorf("Expected gap window source, got %s", gapTriggeredSession.WindowSource)
 	}
 }
+
+func TestAddLogToSessionServiceTiers(t *testing.T) {
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", "/tmp")
+
+	baseTime := time.Now().UTC().Add(-time.Hour).Unix()
+	sess := &Session{
+		ID:                "tier-session",
+		StartTime:         baseTime,
+		EndTime:           baseTime + 5*3600,
+		Projects:          make(map[string]*ProjectStats),
+		ModelDistribution: make(map[string]*model.ModelStats),
+	}
+
+	addLog := func(offset int64, tier string) {
+		detector.AddLogToSession(sess, timeline.TimestampedLog{
+			Timestamp:   baseTime + offset,
+			ProjectName: "test-project",
+			Log: model.ConversationLog{
+				Type: "assistant",
+				Message: model.Message{
+					Model: "claude-3-sonnet",
+					Usage: model.Usage{
+						InputTokens:  1000,
+						OutputTokens: 500,
+						ServiceTier:  tier,
+					},
+				},
+			},
+		})
+	}
+	addLog(60, "standard")
+	addLog(120, model.ServiceTierBatch)
+	addLog(180, model.ServiceTierBatch)
+
+	if len(sess.TierDistribution) != 2 {
+		t.Fatalf("Expected 2 service tiers, got %d", len(sess.TierDistribution))
+	}
+	standard := sess.TierDistribution[model.ServiceTierStandard]
+	batch := sess.TierDistribution[model.ServiceTierBatch]
+	if standard.Tokens != 1500 || standard.Count != 1 {
+		t.Errorf("Unexpected standard tier stats: %+v", standard)
+	}
+	if batch.Tokens != 3000 || batch.Count != 2 {
+		t.Errorf("Unexpected batch tier stats: %+v", batch)
+	}
+
+	// Batch costs half the standard rate per token
+	if diff := batch.Cost - standard.Cost; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected two batch requests to cost the same as one standard request, got %f vs %f",
+			batch.Cost, standard.Cost)
+	}
+	if diff := sess.TotalCost - (standard.Cost + batch.Cost); diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Tier costs %f + %f do not add up to session cost %f", standard.Cost, batch.Cost, sess.TotalCost)
+	}
+}
+
+func TestSuppressPhantomFutureWindows(t *testing.T) {
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", "/tmp")
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+	detector.SetSuppressFutureWindows(true)
+
+	now := time.Now().Unix()
+	pastStart := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour).Unix()
+
+	// A future window left behind by an earlier run, with no logs in it
+	futureStart := now + 3600
+	detector.windowHistory.history.Windows = append(detector.windowHistory.history.Windows, WindowRecord{
+		SessionID:      "phantom",
+		Source:         "first_message",
+		StartTime:      futureStart,
+		EndTime:        futureStart + 5*3600,
+		IsAccountLevel: true,
+	})
+
+	hourlyData := []aggregator.HourlyData{
+		{
+			Hour:           pastStart,
+			FirstEntryTime: pastStart + 600,
+			LastEntryTime:  pastStart + 1800,
+			InputTokens:    800,
+			OutputTokens:   200,
+			TotalTokens:    1000,
+			MessageCount:   5,
+			ProjectName:    "test-project",
+		},
+	}
+	timelineBuilder := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := timelineBuilder.ConvertToTimestampedLogs(timelineBuilder.BuildFromHourlyData(hourlyData))
+
+	sessions := detector.DetectSessionsWithLimits(SessionDetectionInput{
+		GlobalTimeline:   globalTimeline,
+		CachedWindowInfo: make(map[string]*WindowDetectionInfo),
+	})
+
+	if len(sessions) == 0 {
+		t.Fatal("Expected the real session to be detected")
+	}
+	for _, sess := range sessions {
+		if sess.StartTime > now {
+			t.Errorf("Phantom future session %s should not be reported (start %s)",
+				sess.ID, time.Unix(sess.StartTime, 0).Format(time.RFC3339))
+		}
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		empty := &Session{ID: "future-empty", StartTime: futureStart, EndTime: futureStart + 5*3600}
+		active := &Session{ID: "future-active", StartTime: futureStart, EndTime: futureStart + 5*3600, TotalTokens: 10, MessageCount: 1}
+		current := &Session{ID: "current", StartTime: now - 600, EndTime: now + 4*3600}
+
+		filtered := detector.FilterFutureWindows([]*Session{empty, active, current}, now)
+		if len(filtered) != 2 || detector.GetSuppressedFutureWindowCount() != 1 {
+			t.Errorf("Expected only the empty future session to be dropped, got %d sessions, %d suppressed",
+				len(filtered), detector.GetSuppressedFutureWindowCount())
+		}
+
+		detector.SetSuppressFutureWindows(false)
+		if filtered := detector.FilterFutureWindows([]*Session{empty, current}, now); len(filtered) != 2 {
+			t.Errorf("Expected no filtering when suppression is disabled, got %d sessions", len(filtered))
+		}
+	})
+}
+
+func TestProjectionConfidenceRisesWithElapsedFraction(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+
+	now := time.Now().Unix()
+	windowSeconds := int64(5 * 3600)
+	const tokensPerMinute = 100
+
+	tests := []struct {
+		elapsedFraction float64
+		expected        string
+	}{
+		{0.1, model.ProjectionConfidenceLow},
+		{0.4, model.ProjectionConfidenceMedium},
+		{0.9, model.ProjectionConfidenceHigh},
+	}
+
+	var lastFraction float64
+	for _, tt := range tests {
+		elapsed := int64(tt.elapsedFraction * float64(windowSeconds))
+		sess := &Session{
+			StartTime:      now - elapsed,
+			EndTime:        now - elapsed + windowSeconds,
+			TotalTokens:    int(tokensPerMinute * elapsed / 60),
+			TotalCost:      float64(elapsed) / 3600,
+			ProjectionData: make(map[string]interface{}),
+		}
+
+		detector.CalculateMetrics(sess, now)
+
+		if sess.TokensPerMinute < tokensPerMinute-1 || sess.TokensPerMinute > tokensPerMinute+1 {
+			t.Fatalf("Expected fixed burn rate of %d tokens/min, got %.2f", tokensPerMinute, sess.TokensPerMinute)
+		}
+		if got := sess.ProjectionData["confidence"]; got != tt.expected {
+			t.Errorf("Elapsed fraction %.1f: expected confidence %s, got %v", tt.elapsedFraction, tt.expected, got)
+		}
+		fraction, ok := sess.ProjectionData["elapsed_fraction"].(float64)
+		if !ok || fraction <= lastFraction {
+			t.Errorf("Expected elapsed fraction to increase past %.2f, got %v", lastFraction, sess.ProjectionData["elapsed_fraction"])
+		}
+		lastFraction = fraction
+	}
+}
+
+func TestMinGapDurationHidesShortGaps(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+
+	firstStart := time.Now().Add(-12 * time.Hour).Truncate(time.Hour).Unix()
+	firstEnd := firstStart + 3600
+	// Idle just over one session duration
+	secondStart := firstEnd + int64(detector.sessionDuration.Seconds()) + 600
+
+	newSessions := func() []*Session {
+		return []*Session{
+			{ID: "first", StartTime: firstStart, EndTime: firstStart + 5*3600, ActualEndTime: &firstEnd},
+			{ID: "second", StartTime: secondStart, EndTime: secondStart + 5*3600},
+		}
+	}
+
+	countGaps := func(sessions []*Session) int {
+		gaps := 0
+		for _, s := range sessions {
+			if s.IsGap {
+				gaps++
+			}
+		}
+		return gaps
+	}
+
+	if gaps := countGaps(detector.insertGapSessions(newSessions())); gaps != 1 {
+		t.Errorf("Expected 1 gap session by default, got %d", gaps)
+	}
+
+	detector.SetMinGapDuration(8 * time.Hour)
+	if gaps := countGaps(detector.insertGapSessions(newSessions())); gaps != 0 {
+		t.Errorf("Expected gap to be hidden with raised threshold, got %d gap sessions", gaps)
+	}
+
+	detector.SetMinGapDuration(0)
+	if gaps := countGaps(detector.insertGapSessions(newSessions())); gaps != 1 {
+		t.Errorf("Expected default threshold to be restored, got %d gap sessions", gaps)
+	}
+}
+
+func TestSessionGapSplitsContinuousWindow(t *testing.T) {
+	base := time.Now().UTC().Add(-10 * time.Hour).Truncate(time.Hour).Unix()
+
+	// Activity at :30, then again 2.5 hours later
+	hourlyData := []aggregator.HourlyData{
+		{Hour: base, FirstEntryTime: base + 1800, LastEntryTime: base + 1800, Model: "claude-3-5-sonnet", InputTokens: 600, OutputTokens: 400, TotalTokens: 1000, MessageCount: 5, ProjectName: "test-project"},
+		{Hour: base + 3*3600, FirstEntryTime: base + 3*3600, LastEntryTime: base + 3*3600 + 600, Model: "claude-3-5-sonnet", InputTokens: 300, OutputTokens: 200, TotalTokens: 500, MessageCount: 3, ProjectName: "test-project"},
+	}
+	timelineBuilder := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := timelineBuilder.ConvertToTimestampedLogs(timelineBuilder.BuildFromHourlyData(hourlyData))
+
+	detect := func(gap time.Duration) []*Session {
+		detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+		detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+		detector.SetSessionGap(gap)
+		var withUsage []*Session
+		for _, sess := range detector.DetectSessionsWithLimits(SessionDetectionInput{GlobalTimeline: globalTimeline}) {
+			if !sess.IsGap && sess.TotalTokens > 0 {
+				withUsage = append(withUsage, sess)
+			}
+		}
+		sort.Slice(withUsage, func(i, j int) bool { return withUsage[i].StartTime < withUsage[j].StartTime })
+		return withUsage
+	}
+
+	// By default the gap is shorter than a session, so one window holds both
+	sessions := detect(0)
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session with the default gap, got %d", len(sessions))
+	}
+	if sessions[0].StartTime != base || sessions[0].TotalTokens != 1500 {
+		t.Errorf("Expected one window from %d with 1500 tokens, got %d with %d",
+			base, sessions[0].StartTime, sessions[0].TotalTokens)
+	}
+
+	// A 2-hour gap splits the activity at the hour it resumes
+	sessions = detect(2 * time.Hour)
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions with a 2h gap, got %d", len(sessions))
+	}
+	first, second := sessions[0], sessions[1]
+	if first.StartTime != base || first.EndTime != base+3*3600 || first.TotalTokens != 1000 {
+		t.Errorf("Expected first window %d-%d with 1000 tokens, got %d-%d with %d",
+			base, base+3*3600, first.StartTime, first.EndTime, first.TotalTokens)
+	}
+	if second.StartTime != base+3*3600 || second.EndTime != base+8*3600 || second.TotalTokens != 500 {
+		t.Errorf("Expected second window %d-%d with 500 tokens, got %d-%d with %d",
+			base+3*3600, base+8*3600, second.StartTime, second.EndTime, second.TotalTokens)
+	}
+
+	// A 3-hour gap is longer than the idle period, so nothing splits
+	if sessions = detect(3 * time.Hour); len(sessions) != 1 {
+		t.Errorf("Expected 1 session with a 3h gap, got %d", len(sessions))
+	}
+}
+
+func TestSessionGapSetsDefaultGapThreshold(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+
+	if got := detector.gapThreshold(); got != int64(detector.sessionDuration.Seconds()) {
+		t.Errorf("Expected the session duration by default, got %d", got)
+	}
+	detector.SetSessionGap(2 * time.Hour)
+	if got := detector.gapThreshold(); got != 2*3600 {
+		t.Errorf("Expected the session gap, got %d", got)
+	}
+	detector.SetMinGapDuration(4 * time.Hour)
+	if got := detector.gapThreshold(); got != 4*3600 {
+		t.Errorf("Expected the minimum gap to take precedence, got %d", got)
+	}
+}
+
+func TestWindowAnchorAlignsContinuousWindows(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+	if err := detector.SetWindowAnchor("09:30"); err != nil {
+		t.Fatalf("Unexpected error setting anchor: %v", err)
+	}
+
+	// Activity every 30 minutes for 12 hours, starting at 07:10 UTC two days ago
+	day := time.Now().UTC().AddDate(0, 0, -2)
+	first := time.Date(day.Year(), day.Month(), day.Day(), 7, 10, 0, 0, time.UTC).Unix()
+	var entries []timeline.TimestampedLog
+	for ts := first; ts < first+12*3600; ts += 1800 {
+		entries = append(entries, timeline.TimestampedLog{
+			Timestamp: ts,
+			Log: model.ConversationLog{
+				Type:    "synthetic",
+				Message: model.Message{Usage: model.Usage{InputTokens: 100}},
+			},
+		})
+	}
+
+	candidates := detector.collectWindowCandidates(SessionDetectionInput{GlobalTimeline: entries})
+
+	var starts []int64
+	for _, c := range candidates {
+		if c.Source == "continuous_activity" {
+			starts = append(starts, c.StartTime)
+		}
+	}
+	if len(starts) == 0 {
+		t.Fatal("Expected continuous activity windows")
+	}
+	if starts[0] > first {
+		t.Errorf("First window should contain the first activity, starts at %s", time.Unix(starts[0], 0).UTC())
+	}
+	for _, start := range starts {
+		if minute := time.Unix(start, 0).UTC().Minute(); minute != 30 {
+			t.Errorf("Expected window to start at :30, got %s", time.Unix(start, 0).UTC().Format("15:04"))
+		}
+	}
+	// The anchor grid runs 04:30, 09:30, 14:30, ...
+	if got := time.Unix(starts[0], 0).UTC().Format("15:04"); got != "04:30" {
+		t.Errorf("Expected first window at 04:30, got %s", got)
+	}
+
+	if err := detector.SetWindowAnchor("9am"); err == nil {
+		t.Error("Expected error for malformed anchor")
+	}
+}
+
+func TestWindowAnchorTimezoneSnapsToServerReset(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+	if err := detector.SetWindowAnchor("03:00"); err != nil {
+		t.Fatalf("Unexpected error setting anchor: %v", err)
+	}
+	if err := detector.SetWindowAnchorTimezone("America/Los_Angeles"); err != nil {
+		t.Fatalf("Unexpected error setting anchor timezone: %v", err)
+	}
+
+	// Activity every 30 minutes for 6 hours from 10:10 Los Angeles time two days ago
+	day := time.Now().In(losAngeles).AddDate(0, 0, -2)
+	first := time.Date(day.Year(), day.Month(), day.Day(), 10, 10, 0, 0, losAngeles).Unix()
+	var entries []timeline.TimestampedLog
+	for ts := first; ts < first+6*3600; ts += 1800 {
+		entries = append(entries, timeline.TimestampedLog{
+			Timestamp: ts,
+			Log: model.ConversationLog{
+				Type:    "synthetic",
+				Message: model.Message{Usage: model.Usage{InputTokens: 100}},
+			},
+		})
+	}
+
+	candidates := detector.collectWindowCandidates(SessionDetectionInput{GlobalTimeline: entries})
+
+	// The anchor grid runs 03:00, 08:00, 13:00, ... in Los Angeles, whatever
+	// the display timezone
+	var anchored []WindowCandidate
+	for _, c := range candidates {
+		if c.Source == "continuous_activity" {
+			anchored = append(anchored, c)
+		}
+	}
+	var starts []string
+	for _, c := range anchored {
+		starts = append(starts, time.Unix(c.StartTime, 0).In(losAngeles).Format("15:04"))
+	}
+	if strings.Join(starts, ",") != "08:00,13:00" {
+		t.Fatalf("Expected windows at 08:00 and 13:00 Los Angeles time, got %v", starts)
+	}
+
+	// A limit-derived window overlapping the anchored grid still wins
+	limitStart := first + 1800
+	limit := WindowCandidate{StartTime: limitStart, EndTime: limitStart + 5*3600, Source: "limit_message", Priority: 9, IsLimit: true}
+	selected := detector.selectBestWindows(append(anchored, limit))
+	foundLimit := false
+	for _, w := range selected {
+		if w.Source == "limit_message" {
+			foundLimit = w.StartTime == limitStart
+		} else if w.StartTime < limit.EndTime && w.EndTime > limit.StartTime {
+			t.Errorf("Anchored window %s overlaps the limit window", time.Unix(w.StartTime, 0).In(losAngeles).Format("15:04"))
+		}
+	}
+	if !foundLimit {
+		t.Error("Expected the limit-derived window to be selected")
+	}
+
+	if err := detector.SetWindowAnchorTimezone("Mars/Olympus"); err == nil {
+		t.Error("Expected error for unknown timezone")
+	}
+}
+
+func TestAddLogToSessionGitBranches(t *testing.T) {
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", t.TempDir())
+
+	baseTime := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Hour)
+	newLog := func(offset time.Duration, requestId, branch string, outputTokens int) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      "assistant",
+			RequestId: requestId,
+			Timestamp: baseTime.Add(offset).Format(time.RFC3339),
+			GitBranch: branch,
+			Cwd:       "/work/repo",
+			Message: model.Message{
+				Id:    "msg-" + requestId,
+				Model: "claude-3-sonnet",
+				Usage: model.Usage{InputTokens: 100, OutputTokens: outputTokens},
+			},
+		}
+	}
+	logs := []model.ConversationLog{
+		newLog(5*time.Minute, "req-1", "main", 100),
+		newLog(10*time.Minute, "req-2", "feature/login", 900),
+		newLog(20*time.Minute, "req-3", "feature/login", 400),
+	}
+
+	hourly := agg.AggregateByHourAndModel(logs, "test-project")
+	entries := timeline.NewTimelineBuilder("UTC").BuildFromCachedData([]aggregator.AggregatedData{{HourlyStats: hourly}})
+	timestamped := timeline.NewTimelineBuilder("UTC").ConvertToTimestampedLogs(entries)
+
+	sess := &Session{
+		ID:                "branch-session",
+		StartTime:         baseTime.Unix(),
+		EndTime:           baseTime.Add(5 * time.Hour).Unix(),
+		Projects:          make(map[string]*ProjectStats),
+		ModelDistribution: make(map[string]*model.ModelStats),
+	}
+	for _, tl := range timestamped {
+		detector.AddLogToSession(sess, tl)
+	}
+
+	if len(sess.GitBranches) != 2 {
+		t.Fatalf("Expected 2 git branches, got %v", sess.GitBranches)
+	}
+	if sess.GitBranches["main"] != 200 || sess.GitBranches["feature/login"] != 1500 {
+		t.Errorf("Unexpected branch token counts: %v", sess.GitBranches)
+	}
+	if got := sess.DominantGitBranch(); got != "feature/login" {
+		t.Errorf("Expected dominant branch feature/login, got %q", got)
+	}
+	if got := sess.DominantWorkingDir(); got != "/work/repo" {
+		t.Errorf("Expected working dir /work/repo, got %q", got)
+	}
+}
+
+func TestAddLogToSessionAverageTurnaround(t *testing.T) {
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", t.TempDir())
+
+	baseTime := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Hour)
+	userLog := func(offset time.Duration, requestId string) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryUser,
+			RequestId: requestId,
+			Timestamp: baseTime.Add(offset).Format(time.RFC3339),
+			Message: model.Message{
+				Role:    "user",
+				Content: model.FlexibleContent{{Type: "text", Text: "prompt " + requestId}},
+			},
+		}
+	}
+	assistantLog := func(offset time.Duration, requestId string) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryAssistant,
+			RequestId: requestId,
+			Timestamp: baseTime.Add(offset).Format(time.RFC3339),
+			Message: model.Message{
+				Id:    "msg-" + requestId,
+				Model: "claude-3-sonnet",
+				Usage: model.Usage{InputTokens: 100, OutputTokens: 50},
+			},
+		}
+	}
+	logs := []model.ConversationLog{
+		userLog(0, "req-1"),
+		assistantLog(4*time.Second, "req-1"),
+		assistantLog(30*time.Second, "req-1"), // Later chunk of the same response
+		userLog(time.Minute, "req-2"),
+		assistantLog(70*time.Second, "req-2"),
+		assistantLog(2*time.Minute, "req-3"), // No user entry to pair with
+		userLog(3*time.Minute, "req-4"),      // No response
+	}
+
+	hourly := agg.AggregateByHourAndModel(logs, "test-project")
+	builder := timeline.NewTimelineBuilder("UTC")
+	timestamped := builder.ConvertToTimestampedLogs(builder.BuildFromHourlyData(hourly))
+
+	sess := &Session{
+		ID:                "turnaround-session",
+		StartTime:         baseTime.Unix(),
+		EndTime:           baseTime.Add(5 * time.Hour).Unix(),
+		Projects:          make(map[string]*ProjectStats),
+		ModelDistribution: make(map[string]*model.ModelStats),
+	}
+	for _, tl := range timestamped {
+		detector.AddLogToSession(sess, tl)
+	}
+
+	if sess.TurnaroundCount != 2 {
+		t.Fatalf("Expected 2 paired requests, got %d", sess.TurnaroundCount)
+	}
+	if got := sess.AverageTurnaround(); got != 7*time.Second {
+		t.Errorf("Expected average turnaround 7s, got %s", got)
+	}
+	project := sess.Projects["test-project"]
+	if project == nil {
+		t.Fatal("Expected test-project stats")
+	}
+	if got := project.AverageTurnaround(); got != 7*time.Second {
+		t.Errorf("Expected project average turnaround 7s, got %s", got)
+	}
+
+	empty := &Session{}
+	if got := empty.AverageTurnaround(); got != 0 {
+		t.Errorf("Expected 0 turnaround without pairs, got %s", got)
+	}
+}
+
+func TestAddLogToSessionCountsThinkingOutputTokens(t *testing.T) {
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", t.TempDir())
+
+	baseTime := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Hour)
+	assistantLog := func(offset time.Duration, requestId, contentType string, outputTokens int) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryAssistant,
+			RequestId: requestId,
+			Timestamp: baseTime.Add(offset).Format(time.RFC3339),
+			Message: model.Message{
+				Id:      "msg-" + requestId,
+				Model:   "claude-3-sonnet",
+				Content: model.FlexibleContent{{Type: contentType}},
+				Usage:   model.Usage{InputTokens: 100, OutputTokens: outputTokens},
+			},
+		}
+	}
+	logs := []model.ConversationLog{
+		assistantLog(0, "req-1", "thinking", 300),
+		assistantLog(5*time.Second, "req-1", "text", 300), // Reply following the thinking block
+		assistantLog(time.Minute, "req-2", "text", 50),
+	}
+
+	hourly := agg.AggregateByHourAndModel(logs, "test-project")
+	builder := timeline.NewTimelineBuilder("UTC")
+	timestamped := builder.ConvertToTimestampedLogs(builder.BuildFromHourlyData(hourly))
+
+	sess := &Session{
+		ID:                "thinking-session",
+		StartTime:         baseTime.Unix(),
+		EndTime:           baseTime.Add(5 * time.Hour).Unix(),
+		Projects:          make(map[string]*ProjectStats),
+		ModelDistribution: make(map[string]*model.ModelStats),
+	}
+	for _, tl := range timestamped {
+		detector.AddLogToSession(sess, tl)
+	}
+
+	if sess.ThinkingOutputTokens != 300 {
+		t.Errorf("Expected 300 output tokens of thinking requests, got %d", sess.ThinkingOutputTokens)
+	}
+	if sess.OutputTokens != 350 {
+		t.Errorf("Expected output tokens to stay at 350, got %d", sess.OutputTokens)
+	}
+	if sess.TotalTokens != 550 {
+		t.Errorf("Expected 550 total tokens, got %d", sess.TotalTokens)
+	}
+	project := sess.Projects["test-project"]
+	if project == nil {
+		t.Fatal("Expected test-project stats")
+	}
+	if project.ThinkingOutputTokens != 300 {
+		t.Errorf("Expected 300 project output tokens of thinking requests, got %d", project.ThinkingOutputTokens)
+	}
+}
+
+func TestSelectBestWindowsRecordsHistoryAdjustment(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+
+	// A window recorded by an earlier run pushes overlapping candidates past its end
+	base := time.Now().Add(-12 * time.Hour).Truncate(time.Hour).Unix()
+	detector.windowHistory.AddOrUpdateWindow(WindowRecord{
+		StartTime:      base,
+		EndTime:        base + 5*3600,
+		Source:         "first_message",
+		SessionID:      strconv.FormatInt(base, 10),
+		IsAccountLevel: true,
+	})
+
+	candidate := WindowCandidate{StartTime: base + 3600, EndTime: base + 6*3600, Source: "first_message", Priority: 3}
+	selected := detector.selectBestWindows([]WindowCandidate{candidate})
+	if len(selected) != 1 {
+		t.Fatalf("Expected 1 selected window, got %d", len(selected))
+	}
+
+	sess := detector.createSessionForWindow(selected[0], "test-project")
+	if !sess.AdjustedByHistory {
+		t.Fatal("Expected session to be marked as adjusted by history")
+	}
+	if sess.OriginalStartTime != candidate.StartTime || sess.OriginalEndTime != candidate.EndTime {
+		t.Errorf("Expected original window %d-%d, got %d-%d",
+			candidate.StartTime, candidate.EndTime, sess.OriginalStartTime, sess.OriginalEndTime)
+	}
+	if sess.StartTime != base+5*3600 || sess.EndTime != base+10*3600 {
+		t.Errorf("Expected adjusted window to start at the recorded window's end, got %d-%d", sess.StartTime, sess.EndTime)
+	}
+
+	// A candidate matching its own record is left alone
+	unchanged := detector.selectBestWindows([]WindowCandidate{{StartTime: base, EndTime: base + 5*3600, Source: "first_message", Priority: 3}})
+	if len(unchanged) != 1 || unchanged[0].AdjustedByHistory {
+		t.Errorf("Expected the recorded window to be selected unadjusted, got %+v", unchanged)
+	}
+}
+
+func TestGroupActivitySessionsMergesContinuousWindows(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+
+	// 8 hours of continuous activity split into two 5-hour windows
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Hour).Unix()
+	firstLast := start + 5*3600 - 60
+	secondStart := start + 5*3600
+	secondLast := start + 8*3600
+	windows := []*Session{
+		{
+			ID: "second", StartTime: secondStart, EndTime: secondStart + 5*3600,
+			FirstEntryTime: secondStart + 60, ActualEndTime: &secondLast,
+			TotalTokens: 3000, TotalCost: 0.3, MessageCount: 30,
+			ModelDistribution: map[string]*model.ModelStats{"claude-3-opus": {Model: "claude-3-opus", Tokens: 3000, Cost: 0.3, Count: 30}},
+		},
+		{
+			ID: "first", StartTime: start, EndTime: start + 5*3600,
+			FirstEntryTime: start, ActualEndTime: &firstLast,
+			TotalTokens: 5000, TotalCost: 0.5, MessageCount: 50,
+			ModelDistribution: map[string]*model.ModelStats{"claude-3-opus": {Model: "claude-3-opus", Tokens: 5000, Cost: 0.5, Count: 50}},
+		},
+	}
+
+	activities := detector.GroupActivitySessions(windows)
+	if len(activities) != 1 {
+		t.Fatalf("Expected 1 activity session for continuous work, got %d", len(activities))
+	}
+	activity := activities[0]
+	if activity.StartTime != start || activity.EndTime != secondLast {
+		t.Errorf("Expected activity to span %d-%d, got %d-%d", start, secondLast, activity.StartTime, activity.EndTime)
+	}
+	if activity.TotalTokens != 8000 || activity.MessageCount != 80 {
+		t.Errorf("Expected totals of both windows, got %d tokens and %d messages", activity.TotalTokens, activity.MessageCount)
+	}
+	if len(activity.WindowIDs) != 2 || activity.WindowIDs[0] != "first" {
+		t.Errorf("Expected both windows oldest first, got %v", activity.WindowIDs)
+	}
+	if stats := activity.ModelDistribution["claude-3-opus"]; stats == nil || stats.Tokens != 8000 {
+		t.Errorf("Expected merged model distribution, got %+v", stats)
+	}
+
+	// The limit windows themselves are untouched
+	if len(windows) != 2 || windows[0].TotalTokens != 3000 {
+		t.Errorf("Expected windows to be left as-is")
+	}
+
+	// A long idle period still starts a new activity session
+	thirdStart := secondLast + 6*3600
+	thirdLast := thirdStart + 600
+	windows = append(windows, &Session{
+		ID: "third", StartTime: thirdStart, EndTime: thirdStart + 5*3600,
+		FirstEntryTime: thirdStart, ActualEndTime: &thirdLast, TotalTokens: 100, MessageCount: 1,
+	})
+	if activities := detector.GroupActivitySessions(windows); len(activities) != 2 {
+		t.Errorf("Expected idle period to split activity sessions, got %d", len(activities))
+	}
+}
+
+func TestSuppressSpeculativeActiveWindow(t *testing.T) {
+	// Activity well before the current period, nothing since
+	pastStart := time.Now().UTC().Add(-8 * time.Hour).Truncate(time.Hour).Unix()
+	hourlyData := []aggregator.HourlyData{
+		{
+			Hour:           pastStart,
+			FirstEntryTime: pastStart + 600,
+			LastEntryTime:  pastStart + 1800,
+			InputTokens:    800,
+			OutputTokens:   200,
+			TotalTokens:    1000,
+			MessageCount:   5,
+			ProjectName:    "test-project",
+		},
+	}
+	timelineBuilder := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := timelineBuilder.ConvertToTimestampedLogs(timelineBuilder.BuildFromHourlyData(hourlyData))
+
+	detect := func(suppress bool) (*SessionDetector, []*Session) {
+		agg := aggregator.NewAggregatorWithTimezone("UTC")
+		detector := NewSessionDetectorWithAggregator(agg, "UTC", "/tmp")
+		detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+		detector.SetSuppressSpeculativeActive(suppress)
+		return detector, detector.DetectSessionsWithLimits(SessionDetectionInput{
+			GlobalTimeline:   globalTimeline,
+			CachedWindowInfo: make(map[string]*WindowDetectionInfo),
+		})
+	}
+	hasActiveWindow := func(detector *SessionDetector) bool {
+		_, selected := detector.GetWindowSelection()
+		for _, w := range selected {
+			if w.Source == "active_window" {
+				return true
+			}
+		}
+		return false
+	}
+
+	detector, _ := detect(false)
+	if !hasActiveWindow(detector) {
+		t.Error("Expected a speculative active window without the flag")
+	}
+
+	detector, sessions := detect(true)
+	if hasActiveWindow(detector) {
+		t.Error("Expected no active window when speculative active windows are suppressed")
+	}
+	if len(sessions) == 0 {
+		t.Fatal("Expected the past session to still be detected")
+	}
+	for _, sess := range sessions {
+		if sess.IsActive {
+			t.Errorf("Session %s should not be active without current-period logs", sess.ID)
+		}
+	}
+}
+
+func TestDetectionConfidenceByWindowSource(t *testing.T) {
+	tests := []struct {
+		source      string
+		limitBacked bool
+		expected    string
+	}{
+		{"limit_message", true, model.DetectionConfidenceHigh},
+		{"history_limit", true, model.DetectionConfidenceHigh},
+		{"limit_message", false, model.DetectionConfidenceHigh},
+		{"continuous_activity", false, model.DetectionConfidenceMedium},
+		{"history_account", false, model.DetectionConfidenceMedium},
+		{"gap", false, model.DetectionConfidenceLow},
+		{"first_message", false, model.DetectionConfidenceLow},
+		{"rounded_hour", false, model.DetectionConfidenceLow},
+		{"active_window", false, model.DetectionConfidenceSpeculative},
+	}
+
+	for _, tt := range tests {
+		if got := DetectionConfidence(tt.source, tt.limitBacked); got != tt.expected {
+			t.Errorf("DetectionConfidence(%q, %v) = %q, want %q", tt.source, tt.limitBacked, got, tt.expected)
+		}
+	}
+
+	// Cached windows that have not started and hold no activity are speculative
+	now := time.Now().Unix()
+	future := &Session{ID: "future", StartTime: now + 3600, DetectionConfidence: model.DetectionConfidenceMedium}
+	current := &Session{ID: "current", StartTime: now - 600, MessageCount: 1, DetectionConfidence: model.DetectionConfidenceMedium}
+	markSpeculativeSessions([]*Session{future, current}, now)
+	if future.DetectionConfidence != model.DetectionConfidenceSpeculative {
+		t.Errorf("Expected empty future session to be speculative, got %q", future.DetectionConfidence)
+	}
+	if current.DetectionConfidence != model.DetectionConfidenceMedium {
+		t.Errorf("Expected current session to keep its confidence, got %q", current.DetectionConfidence)
+	}
+}
+
+func TestActiveWindowChainsFromLatestContiguousWindow(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+
+	// Activity fills two back-to-back windows [s, s+5h) and [s+5h, s+10h). The
+	// last message follows a 5h15m pause and also yields a gap window
+	// [s+9h, s+14h) that overlaps the second one. Now is in [s+14h, s+15h).
+	hour := int64(3600)
+	start := time.Now().Truncate(time.Hour).Unix() - 14*hour
+	var entries []timeline.TimestampedLog
+	for ts := start + hour/2; ts <= start+9*hour/2; ts += hour / 2 {
+		entries = append(entries, tokenLog(ts, 100))
+	}
+	entries = append(entries, tokenLog(start+39*hour/4, 100))
+
+	candidates := detector.collectWindowCandidates(SessionDetectionInput{GlobalTimeline: entries})
+
+	var active []WindowCandidate
+	for _, c := range candidates {
+		if c.Source == "active_window" {
+			active = append(active, c)
+		}
+	}
+	if len(active) != 1 {
+		t.Fatalf("Expected one active window, got %d", len(active))
+	}
+	if active[0].StartTime != start+10*hour || active[0].EndTime != start+15*hour {
+		t.Errorf("Expected active window to follow the second window at %s-%s, got %s-%s",
+			time.Unix(start+10*hour, 0).Format("15:04"), time.Unix(start+15*hour, 0).Format("15:04"),
+			time.Unix(active[0].StartTime, 0).Format("15:04"), time.Unix(active[0].EndTime, 0).Format("15:04"))
+	}
+}
+
+func TestActiveWindowAnchorIgnoresCandidateOrder(t *testing.T) {
+	hour := int64(3600)
+	now := int64(100 * hour)
+	candidates := []WindowCandidate{
+		{StartTime: 80 * hour, EndTime: 85 * hour, Source: "first_message", Priority: 3},
+		{StartTime: 80 * hour, EndTime: 85 * hour, Source: "continuous_activity", Priority: 8},
+		{StartTime: 85 * hour, EndTime: 90 * hour, Source: "continuous_activity", Priority: 8},
+		{StartTime: 88 * hour, EndTime: 93 * hour, Source: "gap", Priority: 5},
+		{StartTime: 98 * hour, EndTime: 103 * hour, Source: "gap", Priority: 5}, // Still running
+	}
+	reversed := make([]WindowCandidate, len(candidates))
+	for i, c := range candidates {
+		reversed[len(candidates)-1-i] = c
+	}
+
+	for _, order := range [][]WindowCandidate{candidates, reversed} {
+		if got := activeWindowAnchor(order, now); got != 90*hour {
+			t.Errorf("Expected anchor at hour 90, got hour %d", got/hour)
+		}
+	}
+
+	// A higher priority window overlapping the chain replaces the window it overlaps
+	limit := WindowCandidate{StartTime: 87 * hour, EndTime: 92 * hour, Source: "limit_message", Priority: 9}
+	if got := activeWindowAnchor(append(candidates, limit), now); got != 92*hour {
+		t.Errorf("Expected anchor at the limit window end (hour 92), got hour %d", got/hour)
+	}
+	if got := activeWindowAnchor(nil, now); got != 0 {
+		t.Errorf("Expected no anchor without candidates, got %d", got)
+	}
+}
+
+func TestOnlyGaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []*Session
+		want     bool
+	}{
+		{"no sessions", nil, false},
+		{"only gaps", []*Session{{IsGap: true}, {IsGap: true}}, true},
+		{"gap and window", []*Session{{IsGap: true}, {MessageCount: 1}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := OnlyGaps(tt.sessions); got != tt.want {
+			t.Errorf("%s: expected OnlyGaps=%v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSessionCountsDistinctConversations(t *testing.T) {
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	detector := NewSessionDetectorWithAggregator(agg, "UTC", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+
+	baseTime := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	conversationFile := func(sessionId string, offsets ...time.Duration) aggregator.AggregatedData {
+		var logs []model.ConversationLog
+		for i, offset := range offsets {
+			logs = append(logs, model.ConversationLog{
+				Type:      "assistant",
+				SessionId: sessionId,
+				RequestId: fmt.Sprintf("%s-req-%d", sessionId, i),
+				Timestamp: baseTime.Add(offset).Format(time.RFC3339),
+				Message: model.Message{
+					Id:    fmt.Sprintf("%s-msg-%d", sessionId, i),
+					Model: "claude-3-sonnet",
+					Usage: model.Usage{InputTokens: 100, OutputTokens: 50},
+				},
+			})
+		}
+		return aggregator.AggregatedData{
+			SessionId:   sessionId,
+			ProjectName: "test-project",
+			HourlyStats: agg.AggregateByHourAndModel(logs, "test-project"),
+		}
+	}
+
+	// Three conversation files, one of them active across two hours
+	files := []aggregator.AggregatedData{
+		conversationFile("conv-a", 5*time.Minute, 70*time.Minute),
+		conversationFile("conv-b", 20*time.Minute),
+		conversationFile("conv-c", 40*time.Minute, 100*time.Minute),
+	}
+	tb := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := tb.ConvertToTimestampedLogs(tb.MergeTimelines(tb.BuildFromCachedData(files)))
+
+	sessions := detector.DetectSessionsWithLimits(SessionDetectionInput{GlobalTimeline: globalTimeline})
+
+	var window *Session
+	for _, s := range sessions {
+		if !s.IsGap && s.MessageCount > 0 {
+			if window != nil {
+				t.Fatalf("Expected all conversations in one window, got a second window %s", s.ID)
+			}
+			window = s
+		}
+	}
+	if window == nil {
+		t.Fatal("Expected a window with usage")
+	}
+	if got := window.ConversationCount(); got != 3 {
+		t.Errorf("Expected 3 distinct conversations, got %d (%v)", got, window.Conversations)
+	}
+	if window.Conversations["conv-a"] != 300 || window.Conversations["conv-b"] != 150 {
+		t.Errorf("Unexpected conversation token counts: %v", window.Conversations)
+	}
+}
+
+func TestUTCWindowsBoundaries(t *testing.T) {
+	// UTC+05:30 puts local hours half an hour off UTC hours
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+	detector.timezone = kolkata
+
+	ts := time.Date(2024, 3, 10, 12, 45, 0, 0, time.UTC).Unix() // 18:15 IST
+
+	// Hour alignment follows the local clock (18:00 IST) unless the window
+	// timezone is UTC, where windows start at the UTC hour (17:30 IST)
+	utcHour := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC).Unix()
+	localHour := time.Date(2024, 3, 10, 18, 0, 0, 0, kolkata).Unix()
+	if got := detector.alignWindowStart(ts); got != localHour {
+		t.Errorf("Expected local hour start %d, got %d", localHour, got)
+	}
+	if err := detector.SetWindowAnchorTimezone("UTC"); err != nil {
+		t.Fatalf("Unexpected error setting window timezone: %v", err)
+	}
+	if got := detector.alignWindowStart(ts); got != utcHour {
+		t.Errorf("Expected UTC hour start %d, got %d", utcHour, got)
+	}
+	if localHour-utcHour != 1800 {
+		t.Errorf("Expected local hour start 30 minutes after the UTC hour start, got %ds", localHour-utcHour)
+	}
+
+	// An anchor is read in the display timezone unless the window timezone is UTC
+	if err := detector.SetWindowAnchor("09:00"); err != nil {
+		t.Fatalf("Unexpected error setting anchor: %v", err)
+	}
+	if err := detector.SetWindowAnchorTimezone(""); err != nil {
+		t.Fatalf("Unexpected error clearing window timezone: %v", err)
+	}
+	local := detector.alignWindowStart(ts)
+	if want := time.Date(2024, 3, 10, 14, 0, 0, 0, kolkata).Unix(); local != want {
+		t.Errorf("Expected local anchored start 14:00 IST, got %s", time.Unix(local, 0).In(kolkata).Format(time.RFC3339))
+	}
+	if err := detector.SetWindowAnchorTimezone("UTC"); err != nil {
+		t.Fatalf("Unexpected error setting window timezone: %v", err)
+	}
+	utc := detector.alignWindowStart(ts)
+	if want := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC).Unix(); utc != want {
+		t.Errorf("Expected UTC anchored start 09:00 UTC, got %s", time.Unix(utc, 0).UTC().Format(time.RFC3339))
+	}
+
+	// The two modes put boundaries 30 minutes apart (5h30m offset modulo 5h windows)
+	if diff := utc - local; diff != 1800 {
+		t.Errorf("Expected UTC windows 30 minutes after local windows, got %ds", diff)
+	}
+}
+
+func TestClockAtWindowEndExpiresSession(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	hourlyData := []aggregator.HourlyData{
+		{Hour: start.Unix(), FirstEntryTime: start.Add(15 * time.Minute).Unix(), LastEntryTime: start.Add(40 * time.Minute).Unix(),
+			Model: "claude-3-5-sonnet", InputTokens: 600, OutputTokens: 400, TotalTokens: 1000, MessageCount: 5, ProjectName: "test-project"},
+	}
+	timelineBuilder := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := timelineBuilder.ConvertToTimestampedLogs(timelineBuilder.BuildFromHourlyData(hourlyData))
+	end := start.Add(5 * time.Hour)
+
+	detectAt := func(now time.Time) *Session {
+		detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+		detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+		detector.SetClock(util.ClockFunc(func() time.Time { return now }))
+		for _, sess := range detector.DetectSessionsWithLimits(SessionDetectionInput{GlobalTimeline: globalTimeline}) {
+			if !sess.IsGap && sess.TotalTokens > 0 {
+				return sess
+			}
+		}
+		t.Fatalf("Expected a session with usage at %s", now)
+		return nil
+	}
+
+	// One second before the end the window is still running
+	sess := detectAt(end.Add(-time.Second))
+	if sess.StartTime != start.Unix() || sess.EndTime != end.Unix() {
+		t.Fatalf("Expected window %s-%s, got %s-%s", start, end,
+			time.Unix(sess.StartTime, 0).UTC(), time.Unix(sess.EndTime, 0).UTC())
+	}
+	if !sess.IsActive || sess.TimeRemaining != time.Second {
+		t.Errorf("Expected an active session with 1s left, got active=%v remaining=%s", sess.IsActive, sess.TimeRemaining)
+	}
+
+	// At exactly its end time the window has expired
+	sess = detectAt(end)
+	if sess.IsActive {
+		t.Error("Expected the session not to be active at its end time")
+	}
+	if sess.TimeRemaining != 0 {
+		t.Errorf("Expected no time remaining at the end time, got %s", sess.TimeRemaining)
+	}
+}
+
+func TestFrozenClockUsesHistoryWindows(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	activity := start.Add(80 * time.Minute)
+	hourlyData := []aggregator.HourlyData{
+		{Hour: activity.Truncate(time.Hour).Unix(), FirstEntryTime: activity.Unix(), LastEntryTime: activity.Unix(),
+			Model: "claude-3-5-sonnet", InputTokens: 600, OutputTokens: 400, TotalTokens: 1000, MessageCount: 5, ProjectName: "test-project"},
+	}
+	timelineBuilder := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := timelineBuilder.ConvertToTimestampedLogs(timelineBuilder.BuildFromHourlyData(hourlyData))
+
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+	// History recorded around the activity; it is recent and unexpired only
+	// by the frozen clock, not by the wall clock
+	detector.windowHistory.history.Windows = []WindowRecord{
+		{StartTime: start.Unix(), EndTime: start.Add(5 * time.Hour).Unix(), Source: "limit_message",
+			IsLimitReached: true, IsAccountLevel: true},
+		{StartTime: start.Add(-5 * time.Hour).Unix(), EndTime: start.Unix(), Source: "first_message", IsAccountLevel: true},
+	}
+	detector.SetClock(util.ClockFunc(func() time.Time { return start.Add(4 * time.Hour) }))
+
+	sessions := detector.DetectSessionsWithLimits(SessionDetectionInput{GlobalTimeline: globalTimeline})
+
+	// The account window is among the recent history candidates
+	candidates, _ := detector.GetWindowSelection()
+	foundAccount := false
+	for _, candidate := range candidates {
+		if candidate.Source == "history_account" && candidate.StartTime == start.Add(-5*time.Hour).Unix() {
+			foundAccount = true
+		}
+	}
+	if !foundAccount {
+		t.Errorf("Expected the recent account window as a candidate, got %+v", candidates)
+	}
+
+	// The unexpired limit window holds the activity
+	for _, sess := range sessions {
+		if sess.IsGap || sess.TotalTokens == 0 {
+			continue
+		}
+		if sess.WindowSource != "history_limit" || sess.StartTime != start.Unix() {
+			t.Errorf("Expected the history limit window starting %s, got %s from %s",
+				start, time.Unix(sess.StartTime, 0).UTC(), sess.WindowSource)
+		}
+		if !sess.IsActive {
+			t.Error("Expected the history limit window to be active at the frozen time")
+		}
+		return
+	}
+	t.Fatal("Expected a session with usage")
+}
+
+func TestWindowStartsOnLocalHours(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("Asia/Kolkata unavailable: %v", err)
+	}
+	base := time.Date(2024, 3, 10, 9, 0, 0, 0, kolkata)
+
+	// Activity at 09:10 and, after a gap, 15:40 IST
+	hourlyData := []aggregator.HourlyData{
+		{Hour: base.Unix(), FirstEntryTime: base.Add(10 * time.Minute).Unix(), LastEntryTime: base.Add(10 * time.Minute).Unix(),
+			Model: "claude-3-5-sonnet", InputTokens: 600, OutputTokens: 400, TotalTokens: 1000, MessageCount: 5, ProjectName: "test-project"},
+		{Hour: base.Add(6 * time.Hour).Unix(), FirstEntryTime: base.Add(6*time.Hour + 40*time.Minute).Unix(), LastEntryTime: base.Add(6*time.Hour + 40*time.Minute).Unix(),
+			Model: "claude-3-5-sonnet", InputTokens: 300, OutputTokens: 200, TotalTokens: 500, MessageCount: 3, ProjectName: "test-project"},
+	}
+	timelineBuilder := timeline.NewTimelineBuilder("UTC")
+	globalTimeline := timelineBuilder.ConvertToTimestampedLogs(timelineBuilder.BuildFromHourlyData(hourlyData))
+
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "Asia/Kolkata", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+	detector.SetClock(util.ClockFunc(func() time.Time { return base.Add(24 * time.Hour) }))
+
+	var starts []string
+	for _, sess := range detector.DetectSessionsWithLimits(SessionDetectionInput{GlobalTimeline: globalTimeline}) {
+		if sess.IsGap || sess.TotalTokens == 0 {
+			continue
+		}
+		start := time.Unix(sess.StartTime, 0).In(kolkata)
+		starts = append(starts, start.Format("15:04"))
+		if start.Minute() != 0 || start.Second() != 0 {
+			t.Errorf("Expected window %s to start on a local hour, got %s", sess.ID, start.Format(time.RFC3339))
+		}
+	}
+	// The second window follows on from the end of the first
+	sort.Strings(starts)
+	if strings.Join(starts, ",") != "09:00,14:00" {
+		t.Errorf("Expected windows at 09:00 and 14:00 IST, got %v", starts)
+	}
+}
+
+func TestWindowStartsAcrossDSTChanges(t *testing.T) {
+	// Adelaide is UTC+09:30, or UTC+10:30 in summer, so its hours are never UTC hours
+	adelaide, err := time.LoadLocation("Australia/Adelaide")
+	if err != nil {
+		t.Skipf("Australia/Adelaide unavailable: %v", err)
+	}
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "Australia/Adelaide", t.TempDir())
+	detector.windowHistory = newWindowHistoryManager(t.TempDir(), t.TempDir())
+
+	tests := []struct {
+		name  string
+		at    time.Time // Activity time
+		start time.Time // Expected window start
+	}{
+		// Clocks fall back from 03:00 ACDT to 02:00 ACST on 7 April 2024, so
+		// 02:00-03:00 happens twice
+		{
+			name:  "first 02:xx",
+			at:    time.Date(2024, 4, 6, 15, 50, 0, 0, time.UTC), // 02:20 ACDT
+			start: time.Date(2024, 4, 6, 15, 30, 0, 0, time.UTC), // 02:00 ACDT
+		},
+		{
+			name:  "repeated 02:xx",
+			at:    time.Date(2024, 4, 6, 16, 50, 0, 0, time.UTC), // 02:20 ACST
+			start: time.Date(2024, 4, 6, 16, 30, 0, 0, time.UTC), // 02:00 ACST
+		},
+		// Clocks spring forward from 02:00 ACST to 03:00 ACDT on 6 October
+		// 2024, so there is no 02:xx
+		{
+			name:  "before the skipped hour",
+			at:    time.Date(2024, 10, 5, 16, 20, 0, 0, time.UTC), // 01:50 ACST
+			start: time.Date(2024, 10, 5, 15, 30, 0, 0, time.UTC), // 01:00 ACST
+		},
+		{
+			name:  "after the skipped hour",
+			at:    time.Date(2024, 10, 5, 16, 40, 0, 0, time.UTC), // 03:10 ACDT
+			start: time.Date(2024, 10, 5, 16, 30, 0, 0, time.UTC), // 03:00 ACDT
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := time.Unix(detector.alignWindowStart(tt.at.Unix()), 0).In(adelaide)
+			if !got.Equal(tt.start) {
+				t.Errorf("Expected window start %s, got %s", tt.start.In(adelaide).Format(time.RFC3339), got.Format(time.RFC3339))
+			}
+			if got.Minute() != 0 {
+				t.Errorf("Expected a local hour boundary, got %s", got.Format(time.RFC3339))
+			}
+		})
+	}
+}
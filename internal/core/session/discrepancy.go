@@ -0,0 +1,138 @@
+package session
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session/internal"
+	"github.com/penwyp/go-claude-monitor/internal/core/timeline"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// DefaultTokenMismatchThreshold is the percentage difference between session
+// and timeline totals above which a mismatch is reported
+const DefaultTokenMismatchThreshold = 1.0
+
+// WindowOverlap describes tokens assigned to a window that were also assigned
+// to at least one other window
+type WindowOverlap struct {
+	SessionID     string
+	Source        string
+	StartTime     int64
+	EndTime       int64
+	OverlapTokens int      // Tokens in this window also counted by another window
+	OverlapsWith  []string // IDs of the other windows sharing those tokens
+}
+
+// TokenDiscrepancy explains a mismatch between the tokens assigned to sessions
+// and the tokens present in the global timeline
+type TokenDiscrepancy struct {
+	SessionTokens    int64
+	TimelineTokens   int64
+	DeltaTokens      int64   // SessionTokens - TimelineTokens
+	Percentage       float64 // DeltaTokens as a percentage of TimelineTokens
+	DoubleCounted    int64   // Extra tokens from entries assigned to more than one window
+	UnassignedTokens int64   // Timeline tokens that fall outside every window
+	Overlaps         []WindowOverlap
+}
+
+// analyzeTokenDiscrepancy compares session totals with the timeline and, when
+// they differ by more than threshold percent, attributes the difference to
+// overlapping windows. It returns nil when the totals agree.
+func analyzeTokenDiscrepancy(sessions []*Session, entries []timeline.TimestampedLog, threshold float64) *TokenDiscrepancy {
+	var sessionTokens, timelineTokens int64
+	for _, s := range sessions {
+		sessionTokens += int64(s.TotalTokens)
+	}
+	for _, tl := range entries {
+		timelineTokens += int64(internal.CalculateTotalTokens(tl.Log.Message.Usage))
+	}
+
+	if sessionTokens == timelineTokens || timelineTokens == 0 {
+		return nil
+	}
+	delta := sessionTokens - timelineTokens
+	percentage := float64(delta) / float64(timelineTokens) * 100
+	if math.Abs(percentage) <= threshold {
+		return nil
+	}
+
+	discrepancy := &TokenDiscrepancy{
+		SessionTokens:  sessionTokens,
+		TimelineTokens: timelineTokens,
+		DeltaTokens:    delta,
+		Percentage:     percentage,
+	}
+
+	overlaps := make(map[string]*WindowOverlap)
+	partners := make(map[string]map[string]bool)
+	for _, tl := range entries {
+		tokens := internal.CalculateTotalTokens(tl.Log.Message.Usage)
+		if tokens == 0 {
+			continue
+		}
+
+		var owners []*Session
+		for _, s := range sessions {
+			if !s.IsGap && tl.Timestamp >= s.StartTime && tl.Timestamp < s.EndTime {
+				owners = append(owners, s)
+			}
+		}
+
+		switch {
+		case len(owners) == 0:
+			discrepancy.UnassignedTokens += int64(tokens)
+		case len(owners) > 1:
+			discrepancy.DoubleCounted += int64(tokens * (len(owners) - 1))
+			for _, s := range owners {
+				overlap, ok := overlaps[s.ID]
+				if !ok {
+					overlap = &WindowOverlap{
+						SessionID: s.ID,
+						Source:    s.WindowSource,
+						StartTime: s.StartTime,
+						EndTime:   s.EndTime,
+					}
+					overlaps[s.ID] = overlap
+					partners[s.ID] = make(map[string]bool)
+				}
+				overlap.OverlapTokens += tokens
+				for _, other := range owners {
+					if other.ID != s.ID {
+						partners[s.ID][other.ID] = true
+					}
+				}
+			}
+		}
+	}
+
+	for id, overlap := range overlaps {
+		for other := range partners[id] {
+			overlap.OverlapsWith = append(overlap.OverlapsWith, other)
+		}
+		sort.Strings(overlap.OverlapsWith)
+		discrepancy.Overlaps = append(discrepancy.Overlaps, *overlap)
+	}
+	sort.Slice(discrepancy.Overlaps, func(i, j int) bool {
+		return discrepancy.Overlaps[i].StartTime < discrepancy.Overlaps[j].StartTime
+	})
+
+	return discrepancy
+}
+
+// logTokenDiscrepancy writes the mismatch warning followed by one line per
+// overlapping window
+func logTokenDiscrepancy(discrepancy *TokenDiscrepancy) {
+	util.LogWarn(fmt.Sprintf("Token count mismatch: %.1f%% difference (Sessions=%d, Timeline=%d, Delta=%d, DoubleCounted=%d, Unassigned=%d)",
+		discrepancy.Percentage, discrepancy.SessionTokens, discrepancy.TimelineTokens,
+		discrepancy.DeltaTokens, discrepancy.DoubleCounted, discrepancy.UnassignedTokens))
+	for _, overlap := range discrepancy.Overlaps {
+		util.LogWarn(fmt.Sprintf("  Window %s %s-%s (source: %s): %d tokens shared with %v",
+			overlap.SessionID,
+			time.Unix(overlap.StartTime, 0).Format("2006-01-02 15:04:05"),
+			time.Unix(overlap.EndTime, 0).Format("2006-01-02 15:04:05"),
+			overlap.Source, overlap.OverlapTokens, overlap.OverlapsWith))
+	}
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/core/timeline"
+)
+
+func tokenLog(timestamp int64, tokens int) timeline.TimestampedLog {
+	return timeline.TimestampedLog{
+		Timestamp: timestamp,
+		Log: model.ConversationLog{
+			Type:    "synthetic",
+			Message: model.Message{Usage: model.Usage{InputTokens: tokens}},
+		},
+	}
+}
+
+func TestAnalyzeTokenDiscrepancyIdentifiesOverlap(t *testing.T) {
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Hour).Unix()
+
+	entries := []timeline.TimestampedLog{
+		tokenLog(start+600, 1000),    // Only in the first window
+		tokenLog(start+4*3600, 500),  // In both windows
+		tokenLog(start+6*3600, 2000), // Only in the second window
+	}
+
+	// The second window was started early and overlaps the first one by two hours
+	sessions := []*Session{
+		{ID: "first", StartTime: start, EndTime: start + 5*3600, WindowSource: "first_message", TotalTokens: 1500},
+		{ID: "second", StartTime: start + 3*3600, EndTime: start + 8*3600, WindowSource: "limit_message", TotalTokens: 2500},
+	}
+
+	discrepancy := analyzeTokenDiscrepancy(sessions, entries, DefaultTokenMismatchThreshold)
+	if discrepancy == nil {
+		t.Fatal("Expected a token discrepancy to be reported")
+	}
+
+	if discrepancy.DeltaTokens != 500 {
+		t.Errorf("Expected delta of 500 tokens, got %d", discrepancy.DeltaTokens)
+	}
+	if discrepancy.DoubleCounted != 500 {
+		t.Errorf("Expected 500 double-counted tokens, got %d", discrepancy.DoubleCounted)
+	}
+	if discrepancy.UnassignedTokens != 0 {
+		t.Errorf("Expected no unassigned tokens, got %d", discrepancy.UnassignedTokens)
+	}
+
+	if len(discrepancy.Overlaps) != 2 {
+		t.Fatalf("Expected both windows in the overlap detail, got %d", len(discrepancy.Overlaps))
+	}
+	second := discrepancy.Overlaps[1]
+	if second.SessionID != "second" || second.Source != "limit_message" {
+		t.Errorf("Expected second overlap to be the limit_message window, got %s (%s)", second.SessionID, second.Source)
+	}
+	if second.OverlapTokens != 500 {
+		t.Errorf("Expected 500 shared tokens, got %d", second.OverlapTokens)
+	}
+	if len(second.OverlapsWith) != 1 || second.OverlapsWith[0] != "first" {
+		t.Errorf("Expected second window to overlap with first, got %v", second.OverlapsWith)
+	}
+}
+
+func TestAnalyzeTokenDiscrepancyNilWhenTotalsAgree(t *testing.T) {
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Hour).Unix()
+
+	entries := []timeline.TimestampedLog{tokenLog(start+600, 1000)}
+	sessions := []*Session{
+		{ID: "only", StartTime: start, EndTime: start + 5*3600, TotalTokens: 1000},
+	}
+
+	if discrepancy := analyzeTokenDiscrepancy(sessions, entries, DefaultTokenMismatchThreshold); discrepancy != nil {
+		t.Errorf("Expected no discrepancy, got %+v", discrepancy)
+	}
+}
+
+func TestAnalyzeTokenDiscrepancyRespectsThreshold(t *testing.T) {
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Hour).Unix()
+
+	// 500 of 3500 timeline tokens are counted twice, a 14.3% difference
+	entries := []timeline.TimestampedLog{
+		tokenLog(start+600, 1000),
+		tokenLog(start+4*3600, 500),
+		tokenLog(start+6*3600, 2000),
+	}
+	sessions := []*Session{
+		{ID: "first", StartTime: start, EndTime: start + 5*3600, TotalTokens: 1500},
+		{ID: "second", StartTime: start + 3*3600, EndTime: start + 8*3600, TotalTokens: 2500},
+	}
+
+	if discrepancy := analyzeTokenDiscrepancy(sessions, entries, 20); discrepancy != nil {
+		t.Errorf("Expected a 14.3%% difference to be tolerated at 20%%, got %+v", discrepancy)
+	}
+	if discrepancy := analyzeTokenDiscrepancy(sessions, entries, 10); discrepancy == nil {
+		t.Error("Expected a 14.3% difference to be reported at 10%")
+	}
+}
@@ -95,16 +95,16 @@ func (s *ResetTimestampStrategy) Parse(content string, timestamp int64, metadata
 	var resetTimestamp int64
 	fmt.Sscanf(matches[1], "%d", &resetTimestamp)
 	
-	// Convert milliseconds to seconds if needed
-	if resetTimestamp > 1e12 {
-		resetTimestamp = resetTimestamp / 1000
-	}
-	
-	return &LimitResult{
+	result := &LimitResult{
 		Type:       "general_limit",
-		ResetTime:  &resetTimestamp,
 		Confidence: 1.0, // Very confident with explicit timestamp
 	}
+	if normalized, ok := NormalizeResetTimestamp(resetTimestamp); ok {
+		result.ResetTime = &normalized
+	} else {
+		util.LogWarn(fmt.Sprintf("Ignoring implausible limit reset time %d in general_limit message", resetTimestamp))
+	}
+	return result
 }
 
 func (s *ResetTimestampStrategy) Priority() int {
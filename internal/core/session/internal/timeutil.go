@@ -4,11 +4,47 @@ import (
 	"time"
 )
 
-// TruncateToHour rounds down a timestamp to the nearest hour
+// Plausible range for limit reset timestamps, in Unix seconds
+const (
+	minResetTimestamp int64 = 946684800  // 2000-01-01T00:00:00Z
+	maxResetTimestamp int64 = 4102444800 // 2100-01-01T00:00:00Z
+)
+
+// NormalizeResetTimestamp converts a limit reset timestamp given in seconds
+// or milliseconds to seconds. Values outside the plausible range are
+// rejected so that a stray unit never places a window thousands of years
+// away. Every path reading a reset timestamp goes through here.
+func NormalizeResetTimestamp(ts int64) (int64, bool) {
+	if ts > 1e12 {
+		ts /= 1000 // Milliseconds
+	}
+	if ts < minResetTimestamp || ts > maxResetTimestamp {
+		return 0, false
+	}
+	return ts, true
+}
+
+// TruncateToHour rounds down a timestamp to the start of its UTC hour. This
+// matches the aggregator's hourly buckets; in zones with a non-whole-hour
+// offset it differs from the local hour start.
 func TruncateToHour(timestamp int64) int64 {
 	return (timestamp / 3600) * 3600
 }
 
+// TruncateToHourIn rounds down a timestamp to the start of its hour on the
+// clock of loc, e.g. to 18:00 rather than 17:30 in UTC+05:30. Around a DST
+// change the offset in effect at the timestamp is used, so each occurrence of
+// a repeated hour starts at its own instant and the hour after a skipped one
+// starts where the clock resumes.
+func TruncateToHourIn(timestamp int64, loc *time.Location) int64 {
+	if loc == nil {
+		return TruncateToHour(timestamp)
+	}
+	_, offset := time.Unix(timestamp, 0).In(loc).Zone()
+	local := timestamp + int64(offset)
+	return timestamp - ((local%3600)+3600)%3600
+}
+
 // IsWithinDuration checks if a timestamp is within the specified duration from now
 func IsWithinDuration(timestamp int64, duration time.Duration, nowTimestamp int64) bool {
 	cutoff := nowTimestamp - int64(duration.Seconds())
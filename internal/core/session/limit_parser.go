@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/core/session/internal"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
@@ -23,13 +24,17 @@ type LimitInfo struct {
 	SessionID   string // Session ID
 }
 
-// IsUnexpired checks if the limit's reset time is still in the future
-func (l *LimitInfo) IsUnexpired() bool {
-	if l.ResetTime == nil {
-		return false
-	}
-	currentTime := time.Now().Unix()
-	return *l.ResetTime > currentTime
+// LimitEvent reports an unexpired limit found by a detection
+type LimitEvent struct {
+	Type       string   // Limit type, see LimitInfo
+	ResetTime  int64    // Unix timestamp when the limit resets
+	DetectedAt int64    // Unix timestamp of the limit message
+	Projects   []string // Projects with activity in the limited window, sorted
+}
+
+// IsUnexpiredAt checks if the limit's reset time is after the Unix time now
+func (l *LimitInfo) IsUnexpiredAt(now int64) bool {
+	return l.ResetTime != nil && *l.ResetTime > now
 }
 
 // LimitParser parses conversation logs to detect rate limit messages
@@ -39,6 +44,8 @@ type LimitParser struct {
 	waitPattern    *regexp.Regexp
 	resetPattern   *regexp.Regexp
 	generalPattern *regexp.Regexp
+
+	clock util.Clock // Clock limits expire against
 }
 
 // NewLimitParser creates a new limit message parser
@@ -52,7 +59,32 @@ func NewLimitParser() *LimitParser {
 		resetPattern: regexp.MustCompile(`(?i)limit\s+reached\|(\d+)`),
 		// General limit patterns
 		generalPattern: regexp.MustCompile(`(?i)(rate\s*limit|limit\s*exceeded|limit\s*reached|you've\s*reached|quota\s*exceeded)`),
+		clock:          util.SystemClock,
+	}
+}
+
+// SetClock sets the clock limits expire against. Nil restores the system
+// clock.
+func (p *LimitParser) SetClock(clock util.Clock) {
+	if clock == nil {
+		clock = util.SystemClock
 	}
+	p.clock = clock
+}
+
+// normalizeLimitResetTime normalizes limit.ResetTime in place, clearing it
+// when the value is implausible
+func normalizeLimitResetTime(limit *LimitInfo) {
+	if limit.ResetTime == nil {
+		return
+	}
+	resetTime, ok := internal.NormalizeResetTimestamp(*limit.ResetTime)
+	if !ok {
+		util.LogWarn(fmt.Sprintf("Ignoring implausible limit reset time %d in %s message", *limit.ResetTime, limit.Type))
+		limit.ResetTime = nil
+		return
+	}
+	limit.ResetTime = &resetTime
 }
 
 // ParseLogs parses conversation logs and returns detected limit information
@@ -68,6 +100,7 @@ func (p *LimitParser) ParseLogs(logs []model.ConversationLog) []LimitInfo {
 		switch log.Type {
 		case "system":
 			if limit := p.parseSystemMessage(log); limit != nil {
+				normalizeLimitResetTime(limit)
 				limits = append(limits, *limit)
 				util.LogInfo(fmt.Sprintf("Found system limit message: %s", limit.Type))
 				util.LogDebug(fmt.Sprintf("System limit details - Type: %s, Timestamp: %s, ResetTime: %v, Content: %.100s",
@@ -78,6 +111,7 @@ func (p *LimitParser) ParseLogs(logs []model.ConversationLog) []LimitInfo {
 			}
 		case "user", "assistant":
 			if limit := p.parseUserAssistantMessage(log); limit != nil {
+				normalizeLimitResetTime(limit)
 				limits = append(limits, *limit)
 				resetTimeStr := "nil"
 				if limit.ResetTime != nil {
@@ -102,10 +136,10 @@ func (p *LimitParser) ParseLogs(logs []model.ConversationLog) []LimitInfo {
 // FilterUnexpiredLimits returns only limits with reset times in the future
 func (p *LimitParser) FilterUnexpiredLimits(limits []LimitInfo) []LimitInfo {
 	var unexpired []LimitInfo
-	currentTime := time.Now().Unix()
+	currentTime := p.clock.Now().Unix()
 	
 	for _, limit := range limits {
-		if limit.IsUnexpired() {
+		if limit.IsUnexpiredAt(currentTime) {
 			unexpired = append(unexpired, limit)
 			util.LogInfo(fmt.Sprintf("Found unexpired limit: reset at %s (in %d minutes)",
 				time.Unix(*limit.ResetTime, 0).Format("2006-01-02 15:04:05"),
@@ -239,11 +273,8 @@ func (p *LimitParser) parseToolResult(item model.ContentItem, log model.Conversa
 	if matches := p.resetPattern.FindStringSubmatch(contentStr); len(matches) > 1 {
 		var resetTimestamp int64
 		fmt.Sscanf(matches[1], "%d", &resetTimestamp)
-		// Convert milliseconds to seconds if needed
-		if resetTimestamp > 1e12 {
-			resetTimestamp = resetTimestamp / 1000
-		}
 		limit.ResetTime = &resetTimestamp
+		normalizeLimitResetTime(limit)
 	}
 
 	// Set message ID if available
@@ -281,14 +312,11 @@ func (p *LimitParser) parseTextContent(text string, log model.ConversationLog, m
 	if matches := p.resetPattern.FindStringSubmatch(text); len(matches) > 1 {
 		var resetTimestamp int64
 		fmt.Sscanf(matches[1], "%d", &resetTimestamp)
-		// Convert milliseconds to seconds if needed
-		if resetTimestamp > 1e12 {
-			resetTimestamp = resetTimestamp / 1000
-		}
 		limit.ResetTime = &resetTimestamp
+		normalizeLimitResetTime(limit)
 
-		util.LogInfo(fmt.Sprintf("Parsed limit message with reset time: %d from text: %s",
-			resetTimestamp, text))
+		util.LogInfo(fmt.Sprintf("Parsed limit message with reset time: %v from text: %s",
+			limit.ResetTime, text))
 	}
 
 	// Set message ID if available
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/core/session/internal"
 )
 
 func TestNewLimitParser(t *testing.T) {
@@ -772,3 +773,82 @@ func intPtr(i int) *int {
 func int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func TestNormalizeResetTimestamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int64
+		expected int64
+		ok       bool
+	}{
+		{"seconds", 1704106800, 1704106800, true},
+		{"milliseconds", 1704106800000, 1704106800, true},
+		{"milliseconds with remainder", 1704106800999, 1704106800, true},
+		{"zero", 0, 0, false},
+		{"negative", -1704106800, 0, false},
+		{"too small", 12345, 0, false},
+		{"microseconds", 1704106800000000, 0, false},
+		{"far future seconds", 55000000000, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := internal.NormalizeResetTimestamp(tt.input)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("NormalizeResetTimestamp(%d) = (%d, %v), want (%d, %v)", tt.input, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestResetTimestampStrategyNormalizesResetTime(t *testing.T) {
+	strategy := internal.NewResetTimestampStrategy()
+
+	result := strategy.Parse("Claude AI usage limit reached|1704106800000", 1704100000, nil)
+	if result == nil || result.ResetTime == nil || *result.ResetTime != 1704106800 {
+		t.Fatalf("Expected millisecond reset time to be normalized to 1704106800, got %+v", result)
+	}
+
+	result = strategy.Parse("Claude AI usage limit reached|55000000000", 1704100000, nil)
+	if result == nil {
+		t.Fatal("Expected the limit to still be reported")
+	}
+	if result.ResetTime != nil {
+		t.Errorf("Expected implausible reset time to be dropped, got %d", *result.ResetTime)
+	}
+}
+
+func TestParseLogsNormalizesMixedResetTimes(t *testing.T) {
+	parser := NewLimitParser()
+
+	newLog := func(text string) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      "assistant",
+			Timestamp: "2024-01-01T10:00:00Z",
+			Message: model.Message{
+				Content: []model.ContentItem{{Type: "text", Text: text}},
+			},
+		}
+	}
+	logs := []model.ConversationLog{
+		newLog("Claude AI usage limit reached|1704106800"),
+		newLog("Claude AI usage limit reached|1704106800000"),
+		newLog("Claude AI usage limit reached|99999999999999999"),
+		newLog("Claude AI usage limit reached|42"),
+	}
+
+	limits := parser.ParseLogs(logs)
+	if len(limits) != 4 {
+		t.Fatalf("Expected 4 limits, got %d", len(limits))
+	}
+	for i, limit := range limits[:2] {
+		if limit.ResetTime == nil || *limit.ResetTime != 1704106800 {
+			t.Errorf("Limit %d: expected reset time 1704106800, got %v", i, limit.ResetTime)
+		}
+	}
+	for i, limit := range limits[2:] {
+		if limit.ResetTime != nil {
+			t.Errorf("Limit %d: expected implausible reset time to be rejected, got %d", i+2, *limit.ResetTime)
+		}
+	}
+}
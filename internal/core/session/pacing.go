@@ -0,0 +1,32 @@
+package session
+
+import "time"
+
+// Burst pacing: the opening stretch of a window is judged against the rest
+const (
+	PacingBurstWindow = 30 * time.Minute // Opening stretch whose rate is judged
+	PacingMinElapsed  = 5 * time.Minute  // Usage observed before the pace is judged
+	PacingWarningLead = time.Hour        // Warn when the limit would be hit at least this long before reset
+)
+
+// BurstTokensPerMinute returns the token rate over the opening minutes of a
+// window starting at start, up to PacingBurstWindow or now. It reports false
+// until PacingMinElapsed has passed.
+func BurstTokensPerMinute(minuteTokens map[int64]int, start, now int64) (float64, bool) {
+	elapsed := time.Duration(now-start) * time.Second
+	if elapsed < PacingMinElapsed {
+		return 0, false
+	}
+	if elapsed > PacingBurstWindow {
+		elapsed = PacingBurstWindow
+	}
+
+	end := start + int64(elapsed.Seconds())
+	tokens := 0
+	for minute, count := range minuteTokens {
+		if minute >= start-start%60 && minute < end {
+			tokens += count
+		}
+	}
+	return float64(tokens) / elapsed.Minutes(), true
+}
@@ -0,0 +1,44 @@
+package session
+
+import "fmt"
+
+// ValidateBurnRateSmoothing reports whether alpha is a usable smoothing factor.
+// Zero disables smoothing.
+func ValidateBurnRateSmoothing(alpha float64) error {
+	if alpha < 0 || alpha > 1 {
+		return fmt.Errorf("burn rate smoothing %g is out of range: must be between 0 and 1", alpha)
+	}
+	return nil
+}
+
+// SmoothedTokensPerMinute returns the exponentially smoothed token rate over
+// the complete minutes between from and now, counting idle minutes as zero.
+// Higher alpha follows recent minutes more closely. It reports false when no
+// minute has completed yet.
+func SmoothedTokensPerMinute(minuteTokens map[int64]int, from, now int64, alpha float64) (float64, bool) {
+	first := from - from%60
+	end := now - now%60
+	if end <= first {
+		return 0, false
+	}
+
+	rate := float64(minuteTokens[first])
+	for minute := first + 60; minute < end; minute += 60 {
+		rate = alpha*float64(minuteTokens[minute]) + (1-alpha)*rate
+	}
+	return rate, true
+}
+
+// ProjectionRates returns the token and cost rates per minute used for
+// projections: the smoothed rate when one was computed, otherwise the session
+// average. The cost rate follows the token rate.
+func (s *Session) ProjectionRates() (tokensPerMinute, costPerMinute float64) {
+	if s.SmoothedTokensPerMinute == nil {
+		return s.TokensPerMinute, s.CostPerMinute
+	}
+	tokensPerMinute = *s.SmoothedTokensPerMinute
+	if s.TokensPerMinute > 0 {
+		costPerMinute = s.CostPerMinute * tokensPerMinute / s.TokensPerMinute
+	}
+	return tokensPerMinute, costPerMinute
+}
@@ -0,0 +1,143 @@
+package session
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+)
+
+// burstThenIdleSession returns a window whose usage all happened in its first
+// minute, one hour before now
+func burstThenIdleSession(now int64) *Session {
+	start := now - now%60 - 3600
+	return &Session{
+		StartTime:    start,
+		EndTime:      start + 5*3600,
+		TotalTokens:  120000,
+		TotalCost:    12,
+		MessageCount: 40,
+		MinuteTokens: map[int64]int{start: 120000},
+	}
+}
+
+func TestSmoothedProjectionLessExtremeAfterBurst(t *testing.T) {
+	now := time.Now().Unix()
+
+	linear := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	linearSession := burstThenIdleSession(now)
+	linear.CalculateMetrics(linearSession, now)
+
+	smoothed := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	smoothed.SetBurnRateSmoothing(0.2)
+	smoothedSession := burstThenIdleSession(now)
+	smoothed.CalculateMetrics(smoothedSession, now)
+
+	if smoothedSession.SmoothedTokensPerMinute == nil {
+		t.Fatal("Expected a smoothed burn rate")
+	}
+	if smoothedSession.ProjectedTokens >= linearSession.ProjectedTokens {
+		t.Errorf("Expected smoothed projection below linear projection, got %d >= %d",
+			smoothedSession.ProjectedTokens, linearSession.ProjectedTokens)
+	}
+	if smoothedSession.ProjectedTokens < smoothedSession.TotalTokens {
+		t.Errorf("Projection %d fell below current usage %d", smoothedSession.ProjectedTokens, smoothedSession.TotalTokens)
+	}
+	if smoothedSession.ProjectedCost >= linearSession.ProjectedCost {
+		t.Errorf("Expected smoothed cost projection below linear, got %.2f >= %.2f",
+			smoothedSession.ProjectedCost, linearSession.ProjectedCost)
+	}
+	// The session average itself is still reported unchanged
+	if smoothedSession.TokensPerMinute != linearSession.TokensPerMinute {
+		t.Errorf("Expected TokensPerMinute to stay the session average, got %.1f and %.1f",
+			smoothedSession.TokensPerMinute, linearSession.TokensPerMinute)
+	}
+}
+
+func TestSmoothedTimeToLimitLaterAfterBurst(t *testing.T) {
+	now := time.Now().Unix()
+	calculator := NewMetricsCalculator(pricing.Plan{TokenLimit: 1000000})
+
+	linear := burstThenIdleSession(now)
+	linear.TokensPerMinute = 5000
+	linear.ResetTime = linear.EndTime
+	linear.PredictedEndTime = linear.ResetTime
+	calculator.Calculate(linear)
+
+	smoothedRate := 50.0
+	smoothed := burstThenIdleSession(now)
+	smoothed.TokensPerMinute = 5000
+	smoothed.SmoothedTokensPerMinute = &smoothedRate
+	smoothed.ResetTime = smoothed.EndTime
+	smoothed.PredictedEndTime = smoothed.ResetTime
+	calculator.Calculate(smoothed)
+
+	if linear.PredictedEndTime >= linear.ResetTime {
+		t.Fatalf("Expected the linear rate to reach the limit before reset, got %d", linear.PredictedEndTime)
+	}
+	if smoothed.PredictedEndTime <= linear.PredictedEndTime {
+		t.Errorf("Expected the smoothed rate to reach the limit later, got %d <= %d",
+			smoothed.PredictedEndTime, linear.PredictedEndTime)
+	}
+}
+
+func TestSmoothedTokensPerMinute(t *testing.T) {
+	start := int64(1700000040) // Minute aligned
+
+	if _, ok := SmoothedTokensPerMinute(nil, start, start+30, 0.5); ok {
+		t.Error("Expected no smoothed rate before a minute completed")
+	}
+
+	// Steady usage smooths to the steady rate
+	steady := map[int64]int{}
+	for m := int64(0); m < 10; m++ {
+		steady[start+m*60] = 100
+	}
+	if rate, ok := SmoothedTokensPerMinute(steady, start, start+600, 0.3); !ok || math.Abs(rate-100) > 1e-9 {
+		t.Errorf("Expected steady rate 100, got %.2f (ok=%v)", rate, ok)
+	}
+
+	// Idle minutes decay a burst by (1-alpha) each
+	burst := map[int64]int{start: 1000}
+	rate, _ := SmoothedTokensPerMinute(burst, start, start+3*60, 0.5)
+	if math.Abs(rate-250) > 1e-9 {
+		t.Errorf("Expected 250 after two idle minutes, got %.2f", rate)
+	}
+}
+
+func TestValidateBurnRateSmoothing(t *testing.T) {
+	for _, alpha := range []float64{0, 0.3, 1} {
+		if err := ValidateBurnRateSmoothing(alpha); err != nil {
+			t.Errorf("Expected alpha %g to be accepted: %v", alpha, err)
+		}
+	}
+	for _, alpha := range []float64{-0.1, 1.5} {
+		if err := ValidateBurnRateSmoothing(alpha); err == nil {
+			t.Errorf("Expected alpha %g to be rejected", alpha)
+		}
+	}
+}
+
+func TestDeduplicateSessionsMergesMinuteTokens(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	start := time.Now().Truncate(time.Hour).Unix()
+
+	first := &Session{ID: "a", StartTime: start, EndTime: start + 5*3600, MinuteTokens: map[int64]int{start: 100, start + 60: 50}}
+	second := &Session{ID: "b", StartTime: start, EndTime: start + 5*3600, MinuteTokens: map[int64]int{start + 60: 25, start + 120: 10}}
+
+	merged := detector.deduplicateSessions([]*Session{first, second})
+	if len(merged) != 1 {
+		t.Fatalf("Expected 1 session after deduplication, got %d", len(merged))
+	}
+	want := map[int64]int{start: 100, start + 60: 75, start + 120: 10}
+	for minute, tokens := range want {
+		if got := merged[0].MinuteTokens[minute]; got != tokens {
+			t.Errorf("Minute %d: expected %d tokens, got %d", minute, tokens, got)
+		}
+	}
+	if len(merged[0].MinuteTokens) != len(want) {
+		t.Errorf("Expected %d minute buckets, got %d", len(want), len(merged[0].MinuteTokens))
+	}
+}
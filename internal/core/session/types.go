@@ -17,6 +17,18 @@ type ProjectStats struct {
 	HourlyMetrics     []*model.HourlyMetric
 	FirstEntryTime    int64 // First message time for this project in the session
 	LastEntryTime     int64 // Last message time for this project in the session
+
+	// Request turnaround, see Session.AverageTurnaround
+	TurnaroundSeconds int64
+	TurnaroundCount   int
+
+	ThinkingOutputTokens int // Output tokens of requests with extended thinking
+}
+
+// AverageTurnaround returns the mean request turnaround of the project, or 0
+// when no request could be paired
+func (p *ProjectStats) AverageTurnaround() time.Duration {
+	return averageTurnaround(p.TurnaroundSeconds, p.TurnaroundCount)
 }
 
 // Session represents an active Claude usage session (account-level)
@@ -40,13 +52,48 @@ type Session struct {
 	WindowSource     string // Source of window detection: "limit_message", "gap", "first_message", "rounded_hour"
 	FirstEntryTime   int64  // Timestamp of the first message in this session
 
+	// How reliably the window was detected: high, medium, low or speculative
+	DetectionConfidence string
+
+	// Window history can shift a detected window to avoid conflicts with
+	// recorded ones; the original times are the raw detection
+	AdjustedByHistory bool
+	OriginalStartTime int64 // Unix timestamp
+	OriginalEndTime   int64 // Unix timestamp
+
 	// Statistics (account-level totals)
 	TotalTokens       int
 	TotalCost         float64
 	MessageCount      int
+
+	// Token breakdown by type; the four sum to TotalTokens
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+
+	// Token limit usage; only the configured token types count
+	LimitTokens     int     // Tokens counted toward the plan's token limit
+	TokenPercentage float64 // LimitTokens as a percentage of the token limit
+	HasLimitTokens  bool    // Whether LimitTokens was calculated for the session
+
+	// Request turnaround: wall-clock gap between a user entry and the first
+	// assistant entry sharing its requestId, summed over paired requests
+	TurnaroundSeconds int64
+	TurnaroundCount   int
+
+	// Output tokens of requests that included extended thinking. They are
+	// billed as output and already counted in OutputTokens.
+	ThinkingOutputTokens int
+
 	ModelDistribution map[string]*model.ModelStats
+	TierDistribution  map[string]*model.TierStats       // Key: normalized service tier
+	GitBranches       map[string]int                    // Tokens by git branch
+	WorkingDirs       map[string]int                    // Tokens by working directory (cwd)
+	Conversations     map[string]int                    // Tokens by conversation (log sessionId)
 	PerModelStats     map[string]map[string]interface{} // Detailed per-model statistics
 	HourlyMetrics     []*model.HourlyMetric
+	MinuteTokens      map[int64]int                     // Tokens by minute start (Unix), for smoothed burn rates
 
 	// Real-time metrics
 	TimeRemaining    time.Duration
@@ -59,6 +106,23 @@ type Session struct {
 	ResetTime        int64 // Unix timestamp
 	PredictedEndTime int64 // Unix timestamp
 
+	// Exponentially smoothed token rate used for projections; nil when
+	// smoothing is off
+	SmoothedTokensPerMinute *float64
+
+	// Message limit usage (plans capped by message count)
+	CountedMessages   int     // Messages counted toward the plan's message limit
+	MessagePercentage float64 // CountedMessages as a percentage of the message limit
+	ProjectedMessages int     // Projected messages at window end, capped at the limit
+
+	// Highest share of any plan limit used and the limit it is of
+	LimitPercentage float64
+	LimitBound      string // LimitBoundTokens, LimitBoundCost, LimitBoundMessages or empty without limits
+
+	// PacingLead is how long before reset the token limit would be hit if the
+	// window's opening burst pace continued (0 = no pacing warning)
+	PacingLead time.Duration
+
 	// Additional fields from Python
 	LimitMessages    []map[string]interface{} // Limit messages detected in this session
 	ProjectionData   map[string]interface{}   // Projection data
@@ -71,3 +135,65 @@ type Session struct {
 	EntriesCount     int
 	WindowPriority   int
 }
+
+// DominantGitBranch returns the git branch with the most tokens in the session
+func (s *Session) DominantGitBranch() string {
+	return dominantKey(s.GitBranches)
+}
+
+// DominantWorkingDir returns the working directory with the most tokens in the session
+func (s *Session) DominantWorkingDir() string {
+	return dominantKey(s.WorkingDirs)
+}
+
+// IsAccountLevel reports whether the session spans several projects. Windows are
+// always tracked per account; this tells whether its usage came from one project.
+func (s *Session) IsAccountLevel() bool {
+	return len(s.Projects) > 1
+}
+
+// AverageTurnaround returns the mean request turnaround of the session, or 0
+// when no request could be paired
+func (s *Session) AverageTurnaround() time.Duration {
+	return averageTurnaround(s.TurnaroundSeconds, s.TurnaroundCount)
+}
+
+// ConversationCount returns the number of distinct conversations, i.e.
+// Claude Code session files, that contributed to the window
+func (s *Session) ConversationCount() int {
+	return len(s.Conversations)
+}
+
+// OnlyGaps reports whether sessions is non-empty and made up solely of idle
+// gaps, i.e. the period holds no usage at all
+func OnlyGaps(sessions []*Session) bool {
+	if len(sessions) == 0 {
+		return false
+	}
+	for _, s := range sessions {
+		if !s.IsGap {
+			return false
+		}
+	}
+	return true
+}
+
+func averageTurnaround(seconds int64, count int) time.Duration {
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second / time.Duration(count)
+}
+
+// dominantKey returns the key with the highest count, breaking ties by name
+func dominantKey(counts map[string]int) string {
+	best := ""
+	bestCount := -1
+	for key, count := range counts {
+		if count > bestCount || (count == bestCount && key < best) {
+			best = key
+			bestCount = count
+		}
+	}
+	return best
+}
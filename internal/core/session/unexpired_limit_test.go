@@ -215,4 +215,57 @@ func TestUnexpiredLimitOverridesExistingWindow(t *testing.T) {
 			time.Unix(limitSession.EndTime, 0).Format("2006-01-02 15:04:05"),
 			messageCount)
 	}
-}
\ No newline at end of file
+}
+func TestLimitHandlerReceivesUnexpiredLimit(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(nil, "Local", t.TempDir())
+	var events []LimitEvent
+	detector.SetLimitHandler(func(event LimitEvent) {
+		events = append(events, event)
+	})
+
+	currentTime := time.Now()
+	resetTime := currentTime.Add(2 * time.Hour).Unix()
+	message := func(at time.Time, project string) timeline.TimestampedLog {
+		return timeline.TimestampedLog{
+			Timestamp:   at.Unix(),
+			ProjectName: project,
+			Log: model.ConversationLog{
+				Type:      "message:sent",
+				Timestamp: at.Format(time.RFC3339),
+				Message: model.Message{
+					Model: "claude-3-5-sonnet-20241022",
+					Usage: model.Usage{InputTokens: 100, OutputTokens: 50},
+				},
+			},
+		}
+	}
+	limitAt := currentTime.Add(-30 * time.Minute)
+	globalTimeline := []timeline.TimestampedLog{
+		message(currentTime.Add(-10*time.Hour), "old-project"),
+		message(currentTime.Add(-2*time.Hour), "web"),
+		message(currentTime.Add(-time.Hour), "api"),
+		{
+			Timestamp:   limitAt.Unix(),
+			ProjectName: "api",
+			Log: model.ConversationLog{
+				Type:      "assistant",
+				Timestamp: limitAt.Format(time.RFC3339),
+				Message: model.Message{
+					Model: "claude-3-5-sonnet-20241022",
+					Content: []model.ContentItem{{
+						Type:    "tool_result",
+						Content: fmt.Sprintf("Claude AI usage limit reached|%d", resetTime),
+					}},
+				},
+			},
+		},
+	}
+
+	detector.DetectSessionsWithLimits(SessionDetectionInput{GlobalTimeline: globalTimeline})
+
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, resetTime, events[0].ResetTime)
+		assert.Equal(t, limitAt.Unix(), events[0].DetectedAt)
+		assert.Equal(t, []string{"api", "web"}, events[0].Projects)
+	}
+}
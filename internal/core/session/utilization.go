@@ -0,0 +1,42 @@
+package session
+
+import "time"
+
+// Utilization compares the time covered by usage windows with the idle gaps
+// between them
+type Utilization struct {
+	ActiveTime time.Duration // Time spanned by non-gap windows, up to now for open ones
+	IdleTime   time.Duration // Time spanned by gap sessions
+}
+
+// CalculateUtilization totals the active window time and idle gap time of
+// sessions. Windows still open at now only count the time elapsed so far.
+func CalculateUtilization(sessions []*Session, now int64) Utilization {
+	var u Utilization
+	for _, s := range sessions {
+		end := s.EndTime
+		if end > now {
+			end = now
+		}
+		if end <= s.StartTime {
+			continue
+		}
+
+		span := time.Duration(end-s.StartTime) * time.Second
+		if s.IsGap {
+			u.IdleTime += span
+		} else {
+			u.ActiveTime += span
+		}
+	}
+	return u
+}
+
+// Ratio returns the share of active time in active plus idle time, 0-1
+func (u Utilization) Ratio() float64 {
+	total := u.ActiveTime + u.IdleTime
+	if total <= 0 {
+		return 0
+	}
+	return float64(u.ActiveTime) / float64(total)
+}
@@ -0,0 +1,35 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateUtilization(t *testing.T) {
+	base := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC).Unix()
+	now := base + 14*3600
+
+	// Two full windows, a 2h gap between them and an open window 1h in
+	sessions := []*Session{
+		{StartTime: base, EndTime: base + 5*3600},
+		{IsGap: true, StartTime: base + 5*3600, EndTime: base + 7*3600},
+		{StartTime: base + 7*3600, EndTime: base + 12*3600},
+		{IsGap: true, StartTime: base + 12*3600, EndTime: base + 13*3600},
+		{IsActive: true, StartTime: base + 13*3600, EndTime: base + 18*3600},
+	}
+
+	u := CalculateUtilization(sessions, now)
+	if u.ActiveTime != 11*time.Hour {
+		t.Errorf("Expected 11h active, got %s", u.ActiveTime)
+	}
+	if u.IdleTime != 3*time.Hour {
+		t.Errorf("Expected 3h idle, got %s", u.IdleTime)
+	}
+	if ratio := u.Ratio(); ratio < 0.7857 || ratio > 0.7858 {
+		t.Errorf("Expected a utilization ratio of 11/14, got %f", ratio)
+	}
+
+	if ratio := CalculateUtilization(nil, now).Ratio(); ratio != 0 {
+		t.Errorf("Expected no utilization without sessions, got %f", ratio)
+	}
+}
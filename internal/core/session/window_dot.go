@@ -0,0 +1,113 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// GetWindowSelection returns the candidate windows considered by the last
+// detection and the windows that were selected from them
+func (d *SessionDetector) GetWindowSelection() (candidates, selected []WindowCandidate) {
+	return d.lastCandidates, d.lastSelected
+}
+
+// WriteWindowSelectionDOT writes the last window selection as a Graphviz DOT graph
+func (d *SessionDetector) WriteWindowSelectionDOT(w io.Writer) error {
+	return writeWindowSelectionDOT(w, d.lastCandidates, d.lastSelected, d.timezone)
+}
+
+// writeWindowSelectionDOT renders one node per candidate window, labeled with
+// its source and priority. Selected windows are green and chained in time
+// order; rejected windows are grey with a dashed red edge from each selected
+// window they overlap. Selected windows that were adjusted during selection
+// and no longer match a candidate get their own node.
+func writeWindowSelectionDOT(w io.Writer, candidates, selected []WindowCandidate, loc *time.Location) error {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	sorted := make([]WindowCandidate, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].StartTime != sorted[j].StartTime {
+			return sorted[i].StartTime < sorted[j].StartTime
+		}
+		return sorted[i].Priority > sorted[j].Priority
+	})
+
+	type node struct {
+		id       string
+		window   WindowCandidate
+		selected bool
+		adjusted bool
+	}
+
+	// Match each selected window to the first unmatched candidate with the
+	// same source, priority and start time
+	nodes := make([]*node, len(sorted))
+	for i, c := range sorted {
+		nodes[i] = &node{id: fmt.Sprintf("c%d", i), window: c}
+	}
+	var selectedNodes []*node
+	for _, s := range selected {
+		var match *node
+		for _, n := range nodes {
+			if !n.selected && n.window.Source == s.Source && n.window.Priority == s.Priority && n.window.StartTime == s.StartTime {
+				match = n
+				break
+			}
+		}
+		if match == nil {
+			match = &node{id: fmt.Sprintf("s%d", len(selectedNodes)), adjusted: true}
+			nodes = append(nodes, match)
+		}
+		match.window = s
+		match.selected = true
+		selectedNodes = append(selectedNodes, match)
+	}
+	sort.SliceStable(selectedNodes, func(i, j int) bool {
+		return selectedNodes[i].window.StartTime < selectedNodes[j].window.StartTime
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph windows {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, `  node [shape=box, style="rounded,filled", fontname="Helvetica"];`)
+
+	for _, n := range nodes {
+		label := fmt.Sprintf("%s (p%d)\\n%s - %s", n.window.Source, n.window.Priority,
+			time.Unix(n.window.StartTime, 0).In(loc).Format("2006-01-02 15:04"),
+			time.Unix(n.window.EndTime, 0).In(loc).Format("15:04"))
+		if n.adjusted {
+			label += "\\n(adjusted)"
+		}
+		if n.selected {
+			fmt.Fprintf(bw, "  %s [label=\"%s\", fillcolor=palegreen, color=darkgreen, penwidth=2];\n", n.id, label)
+		} else {
+			fmt.Fprintf(bw, "  %s [label=\"%s\", fillcolor=lightgrey, color=gray50, fontcolor=gray30];\n", n.id, label)
+		}
+	}
+
+	// Selected windows in time order
+	for i := 1; i < len(selectedNodes); i++ {
+		fmt.Fprintf(bw, "  %s -> %s [color=darkgreen, penwidth=2];\n", selectedNodes[i-1].id, selectedNodes[i].id)
+	}
+
+	// Rejected candidates that overlap a selected window
+	for _, s := range selectedNodes {
+		for _, n := range nodes {
+			if n.selected {
+				continue
+			}
+			if n.window.StartTime < s.window.EndTime && n.window.EndTime > s.window.StartTime {
+				fmt.Fprintf(bw, "  %s -> %s [label=\"overlaps\", style=dashed, color=red];\n", s.id, n.id)
+			}
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+)
+
+func TestWriteWindowSelectionDOT(t *testing.T) {
+	detector := NewSessionDetectorWithAggregator(aggregator.NewAggregatorWithTimezone("UTC"), "UTC", t.TempDir())
+	detector.windowHistory = nil
+
+	base := time.Now().Add(-48 * time.Hour).Truncate(time.Hour).Unix()
+	candidates := []WindowCandidate{
+		{StartTime: base, EndTime: base + 5*3600, Source: "limit_message", Priority: 9, IsLimit: true},
+		{StartTime: base + 3600, EndTime: base + 6*3600, Source: "first_message", Priority: 3},
+		{StartTime: base + 6*3600, EndTime: base + 11*3600, Source: "gap", Priority: 5},
+	}
+	selected := detector.selectBestWindows(candidates)
+	if len(selected) != 2 {
+		t.Fatalf("Expected 2 selected windows, got %d", len(selected))
+	}
+	detector.lastCandidates = candidates
+	detector.lastSelected = selected
+
+	var buf bytes.Buffer
+	if err := detector.WriteWindowSelectionDOT(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	dot := buf.String()
+
+	if !strings.HasPrefix(dot, "digraph windows {") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("Expected a complete digraph, got:\n%s", dot)
+	}
+
+	// One node per candidate, labeled with source and priority
+	nodePattern := regexp.MustCompile(`(?m)^  \w+ \[label=`)
+	if nodes := len(nodePattern.FindAllString(dot, -1)); nodes != len(candidates) {
+		t.Errorf("Expected %d nodes, got %d:\n%s", len(candidates), nodes, dot)
+	}
+	for _, label := range []string{"limit_message (p9)", "first_message (p3)", "gap (p5)"} {
+		if !strings.Contains(dot, label) {
+			t.Errorf("Expected node labeled %q", label)
+		}
+	}
+
+	// Selected windows are highlighted, the overlapping first_message is rejected
+	if selectedNodes := strings.Count(dot, "fillcolor=palegreen"); selectedNodes != 2 {
+		t.Errorf("Expected 2 selected nodes, got %d", selectedNodes)
+	}
+	if !strings.Contains(dot, `c0 [label="limit_message (p9)`) || !strings.Contains(dot, `c1 [label="first_message (p3)`) {
+		t.Errorf("Expected candidates in start order:\n%s", dot)
+	}
+	if !strings.Contains(dot, "c0 -> c1 [label=\"overlaps\", style=dashed, color=red];") {
+		t.Errorf("Expected overlap edge from selected limit window to rejected candidate:\n%s", dot)
+	}
+	if !strings.Contains(dot, "c0 -> c2 [color=darkgreen, penwidth=2];") {
+		t.Errorf("Expected selected windows to be chained in time order:\n%s", dot)
+	}
+}
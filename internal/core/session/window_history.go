@@ -52,7 +52,12 @@ type WindowHistory struct {
 type WindowHistoryManager struct {
 	history     *WindowHistory
 	historyPath string
+	memoryOnly  bool // true when no writable location exists; Save becomes a no-op
 	mu          sync.Mutex
+
+	sessionSeconds int64 // Length of a window, constants.SessionDurationSeconds unless set
+
+	clock util.Clock // Clock windows are validated and expired against
 }
 
 // NewWindowHistoryManager creates a new window history manager
@@ -61,17 +66,93 @@ func NewWindowHistoryManager(cacheDir string) *WindowHistoryManager {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		// Fallback to cache directory if home directory is not accessible
-		return &WindowHistoryManager{
-			historyPath: filepath.Join(cacheDir, "window_history.json"),
-			history:     &WindowHistory{Windows: make([]WindowRecord, 0)},
+		return newWindowHistoryManager("", cacheDir)
+	}
+
+	return newWindowHistoryManager(filepath.Join(homeDir, ".go-claude-monitor", "history"), cacheDir)
+}
+
+// SetClock sets the clock windows are validated and expired against, so
+// history follows the detector's clock. Nil restores the system clock.
+func (m *WindowHistoryManager) SetClock(clock util.Clock) {
+	if clock == nil {
+		clock = util.SystemClock
+	}
+	m.clock = clock
+}
+
+// now returns the current Unix time of the history clock
+func (m *WindowHistoryManager) now() int64 {
+	return m.clock.Now().Unix()
+}
+
+// SetSessionDuration sets the window length used to validate windows and to
+// derive them from limit reset times
+func (m *WindowHistoryManager) SetSessionDuration(duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionSeconds = int64(duration.Seconds())
+}
+
+// newWindowHistoryManager picks the first writable directory among historyDir and
+// cacheDir. If neither can be written, the manager keeps history in memory only so
+// that periodic saves don't fail on every cycle.
+func newWindowHistoryManager(historyDir, cacheDir string) *WindowHistoryManager {
+	m := &WindowHistoryManager{
+		history:        &WindowHistory{Windows: make([]WindowRecord, 0)},
+		sessionSeconds: constants.SessionDurationSeconds,
+		clock:          util.SystemClock,
+	}
+
+	for _, dir := range []string{historyDir, cacheDir} {
+		if dir == "" {
+			continue
+		}
+		if err := checkDirWritable(dir); err != nil {
+			util.LogDebug(fmt.Sprintf("Window history directory %s is not writable: %v", dir, err))
+			continue
+		}
+		if dir != historyDir && historyDir != "" {
+			util.LogWarn(fmt.Sprintf("Window history directory %s is not writable, using %s instead", historyDir, dir))
 		}
+		m.historyPath = filepath.Join(dir, "window_history.json")
+		return m
 	}
 
-	historyDir := filepath.Join(homeDir, ".go-claude-monitor", "history")
-	return &WindowHistoryManager{
-		historyPath: filepath.Join(historyDir, "window_history.json"),
-		history:     &WindowHistory{Windows: make([]WindowRecord, 0)},
+	// Remember the preferred location so Load can still read an existing file
+	preferred := historyDir
+	if preferred == "" {
+		preferred = cacheDir
 	}
+	m.historyPath = filepath.Join(preferred, "window_history.json")
+	m.memoryOnly = true
+	util.LogWarn(fmt.Sprintf("No writable directory for window history (tried %s and %s), keeping history in memory only",
+		historyDir, cacheDir))
+	return m
+}
+
+// checkDirWritable ensures dir exists and a file can be created inside it
+func checkDirWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".write-probe-*")
+	if err != nil {
+		return err
+	}
+	name := probe.Name()
+	probe.Close()
+	return os.Remove(name)
+}
+
+// IsMemoryOnly reports whether window history is kept in memory without persistence
+func (m *WindowHistoryManager) IsMemoryOnly() bool {
+	return m.memoryOnly
+}
+
+// GetHistoryPath returns the file path used to persist window history
+func (m *WindowHistoryManager) GetHistoryPath() string {
+	return m.historyPath
 }
 
 // Load loads window history from disk
@@ -90,7 +171,7 @@ func (m *WindowHistoryManager) Load() error {
 
 	var history WindowHistory
 	if err := json.Unmarshal(data, &history); err != nil {
-		return fmt.Errorf("failed to unmarshal window history: %w", err)
+		return m.recoverCorruptHistory(err)
 	}
 
 	// Populate string fields for all loaded records
@@ -98,18 +179,94 @@ func (m *WindowHistoryManager) Load() error {
 		history.Windows[i].populateStringFields()
 	}
 
+	// The file may have been edited by hand or partially corrupted
+	windows, mergedCount := mergeDuplicateWindows(history.Windows)
+	if mergedCount > 0 {
+		util.LogWarn(fmt.Sprintf("Merged %d duplicate window records in %s", mergedCount, m.historyPath))
+		history.Windows = windows
+	}
+
 	m.history = &history
 	util.LogInfo(fmt.Sprintf("Loaded %d window records from history", len(history.Windows)))
 	return nil
 }
 
+// recoverCorruptHistory moves an unreadable history file aside to
+// window_history.json.bad for inspection and starts with an empty history, so
+// later saves replace it. The caller must hold m.mu.
+func (m *WindowHistoryManager) recoverCorruptHistory(parseErr error) error {
+	backupPath := m.historyPath + ".bad"
+	if err := os.Rename(m.historyPath, backupPath); err != nil {
+		return fmt.Errorf("failed to back up corrupt window history: %w", err)
+	}
+	m.history = &WindowHistory{Windows: make([]WindowRecord, 0)}
+	util.LogWarn(fmt.Sprintf("Window history %s is not valid JSON (%v), moved it to %s and starting fresh",
+		m.historyPath, parseErr, backupPath))
+	return nil
+}
+
+// windowRange identifies a window by its time range
+type windowRange struct {
+	start, end int64
+}
+
+// mergeDuplicateWindows collapses records sharing a session ID or an identical
+// time range into one, keeping the strongest record of each group. It returns
+// the remaining records sorted by start time and the number of records merged away.
+func mergeDuplicateWindows(windows []WindowRecord) ([]WindowRecord, int) {
+	var result []WindowRecord
+	byID := make(map[string]int)
+	byRange := make(map[windowRange]int)
+	mergedCount := 0
+
+	for _, record := range windows {
+		key := windowRange{record.StartTime, record.EndTime}
+		idx, found := byID[record.SessionID]
+		if !found {
+			idx, found = byRange[key]
+		}
+		if !found {
+			idx = len(result)
+			result = append(result, record)
+		} else {
+			mergedCount++
+			if isStrongerWindow(record, result[idx]) {
+				result[idx] = record
+			}
+		}
+		byID[record.SessionID] = idx
+		byRange[key] = idx
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartTime < result[j].StartTime
+	})
+	return result, mergedCount
+}
+
+// isStrongerWindow reports whether candidate should replace existing.
+// Windows confirmed by a limit message win; otherwise the newer record wins.
+func isStrongerWindow(candidate, existing WindowRecord) bool {
+	candidateLimit := candidate.IsLimitReached || candidate.Source == "limit_message"
+	existingLimit := existing.IsLimitReached || existing.Source == "limit_message"
+	if candidateLimit != existingLimit {
+		return candidateLimit
+	}
+	return candidate.CreatedAt > existing.CreatedAt
+}
+
 // Save saves window history to disk
 func (m *WindowHistoryManager) Save() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.memoryOnly {
+		util.LogDebug(fmt.Sprintf("Window history is memory-only, skipping save of %d records", len(m.history.Windows)))
+		return nil
+	}
+
 	m.history.mu.Lock()
-	m.history.LastUpdated = time.Now().Unix()
+	m.history.LastUpdated = m.now()
 	data, err := sonic.MarshalIndent(m.history, "", "  ")
 	m.history.mu.Unlock()
 
@@ -143,7 +300,7 @@ func (m *WindowHistoryManager) AddOrUpdateWindow(record WindowRecord) {
 	defer m.history.mu.Unlock()
 
 	// Check time validity based on window type
-	currentTime := time.Now().Unix()
+	currentTime := m.now()
 
 	if record.IsLimitReached {
 		// Limit-reached windows must be historical (not in future)
@@ -199,7 +356,9 @@ func (m *WindowHistoryManager) AddOrUpdateWindow(record WindowRecord) {
 	})
 }
 
-// ValidateNewWindow checks if a proposed window is valid based on history
+// ValidateNewWindow checks if a proposed window is valid based on history. The
+// returned bounds may be shifted clear of recorded windows; isValid is false
+// only when the window must be rejected.
 func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int64) (validStart, validEnd int64, isValid bool) {
 	m.history.mu.RLock()
 	defer m.history.mu.RUnlock()
@@ -213,7 +372,7 @@ func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int6
 		time.Unix(proposedEnd, 0).Format("2006-01-02 15:04:05")))
 
 	// Get current time and reasonable time bounds
-	currentTime := time.Now().Unix()
+	currentTime := m.now()
 	minReasonableTime := currentTime - constants.LimitWindowRetentionSeconds
 	maxReasonableTime := currentTime + constants.MaxFutureWindowSeconds
 	
@@ -227,7 +386,11 @@ func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int6
 		// Special handling for unexpired limit_message windows
 		if record.IsLimitReached && record.Source == "limit_message" && record.EndTime > currentTime {
 			// This is an unexpired limit window - it has absolute authority
-			
+			if proposedStart == record.StartTime && proposedEnd == record.EndTime {
+				// The proposed window is this limit window itself
+				continue
+			}
+
 			// Check for overlap
 			if proposedStart < record.EndTime && proposedEnd > record.StartTime {
 				// There's an overlap with an unexpired limit window
@@ -249,16 +412,16 @@ func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int6
 					if proposedStart < record.StartTime {
 						// Proposed window starts before limit window - truncate at limit start
 						validEnd = record.StartTime
-						validStart = validEnd - constants.SessionDurationSeconds
+						validStart = validEnd - m.sessionSeconds
 						if validStart < 0 {
 							// Can't fit a full window before the limit window
 							validStart = record.EndTime
-							validEnd = validStart + constants.SessionDurationSeconds
+							validEnd = validStart + m.sessionSeconds
 						}
 					} else {
 						// Proposed window overlaps end of limit window - start after it
 						validStart = record.EndTime
-						validEnd = validStart + constants.SessionDurationSeconds
+						validEnd = validStart + m.sessionSeconds
 					}
 				}
 			}
@@ -271,6 +434,10 @@ func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int6
 		if record.IsLimitReached && record.Source == "limit_message" && record.EndTime > currentTime {
 			continue
 		}
+		// A window never conflicts with its own record
+		if record.StartTime == proposedStart {
+			continue
+		}
 		
 		// Handle expired limit_message windows
 		if record.IsLimitReached && record.Source == "limit_message" {
@@ -299,7 +466,7 @@ func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int6
 					time.Unix(record.StartTime, 0).Format("2006-01-02 15:04:05"),
 					time.Unix(record.EndTime, 0).Format("2006-01-02 15:04:05")))
 				validStart = record.EndTime
-				validEnd = validStart + constants.SessionDurationSeconds
+				validEnd = validStart + m.sessionSeconds
 			}
 		} else {
 			// Handle non-limit windows
@@ -319,15 +486,15 @@ func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int6
 						time.Unix(record.StartTime, 0).Format("2006-01-02 15:04:05"),
 						time.Unix(record.EndTime, 0).Format("2006-01-02 15:04:05")))
 					validStart = record.EndTime
-					validEnd = validStart + constants.SessionDurationSeconds
+					validEnd = validStart + m.sessionSeconds
 				}
 			}
 		}
 	}
 
 	// Validate the window is still session duration
-	if validEnd-validStart != constants.SessionDurationSeconds {
-		validEnd = validStart + constants.SessionDurationSeconds
+	if validEnd-validStart != m.sessionSeconds {
+		validEnd = validStart + m.sessionSeconds
 	}
 
 	// Check if adjusted window is too far in the future
@@ -340,10 +507,10 @@ func (m *WindowHistoryManager) ValidateNewWindow(proposedStart, proposedEnd int6
 		return proposedStart, proposedEnd, true
 	}
 
-	isValid = validStart == proposedStart // Window was NOT adjusted if start time is the same
+	isValid = true
 	
 	// Log final result
-	if !isValid {
+	if validStart != proposedStart {
 		util.LogDebug(fmt.Sprintf("Window validation result: Window was adjusted from %s-%s to %s-%s",
 			time.Unix(proposedStart, 0).Format("2006-01-02 15:04:05"),
 			time.Unix(proposedEnd, 0).Format("2006-01-02 15:04:05"),
@@ -372,6 +539,42 @@ func (m *WindowHistoryManager) GetLimitReachedWindows() []WindowRecord {
 	return limitReached
 }
 
+// WindowsAt returns the records as they stood at the given Unix time: those
+// whose CreatedAt is not after it, ordered by start time. CreatedAt is
+// refreshed whenever a record is updated, so records changed later are left
+// out as well. A zero time returns every record.
+func (m *WindowHistoryManager) WindowsAt(at int64) []WindowRecord {
+	m.history.mu.RLock()
+	defer m.history.mu.RUnlock()
+
+	var windows []WindowRecord
+	for _, record := range m.history.Windows {
+		if at == 0 || record.CreatedAt <= at {
+			windows = append(windows, record)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].StartTime < windows[j].StartTime
+	})
+	return windows
+}
+
+// RetainLimitWindows drops every record not learned from a limit message and
+// returns how many records were kept
+func (m *WindowHistoryManager) RetainLimitWindows() int {
+	m.history.mu.Lock()
+	defer m.history.mu.Unlock()
+
+	kept := m.history.Windows[:0]
+	for _, record := range m.history.Windows {
+		if record.IsLimitReached && record.Source == "limit_message" {
+			kept = append(kept, record)
+		}
+	}
+	m.history.Windows = kept
+	return len(kept)
+}
+
 // GetAccountLevelWindows returns all account-level windows (across all projects)
 func (m *WindowHistoryManager) GetAccountLevelWindows() []WindowRecord {
 	m.history.mu.RLock()
@@ -391,7 +594,7 @@ func (m *WindowHistoryManager) GetRecentWindows(duration time.Duration) []Window
 	m.history.mu.RLock()
 	defer m.history.mu.RUnlock()
 
-	cutoff := time.Now().Unix() - int64(duration.Seconds())
+	cutoff := m.now() - int64(duration.Seconds())
 	var recent []WindowRecord
 	
 	for _, record := range m.history.Windows {
@@ -406,10 +609,17 @@ func (m *WindowHistoryManager) GetRecentWindows(duration time.Duration) []Window
 
 // UpdateFromLimitMessage updates window history based on a limit message
 func (m *WindowHistoryManager) UpdateFromLimitMessage(resetTime int64, messageTime int64, limitMessage string) {
+	normalized, ok := internal.NormalizeResetTimestamp(resetTime)
+	if !ok {
+		util.LogWarn(fmt.Sprintf("Ignoring limit window with implausible reset time: %d", resetTime))
+		return
+	}
+	resetTime = normalized
+
 	// Calculate window boundaries from reset time
 	windowEnd := resetTime
-	windowStart := windowEnd - constants.SessionDurationSeconds
-	currentTime := time.Now().Unix()
+	windowStart := windowEnd - m.sessionSeconds
+	currentTime := m.now()
 
 	// Check if this is an unexpired limit
 	isUnexpired := resetTime > currentTime
@@ -422,7 +632,7 @@ func (m *WindowHistoryManager) UpdateFromLimitMessage(resetTime int64, messageTi
 		IsAccountLevel: true, // Limit messages apply to the entire account
 		LimitMessage:   limitMessage,
 		SessionID:      fmt.Sprintf("%d", windowStart),
-		CreatedAt:      time.Now().Unix(),
+		CreatedAt:      m.now(),
 	}
 
 	// Populate string fields
@@ -493,7 +703,7 @@ func (m *WindowHistoryManager) LoadHistoricalLimitWindows(logs []model.Conversat
 
 	// Track how many new windows were added
 	addedCount := 0
-	currentTime := time.Now().Unix()
+	currentTime := m.now()
 	minAllowedTime := currentTime - constants.HistoricalScanSeconds // Historical scan period
 	
 	util.LogDebug(fmt.Sprintf("LoadHistoricalLimitWindows: Scanning period from %s to %s (%d days)",
@@ -517,8 +727,11 @@ func (m *WindowHistoryManager) LoadHistoricalLimitWindows(logs []model.Conversat
 		}
 
 		// Calculate window boundaries from reset time
-		windowEnd := *limit.ResetTime
-		windowStart := windowEnd - constants.SessionDurationSeconds
+		windowEnd, ok := internal.NormalizeResetTimestamp(*limit.ResetTime)
+		if !ok {
+			continue
+		}
+		windowStart := windowEnd - m.sessionSeconds
 
 		// Check if this window already exists
 		existing := false
@@ -634,7 +847,7 @@ func (m *WindowHistoryManager) CleanOldWindows() int {
 	m.history.mu.Lock()
 	defer m.history.mu.Unlock()
 
-	currentTime := time.Now().Unix()
+	currentTime := m.now()
 	minRetentionTime := currentTime - constants.LimitWindowRetentionSeconds
 	
 	var kept []WindowRecord
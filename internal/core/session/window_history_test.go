@@ -0,0 +1,164 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockedDir returns a directory path that can never be created because one
+// of its parents is a regular file. This works even when tests run as root.
+func blockedDir(t *testing.T) string {
+	t.Helper()
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	require.NoError(t, os.WriteFile(blocker, []byte("x"), 0644))
+	return filepath.Join(blocker, "history")
+}
+
+func testWindowRecord() WindowRecord {
+	start := time.Now().Add(-time.Hour).Truncate(time.Hour).Unix()
+	return WindowRecord{
+		StartTime: start,
+		EndTime:   start + 5*3600,
+		Source:    "first_message",
+	}
+}
+
+func TestWindowHistoryManagerUsesWritableHistoryDir(t *testing.T) {
+	historyDir := filepath.Join(t.TempDir(), "history")
+	cacheDir := t.TempDir()
+
+	m := newWindowHistoryManager(historyDir, cacheDir)
+
+	assert.False(t, m.IsMemoryOnly())
+	assert.Equal(t, filepath.Join(historyDir, "window_history.json"), m.GetHistoryPath())
+
+	m.AddOrUpdateWindow(testWindowRecord())
+	require.NoError(t, m.Save())
+	assert.FileExists(t, m.GetHistoryPath())
+
+	// No probe files should be left behind
+	entries, err := os.ReadDir(historyDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWindowHistoryManagerFallsBackToCacheDir(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	m := newWindowHistoryManager(blockedDir(t), cacheDir)
+
+	assert.False(t, m.IsMemoryOnly())
+	assert.Equal(t, filepath.Join(cacheDir, "window_history.json"), m.GetHistoryPath())
+
+	m.AddOrUpdateWindow(testWindowRecord())
+	require.NoError(t, m.Save())
+	assert.FileExists(t, m.GetHistoryPath())
+
+	reloaded := newWindowHistoryManager(blockedDir(t), cacheDir)
+	require.NoError(t, reloaded.Load())
+	assert.Len(t, reloaded.GetRecentWindows(time.Hour*24), 1)
+}
+
+func TestWindowHistoryManagerMemoryOnlyWhenNothingWritable(t *testing.T) {
+	m := newWindowHistoryManager(blockedDir(t), blockedDir(t))
+
+	assert.True(t, m.IsMemoryOnly())
+
+	m.AddOrUpdateWindow(testWindowRecord())
+
+	// Every refresh cycle calls Save; none of them should report an error
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, m.Save())
+	}
+	assert.NoFileExists(t, m.GetHistoryPath())
+
+	// History is still available in memory
+	assert.Len(t, m.GetRecentWindows(time.Hour*24), 1)
+}
+
+func TestWindowHistoryManagerLoadMergesDuplicateRecords(t *testing.T) {
+	historyDir := t.TempDir()
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Hour).Unix()
+	end := start + 5*3600
+
+	history := WindowHistory{Windows: []WindowRecord{
+		{SessionID: "a", Source: "first_message", StartTime: start, EndTime: end, CreatedAt: 100},
+		{SessionID: "a", Source: "gap", StartTime: start + 60, EndTime: end + 60, CreatedAt: 200},
+		{SessionID: "b", Source: "limit_message", StartTime: start, EndTime: end, CreatedAt: 50, IsLimitReached: true},
+		{SessionID: "c", Source: "rounded_hour", StartTime: end, EndTime: end + 5*3600, CreatedAt: 100},
+	}}
+	data, err := json.Marshal(&history)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(historyDir, "window_history.json"), data, 0644))
+
+	m := newWindowHistoryManager(historyDir, t.TempDir())
+	require.NoError(t, m.Load())
+
+	windows := m.history.Windows
+	require.Len(t, windows, 2)
+	assert.Equal(t, "b", windows[0].SessionID)
+	assert.Equal(t, "limit_message", windows[0].Source)
+	assert.True(t, windows[0].IsLimitReached)
+	assert.Equal(t, "c", windows[1].SessionID)
+}
+
+func TestWindowHistoryManagerLoadBacksUpCorruptFile(t *testing.T) {
+	historyDir := t.TempDir()
+	historyPath := filepath.Join(historyDir, "window_history.json")
+	corrupt := []byte(`{"windows": [{"session_id": "a",`)
+	require.NoError(t, os.WriteFile(historyPath, corrupt, 0644))
+
+	m := newWindowHistoryManager(historyDir, t.TempDir())
+	require.NoError(t, m.Load())
+	assert.Empty(t, m.history.Windows)
+
+	backup, err := os.ReadFile(historyPath + ".bad")
+	require.NoError(t, err)
+	assert.Equal(t, corrupt, backup)
+	assert.NoFileExists(t, historyPath)
+
+	// The fresh history saves over the corrupt one
+	require.NoError(t, m.Save())
+	require.NoError(t, m.Load())
+	assert.Empty(t, m.history.Windows)
+	assert.FileExists(t, historyPath)
+}
+
+func TestValidateNewWindowReturnsShiftedBoundsAsValid(t *testing.T) {
+	m := newWindowHistoryManager(t.TempDir(), t.TempDir())
+	record := testWindowRecord()
+	record.StartTime -= 6 * 3600
+	record.EndTime -= 6 * 3600
+	m.AddOrUpdateWindow(record)
+
+	// Overlapping the recorded window moves the proposal past its end
+	start, end, valid := m.ValidateNewWindow(record.StartTime+3600, record.EndTime+3600)
+	assert.True(t, valid)
+	assert.Equal(t, record.EndTime, start)
+	assert.Equal(t, record.EndTime+5*3600, end)
+
+	// A proposal clear of history is returned unchanged
+	start, end, valid = m.ValidateNewWindow(record.EndTime, record.EndTime+5*3600)
+	assert.True(t, valid)
+	assert.Equal(t, record.EndTime, start)
+	assert.Equal(t, record.EndTime+5*3600, end)
+}
+
+func TestValidateNewWindowIgnoresItsOwnRecord(t *testing.T) {
+	m := newWindowHistoryManager(t.TempDir(), t.TempDir())
+	record := testWindowRecord()
+	record.StartTime -= 6 * 3600
+	record.EndTime -= 6 * 3600
+	m.AddOrUpdateWindow(record)
+
+	start, end, valid := m.ValidateNewWindow(record.StartTime, record.EndTime)
+	assert.True(t, valid)
+	assert.Equal(t, record.StartTime, start)
+	assert.Equal(t, record.EndTime, end)
+}
@@ -75,6 +75,9 @@ func (tb *TimelineBuilder) BuildFromCachedData(cachedData []aggregator.Aggregate
 	for _, data := range cachedData {
 		// Add entries from hourly data
 		hourlyEntries := tb.BuildFromHourlyData(data.HourlyStats)
+		for i := range hourlyEntries {
+			hourlyEntries[i].SessionId = data.SessionId
+		}
 		entries = append(entries, hourlyEntries...)
 		
 		// Add entries from cached limit messages
@@ -120,10 +123,20 @@ func (tb *TimelineBuilder) ConvertToTimestampedLogs(entries []TimelineEntry) []T
 		switch entry.Type {
 		case "message":
 			if log, ok := entry.Data.(model.ConversationLog); ok {
+				userTurns := 0
+				if log.IsUserTurn() {
+					userTurns = 1
+				}
+				thinkingOutputTokens := 0
+				if log.HasThinking() {
+					thinkingOutputTokens = log.Message.Usage.OutputTokens
+				}
 				logs = append(logs, TimestampedLog{
-					Log:         log,
-					Timestamp:   entry.Timestamp,
-					ProjectName: entry.ProjectName,
+					Log:                  log,
+					Timestamp:            entry.Timestamp,
+					ProjectName:          entry.ProjectName,
+					UserTurns:            userTurns,
+					ThinkingOutputTokens: thinkingOutputTokens,
 				})
 			}
 		case "hourly":
@@ -133,6 +146,7 @@ func (tb *TimelineBuilder) ConvertToTimestampedLogs(entries []TimelineEntry) []T
 				log := model.ConversationLog{
 					Timestamp: time.Unix(entry.Timestamp, 0).Format(time.RFC3339),
 					Type:      "synthetic",
+					SessionId: entry.SessionId,
 					Message: model.Message{
 						Model: data.Model,
 						Usage: model.Usage{
@@ -140,13 +154,21 @@ func (tb *TimelineBuilder) ConvertToTimestampedLogs(entries []TimelineEntry) []T
 							OutputTokens:             data.OutputTokens,
 							CacheCreationInputTokens: data.CacheCreation,
 							CacheReadInputTokens:     data.CacheRead,
+							ServiceTier:              data.ServiceTier,
 						},
 					},
 				}
 				logs = append(logs, TimestampedLog{
-					Log:         log,
-					Timestamp:   entry.Timestamp,
-					ProjectName: entry.ProjectName,
+					Log:                  log,
+					Timestamp:            entry.Timestamp,
+					ProjectName:          entry.ProjectName,
+					UserTurns:            data.UserTurns,
+					Messages:             data.MessageCount,
+					GitBranches:          data.GitBranches,
+					Cwds:                 data.Cwds,
+					TurnaroundSeconds:    data.TurnaroundSeconds,
+					TurnaroundCount:      data.TurnaroundCount,
+					ThinkingOutputTokens: data.ThinkingOutputTokens,
 				})
 			}
 		}
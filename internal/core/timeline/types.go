@@ -9,6 +9,16 @@ type TimestampedLog struct {
 	Log         model.ConversationLog
 	Timestamp   int64  // Unix timestamp for sorting
 	ProjectName string // Project this log belongs to
+	UserTurns   int    // User prompts represented by this entry
+	Messages    int    // Assistant messages represented by this entry (0 means one)
+	GitBranches map[string]int // Tokens by git branch, for synthetic entries
+	Cwds        map[string]int // Tokens by working directory, for synthetic entries
+
+	// Request turnaround of the paired requests represented by this entry
+	TurnaroundSeconds int64 // Summed user-to-assistant gap
+	TurnaroundCount   int   // Requests with a paired user entry
+
+	ThinkingOutputTokens int // Output tokens of requests with extended thinking
 }
 
 // TimelineEntry represents a single point in the timeline
@@ -18,4 +28,5 @@ type TimelineEntry struct {
 	Type           string // "message", "limit", "hourly"
 	Data           interface{}
 	IsSupplementary bool   // Marks if this is supplementary data (e.g., aggregated when raw exists)
+	SessionId       string // Conversation of hourly data; messages carry their own
 }
\ No newline at end of file
@@ -2,8 +2,8 @@ package aggregator
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -13,25 +13,49 @@ import (
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
+// ErrUnpriced is returned by CalculateCost when a model has no pricing entry
+// and strict pricing forbids falling back to default rates
+var ErrUnpriced = errors.New("no pricing available for model")
+
 // Aggregator is responsible for aggregating conversation logs by hour and model.
 type Aggregator struct {
-	pricing  pricing.PricingProvider
-	timezone string
+	pricing           pricing.PricingProvider
+	strictPricing     bool                            // Report unknown models as unpriced instead of using default rates
+	cacheReadDiscount float64                         // Multiplier applied to the cache-read rate
+	overrides         map[string]pricing.ModelPricing // Rates used instead of the pricing source, by model
+	timezone          string
 }
 
 // HourlyData holds aggregated statistics for a specific hour and model.
 type HourlyData struct {
-	Hour           int64  `json:"hour"` // Unix timestamp (truncated to hour)
-	Model          string `json:"model"`
-	ProjectName    string `json:"projectName"`
-	InputTokens    int    `json:"inputTokens"`
-	OutputTokens   int    `json:"outputTokens"`
-	CacheCreation  int    `json:"cacheCreation"`
-	CacheRead      int    `json:"cacheRead"`
-	TotalTokens    int    `json:"totalTokens"`
-	MessageCount   int    `json:"messageCount"`
-	FirstEntryTime int64  `json:"firstEntryTime"` // Unix timestamp of first entry in this hour
-	LastEntryTime  int64  `json:"lastEntryTime"`  // Unix timestamp of last entry in this hour
+	Hour           int64          `json:"hour"` // Unix timestamp (truncated to hour)
+	Model          string         `json:"model"`
+	ServiceTier    string         `json:"serviceTier,omitempty"` // Normalized service tier (standard, priority, batch)
+	ProjectName    string         `json:"projectName"`
+	GitBranch      string         `json:"gitBranch,omitempty"` // Git branch the requests were made on
+	InputTokens    int            `json:"inputTokens"`
+	OutputTokens   int            `json:"outputTokens"`
+	CacheCreation  int            `json:"cacheCreation"`
+	CacheRead      int            `json:"cacheRead"`
+	TotalTokens    int            `json:"totalTokens"`
+	MessageCount   int            `json:"messageCount"`
+	UserTurns      int            `json:"userTurns,omitempty"`   // User prompts answered by requests in this bucket
+	GitBranches    map[string]int `json:"gitBranches,omitempty"` // Tokens by git branch
+	Cwds           map[string]int `json:"cwds,omitempty"`        // Tokens by working directory
+	FirstEntryTime int64          `json:"firstEntryTime"`        // Unix timestamp of first entry in this hour
+	LastEntryTime  int64          `json:"lastEntryTime"`         // Unix timestamp of last entry in this hour
+
+	// Summed gap between a user entry and the first assistant entry sharing its
+	// requestId, over the TurnaroundCount requests where both were found
+	TurnaroundSeconds int64 `json:"turnaroundSeconds,omitempty"`
+	TurnaroundCount   int   `json:"turnaroundCount,omitempty"`
+
+	// Output tokens of requests that included extended thinking; already
+	// counted in OutputTokens
+	ThinkingOutputTokens int `json:"thinkingOutputTokens,omitempty"`
+
+	// Machine or user an imported export was recorded by; empty for local usage
+	Label string `json:"label,omitempty"`
 }
 
 // CachedLimitInfo contains essential limit message information for caching
@@ -55,25 +79,30 @@ type AggregatedData struct {
 	Inode              uint64       `json:"inode"`                         // File inode
 	ContentFingerprint string       `json:"content_fingerprint,omitempty"` // Content fingerprint for change detection
 	LimitMessages      []CachedLimitInfo  `json:"limitMessages,omitempty"`       // Detected limit messages for window detection
+	SchemaVersion      int                `json:"schemaVersion,omitempty"`       // Cache layout version the entry was written with
 }
 
 // NewAggregatorWithTimezone creates a new Aggregator with a specified timezone.
 func NewAggregatorWithTimezone(timezone string) *Aggregator {
 	return &Aggregator{
-		pricing:  pricing.NewDefaultProvider(),
-		timezone: timezone,
+		pricing:           pricing.NewDefaultProvider(),
+		cacheReadDiscount: 1,
+		timezone:          timezone,
 	}
 }
 
-// NewAggregatorWithConfig creates a new Aggregator with pricing and timezone configuration.
-func NewAggregatorWithConfig(pricingSource string, pricingOfflineMode bool, cacheDir, timezone string) (*Aggregator, error) {
-	util.LogDebug(fmt.Sprintf("Creating aggregator with pricing config: source=%s, offline=%t, timezone=%s",
-		pricingSource, pricingOfflineMode, timezone))
+// NewAggregatorWithConfig creates a new Aggregator with pricing and timezone
+// configuration. Cached pricing of a remote source younger than
+// pricingMaxAge is reused instead of fetched (0 = pricing.DefaultPricingMaxAge).
+func NewAggregatorWithConfig(pricingSource string, pricingOfflineMode bool, pricingMaxAge time.Duration, cacheDir, timezone string) (*Aggregator, error) {
+	util.LogDebug(fmt.Sprintf("Creating aggregator with pricing config: source=%s, offline=%t, max_age=%s, timezone=%s",
+		pricingSource, pricingOfflineMode, pricingMaxAge, timezone))
 
 	// Create pricing configuration
 	pricingConfig := &pricing.SourceConfig{
 		PricingSource:      pricingSource,
 		PricingOfflineMode: pricingOfflineMode,
+		PricingMaxAge:      pricingMaxAge,
 	}
 
 	// Create pricing provider using factory
@@ -86,10 +115,34 @@ func NewAggregatorWithConfig(pricingSource string, pricingOfflineMode bool, cach
 	util.LogDebug(fmt.Sprintf("Successfully created aggregator with %s pricing provider",
 		pricingProvider.GetProviderName()))
 
+	return NewAggregatorWithProvider(pricingProvider, timezone), nil
+}
+
+// NewAggregatorWithProvider creates a new Aggregator using the given pricing provider.
+func NewAggregatorWithProvider(provider pricing.PricingProvider, timezone string) *Aggregator {
 	return &Aggregator{
-		pricing:  pricingProvider,
-		timezone: timezone,
-	}, nil
+		pricing:           provider,
+		cacheReadDiscount: 1,
+		timezone:          timezone,
+	}
+}
+
+// SetCacheReadDiscount sets the multiplier applied to the cache-read rate of
+// the pricing source, e.g. 0.1 for a negotiated 90% discount
+func (a *Aggregator) SetCacheReadDiscount(discount float64) {
+	a.cacheReadDiscount = discount
+}
+
+// SetStrictPricing makes CalculateCost report models the pricing source does
+// not know as ErrUnpriced instead of billing them at default rates
+func (a *Aggregator) SetStrictPricing(strict bool) {
+	a.strictPricing = strict
+}
+
+// SetPricingOverrides sets per-model rates that take precedence over the
+// pricing source; models without an override still use the source
+func (a *Aggregator) SetPricingOverrides(overrides map[string]pricing.ModelPricing) {
+	a.overrides = overrides
 }
 
 // calculateCost computes the cost for the given HourlyData and pricing.
@@ -104,9 +157,16 @@ func (a *Aggregator) calculateCost(data *HourlyData, pricing pricing.ModelPricin
 
 // CalculateCost provides a public interface for real-time cost calculation.
 func (a *Aggregator) CalculateCost(data *HourlyData) (float64, error) {
-	modelPricing, err := a.pricing.GetPricing(context.Background(), data.Model)
+	modelPricing, ok := pricing.LookupOverride(a.overrides, data.Model)
+	var err error
+	if !ok {
+		modelPricing, err = a.pricing.GetPricing(context.Background(), data.Model)
+	}
 	if err != nil {
 		util.LogDebug(fmt.Sprintf("Failed to get pricing for model %s: %v", data.Model, err))
+		if a.strictPricing {
+			return 0, fmt.Errorf("%w: %s", ErrUnpriced, data.Model)
+		}
 		// Use default pricing as fallback
 		modelPricing = pricing.ModelPricing{
 			Input:         3.0, // Default pricing per million tokens
@@ -115,39 +175,29 @@ func (a *Aggregator) CalculateCost(data *HourlyData) (float64, error) {
 			CacheRead:     0.3,
 		}
 	}
+	modelPricing = modelPricing.ApplyServiceTier(data.ServiceTier).ApplyCacheReadDiscount(a.cacheReadDiscount)
 	return a.calculateCost(data, modelPricing), nil
 }
 
-// ExtractProjectName extracts the project name from the file path.
-func ExtractProjectName(filePath string) string {
-	dir := filepath.Dir(filePath)
-	projectName := filepath.Base(dir)
-
-	if isUUID(projectName) {
-		parentDir := filepath.Dir(dir)
-		parentName := filepath.Base(parentDir)
-		if parentName != "projects" && parentName != "." {
-			projectName = parentName + "/" + projectName
-		}
+// HasPricing reports whether the pricing source has its own entry for model.
+// It is false for models CalculateCost can only bill at fallback rates.
+func (a *Aggregator) HasPricing(model string) bool {
+	if _, ok := pricing.LookupOverride(a.overrides, model); ok {
+		return true
 	}
-
-	return projectName
-}
-
-// isUUID checks if the given string is a UUID.
-func isUUID(s string) bool {
-	if len(s) != 36 {
-		return false
+	ctx := context.Background()
+	if all, err := a.pricing.GetAllPricings(ctx); err == nil {
+		if _, ok := all[model]; ok {
+			return true
+		}
 	}
-
-	parts := strings.Split(s, "-")
-	if len(parts) != 5 {
+	// The default source resolves unknown models to Sonnet rates, so only an
+	// exact entry counts; other sources report misses as errors
+	if strings.HasPrefix(a.pricing.GetProviderName(), "default") {
 		return false
 	}
-
-	return len(parts[0]) == 8 && len(parts[1]) == 4 &&
-		len(parts[2]) == 4 && len(parts[3]) == 4 &&
-		len(parts[4]) == 12
+	_, err := a.pricing.GetPricing(ctx, model)
+	return err == nil
 }
 
 // TokenCounts holds extracted token information matching Python reference.
@@ -192,7 +242,9 @@ func extractTokens(log model.ConversationLog) TokenCounts {
 	return tokens
 }
 
-// Helper function to truncate timestamp to hour in UTC.
+// Helper function to truncate timestamp to hour in UTC. The session detector
+// truncates window starts the same way, so hourly buckets never straddle a
+// window boundary.
 func truncateToHourUTC(timestamp int64) int64 {
 	return (timestamp / 3600) * 3600
 }
@@ -206,10 +258,13 @@ func parseToUnixTimestamp(timestampStr string) (int64, error) {
 	return t.Unix(), nil
 }
 
-// normalizeModelName returns a normalized model name, defaulting to "unknown" if empty.
+// UnknownModel is the model name recorded for usage logged without one
+const UnknownModel = "unknown"
+
+// normalizeModelName returns a normalized model name, defaulting to UnknownModel if empty.
 func normalizeModelName(model string) string {
 	if model == "" {
-		return "unknown"
+		return UnknownModel
 	}
 	return model
 }
@@ -217,9 +272,19 @@ func normalizeModelName(model string) string {
 // AggregateByHourAndModel aggregates conversation logs using Unix timestamps internally.
 // This version works entirely in UTC to avoid timezone confusion.
 func (a *Aggregator) AggregateByHourAndModel(logs []model.ConversationLog, projectName string) []HourlyData {
-	// First pass: Find the first occurrence hour for each requestId.
+	// First pass: Find the first occurrence hour for each requestId, and when
+	// the user entry of each request was written.
 	requestIdFirstHour := make(map[string]int64)
+	requestIdUserTime := make(map[string]int64)
 	for _, log := range logs {
+		if log.Type == model.EntryUser && log.RequestId != "" {
+			if timestamp, err := parseToUnixTimestamp(log.Timestamp); err == nil {
+				if first, exists := requestIdUserTime[log.RequestId]; !exists || timestamp < first {
+					requestIdUserTime[log.RequestId] = timestamp
+				}
+			}
+			continue
+		}
 		if log.Type != model.EntryMessage && log.Type != model.EntryAssistant {
 			continue
 		}
@@ -247,19 +312,33 @@ func (a *Aggregator) AggregateByHourAndModel(logs []model.ConversationLog, proje
 	// Structure to hold tokens by requestId.
 	type RequestIdTokens struct {
 		Hour           int64 // Unix timestamp (truncated to hour)
+		RequestId      string
 		Model          string
+		ServiceTier    string
 		InputTokens    int
 		OutputTokens   int
 		CacheCreation  int
 		CacheRead      int
 		MessageCount   int
+		UserTurns      int
+		HasThinking    bool
+		GitBranch      string
+		Cwd            string
 		FirstEntryTime int64 // Unix timestamp
 		LastEntryTime  int64 // Unix timestamp
 	}
 	requestIdTokensMap := make(map[string]*RequestIdTokens)
 
+	// User prompts carry no usage, so attribute each one to the request that answers it
+	pendingUserTurns := 0
+	lastKey := ""
+
 	// Second pass: Aggregate tokens by requestId.
 	for _, log := range logs {
+		if log.IsUserTurn() {
+			pendingUserTurns++
+			continue
+		}
 		if log.Type != model.EntryMessage && log.Type != model.EntryAssistant {
 			continue
 		}
@@ -284,7 +363,11 @@ func (a *Aggregator) AggregateByHourAndModel(logs []model.ConversationLog, proje
 		if _, exists := requestIdTokensMap[key]; !exists {
 			requestIdTokensMap[key] = &RequestIdTokens{
 				Hour:           firstHour,
+				RequestId:      log.RequestId,
 				Model:          model,
+				ServiceTier:    pricing.NormalizeServiceTier(log.Message.Usage.ServiceTier),
+				GitBranch:      log.GitBranch,
+				Cwd:            log.Cwd,
 				FirstEntryTime: timestamp,
 				LastEntryTime:  timestamp,
 				MessageCount:   1,
@@ -292,6 +375,13 @@ func (a *Aggregator) AggregateByHourAndModel(logs []model.ConversationLog, proje
 		}
 
 		reqTokens := requestIdTokensMap[key]
+		reqTokens.UserTurns += pendingUserTurns
+		pendingUserTurns = 0
+		lastKey = key
+		// Thinking blocks and the reply they precede are logged as separate entries
+		if log.HasThinking() {
+			reqTokens.HasThinking = true
+		}
 		// Update first/last entry times.
 		if timestamp < reqTokens.FirstEntryTime {
 			reqTokens.FirstEntryTime = timestamp
@@ -314,17 +404,24 @@ func (a *Aggregator) AggregateByHourAndModel(logs []model.ConversationLog, proje
 		}
 
 	}
+	// Prompts left without a response (e.g. interrupted) still count as turns
+	if pendingUserTurns > 0 && lastKey != "" {
+		requestIdTokensMap[lastKey].UserTurns += pendingUserTurns
+	}
 
 	// Third pass: Aggregate requestId data into hourly data.
 	hourlyMap := make(map[string]*HourlyData)
+	pairedRequests := make(map[string]bool)
 	for _, reqTokens := range requestIdTokensMap {
-		key := fmt.Sprintf("%d|%s", reqTokens.Hour, reqTokens.Model)
+		key := fmt.Sprintf("%d|%s|%s|%s", reqTokens.Hour, reqTokens.Model, reqTokens.ServiceTier, reqTokens.GitBranch)
 
 		if _, exists := hourlyMap[key]; !exists {
 			hourlyMap[key] = &HourlyData{
 				Hour:           reqTokens.Hour,
 				Model:          reqTokens.Model,
+				ServiceTier:    reqTokens.ServiceTier,
 				ProjectName:    projectName,
+				GitBranch:      reqTokens.GitBranch,
 				InputTokens:    0,
 				OutputTokens:   0,
 				CacheCreation:  0,
@@ -342,6 +439,33 @@ func (a *Aggregator) AggregateByHourAndModel(logs []model.ConversationLog, proje
 		hourly.CacheCreation += reqTokens.CacheCreation
 		hourly.CacheRead += reqTokens.CacheRead
 		hourly.MessageCount += reqTokens.MessageCount
+		hourly.UserTurns += reqTokens.UserTurns
+		if reqTokens.HasThinking {
+			hourly.ThinkingOutputTokens += reqTokens.OutputTokens
+		}
+
+		// Requests without a user entry carrying the same requestId have no turnaround
+		if userTime, ok := requestIdUserTime[reqTokens.RequestId]; ok && !pairedRequests[reqTokens.RequestId] &&
+			reqTokens.FirstEntryTime >= userTime {
+			pairedRequests[reqTokens.RequestId] = true
+			hourly.TurnaroundSeconds += reqTokens.FirstEntryTime - userTime
+			hourly.TurnaroundCount++
+		}
+
+		// Track where the work happened
+		reqTotal := reqTokens.InputTokens + reqTokens.OutputTokens + reqTokens.CacheCreation + reqTokens.CacheRead
+		if reqTokens.GitBranch != "" {
+			if hourly.GitBranches == nil {
+				hourly.GitBranches = make(map[string]int)
+			}
+			hourly.GitBranches[reqTokens.GitBranch] += reqTotal
+		}
+		if reqTokens.Cwd != "" {
+			if hourly.Cwds == nil {
+				hourly.Cwds = make(map[string]int)
+			}
+			hourly.Cwds[reqTokens.Cwd] += reqTotal
+		}
 
 		// Update first/last entry times.
 		if reqTokens.FirstEntryTime < hourly.FirstEntryTime {
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/core/pricing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,7 +56,7 @@ func TestNewAggregatorWithConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			aggregator, err := NewAggregatorWithConfig(tt.pricingSource, tt.pricingOfflineMode, tt.cacheDir, tt.timezone)
+			aggregator, err := NewAggregatorWithConfig(tt.pricingSource, tt.pricingOfflineMode, 0, tt.cacheDir, tt.timezone)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -125,106 +126,80 @@ func TestCalculateCost(t *testing.T) {
 	}
 }
 
-func TestExtractProjectName(t *testing.T) {
-	tests := []struct {
-		name     string
-		filePath string
-		expected string
-	}{
-		{
-			name:     "simple project name",
-			filePath: "/home/user/.claude/projects/my-project/session.jsonl",
-			expected: "my-project",
-		},
-		{
-			name:     "UUID project name",
-			filePath: "/home/user/.claude/projects/12345678-1234-1234-1234-123456789012/session.jsonl",
-			expected: "12345678-1234-1234-1234-123456789012",
-		},
-		{
-			name:     "nested UUID project",
-			filePath: "/home/user/.claude/projects/parent/12345678-1234-1234-1234-123456789012/session.jsonl",
-			expected: "parent/12345678-1234-1234-1234-123456789012",
-		},
-		{
-			name:     "nested non-UUID project",
-			filePath: "/home/user/.claude/projects/parent/child/session.jsonl",
-			expected: "child",
-		},
-		{
-			name:     "root level file",
-			filePath: "/session.jsonl",
-			expected: "/",
-		},
-		{
-			name:     "current directory",
-			filePath: "./session.jsonl",
-			expected: ".",
-		},
-	}
+func TestCalculateCostCacheReadDiscount(t *testing.T) {
+	base := NewAggregatorWithTimezone("UTC")
+	discounted := NewAggregatorWithTimezone("UTC")
+	discounted.SetCacheReadDiscount(0.1)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := ExtractProjectName(tt.filePath)
-			assert.Equal(t, tt.expected, result)
-		})
+	costOf := func(a *Aggregator, data HourlyData) float64 {
+		cost, err := a.CalculateCost(&data)
+		require.NoError(t, err)
+		return cost
 	}
+
+	uncached := HourlyData{Model: "claude-3-sonnet", InputTokens: 1000, OutputTokens: 500, CacheCreation: 250}
+	cacheRead := HourlyData{Model: "claude-3-sonnet", CacheRead: 1_000_000}
+	combined := uncached
+	combined.CacheRead = cacheRead.CacheRead
+
+	// Input, output and cache creation costs are unchanged
+	assert.InDelta(t, costOf(base, uncached), costOf(discounted, uncached), 1e-12)
+	// Only the cache-read component drops by 90%
+	assert.InDelta(t, costOf(base, cacheRead)*0.1, costOf(discounted, cacheRead), 1e-12)
+	assert.InDelta(t, costOf(base, uncached)+costOf(base, cacheRead)*0.1, costOf(discounted, combined), 1e-12)
 }
 
-func TestIsUUID(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected bool
-	}{
-		{
-			name:     "valid UUID",
-			input:    "12345678-1234-1234-1234-123456789012",
-			expected: true,
-		},
-		{
-			name:     "valid UUID with different chars",
-			input:    "abcdefab-cdef-abcd-efab-cdefabcdefab",
-			expected: true,
-		},
-		{
-			name:     "invalid UUID - too short",
-			input:    "12345678-1234-1234-1234-12345678901",
-			expected: false,
-		},
-		{
-			name:     "invalid UUID - too long",
-			input:    "12345678-1234-1234-1234-1234567890123",
-			expected: false,
-		},
-		{
-			name:     "invalid UUID - wrong segments",
-			input:    "12345678-1234-1234-12345-123456789012",
-			expected: false,
-		},
-		{
-			name:     "invalid UUID - missing dashes",
-			input:    "123456781234123412341234567890123",
-			expected: false,
-		},
-		{
-			name:     "empty string",
-			input:    "",
-			expected: false,
-		},
-		{
-			name:     "regular string",
-			input:    "my-project",
-			expected: false,
-		},
-	}
+func TestCalculateCostPricingOverrides(t *testing.T) {
+	aggregator := NewAggregatorWithTimezone("UTC")
+	aggregator.SetPricingOverrides(map[string]pricing.ModelPricing{
+		"claude-3-sonnet": {Input: 1.5, Output: 7.5, CacheCreation: 2, CacheRead: 0.1},
+	})
+
+	overridden := &HourlyData{Model: "claude-3-sonnet", InputTokens: 1000, OutputTokens: 500, CacheCreation: 250, CacheRead: 100}
+	cost, err := aggregator.CalculateCost(overridden)
+	require.NoError(t, err)
+	// (1000/1M * 1.5) + (500/1M * 7.5) + (250/1M * 2) + (100/1M * 0.1)
+	assert.InDelta(t, 0.00576, cost, 1e-12)
+	assert.True(t, aggregator.HasPricing("claude-3-sonnet"))
+
+	// Logs carry dated model IDs, which the undated name applies to
+	dated := *overridden
+	dated.Model = "claude-3-sonnet-20240229"
+	cost, err = aggregator.CalculateCost(&dated)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.00576, cost, 1e-12)
+	assert.True(t, aggregator.HasPricing("claude-3-sonnet-20240229"))
+
+	// Models without an override still use the pricing source
+	other := &HourlyData{Model: "unknown-model", InputTokens: 1000, OutputTokens: 500}
+	cost, err = aggregator.CalculateCost(other)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.0105, cost, 1e-12)
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := isUUID(tt.input)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
+func TestCalculateCostServiceTierOverrides(t *testing.T) {
+	aggregator := NewAggregatorWithTimezone("UTC")
+	aggregator.SetPricingOverrides(map[string]pricing.ModelPricing{
+		"claude-3-sonnet": {Input: 3, Output: 15, ServiceTiers: map[string]pricing.ModelPricing{
+			model.ServiceTierPriority: {Input: 4.5, Output: 22.5},
+		}},
+	})
+
+	usage := HourlyData{Model: "claude-3-sonnet", InputTokens: 1_000_000, OutputTokens: 100_000}
+	standard, err := aggregator.CalculateCost(&usage)
+	require.NoError(t, err)
+	assert.InDelta(t, 4.5, standard, 1e-9)
+
+	usage.ServiceTier = model.ServiceTierPriority
+	priority, err := aggregator.CalculateCost(&usage)
+	require.NoError(t, err)
+	assert.InDelta(t, 6.75, priority, 1e-9)
+
+	// Tiers without their own rates keep the tier multiplier
+	usage.ServiceTier = model.ServiceTierBatch
+	batch, err := aggregator.CalculateCost(&usage)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.25, batch, 1e-9)
 }
 
 func TestExtractTokens(t *testing.T) {
@@ -773,6 +748,100 @@ func TestAggregateByHourAndModel(t *testing.T) {
 	}
 }
 
+func TestAggregateByHourAndModelServiceTiers(t *testing.T) {
+	aggregator := NewAggregatorWithTimezone("UTC")
+
+	newLog := func(requestId, tier string, input, output int) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryAssistant,
+			RequestId: requestId,
+			Timestamp: "2022-01-01T00:30:00Z",
+			Message: model.Message{
+				Id:    "msg-" + requestId,
+				Model: "claude-3-sonnet",
+				Usage: model.Usage{
+					InputTokens:  input,
+					OutputTokens: output,
+					ServiceTier:  tier,
+				},
+			},
+		}
+	}
+
+	logs := []model.ConversationLog{
+		newLog("req-1", "standard", 1000, 500),
+		newLog("req-2", "", 2000, 1000), // Missing tier counts as standard
+		newLog("req-3", model.ServiceTierBatch, 4000, 2000),
+		newLog("req-4", model.ServiceTierPriority, 100, 50),
+	}
+
+	result := aggregator.AggregateByHourAndModel(logs, "test-project")
+	require.Len(t, result, 3)
+
+	byTier := make(map[string]HourlyData)
+	for _, hourly := range result {
+		byTier[hourly.ServiceTier] = hourly
+	}
+
+	assert.Equal(t, 4500, byTier[model.ServiceTierStandard].TotalTokens)
+	assert.Equal(t, 2, byTier[model.ServiceTierStandard].MessageCount)
+	assert.Equal(t, 6000, byTier[model.ServiceTierBatch].TotalTokens)
+	assert.Equal(t, 150, byTier[model.ServiceTierPriority].TotalTokens)
+
+	// Standard: 3000 input * $3/M + 1500 output * $15/M
+	standard := byTier[model.ServiceTierStandard]
+	cost, err := aggregator.CalculateCost(&standard)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.0315, cost, 0.0000001)
+
+	// Batch is billed at half the standard rate
+	batch := byTier[model.ServiceTierBatch]
+	cost, err = aggregator.CalculateCost(&batch)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.021, cost, 0.0000001)
+
+	priority := byTier[model.ServiceTierPriority]
+	cost, err = aggregator.CalculateCost(&priority)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.00105, cost, 0.0000001)
+}
+
+func TestAggregateByHourAndModelGitBranches(t *testing.T) {
+	aggregator := NewAggregatorWithTimezone("UTC")
+
+	newLog := func(requestId, branch string, input int) model.ConversationLog {
+		return model.ConversationLog{
+			Type:      model.EntryAssistant,
+			RequestId: requestId,
+			Timestamp: "2022-01-01T00:30:00Z",
+			GitBranch: branch,
+			Message: model.Message{
+				Id:    "msg-" + requestId,
+				Model: "claude-3-sonnet",
+				Usage: model.Usage{InputTokens: input},
+			},
+		}
+	}
+
+	result := aggregator.AggregateByHourAndModel([]model.ConversationLog{
+		newLog("req-1", "main", 1000),
+		newLog("req-2", "feature/login", 2000),
+		newLog("req-3", "main", 500),
+		newLog("req-4", "", 100),
+	}, "test-project")
+	require.Len(t, result, 3)
+
+	byBranch := make(map[string]HourlyData)
+	for _, hourly := range result {
+		byBranch[hourly.GitBranch] = hourly
+	}
+	assert.Equal(t, 1500, byBranch["main"].TotalTokens)
+	assert.Equal(t, map[string]int{"main": 1500}, byBranch["main"].GitBranches)
+	assert.Equal(t, 2000, byBranch["feature/login"].TotalTokens)
+	assert.Equal(t, 100, byBranch[""].TotalTokens)
+	assert.Nil(t, byBranch[""].GitBranches)
+}
+
 func TestHourlyDataAndAggregatedDataStructures(t *testing.T) {
 	// Test HourlyData structure
 	hourlyData := HourlyData{
@@ -886,12 +955,3 @@ func BenchmarkExtractTokens(b *testing.B) {
 		extractTokens(log)
 	}
 }
-
-func BenchmarkExtractProjectName(b *testing.B) {
-	filePath := "/home/user/.claude/projects/my-project/session.jsonl"
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		ExtractProjectName(filePath)
-	}
-}
\ No newline at end of file
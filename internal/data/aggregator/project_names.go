@@ -0,0 +1,97 @@
+package aggregator
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/penwyp/go-claude-monitor/internal/data/scanner"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// DisambiguateProjectNames returns the project name of every file. Names that
+// scanner.ExtractProjectName yields for more than one directory are qualified with as
+// many parent directories as needed to tell them apart, e.g. "a/api" and "b/api".
+func DisambiguateProjectNames(files []string) map[string]string {
+	names := make(map[string]string, len(files))
+	dirsByName := make(map[string]map[string]bool)
+	for _, file := range files {
+		name := scanner.ExtractProjectName(file)
+		names[file] = name
+		if dirsByName[name] == nil {
+			dirsByName[name] = make(map[string]bool)
+		}
+		dirsByName[name][filepath.Dir(file)] = true
+	}
+
+	qualifiedByDir := make(map[string]string)
+	for name, dirSet := range dirsByName {
+		if len(dirSet) < 2 {
+			continue
+		}
+		dirs := make([]string, 0, len(dirSet))
+		for dir := range dirSet {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+
+		qualified := qualifyProjectName(name, dirs)
+		for dir, q := range qualified {
+			qualifiedByDir[dir] = q
+		}
+		util.LogWarn(fmt.Sprintf("Project name %q is shared by %d directories, reporting them separately", name, len(dirs)))
+	}
+
+	for file := range names {
+		if q, ok := qualifiedByDir[filepath.Dir(file)]; ok {
+			names[file] = q
+		}
+	}
+	return names
+}
+
+// qualifyProjectName prefixes name with the fewest parent directories of each
+// dir that makes the results distinct
+func qualifyProjectName(name string, dirs []string) map[string]string {
+	// Path components already represented by name
+	used := strings.Count(name, "/") + 1
+
+	parents := make(map[string][]string, len(dirs))
+	maxDepth := 0
+	for _, dir := range dirs {
+		parts := strings.Split(strings.Trim(filepath.ToSlash(dir), "/"), "/")
+		if len(parts) > used {
+			parts = parts[:len(parts)-used]
+		} else {
+			parts = nil
+		}
+		parents[dir] = parts
+		if len(parts) > maxDepth {
+			maxDepth = len(parts)
+		}
+	}
+
+	var result map[string]string
+	for depth := 1; depth <= maxDepth; depth++ {
+		result = make(map[string]string, len(dirs))
+		seen := make(map[string]bool, len(dirs))
+		unique := true
+		for _, dir := range dirs {
+			parts := parents[dir]
+			if len(parts) > depth {
+				parts = parts[len(parts)-depth:]
+			}
+			q := strings.Join(append(append([]string{}, parts...), name), "/")
+			if seen[q] {
+				unique = false
+			}
+			seen[q] = true
+			result[dir] = q
+		}
+		if unique {
+			break
+		}
+	}
+	return result
+}
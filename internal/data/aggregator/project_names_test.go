@@ -0,0 +1,28 @@
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisambiguateProjectNames(t *testing.T) {
+	files := []string{
+		"/data/work/api/s1.jsonl",
+		"/data/work/api/s2.jsonl",
+		"/data/personal/api/s3.jsonl",
+		"/data/work/web/s4.jsonl",
+		"/x/one/team/db/s5.jsonl",
+		"/x/two/team/db/s6.jsonl",
+	}
+
+	names := DisambiguateProjectNames(files)
+
+	assert.Equal(t, "work/api", names["/data/work/api/s1.jsonl"])
+	assert.Equal(t, "work/api", names["/data/work/api/s2.jsonl"])
+	assert.Equal(t, "personal/api", names["/data/personal/api/s3.jsonl"])
+	assert.Equal(t, "web", names["/data/work/web/s4.jsonl"])
+	// A single parent is not enough to separate these two
+	assert.Equal(t, "one/team/db", names["/x/one/team/db/s5.jsonl"])
+	assert.Equal(t, "two/team/db", names["/x/two/team/db/s6.jsonl"])
+}
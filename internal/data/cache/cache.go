@@ -1,16 +1,20 @@
 package cache
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/penwyp/go-claude-monitor/internal/data/scanner"
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
@@ -25,8 +29,41 @@ const (
 	MissReasonFingerprint
 	MissReasonNoFingerprint
 	MissReasonNotFound
+	MissReasonSchema
 )
 
+// SchemaVersion is the layout version of cache entries. Entries written with
+// another version are reparsed; bump it when HourlyData gains or renames a
+// field older entries lack, as GitBranch did in version 1 and
+// ThinkingOutputTokens in version 2.
+const SchemaVersion = 2
+
+// String returns the short name of the miss reason
+func (r CacheMissReason) String() string {
+	switch r {
+	case MissReasonNone:
+		return "none"
+	case MissReasonError:
+		return "error"
+	case MissReasonInode:
+		return "inode"
+	case MissReasonSize:
+		return "size"
+	case MissReasonModTime:
+		return "modtime"
+	case MissReasonFingerprint:
+		return "fingerprint"
+	case MissReasonNoFingerprint:
+		return "no_fingerprint"
+	case MissReasonNotFound:
+		return "not_found"
+	case MissReasonSchema:
+		return "schema"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(r))
+	}
+}
+
 type CacheResult struct {
 	Data       *aggregator.AggregatedData
 	Found      bool
@@ -39,12 +76,35 @@ type Cache interface {
 	Clear() error
 	Preload() error
 	BatchValidate(sessionIds []string) map[string]BatchValidateResult
+	LoadAll() ([]*aggregator.AggregatedData, error)
+}
+
+// Cache file extensions. Entries are written with one of them depending on
+// SetCompress and read with either.
+const (
+	cacheFileExt           = ".json"
+	compressedCacheFileExt = ".json.gz"
+)
+
+// SessionIDFromCacheFile returns the session ID of a cache file name, plain or
+// gzip-compressed, and whether name is a cache file at all
+func SessionIDFromCacheFile(name string) (string, bool) {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{compressedCacheFileExt, cacheFileExt} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)], true
+		}
+	}
+	return "", false
 }
 
 type FileCache struct {
-	baseDir     string
-	mu          sync.RWMutex
-	memoryCache map[string]*aggregator.AggregatedData
+	baseDir        string
+	mu             sync.RWMutex
+	memoryCache    map[string]*aggregator.AggregatedData
+	preloadWorkers int    // Preload worker pool size (0 = CPU count)
+	compress       bool   // Write entries gzip-compressed
+	onWorkerStart  func() // Test hook called when a preload worker starts
 }
 
 func NewFileCache(baseDir string) (*FileCache, error) {
@@ -58,11 +118,33 @@ func NewFileCache(baseDir string) (*FileCache, error) {
 	}, nil
 }
 
+// SetPreloadWorkers sets the number of workers used by Preload.
+// Zero or a negative value uses one worker per CPU.
+func (c *FileCache) SetPreloadWorkers(n int) {
+	c.preloadWorkers = n
+}
+
+// SetCompress makes Set write gzip-compressed entries. Entries are read in
+// either form regardless, so the setting can change between runs.
+func (c *FileCache) SetCompress(compress bool) {
+	c.compress = compress
+}
+
+// cachePaths returns the paths an entry may be stored at, the one Set writes
+// first
+func (c *FileCache) cachePaths(sessionId string) (current, other string) {
+	plain := filepath.Join(c.baseDir, sessionId+cacheFileExt)
+	compressed := filepath.Join(c.baseDir, sessionId+compressedCacheFileExt)
+	if c.compress {
+		return compressed, plain
+	}
+	return plain, compressed
+}
+
 // extractSessionId extracts the session ID from a file path
 // e.g., "/path/to/00aec530-0614-436f-a53b-faaa0b32f123.jsonl" -> "00aec530-0614-436f-a53b-faaa0b32f123"
 func extractSessionId(filePath string) string {
-	filename := filepath.Base(filePath)
-	return strings.TrimSuffix(filename, filepath.Ext(filename))
+	return scanner.SessionID(filePath)
 }
 
 func (c *FileCache) Get(sessionId string) CacheResult {
@@ -84,33 +166,29 @@ func (c *FileCache) Get(sessionId string) CacheResult {
 }
 
 func (c *FileCache) getFromFile(sessionId string) CacheResult {
-	// Use session ID based filename
-	cachePath := filepath.Join(c.baseDir, sessionId+".json")
+	// Use session ID based filename, in the form Set currently writes first
+	current, other := c.cachePaths(sessionId)
+	cachePath := current
+	if _, err := os.Stat(cachePath); err != nil {
+		cachePath = other
+	}
 
-	file, err := os.Open(cachePath)
-	if err != nil {
+	data, err := readCacheFile(cachePath)
+	if os.IsNotExist(err) {
 		return CacheResult{Data: nil, Found: false, MissReason: MissReasonNotFound}
 	}
-	defer file.Close()
-
-	var data aggregator.AggregatedData
-	if err := json.NewDecoder(file).Decode(&data); err != nil {
+	if err != nil {
 		return CacheResult{Data: nil, Found: false, MissReason: MissReasonError}
 	}
 
-	// Ensure SessionId is set
-	if data.SessionId == "" && data.FilePath != "" {
-		data.SessionId = extractSessionId(data.FilePath)
-	}
-
-	if ret := c.validateCachedData(&data); !ret.cached {
+	if ret := c.validateCachedData(data); !ret.cached {
 		return CacheResult{Data: nil, Found: false, MissReason: ret.reason}
 	}
 
 	// Add valid data to memory cache for future access
-	c.memoryCache[sessionId] = &data
+	c.memoryCache[sessionId] = data
 
-	return CacheResult{Data: &data, Found: true, MissReason: MissReasonNone}
+	return CacheResult{Data: data, Found: true, MissReason: MissReasonNone}
 }
 
 type ValidateResult struct {
@@ -119,6 +197,12 @@ type ValidateResult struct {
 }
 
 func (c *FileCache) validateCachedData(data *aggregator.AggregatedData) ValidateResult {
+	if data.SchemaVersion != SchemaVersion {
+		util.LogDebug(fmt.Sprintf("Cache invalidated for %s: schema version changed (cached: %d, current: %d)",
+			data.FilePath, data.SchemaVersion, SchemaVersion))
+		return ValidateResult{cached: false, reason: MissReasonSchema}
+	}
+
 	currentInfo, err := util.GetFileInfo(data.FilePath)
 	if err != nil {
 		util.LogDebug(fmt.Sprintf("Cache validation failed for %s: unable to get file info: %v", data.FilePath, err))
@@ -193,18 +277,43 @@ func (c *FileCache) Set(sessionId string, data *aggregator.AggregatedData) error
 	if data.SessionId == "" {
 		data.SessionId = sessionId
 	}
-
-	// Write to file cache first - use session ID as filename
-	cachePath := filepath.Join(c.baseDir, sessionId+".json")
-	file, err := os.Create(cachePath)
+	data.SchemaVersion = SchemaVersion
+
+	// Write to file cache first - use session ID as filename. The entry is
+	// written to a temporary file and renamed so an interrupted run never
+	// leaves a truncated entry behind.
+	cachePath, otherPath := c.cachePaths(sessionId)
+	tmpPath := cachePath + ".tmp"
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmpPath)
 	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
+	if c.compress {
+		gz := gzip.NewWriter(file)
+		if err := json.NewEncoder(gz).Encode(data); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	} else {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, cachePath); err != nil {
+		return err
+	}
+	// Drop the entry written before SetCompress changed, if any
+	if err := os.Remove(otherPath); err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
@@ -221,13 +330,14 @@ func (c *FileCache) Clear() error {
 	// Clear memory cache
 	c.memoryCache = make(map[string]*aggregator.AggregatedData)
 
-	// Clear file cache - removes all .json files (both hash-based and session-id-based)
+	// Clear file cache - removes all .json and .json.gz files (both
+	// hash-based and session-id-based)
 	return filepath.Walk(c.baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !info.IsDir() && filepath.Ext(path) == ".json" {
+		if _, ok := SessionIDFromCacheFile(info.Name()); ok && !info.IsDir() {
 			os.Remove(path)
 		}
 
@@ -244,7 +354,7 @@ func (c *FileCache) Preload() error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(strings.ToLower(path), ".json") {
+		if _, ok := SessionIDFromCacheFile(info.Name()); ok && !info.IsDir() {
 			cacheFiles = append(cacheFiles, path)
 		}
 		return nil
@@ -262,7 +372,10 @@ func (c *FileCache) Preload() error {
 	util.LogInfo(fmt.Sprintf("Found %d cache files, starting concurrent loading...", len(cacheFiles)))
 
 	// Use worker pool for concurrent loading
-	numWorkers := runtime.NumCPU()
+	numWorkers := c.preloadWorkers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
 	if numWorkers > len(cacheFiles) {
 		numWorkers = len(cacheFiles)
 	}
@@ -338,14 +451,16 @@ type preloadResult struct {
 
 func (c *FileCache) preloadWorker(filesChan <-chan string, resultsChan chan<- preloadResult, wg *sync.WaitGroup) {
 	defer wg.Done()
+	if c.onWorkerStart != nil {
+		c.onWorkerStart()
+	}
 
 	for filePath := range filesChan {
 		result := preloadResult{filePath: filePath}
 
 		// Extract session ID from filename
-		fileName := filepath.Base(filePath)
-		if strings.HasSuffix(fileName, ".json") {
-			result.sessionId = strings.TrimSuffix(fileName, ".json")
+		if sessionId, ok := SessionIDFromCacheFile(filepath.Base(filePath)); ok {
+			result.sessionId = sessionId
 		} else {
 			result.err = fmt.Errorf("Invalid cache file name format")
 			resultsChan <- result
@@ -353,31 +468,65 @@ func (c *FileCache) preloadWorker(filesChan <-chan string, resultsChan chan<- pr
 		}
 
 		// Load and parse file
-		file, err := os.Open(filePath)
+		result.data, result.err = readCacheFile(filePath)
+		resultsChan <- result
+	}
+}
+
+// readCacheFile decodes one cache file, decompressing .json.gz files
+func readCacheFile(filePath string) (*aggregator.AggregatedData, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(strings.ToLower(filePath), compressedCacheFileExt) {
+		gz, err := gzip.NewReader(file)
 		if err != nil {
-			result.err = err
-			resultsChan <- result
-			continue
+			return nil, err
 		}
+		defer gz.Close()
+		reader = gz
+	}
 
-		var data aggregator.AggregatedData
-		err = json.NewDecoder(file).Decode(&data)
-		file.Close()
+	var data aggregator.AggregatedData
+	if err := json.NewDecoder(reader).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	// If SessionId is empty (old cache files), extract it from FilePath
+	if data.SessionId == "" && data.FilePath != "" {
+		data.SessionId = extractSessionId(data.FilePath)
+	}
+	return &data, nil
+}
 
+// LoadAll returns every cached entry without validating it against its source
+// file, so no source log is stat'ed or read. Entries may be stale.
+func (c *FileCache) LoadAll() ([]*aggregator.AggregatedData, error) {
+	entries, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var result []*aggregator.AggregatedData
+	for _, entry := range entries {
+		if _, ok := SessionIDFromCacheFile(entry.Name()); !ok || entry.IsDir() {
+			continue
+		}
+		data, err := readCacheFile(filepath.Join(c.baseDir, entry.Name()))
 		if err != nil {
-			result.err = err
-			resultsChan <- result
+			util.LogWarn(fmt.Sprintf("Failed to load cache file %s: %v", entry.Name(), err))
 			continue
 		}
-
-		// If SessionId is empty (old cache files), extract it from FilePath
-		if data.SessionId == "" && data.FilePath != "" {
-			data.SessionId = extractSessionId(data.FilePath)
+		if data.FilePath == "" {
+			continue
 		}
-
-		result.data = &data
-		resultsChan <- result
+		result = append(result, data)
 	}
+	return result, nil
 }
 
 func (c *FileCache) GetCacheStats() (memoryCount, fileCount int) {
@@ -391,7 +540,7 @@ func (c *FileCache) GetCacheStats() (memoryCount, fileCount int) {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(strings.ToLower(path), ".json") {
+		if _, ok := SessionIDFromCacheFile(info.Name()); ok && !info.IsDir() {
 			fileCount++
 		}
 		return nil
@@ -400,6 +549,151 @@ func (c *FileCache) GetCacheStats() (memoryCount, fileCount int) {
 	return memoryCount, fileCount
 }
 
+// Stats describes the cache directory and how its entries validate against
+// their source files
+type Stats struct {
+	Entries    int                     // Cached sessions
+	TotalBytes int64                   // On-disk size of the cache files
+	Oldest     time.Time               // Write time of the oldest cache file
+	Newest     time.Time               // Write time of the newest cache file
+	Valid      int                     // Entries that still match their source file
+	Misses     map[CacheMissReason]int // Invalidated entries by reason
+}
+
+// CollectStats walks the cache directory and validates every session entry
+// the way Get does. Files that cannot be decoded count as MissReasonError;
+// entries without a source file, such as the window history, are skipped.
+func (c *FileCache) CollectStats() (*Stats, error) {
+	entries, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	stats := &Stats{Misses: make(map[CacheMissReason]int)}
+	for _, entry := range entries {
+		if _, ok := SessionIDFromCacheFile(entry.Name()); !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		reason := MissReasonNone
+		data, err := readCacheFile(filepath.Join(c.baseDir, entry.Name()))
+		if err != nil {
+			reason = MissReasonError
+		} else if data.FilePath == "" {
+			continue
+		} else if ret := c.validateCachedData(data); !ret.cached {
+			reason = ret.reason
+		}
+
+		stats.Entries++
+		stats.TotalBytes += info.Size()
+		if stats.Oldest.IsZero() || info.ModTime().Before(stats.Oldest) {
+			stats.Oldest = info.ModTime()
+		}
+		if info.ModTime().After(stats.Newest) {
+			stats.Newest = info.ModTime()
+		}
+		if reason == MissReasonNone {
+			stats.Valid++
+		} else {
+			stats.Misses[reason]++
+		}
+	}
+	return stats, nil
+}
+
+// PruneOptions limits what stays in the cache directory. A zero limit is
+// not applied.
+type PruneOptions struct {
+	OlderThan time.Duration   // Remove entries last written longer ago than this
+	MaxBytes  int64           // Remove the oldest entries until the cache fits
+	Keep      map[string]bool // Session IDs never removed, e.g. of active sessions
+}
+
+// PruneResult summarizes what Prune removed
+type PruneResult struct {
+	Removed    []string // Session IDs of the removed entries, oldest first
+	FreedBytes int64
+}
+
+// pruneCandidate is one session entry in the cache directory
+type pruneCandidate struct {
+	sessionId string
+	path      string
+	size      int64
+	modTime   time.Time
+}
+
+// Prune deletes cache files, least recently written first, until every limit
+// in opts is met, and drops them from the memory cache. Each file is removed
+// on its own, so an interrupted prune leaves only whole entries behind. Files
+// that are not session entries, such as the window history, are left alone
+// and do not count towards MaxBytes.
+func (c *FileCache) Prune(opts PruneOptions) (*PruneResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var candidates []pruneCandidate
+	var totalBytes int64
+	for _, entry := range entries {
+		if _, ok := SessionIDFromCacheFile(entry.Name()); !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.baseDir, entry.Name())
+		if data, err := readCacheFile(path); err == nil && data.FilePath == "" {
+			continue
+		}
+		totalBytes += info.Size()
+
+		sessionId, _ := SessionIDFromCacheFile(entry.Name())
+		if opts.Keep[sessionId] {
+			continue
+		}
+		candidates = append(candidates, pruneCandidate{
+			sessionId: sessionId,
+			path:      path,
+			size:      info.Size(),
+			modTime:   info.ModTime(),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].modTime.Before(candidates[j].modTime)
+	})
+
+	result := &PruneResult{}
+	cutoff := time.Now().Add(-opts.OlderThan)
+	for _, candidate := range candidates {
+		expired := opts.OlderThan > 0 && candidate.modTime.Before(cutoff)
+		oversized := opts.MaxBytes > 0 && totalBytes > opts.MaxBytes
+		if !expired && !oversized {
+			break
+		}
+		if err := os.Remove(candidate.path); err != nil && !os.IsNotExist(err) {
+			return result, fmt.Errorf("failed to remove cache file %s: %w", candidate.path, err)
+		}
+		delete(c.memoryCache, candidate.sessionId)
+		totalBytes -= candidate.size
+		result.Removed = append(result.Removed, candidate.sessionId)
+		result.FreedBytes += candidate.size
+	}
+
+	util.LogInfo(fmt.Sprintf("Pruned %d cache entries, freed %d bytes", len(result.Removed), result.FreedBytes))
+	return result, nil
+}
+
 func (c *FileCache) ValidateCache(files []string) map[string]bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -214,6 +215,308 @@ func TestFileCacheInvalidJSON(t *testing.T) {
 	assert.Nil(t, result.Data)
 }
 
+func TestFileCacheCollectStats(t *testing.T) {
+	cacheDir := t.TempDir()
+	sourceDir := t.TempDir()
+	cache, err := NewFileCache(cacheDir)
+	require.NoError(t, err)
+
+	writeSource := func(name, content string) string {
+		path := filepath.Join(sourceDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	intact := writeSource("intact.jsonl", `{"test": "data"}`)
+	edited := writeSource("edited.jsonl", `{"test": "aaaa"}`)
+	grown := writeSource("grown.jsonl", `{"test": "data"}`)
+
+	for id, path := range map[string]string{"intact": intact, "edited": edited, "grown": grown} {
+		require.NoError(t, cache.Set(id, &aggregator.AggregatedData{FilePath: path, ProjectName: "test-project"}))
+	}
+
+	// Same size and modtime, different content: only the fingerprint differs
+	info, err := os.Stat(edited)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(edited, []byte(`{"test": "bbbb"}`), 0644))
+	require.NoError(t, os.Chtimes(edited, info.ModTime(), info.ModTime()))
+
+	require.NoError(t, os.WriteFile(grown, []byte(`{"test": "more data"}`), 0644))
+
+	// Undecodable entries are misses, entries without a source are skipped
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "broken.json"), []byte("{"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "window_history.json"), []byte(`{"windows": []}`), 0644))
+
+	stats, err := cache.CollectStats()
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, stats.Entries)
+	assert.Equal(t, 1, stats.Valid)
+	assert.Equal(t, map[CacheMissReason]int{
+		MissReasonFingerprint: 1,
+		MissReasonSize:        1,
+		MissReasonError:       1,
+	}, stats.Misses)
+
+	var totalBytes int64
+	for _, name := range []string{"intact.json", "edited.json", "grown.json", "broken.json"} {
+		info, err := os.Stat(filepath.Join(cacheDir, name))
+		require.NoError(t, err)
+		totalBytes += info.Size()
+	}
+	assert.Equal(t, totalBytes, stats.TotalBytes)
+	assert.False(t, stats.Oldest.IsZero())
+	assert.False(t, stats.Newest.Before(stats.Oldest))
+}
+
+func TestFileCacheCollectStatsMissingDirectory(t *testing.T) {
+	cache, err := NewFileCache(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(cache.baseDir))
+
+	_, err = cache.CollectStats()
+	assert.Error(t, err)
+}
+
+// populatePruneCache caches one entry per age, writing each cache file that
+// long ago, and returns the cache with the window history next to them
+func populatePruneCache(t *testing.T, ages map[string]time.Duration) *FileCache {
+	cacheDir := t.TempDir()
+	sourceDir := t.TempDir()
+	cache, err := NewFileCache(cacheDir)
+	require.NoError(t, err)
+
+	for id, age := range ages {
+		source := filepath.Join(sourceDir, id+".jsonl")
+		require.NoError(t, os.WriteFile(source, []byte(`{"test": "data"}`), 0644))
+		require.NoError(t, cache.Set(id, &aggregator.AggregatedData{FilePath: source}))
+		written := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(filepath.Join(cacheDir, id+".json"), written, written))
+	}
+	history := filepath.Join(cacheDir, "window_history.json")
+	require.NoError(t, os.WriteFile(history, []byte(`{"windows": []}`), 0644))
+	old := time.Now().Add(-365 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(history, old, old))
+	return cache
+}
+
+func cacheFileSize(t *testing.T, cache *FileCache, sessionId string) int64 {
+	info, err := os.Stat(filepath.Join(cache.baseDir, sessionId+".json"))
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func TestFileCachePruneOlderThan(t *testing.T) {
+	day := 24 * time.Hour
+	cache := populatePruneCache(t, map[string]time.Duration{
+		"ancient": 90 * day,
+		"old":     40 * day,
+		"recent":  2 * day,
+	})
+	freed := cacheFileSize(t, cache, "ancient") + cacheFileSize(t, cache, "old")
+
+	result, err := cache.Prune(PruneOptions{OlderThan: 30 * day})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"ancient", "old"}, result.Removed)
+	assert.Equal(t, freed, result.FreedBytes)
+	assert.NoFileExists(t, filepath.Join(cache.baseDir, "ancient.json"))
+	assert.NoFileExists(t, filepath.Join(cache.baseDir, "old.json"))
+	assert.FileExists(t, filepath.Join(cache.baseDir, "recent.json"))
+	assert.FileExists(t, filepath.Join(cache.baseDir, "window_history.json"))
+
+	cache.mu.RLock()
+	assert.NotContains(t, cache.memoryCache, "ancient")
+	assert.NotContains(t, cache.memoryCache, "old")
+	assert.Contains(t, cache.memoryCache, "recent")
+	cache.mu.RUnlock()
+
+	// The remaining entry still validates against its source
+	assert.True(t, cache.Get("recent").Found)
+}
+
+func TestFileCachePruneMaxBytes(t *testing.T) {
+	cache := populatePruneCache(t, map[string]time.Duration{
+		"first":  3 * time.Hour,
+		"second": 2 * time.Hour,
+		"third":  time.Hour,
+	})
+	second := cacheFileSize(t, cache, "second")
+	third := cacheFileSize(t, cache, "third")
+
+	// Room for the two newest entries only
+	result, err := cache.Prune(PruneOptions{MaxBytes: second + third})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"first"}, result.Removed)
+	assert.NoFileExists(t, filepath.Join(cache.baseDir, "first.json"))
+	assert.FileExists(t, filepath.Join(cache.baseDir, "second.json"))
+	assert.FileExists(t, filepath.Join(cache.baseDir, "third.json"))
+	assert.FileExists(t, filepath.Join(cache.baseDir, "window_history.json"))
+	assert.True(t, cache.Get("second").Found)
+	assert.True(t, cache.Get("third").Found)
+
+	// Within the limit nothing more is removed
+	result, err = cache.Prune(PruneOptions{MaxBytes: second + third})
+	require.NoError(t, err)
+	assert.Empty(t, result.Removed)
+	assert.Zero(t, result.FreedBytes)
+}
+
+func TestFileCachePruneKeepsActiveSessions(t *testing.T) {
+	cache := populatePruneCache(t, map[string]time.Duration{
+		"active": 10 * time.Hour,
+		"idle":   5 * time.Hour,
+	})
+
+	result, err := cache.Prune(PruneOptions{MaxBytes: 1, Keep: map[string]bool{"active": true}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"idle"}, result.Removed)
+	assert.FileExists(t, filepath.Join(cache.baseDir, "active.json"))
+}
+
+// largeAggregatedData returns an entry for filePath with hours of hourly
+// stats across several models, branches and directories
+func largeAggregatedData(filePath string, hours int) *aggregator.AggregatedData {
+	data := &aggregator.AggregatedData{FilePath: filePath, ProjectName: "large-project"}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	for i := 0; i < hours; i++ {
+		for _, model := range []string{"claude-opus-4", "claude-sonnet-4", "claude-3-5-haiku"} {
+			data.HourlyStats = append(data.HourlyStats, aggregator.HourlyData{
+				Hour:           base + int64(i)*3600,
+				Model:          model,
+				ProjectName:    "large-project",
+				InputTokens:    1000 + i,
+				OutputTokens:   2000 + i,
+				CacheCreation:  300 + i,
+				CacheRead:      40000 + i,
+				TotalTokens:    43300 + 4*i,
+				MessageCount:   10 + i%7,
+				GitBranches:    map[string]int{"main": 20000 + i, fmt.Sprintf("feature-%d", i%5): 23300 + i},
+				Cwds:           map[string]int{"/home/user/src/large-project": 43300 + 4*i},
+				FirstEntryTime: base + int64(i)*3600 + 60,
+				LastEntryTime:  base + int64(i)*3600 + 3540,
+			})
+		}
+	}
+	return data
+}
+
+func TestFileCacheCompressedRoundTrip(t *testing.T) {
+	cacheDir := t.TempDir()
+	source := filepath.Join(t.TempDir(), "large.jsonl")
+	require.NoError(t, os.WriteFile(source, []byte(`{"test": "data"}`), 0644))
+
+	cache, err := NewFileCache(cacheDir)
+	require.NoError(t, err)
+	cache.SetCompress(true)
+
+	data := largeAggregatedData(source, 2000)
+	require.NoError(t, cache.Set("large", data))
+
+	assert.FileExists(t, filepath.Join(cacheDir, "large.json.gz"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, "large.json"))
+
+	// A fresh cache reads the entry back from disk
+	reopened, err := NewFileCache(cacheDir)
+	require.NoError(t, err)
+	result := reopened.Get("large")
+	require.True(t, result.Found)
+	assert.Equal(t, data, result.Data)
+
+	// Compression pays off on entries of this size
+	compressed, err := os.Stat(filepath.Join(cacheDir, "large.json.gz"))
+	require.NoError(t, err)
+	plain, err := json.MarshalIndent(data, "", "  ")
+	require.NoError(t, err)
+	assert.Less(t, compressed.Size()*5, int64(len(plain)))
+}
+
+func TestFileCacheReadsBothForms(t *testing.T) {
+	cacheDir := t.TempDir()
+	sourceDir := t.TempDir()
+	writer, err := NewFileCache(cacheDir)
+	require.NoError(t, err)
+
+	for _, id := range []string{"plain", "compressed"} {
+		source := filepath.Join(sourceDir, id+".jsonl")
+		require.NoError(t, os.WriteFile(source, []byte(`{"test": "data"}`), 0644))
+		writer.SetCompress(id == "compressed")
+		require.NoError(t, writer.Set(id, &aggregator.AggregatedData{FilePath: source}))
+	}
+	assert.FileExists(t, filepath.Join(cacheDir, "plain.json"))
+	assert.FileExists(t, filepath.Join(cacheDir, "compressed.json.gz"))
+
+	for _, compress := range []bool{false, true} {
+		cache, err := NewFileCache(cacheDir)
+		require.NoError(t, err)
+		cache.SetCompress(compress)
+
+		results := cache.BatchValidate([]string{"plain", "compressed"})
+		assert.True(t, results["plain"].Valid, "compress=%v", compress)
+		assert.True(t, results["compressed"].Valid, "compress=%v", compress)
+
+		preloaded, err := NewFileCache(cacheDir)
+		require.NoError(t, err)
+		require.NoError(t, preloaded.Preload())
+		assert.Len(t, preloaded.memoryCache, 2)
+
+		all, err := cache.LoadAll()
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		memCount, fileCount := cache.GetCacheStats()
+		assert.Equal(t, 2, memCount)
+		assert.Equal(t, 2, fileCount)
+	}
+
+	require.NoError(t, writer.Clear())
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFileCacheSetReplacesOtherForm(t *testing.T) {
+	cacheDir := t.TempDir()
+	source := filepath.Join(t.TempDir(), "switch.jsonl")
+	require.NoError(t, os.WriteFile(source, []byte(`{"test": "data"}`), 0644))
+
+	cache, err := NewFileCache(cacheDir)
+	require.NoError(t, err)
+	require.NoError(t, cache.Set("switch", &aggregator.AggregatedData{FilePath: source}))
+	assert.FileExists(t, filepath.Join(cacheDir, "switch.json"))
+
+	cache.SetCompress(true)
+	require.NoError(t, cache.Set("switch", &aggregator.AggregatedData{FilePath: source}))
+	assert.FileExists(t, filepath.Join(cacheDir, "switch.json.gz"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, "switch.json"))
+
+	cache.SetCompress(false)
+	require.NoError(t, cache.Set("switch", &aggregator.AggregatedData{FilePath: source}))
+	assert.FileExists(t, filepath.Join(cacheDir, "switch.json"))
+	assert.NoFileExists(t, filepath.Join(cacheDir, "switch.json.gz"))
+}
+
+func TestSessionIDFromCacheFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		sessionId string
+		ok        bool
+	}{
+		{"abc.json", "abc", true},
+		{"abc.json.gz", "abc", true},
+		{"abc.JSON.GZ", "abc", true},
+		{"abc.json.tmp", "", false},
+		{"abc.gz", "", false},
+		{"since_last.watermark", "", false},
+	}
+	for _, tt := range tests {
+		sessionId, ok := SessionIDFromCacheFile(tt.name)
+		assert.Equal(t, tt.ok, ok, tt.name)
+		assert.Equal(t, tt.sessionId, sessionId, tt.name)
+	}
+}
+
 func TestFileCacheValidation(t *testing.T) {
 	tempDir := t.TempDir()
 	cache, err := NewFileCache(tempDir)
@@ -339,6 +642,42 @@ func TestFileCacheValidationMissingFingerprint(t *testing.T) {
 	assert.Equal(t, MissReasonNone, result.MissReason)
 }
 
+func TestFileCacheValidationOutdatedSchema(t *testing.T) {
+	tempDir := t.TempDir()
+	cache, err := NewFileCache(tempDir)
+	require.NoError(t, err)
+
+	testFile := filepath.Join(tempDir, "test.jsonl")
+	require.NoError(t, os.WriteFile(testFile, []byte(`{"test": "data"}`), 0644))
+	fileInfo, err := util.GetFileInfo(testFile)
+	require.NoError(t, err)
+	fingerprint, err := util.CalculateFileFingerprint(testFile)
+	require.NoError(t, err)
+
+	// An entry written before the schema was versioned matches its source
+	// file but lacks fields such as GitBranch, so it must be reparsed
+	content, err := json.Marshal(&aggregator.AggregatedData{
+		FilePath:           testFile,
+		SessionId:          "old",
+		LastModified:       fileInfo.ModTime,
+		FileSize:           fileInfo.Size,
+		Inode:              fileInfo.Inode,
+		ContentFingerprint: fingerprint,
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "old.json"), content, 0644))
+
+	result := cache.Get("old")
+	assert.False(t, result.Found)
+	assert.Equal(t, MissReasonSchema, result.MissReason)
+
+	// Rewriting the entry stamps the current version
+	require.NoError(t, cache.Set("old", &aggregator.AggregatedData{FilePath: testFile}))
+	result = cache.Get("old")
+	assert.True(t, result.Found)
+	assert.Equal(t, SchemaVersion, result.Data.SchemaVersion)
+}
+
 func TestFileCacheClear(t *testing.T) {
 	tempDir := t.TempDir()
 	cache, err := NewFileCache(tempDir)
@@ -431,6 +770,66 @@ func TestFileCachePreload(t *testing.T) {
 	cache.mu.RUnlock()
 }
 
+func TestFileCacheLoadAllSkipsSourceValidation(t *testing.T) {
+	tempDir := t.TempDir()
+	cache, err := NewFileCache(tempDir)
+	require.NoError(t, err)
+
+	sourceDir := t.TempDir()
+	testFile := filepath.Join(sourceDir, "load-all.jsonl")
+	require.NoError(t, os.WriteFile(testFile, []byte(`{"test": "data"}`), 0644))
+	require.NoError(t, cache.Set("load-all", &aggregator.AggregatedData{
+		FilePath:    testFile,
+		SessionId:   "load-all",
+		ProjectName: "test-project",
+	}))
+
+	// Entries are returned even though their source is gone
+	require.NoError(t, os.Remove(testFile))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "broken.json"), []byte("not json"), 0644))
+
+	entries, err := cache.LoadAll()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "load-all", entries[0].SessionId)
+	assert.Equal(t, testFile, entries[0].FilePath)
+}
+
+func TestFileCachePreloadConfiguredWorkers(t *testing.T) {
+	tempDir := t.TempDir()
+	cache, err := NewFileCache(tempDir)
+	require.NoError(t, err)
+
+	testFile := filepath.Join(tempDir, "test.jsonl")
+	err = os.WriteFile(testFile, []byte(`{"test": "data"}`), 0644)
+	require.NoError(t, err)
+
+	const fileCount = 10
+	for i := 0; i < fileCount; i++ {
+		sessionId := fmt.Sprintf("workers-%d", i)
+		err = cache.Set(sessionId, &aggregator.AggregatedData{
+			FilePath:  testFile,
+			SessionId: sessionId,
+		})
+		require.NoError(t, err)
+	}
+
+	cache.mu.Lock()
+	cache.memoryCache = make(map[string]*aggregator.AggregatedData)
+	cache.mu.Unlock()
+
+	var started atomic.Int32
+	cache.onWorkerStart = func() { started.Add(1) }
+	cache.SetPreloadWorkers(2)
+
+	require.NoError(t, cache.Preload())
+
+	assert.Equal(t, int32(2), started.Load())
+	cache.mu.RLock()
+	assert.Len(t, cache.memoryCache, fileCount)
+	cache.mu.RUnlock()
+}
+
 func TestFileCachePreloadEmptyDirectory(t *testing.T) {
 	tempDir := t.TempDir()
 	cache, err := NewFileCache(tempDir)
@@ -711,6 +1110,7 @@ func TestCacheMissReasonConstants(t *testing.T) {
 		MissReasonFingerprint,
 		MissReasonNoFingerprint,
 		MissReasonNotFound,
+		MissReasonSchema,
 	}
 
 	// Verify they have different values
@@ -784,6 +1184,10 @@ func TestFileCacheInterface(t *testing.T) {
 
 	batchResults := cache.BatchValidate([]string{"test"})
 	assert.NotNil(t, batchResults)
+
+	entries, err := cache.LoadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
 }
 
 // Benchmark tests for performance critical operations
@@ -880,6 +1284,54 @@ func BenchmarkFileCachePreload(b *testing.B) {
 	}
 }
 
+// benchmarkLargeSet caches a large entry b.N times, compressed or not
+func benchmarkLargeSet(b *testing.B, compress bool) {
+	tempDir := b.TempDir()
+	cache, err := NewFileCache(tempDir)
+	require.NoError(b, err)
+	cache.SetCompress(compress)
+
+	testFile := filepath.Join(tempDir, "bench.jsonl")
+	require.NoError(b, os.WriteFile(testFile, []byte(`{"test": "data"}`), 0644))
+	testData := largeAggregatedData(testFile, 500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, cache.Set(fmt.Sprintf("bench-large-%d", i%100), testData))
+	}
+}
+
+// benchmarkLargeGet reads a large entry from disk b.N times, bypassing the
+// memory cache
+func benchmarkLargeGet(b *testing.B, compress bool) {
+	tempDir := b.TempDir()
+	cache, err := NewFileCache(tempDir)
+	require.NoError(b, err)
+	cache.SetCompress(compress)
+
+	testFile := filepath.Join(tempDir, "bench.jsonl")
+	require.NoError(b, os.WriteFile(testFile, []byte(`{"test": "data"}`), 0644))
+	require.NoError(b, cache.Set("bench-large", largeAggregatedData(testFile, 500)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fresh, err := NewFileCache(tempDir)
+		require.NoError(b, err)
+		fresh.SetCompress(compress)
+		require.True(b, fresh.Get("bench-large").Found)
+	}
+}
+
+func BenchmarkFileCacheSetLarge(b *testing.B) {
+	b.Run("plain", func(b *testing.B) { benchmarkLargeSet(b, false) })
+	b.Run("gzip", func(b *testing.B) { benchmarkLargeSet(b, true) })
+}
+
+func BenchmarkFileCacheGetLarge(b *testing.B) {
+	b.Run("plain", func(b *testing.B) { benchmarkLargeGet(b, false) })
+	b.Run("gzip", func(b *testing.B) { benchmarkLargeGet(b, true) })
+}
+
 func TestFileCachePreloadWorkerCount(t *testing.T) {
 	tempDir := t.TempDir()
 	cache, err := NewFileCache(tempDir)
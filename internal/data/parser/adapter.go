@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+)
+
+// Supported input formats
+const (
+	FormatCode    = "code"    // Claude Code project logs (default)
+	FormatDesktop = "desktop" // Claude Desktop usage logs
+)
+
+// InputAdapter decodes a single line of a usage log into a ConversationLog.
+// Both supported formats store one JSON object per line in .jsonl files.
+type InputAdapter interface {
+	// Name returns the input format handled by the adapter
+	Name() string
+	// Decode maps one line of the log file to a ConversationLog
+	Decode(line []byte) (model.ConversationLog, error)
+}
+
+// AdapterForFormat returns the input adapter for the given format name.
+// An empty name selects the Claude Code format.
+func AdapterForFormat(format string) (InputAdapter, error) {
+	switch format {
+	case "", FormatCode:
+		return codeAdapter{}, nil
+	case FormatDesktop:
+		return desktopAdapter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported input format '%s': must be one of %s, %s", format, FormatCode, FormatDesktop)
+	}
+}
+
+// codeAdapter reads Claude Code logs, whose layout matches ConversationLog
+type codeAdapter struct{}
+
+func (codeAdapter) Name() string { return FormatCode }
+
+func (codeAdapter) Decode(line []byte) (model.ConversationLog, error) {
+	var log model.ConversationLog
+	err := sonic.Unmarshal(line, &log)
+	return log, err
+}
+
+// desktopEntry is one line of a Claude Desktop usage log
+type desktopEntry struct {
+	Uuid             string      `json:"uuid"`
+	ConversationUuid string      `json:"conversation_uuid"`
+	ParentUuid       *string     `json:"parent_message_uuid"`
+	Sender           string      `json:"sender"` // "human" or "assistant"
+	CreatedAt        string      `json:"created_at"`
+	Model            string      `json:"model"`
+	MessageId        string      `json:"message_id"`
+	RequestId        string      `json:"request_id"`
+	Text             string      `json:"text"`
+	Usage            model.Usage `json:"usage"`
+	AppVersion       string      `json:"app_version"`
+}
+
+// desktopAdapter reads Claude Desktop logs
+type desktopAdapter struct{}
+
+func (desktopAdapter) Name() string { return FormatDesktop }
+
+func (desktopAdapter) Decode(line []byte) (model.ConversationLog, error) {
+	var entry desktopEntry
+	if err := sonic.Unmarshal(line, &entry); err != nil {
+		return model.ConversationLog{}, err
+	}
+
+	var entryType, role string
+	switch entry.Sender {
+	case "human":
+		entryType, role = model.EntryUser, "user"
+	case "assistant":
+		entryType, role = model.EntryAssistant, "assistant"
+	default:
+		return model.ConversationLog{}, fmt.Errorf("unknown desktop sender: %q", entry.Sender)
+	}
+
+	var content model.FlexibleContent
+	if entry.Text != "" {
+		content = model.FlexibleContent{{Type: "text", Text: entry.Text}}
+	}
+
+	return model.ConversationLog{
+		ParentUuid: entry.ParentUuid,
+		RequestId:  entry.RequestId,
+		SessionId:  entry.ConversationUuid,
+		Timestamp:  entry.CreatedAt,
+		Type:       entryType,
+		Uuid:       entry.Uuid,
+		Version:    entry.AppVersion,
+		Message: model.Message{
+			Content: content,
+			Id:      entry.MessageId,
+			Model:   entry.Model,
+			Role:    role,
+			Type:    "message",
+			Usage:   entry.Usage,
+		},
+	}, nil
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/data/aggregator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const codeFixture = `{"type":"user","uuid":"u-1","sessionId":"conv-1","timestamp":"2025-01-15T10:00:00Z","message":{"role":"user","content":"Hello"},"userType":"external","version":"1.0"}
+{"type":"assistant","uuid":"a-1","parentUuid":"u-1","requestId":"req_1","sessionId":"conv-1","timestamp":"2025-01-15T10:00:05Z","message":{"id":"msg_1","role":"assistant","type":"message","content":"Hi","model":"claude-sonnet-4-20250514","usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":10,"cache_read_input_tokens":20,"service_tier":"standard"}},"userType":"external","version":"1.0"}
+{"type":"user","uuid":"u-2","parentUuid":"a-1","sessionId":"conv-1","timestamp":"2025-01-15T11:30:00Z","message":{"role":"user","content":"More"},"userType":"external","version":"1.0"}
+{"type":"assistant","uuid":"a-2","parentUuid":"u-2","requestId":"req_2","sessionId":"conv-1","timestamp":"2025-01-15T11:30:10Z","message":{"id":"msg_2","role":"assistant","type":"message","content":"Sure","model":"claude-opus-4-20250514","usage":{"input_tokens":200,"output_tokens":80,"cache_creation_input_tokens":0,"cache_read_input_tokens":40,"service_tier":"standard"}},"userType":"external","version":"1.0"}`
+
+const desktopFixture = `{"uuid":"u-1","conversation_uuid":"conv-1","sender":"human","created_at":"2025-01-15T10:00:00Z","text":"Hello","app_version":"1.0"}
+{"uuid":"a-1","conversation_uuid":"conv-1","parent_message_uuid":"u-1","sender":"assistant","created_at":"2025-01-15T10:00:05Z","model":"claude-sonnet-4-20250514","message_id":"msg_1","request_id":"req_1","text":"Hi","usage":{"input_tokens":100,"output_tokens":50,"cache_creation_input_tokens":10,"cache_read_input_tokens":20,"service_tier":"standard"},"app_version":"1.0"}
+{"uuid":"u-2","conversation_uuid":"conv-1","parent_message_uuid":"a-1","sender":"human","created_at":"2025-01-15T11:30:00Z","text":"More","app_version":"1.0"}
+{"uuid":"a-2","conversation_uuid":"conv-1","parent_message_uuid":"u-2","sender":"assistant","created_at":"2025-01-15T11:30:10Z","model":"claude-opus-4-20250514","message_id":"msg_2","request_id":"req_2","text":"Sure","usage":{"input_tokens":200,"output_tokens":80,"cache_creation_input_tokens":0,"cache_read_input_tokens":40,"service_tier":"standard"},"app_version":"1.0"}`
+
+func writeFixture(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "conv-1.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestAdapterForFormat(t *testing.T) {
+	for _, format := range []string{"", FormatCode, FormatDesktop} {
+		adapter, err := AdapterForFormat(format)
+		require.NoError(t, err)
+		assert.NotNil(t, adapter)
+	}
+
+	_, err := AdapterForFormat("web")
+	assert.Error(t, err)
+}
+
+func TestDesktopAdapterMatchesCodeAggregation(t *testing.T) {
+	codeLogs, err := NewParser(1).ParseFile(writeFixture(t, codeFixture))
+	require.NoError(t, err)
+
+	desktopAdapter, err := AdapterForFormat(FormatDesktop)
+	require.NoError(t, err)
+	desktopLogs, err := NewParserWithAdapter(1, desktopAdapter).ParseFile(writeFixture(t, desktopFixture))
+	require.NoError(t, err)
+
+	require.Len(t, desktopLogs, len(codeLogs))
+	assert.Equal(t, model.EntryUser, desktopLogs[0].Type)
+	assert.True(t, desktopLogs[0].IsUserTurn())
+	assert.Equal(t, model.EntryAssistant, desktopLogs[1].Type)
+	assert.Equal(t, "req_1", desktopLogs[1].RequestId)
+
+	agg := aggregator.NewAggregatorWithTimezone("UTC")
+	codeData := agg.AggregateByHourAndModel(codeLogs, "project")
+	desktopData := agg.AggregateByHourAndModel(desktopLogs, "project")
+
+	require.Len(t, codeData, 2)
+	assert.ElementsMatch(t, codeData, desktopData)
+}
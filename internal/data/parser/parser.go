@@ -2,8 +2,8 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"github.com/bytedance/sonic"
 	"os"
 	"sync"
 	"time"
@@ -15,8 +15,17 @@ import (
 // Parser is a struct for parsing conversation log files.
 type Parser struct {
 	concurrency int
+	adapter     InputAdapter
 	mu          sync.Mutex
-	cache       map[string][]model.ConversationLog
+	cache       map[string]parsedFile
+}
+
+// parsedFile is a cached parse result, reused while the file keeps the size
+// and modification time it had when it was read
+type parsedFile struct {
+	logs    []model.ConversationLog
+	size    int64
+	modTime time.Time
 }
 
 // ParseResult represents the result of parsing a single file.
@@ -26,22 +35,30 @@ type ParseResult struct {
 	Error error
 }
 
-// NewParser creates a new Parser instance.
+// NewParser creates a new Parser instance for Claude Code logs.
 func NewParser(concurrency int) *Parser {
+	return NewParserWithAdapter(concurrency, codeAdapter{})
+}
+
+// NewParserWithAdapter creates a new Parser instance that decodes lines with the given adapter.
+func NewParserWithAdapter(concurrency int, adapter InputAdapter) *Parser {
 	return &Parser{
 		concurrency: concurrency,
-		cache:       make(map[string][]model.ConversationLog),
+		adapter:     adapter,
+		cache:       make(map[string]parsedFile),
 	}
 }
 
 // ParseFile parses the log file at the specified path and returns a slice of ConversationLog and an error if any.
 func (p *Parser) ParseFile(filepath string) ([]model.ConversationLog, error) {
 	p.mu.Lock()
-	if cached, ok := p.cache[filepath]; ok {
-		p.mu.Unlock()
-		return cached, nil
-	}
+	cached, ok := p.cache[filepath]
 	p.mu.Unlock()
+	if ok {
+		if info, err := os.Stat(filepath); err == nil && info.Size() == cached.size && info.ModTime().Equal(cached.modTime) {
+			return cached.logs, nil
+		}
+	}
 
 	util.LogDebug(fmt.Sprintf("Start parsing file: %s", filepath))
 
@@ -52,6 +69,11 @@ func (p *Parser) ParseFile(filepath string) ([]model.ConversationLog, error) {
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
 	var logs []model.ConversationLog
 	scanner := bufio.NewScanner(file)
 	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
@@ -60,9 +82,9 @@ func (p *Parser) ParseFile(filepath string) ([]model.ConversationLog, error) {
 	validLogs := 0
 	for scanner.Scan() {
 		lineCount++
-		var log model.ConversationLog
-		if err := sonic.Unmarshal(scanner.Bytes(), &log); err != nil {
-			util.LogDebug(fmt.Sprintf("Skip invalid JSON line %s:%d - %v", filepath, lineCount, err))
+		log, err := p.adapter.Decode(scanner.Bytes())
+		if err != nil {
+			util.LogDebug(fmt.Sprintf("Skip invalid %s line %s:%d - %v", p.adapter.Name(), filepath, lineCount, err))
 			continue
 		}
 		logs = append(logs, log)
@@ -75,7 +97,7 @@ func (p *Parser) ParseFile(filepath string) ([]model.ConversationLog, error) {
 	}
 
 	p.mu.Lock()
-	p.cache[filepath] = logs
+	p.cache[filepath] = parsedFile{logs: logs, size: info.Size(), modTime: info.ModTime()}
 	p.mu.Unlock()
 
 	return logs, nil
@@ -83,6 +105,13 @@ func (p *Parser) ParseFile(filepath string) ([]model.ConversationLog, error) {
 
 // ParseFiles parses multiple files concurrently and returns a channel of ParseResult.
 func (p *Parser) ParseFiles(files []string) <-chan ParseResult {
+	return p.ParseFilesContext(context.Background(), files)
+}
+
+// ParseFilesContext is ParseFiles that stops starting new files once ctx is
+// done; files already being parsed still send their result before the channel
+// is closed.
+func (p *Parser) ParseFilesContext(ctx context.Context, files []string) <-chan ParseResult {
 	start := time.Now()
 	results := make(chan ParseResult, len(files))
 	var wg sync.WaitGroup
@@ -96,8 +125,15 @@ func (p *Parser) ParseFiles(files []string) <-chan ParseResult {
 		go func(f string) {
 			defer wg.Done()
 
-			semaphore <- struct{}{}
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-semaphore }()
+			if ctx.Err() != nil {
+				return
+			}
 
 			fileStart := time.Now()
 			logs, err := p.ParseFile(f)
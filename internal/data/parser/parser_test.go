@@ -187,6 +187,28 @@ func TestParserParseFileCache(t *testing.T) {
 	assert.Contains(t, parser.cache, testFile)
 }
 
+func TestParserParseFileRereadsChangedFile(t *testing.T) {
+	parser := NewParser(1)
+	testFile := filepath.Join(t.TempDir(), "growing.jsonl")
+	line := `{"type":"message","uuid":"test-uuid-%d","sessionId":"session-1","timestamp":"2023-10-15T10:00:00Z","message":{"role":"user","type":"text","content":"Hello"},"userType":"human","version":"1.0"}` + "\n"
+	require.NoError(t, os.WriteFile(testFile, []byte(fmt.Sprintf(line, 1)), 0644))
+
+	logs, err := parser.ParseFile(testFile)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	f, err := os.OpenFile(testFile, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(f, line, 2)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	logs, err = parser.ParseFile(testFile)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "test-uuid-2", logs[1].Uuid)
+}
+
 func TestParserParseFilesSequential(t *testing.T) {
 	parser := NewParser(1) // Single concurrency
 	tempDir := t.TempDir()
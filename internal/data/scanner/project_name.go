@@ -0,0 +1,38 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ExtractProjectName extracts the project name from the file path.
+func ExtractProjectName(filePath string) string {
+	dir := filepath.Dir(filePath)
+	projectName := filepath.Base(dir)
+
+	if isUUID(projectName) {
+		parentDir := filepath.Dir(dir)
+		parentName := filepath.Base(parentDir)
+		if parentName != "projects" && parentName != "." {
+			projectName = parentName + "/" + projectName
+		}
+	}
+
+	return projectName
+}
+
+// isUUID checks if the given string is a UUID.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	parts := strings.Split(s, "-")
+	if len(parts) != 5 {
+		return false
+	}
+
+	return len(parts[0]) == 8 && len(parts[1]) == 4 &&
+		len(parts[2]) == 4 && len(parts[3]) == 4 &&
+		len(parts[4]) == 12
+}
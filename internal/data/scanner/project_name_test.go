@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractProjectName(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		expected string
+	}{
+		{
+			name:     "simple project name",
+			filePath: "/home/user/.claude/projects/my-project/session.jsonl",
+			expected: "my-project",
+		},
+		{
+			name:     "UUID project name",
+			filePath: "/home/user/.claude/projects/12345678-1234-1234-1234-123456789012/session.jsonl",
+			expected: "12345678-1234-1234-1234-123456789012",
+		},
+		{
+			name:     "nested UUID project",
+			filePath: "/home/user/.claude/projects/parent/12345678-1234-1234-1234-123456789012/session.jsonl",
+			expected: "parent/12345678-1234-1234-1234-123456789012",
+		},
+		{
+			name:     "nested non-UUID project",
+			filePath: "/home/user/.claude/projects/parent/child/session.jsonl",
+			expected: "child",
+		},
+		{
+			name:     "root level file",
+			filePath: "/session.jsonl",
+			expected: "/",
+		},
+		{
+			name:     "current directory",
+			filePath: "./session.jsonl",
+			expected: ".",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExtractProjectName(tt.filePath)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestIsUUID(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{
+			name:     "valid UUID",
+			input:    "12345678-1234-1234-1234-123456789012",
+			expected: true,
+		},
+		{
+			name:     "valid UUID with different chars",
+			input:    "abcdefab-cdef-abcd-efab-cdefabcdefab",
+			expected: true,
+		},
+		{
+			name:     "invalid UUID - too short",
+			input:    "12345678-1234-1234-1234-12345678901",
+			expected: false,
+		},
+		{
+			name:     "invalid UUID - too long",
+			input:    "12345678-1234-1234-1234-1234567890123",
+			expected: false,
+		},
+		{
+			name:     "invalid UUID - wrong segments",
+			input:    "12345678-1234-1234-12345-123456789012",
+			expected: false,
+		},
+		{
+			name:     "invalid UUID - missing dashes",
+			input:    "123456781234123412341234567890123",
+			expected: false,
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			expected: false,
+		},
+		{
+			name:     "regular string",
+			input:    "my-project",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isUUID(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func BenchmarkExtractProjectName(b *testing.B) {
+	filePath := "/home/user/.claude/projects/my-project/session.jsonl"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ExtractProjectName(filePath)
+	}
+}
@@ -15,6 +15,10 @@ type FileScanner struct {
 	baseDir    string
 	pattern    string
 	concurrent int
+
+	// Project name patterns files must match, then must not match
+	includeProjects []string
+	excludeProjects []string
 }
 
 // ScanResult represents the result of a scan
@@ -32,6 +36,94 @@ func NewFileScanner(baseDir string) *FileScanner {
 	}
 }
 
+// SetProjectFilter limits files to projects whose name, as derived by
+// ExtractProjectName, matches an include pattern (all when none) and no
+// exclude pattern. Patterns containing *, ? or [ are globs, others match any
+// part of the name. Directories whose name or path relative to the base
+// directory matches an exclude pattern are not scanned at all.
+func (s *FileScanner) SetProjectFilter(include, exclude []string) {
+	s.includeProjects = include
+	s.excludeProjects = exclude
+}
+
+// ValidateProjectPatterns reports the first malformed --project or
+// --exclude-project glob
+func ValidateProjectPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid project pattern '%s': %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesProject reports whether the project name matches any pattern
+func matchesProject(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.ContainsAny(pattern, "*?[") {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				return true
+			}
+		} else if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// filtersOutProject reports whether the project of a file is left out by
+// the project filter
+func (s *FileScanner) filtersOutProject(filePath string) bool {
+	if len(s.includeProjects) == 0 && len(s.excludeProjects) == 0 {
+		return false
+	}
+	name := ExtractProjectName(filePath)
+	if len(s.includeProjects) > 0 && !matchesProject(name, s.includeProjects) {
+		return true
+	}
+	return matchesProject(name, s.excludeProjects)
+}
+
+// isExcludedDir reports whether the directory at path matches an exclude
+// pattern by its name or its path relative to the base directory
+func (s *FileScanner) isExcludedDir(path string) bool {
+	if len(s.excludeProjects) == 0 || path == s.baseDir {
+		return false
+	}
+	if matchesProject(filepath.Base(path), s.excludeProjects) {
+		return true
+	}
+	rel, err := filepath.Rel(s.baseDir, path)
+	if err != nil {
+		return false
+	}
+	return matchesProject(filepath.ToSlash(rel), s.excludeProjects)
+}
+
+// IgnoresFile reports whether a file lies in an excluded project directory
+// or is left out by the project filter, for files known from elsewhere than
+// a scan, such as the cache
+func (s *FileScanner) IgnoresFile(filePath string) bool {
+	if s.filtersOutProject(filePath) {
+		return true
+	}
+	for dir := filepath.Dir(filePath); isBelow(dir, s.baseDir); dir = filepath.Dir(dir) {
+		if s.isExcludedDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBelow reports whether path lies strictly inside dir
+func isBelow(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // Scan scans all files in the directory and returns all .jsonl file paths
 func (s *FileScanner) Scan() ([]string, error) {
 	start := time.Now()
@@ -50,17 +142,26 @@ func (s *FileScanner) Scan() ([]string, error) {
 		}
 
 		if info.IsDir() {
+			if s.isExcludedDir(path) {
+				util.LogDebug(fmt.Sprintf("Skip excluded project: %s", path))
+				return filepath.SkipDir
+			}
 			dirCount++
 			return nil
 		}
 
 		totalCount++
 		if strings.HasSuffix(strings.ToLower(path), ".jsonl") {
+			if s.filtersOutProject(path) {
+				util.LogDebug(fmt.Sprintf("Skip filtered project: %s", path))
+				return nil
+			}
 			files = append(files, path)
 		}
 
 		return nil
 	})
+	files = DedupeSessionFiles(files)
 
 	duration := time.Since(start)
 	// Log: File scan completed
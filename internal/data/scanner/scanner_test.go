@@ -310,4 +310,69 @@ func TestFileScannerConcurrency(t *testing.T) {
 	
 	// Test that concurrency is set to default value
 	assert.Equal(t, 10, scanner.concurrent)
-}
\ No newline at end of file
+}
+func TestFileScannerExcludedDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, rel := range []string{"app/a.jsonl", "scratch-1/b.jsonl", "tmp/nested/c.jsonl", "work/tmp/d.jsonl"} {
+		path := filepath.Join(tempDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0644))
+	}
+
+	scanner := NewFileScanner(tempDir)
+	scanner.SetProjectFilter(nil, []string{"scratch-*", "work/tmp", "tmp"})
+
+	files, err := scanner.Scan()
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tempDir, "app", "a.jsonl")}, files)
+
+	assert.True(t, scanner.IgnoresFile(filepath.Join(tempDir, "scratch-1", "b.jsonl")))
+	assert.True(t, scanner.IgnoresFile(filepath.Join(tempDir, "tmp", "nested", "c.jsonl")))
+	assert.False(t, scanner.IgnoresFile(filepath.Join(tempDir, "app", "a.jsonl")))
+}
+
+func TestFileScannerProjectFilter(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, rel := range []string{"acme-api/a.jsonl", "acme-web/b.jsonl", "acme-sandbox/c.jsonl", "blog/d.jsonl"} {
+		path := filepath.Join(tempDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0644))
+	}
+	file := func(rel string) string { return filepath.Join(tempDir, rel) }
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{"no filter", nil, nil, []string{"acme-api/a.jsonl", "acme-sandbox/c.jsonl", "acme-web/b.jsonl", "blog/d.jsonl"}},
+		{"include substring", []string{"acme"}, nil, []string{"acme-api/a.jsonl", "acme-sandbox/c.jsonl", "acme-web/b.jsonl"}},
+		{"include glob", []string{"*-web", "blog"}, nil, []string{"acme-web/b.jsonl", "blog/d.jsonl"}},
+		{"exclude only", nil, []string{"sandbox"}, []string{"acme-api/a.jsonl", "acme-web/b.jsonl", "blog/d.jsonl"}},
+		{"include then exclude", []string{"acme-*"}, []string{"*sandbox", "web"}, []string{"acme-api/a.jsonl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewFileScanner(tempDir)
+			scanner.SetProjectFilter(tt.include, tt.exclude)
+
+			files, err := scanner.Scan()
+			require.NoError(t, err)
+			var want []string
+			for _, rel := range tt.want {
+				want = append(want, file(rel))
+			}
+			assert.Equal(t, want, files)
+		})
+	}
+
+	scanner := NewFileScanner(tempDir)
+	scanner.SetProjectFilter([]string{"acme"}, []string{"sandbox"})
+	assert.True(t, scanner.IgnoresFile(file("blog/d.jsonl")))
+	assert.True(t, scanner.IgnoresFile(file("acme-sandbox/c.jsonl")))
+	assert.False(t, scanner.IgnoresFile(file("acme-api/a.jsonl")))
+
+	assert.NoError(t, ValidateProjectPatterns([]string{"acme", "acme-*"}))
+	assert.Error(t, ValidateProjectPatterns([]string{"acme-["}))
+}
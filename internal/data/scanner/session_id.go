@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// BackupSuffixes are the name suffixes, before the file extension, that mark
+// a copy of a session file, e.g. "<session>.backup.jsonl"
+var BackupSuffixes = []string{".backup", ".bak"}
+
+// SessionID returns the session id of a log file: its base name without the
+// extension and any backup suffix. Other dots are kept, so
+// "2024.01.01-session.jsonl" and "2024.01.02-session.jsonl" stay distinct
+// while "session.jsonl" and "session.backup.jsonl" share the id "session".
+func SessionID(filePath string) string {
+	// Handle both Unix and Windows path separators
+	filename := filePath
+	if idx := strings.LastIndexAny(filename, `/\`); idx != -1 {
+		filename = filename[idx+1:]
+	}
+	id := strings.TrimSuffix(filename, filepath.Ext(filename))
+	for {
+		suffix := backupSuffix(id)
+		if suffix == "" || len(suffix) == len(id) {
+			return id
+		}
+		id = id[:len(id)-len(suffix)]
+	}
+}
+
+// IsBackup reports whether the file is a backup copy of a session file
+func IsBackup(filePath string) bool {
+	filename := filePath
+	if idx := strings.LastIndexAny(filename, `/\`); idx != -1 {
+		filename = filename[idx+1:]
+	}
+	return SessionID(filePath) != strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+// SupersededBackup reports whether the file is a backup copy whose original
+// session file exists next to it. Scans keep only the original of such a
+// pair; files reported individually, such as by the watcher, need this check.
+func SupersededBackup(filePath string) bool {
+	if !IsBackup(filePath) {
+		return false
+	}
+	original := filepath.Join(filepath.Dir(filePath), SessionID(filePath)+filepath.Ext(filePath))
+	_, err := os.Stat(original)
+	return err == nil
+}
+
+func backupSuffix(name string) string {
+	lower := strings.ToLower(name)
+	for _, suffix := range BackupSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// DedupeSessionFiles keeps one file per session id and directory, so a
+// session and its backups are not counted more than once. The original file
+// is preferred over backups; otherwise the first file in order is kept.
+// Files of the same id in different directories are left alone.
+func DedupeSessionFiles(files []string) []string {
+	type sessionKey struct{ dir, id string }
+	kept := make(map[sessionKey]int, len(files))
+	result := make([]string, 0, len(files))
+	skipped := 0
+
+	for _, file := range files {
+		key := sessionKey{filepath.Dir(file), SessionID(file)}
+		idx, seen := kept[key]
+		if !seen {
+			kept[key] = len(result)
+			result = append(result, file)
+			continue
+		}
+		skipped++
+		if IsBackup(result[idx]) && !IsBackup(file) {
+			result[idx] = file
+		}
+	}
+
+	if skipped > 0 {
+		util.LogInfo(fmt.Sprintf("Skipped %d backup copies of session files", skipped))
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionIDDottedFilenames(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/p/00aec530-0614-436f-a53b-faaa0b32f123.jsonl", "00aec530-0614-436f-a53b-faaa0b32f123"},
+		{"/p/2024.01.01-session.jsonl", "2024.01.01-session"},
+		{"/p/2024.01.02-session.jsonl", "2024.01.02-session"},
+		{"/p/session.backup.jsonl", "session"},
+		{"/p/session.BAK.jsonl", "session"},
+		{"/p/session.backup.bak.jsonl", "session"},
+		{"/p/v1.2.backup.jsonl", "v1.2"},
+		{`C:\p\session.backup.jsonl`, "session"},
+		{"/p/.backup.jsonl", ".backup"},
+		{"/p/backup.jsonl", "backup"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SessionID(tt.path))
+			// Stable across calls
+			assert.Equal(t, SessionID(tt.path), SessionID(tt.path))
+		})
+	}
+
+	// Distinct dotted sessions never collide
+	assert.NotEqual(t, SessionID("/p/2024.01.01-session.jsonl"), SessionID("/p/2024.01.02-session.jsonl"))
+	assert.NotEqual(t, SessionID("/p/v1.2.jsonl"), SessionID("/p/v1.3.jsonl"))
+}
+
+func TestIsBackup(t *testing.T) {
+	assert.True(t, IsBackup("/p/session.backup.jsonl"))
+	assert.True(t, IsBackup("/p/session.bak.jsonl"))
+	assert.False(t, IsBackup("/p/session.jsonl"))
+	assert.False(t, IsBackup("/p/2024.01.01-session.jsonl"))
+}
+
+func TestSupersededBackup(t *testing.T) {
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "session.backup.jsonl")
+	require.NoError(t, os.WriteFile(backup, []byte("{}\n"), 0644))
+
+	// Without the original the backup is all there is
+	assert.False(t, SupersededBackup(backup))
+
+	original := filepath.Join(dir, "session.jsonl")
+	require.NoError(t, os.WriteFile(original, []byte("{}\n"), 0644))
+	assert.True(t, SupersededBackup(backup))
+	assert.False(t, SupersededBackup(original))
+}
+
+func TestDedupeSessionFiles(t *testing.T) {
+	files := []string{
+		"/a/session.backup.jsonl",
+		"/a/session.bak.jsonl",
+		"/a/session.jsonl",
+		"/a/2024.01.01-session.jsonl",
+		"/a/2024.01.02-session.jsonl",
+		"/b/session.backup.jsonl",
+		"/b/session.bak.jsonl",
+	}
+
+	assert.Equal(t, []string{
+		"/a/session.jsonl",
+		"/a/2024.01.01-session.jsonl",
+		"/a/2024.01.02-session.jsonl",
+		"/b/session.backup.jsonl",
+	}, DedupeSessionFiles(files))
+}
+
+func TestFileScannerSkipsBackupCopies(t *testing.T) {
+	tempDir := t.TempDir()
+	projectDir := filepath.Join(tempDir, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	for _, name := range []string{"session.jsonl", "session.backup.jsonl", "other.jsonl"} {
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, name), []byte("{}\n"), 0644))
+	}
+
+	files, err := NewFileScanner(tempDir).Scan()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(projectDir, "other.jsonl"),
+		filepath.Join(projectDir, "session.jsonl"),
+	}, files)
+}
@@ -1,8 +1,23 @@
 package display
 
+import "time"
+
 // DisplayConfig contains display-specific configuration
 type DisplayConfig struct {
 	Plan       string
 	Timezone   string
 	TimeFormat string
-}
\ No newline at end of file
+
+	// SessionDuration is the length of a session window (0 = 5h)
+	SessionDuration time.Duration
+
+	// ClampResetTime caps the displayed reset time at one session duration
+	// from the window start unless an unexpired limit message says otherwise
+	ClampResetTime bool
+
+	// CollapseModels shows only the dominant model in the model distribution
+	CollapseModels bool
+
+	// FollowProject renders only the active window of this project full-screen
+	FollowProject string
+}
@@ -304,6 +304,52 @@ func TestCalculateAggregatedMetrics(t *testing.T) {
 	})
 }
 
+func TestCalculateAggregatedMetricsClampsResetTime(t *testing.T) {
+	currentTime := time.Now().Unix()
+	windowStart := currentTime - 3600
+
+	inflatedSession := func(source string) []*Session {
+		return []*Session{
+			{
+				ID:              "session1",
+				StartTime:       windowStart,
+				WindowStartTime: &windowStart,
+				WindowSource:    source,
+				IsActive:        true,
+				ResetTime:       windowStart + 9*3600, // 4 hours past the 5-hour window
+			},
+		}
+	}
+
+	t.Run("clamps_inflated_reset", func(t *testing.T) {
+		display := NewTerminalDisplay(&DisplayConfig{Plan: "pro", Timezone: "UTC", ClampResetTime: true})
+
+		aggregated := display.CalculateAggregatedMetrics(inflatedSession("first_message"))
+
+		assert.True(t, aggregated.ResetTimeClamped)
+		assert.Equal(t, windowStart+5*3600, aggregated.ResetTime)
+		assert.InDelta(t, (4 * time.Hour).Seconds(), aggregated.TimeRemaining.Seconds(), 2)
+	})
+
+	t.Run("trusts_unexpired_limit_message", func(t *testing.T) {
+		display := NewTerminalDisplay(&DisplayConfig{Plan: "pro", Timezone: "UTC", ClampResetTime: true})
+
+		aggregated := display.CalculateAggregatedMetrics(inflatedSession("limit_message"))
+
+		assert.False(t, aggregated.ResetTimeClamped)
+		assert.Equal(t, windowStart+9*3600, aggregated.ResetTime)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		display := NewTerminalDisplay(&DisplayConfig{Plan: "pro", Timezone: "UTC"})
+
+		aggregated := display.CalculateAggregatedMetrics(inflatedSession("first_message"))
+
+		assert.False(t, aggregated.ResetTimeClamped)
+		assert.Equal(t, windowStart+9*3600, aggregated.ResetTime)
+	})
+}
+
 func TestRenderWithState(t *testing.T) {
 	config := &DisplayConfig{
 		Plan:       "pro",
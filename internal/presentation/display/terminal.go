@@ -29,6 +29,8 @@ type Session struct {
 	WindowSource     string
 	FirstEntryTime   int64
 	TotalTokens      int
+	LimitTokens      int // Tokens counted toward the token limit
+	HasLimitTokens   bool
 	TotalCost        float64
 	ProjectTokens    int
 	ProjectCost      float64
@@ -45,6 +47,14 @@ type Session struct {
 	CostPerMinute     float64
 	TokensPerMinute   float64
 	PredictedEndTime  int64
+
+	// Projections at window end
+	ProjectedTokens      int
+	ProjectedCost        float64
+	ProjectionConfidence string // low, medium or high
+
+	// How long before reset the opening burst pace would hit the limit
+	PacingLead time.Duration
 }
 
 type ProjectStats struct {
@@ -65,6 +75,17 @@ type TerminalDisplay struct {
 	previousScreen       []string // Previous screen content for differential updates
 	isFirstRender        bool     // Track if this is the first render
 	currentMode          model.DisplayMode // Track current display mode for proper transitions
+
+	// Cumulative usage of the current day, shown when hasDailyUsage is set
+	hasDailyUsage bool
+	dailyTokens   int
+	dailyCost     float64
+
+	// Usage against the plan's weekly cap, shown when hasWeeklyUsage is set
+	hasWeeklyUsage   bool
+	weeklyTokens     int
+	weeklyTokenLimit int
+	weeklyResetTime  int64
 }
 
 func NewTerminalDisplay(config *DisplayConfig) *TerminalDisplay {
@@ -216,6 +237,17 @@ func (td *TerminalDisplay) RenderWithState(sessions []*Session, state model.Inte
 
 	// Calculate aggregated metrics
 	aggregated := td.CalculateAggregatedMetrics(sessions)
+	if td.hasDailyUsage {
+		aggregated.HasDailyUsage = true
+		aggregated.DailyTokens = td.dailyTokens
+		aggregated.DailyCost = td.dailyCost
+	}
+	if td.hasWeeklyUsage {
+		aggregated.HasWeeklyUsage = true
+		aggregated.WeeklyTokens = td.weeklyTokens
+		aggregated.WeeklyTokenLimit = td.weeklyTokenLimit
+		aggregated.WeeklyResetTime = td.weeklyResetTime
+	}
 
 	// Add status indicator to aggregated metrics for display
 	if state.DisplayStatus == model.StatusRefreshing || state.DisplayStatus == model.StatusClearing {
@@ -224,8 +256,11 @@ func (td *TerminalDisplay) RenderWithState(sessions []*Session, state model.Inte
 	}
 
 	// Render based on layout style using Strategy Pattern
-	layoutParam := model.LayoutParam{Plan: td.config.Plan, Timezone: td.config.Timezone, TimeFormat: td.config.TimeFormat}
-	layoutStrategy := layout.GetLayoutStrategy(state.LayoutStyle)
+	layoutParam := model.LayoutParam{Plan: td.config.Plan, Timezone: td.config.Timezone, TimeFormat: td.config.TimeFormat, CollapseModels: td.config.CollapseModels, FollowProject: td.config.FollowProject}
+	var layoutStrategy layout.LayoutStrategy = &layout.FollowLayoutStrategy{}
+	if td.config.FollowProject == "" {
+		layoutStrategy = layout.SelectLayoutStrategy(state.LayoutStyle, layout.TerminalWidth())
+	}
 
 	// For smart rendering, we need to capture the output and compare
 	if td.smartRenderEnabled {
@@ -234,14 +269,33 @@ func (td *TerminalDisplay) RenderWithState(sessions []*Session, state model.Inte
 		layoutStrategy.Render(aggregated, layoutParam)
 	}
 
-	// Show status message if present
+	// Show status message if present, else a clock skew warning
 	if state.StatusMessage != "" {
 		td.renderStatusMessage(state.StatusMessage)
+	} else if state.ClockSkew > 0 {
+		td.renderStatusMessage(fmt.Sprintf("system clock moved back %s since the last refresh", state.ClockSkew))
 	}
 
 	td.lastDraw = time.Now().Unix()
 }
 
+// SetDailyUsage sets the cumulative usage of the current day shown next to
+// the active window from the next render on
+func (td *TerminalDisplay) SetDailyUsage(tokens int, cost float64) {
+	td.hasDailyUsage = true
+	td.dailyTokens = tokens
+	td.dailyCost = cost
+}
+
+// SetWeeklyUsage sets the usage against the plan's weekly token cap shown
+// alongside the window cap from the next render on
+func (td *TerminalDisplay) SetWeeklyUsage(tokens, limit int, resetTime int64) {
+	td.hasWeeklyUsage = true
+	td.weeklyTokens = tokens
+	td.weeklyTokenLimit = limit
+	td.weeklyResetTime = resetTime
+}
+
 // smartRender performs differential rendering to preserve text selection
 func (td *TerminalDisplay) smartRender(strategy layout.LayoutStrategy, aggregated *model.AggregatedMetrics, param model.LayoutParam) {
 	// For now, use regular rendering but with cursor positioning
@@ -251,6 +305,29 @@ func (td *TerminalDisplay) smartRender(strategy layout.LayoutStrategy, aggregate
 	fmt.Print(util.RestoreCursor)
 }
 
+// clampResetTime caps a session's reset time at one session duration from its
+// window start. Windows anchored by an unexpired limit message are trusted as-is.
+func clampResetTime(sess *Session, currentTime int64, sessionDuration time.Duration) (int64, bool) {
+	if sess.WindowSource == "limit_message" && sess.ResetTime > currentTime {
+		return sess.ResetTime, false
+	}
+
+	windowStart := sess.StartTime
+	if sess.WindowStartTime != nil {
+		windowStart = *sess.WindowStartTime
+	}
+	maxReset := windowStart + int64(sessionDuration.Seconds())
+	if windowStart == 0 || sess.ResetTime <= maxReset {
+		return sess.ResetTime, false
+	}
+
+	util.LogDebug(fmt.Sprintf("Clamping reset time for session %s from %s to %s",
+		sess.ID,
+		time.Unix(sess.ResetTime, 0).Format("2006-01-02 15:04:05"),
+		time.Unix(maxReset, 0).Format("2006-01-02 15:04:05")))
+	return maxReset, true
+}
+
 // calculateAggregatedMetrics calculates combined metrics from all sessions
 func (td *TerminalDisplay) CalculateAggregatedMetrics(sessions []*Session) *model.AggregatedMetrics {
 	// Get plan limits from pricing package (always needed)
@@ -275,6 +352,7 @@ func (td *TerminalDisplay) CalculateAggregatedMetrics(sessions []*Session) *mode
 		CostLimit:         planLimits.CostLimit,
 		TokenLimit:        planLimits.TokenLimit,
 		MessageLimit:      plan.MessageLimit,
+		SessionDuration:   td.config.SessionDuration,
 	}
 
 	// Calculate totals
@@ -313,6 +391,8 @@ func (td *TerminalDisplay) CalculateAggregatedMetrics(sessions []*Session) *mode
 	if firstActiveSession != nil {
 		aggregated.TotalCost = firstActiveSession.TotalCost
 		aggregated.TotalTokens = firstActiveSession.TotalTokens
+		aggregated.LimitTokens = firstActiveSession.LimitTokens
+		aggregated.HasLimitTokens = firstActiveSession.HasLimitTokens
 		aggregated.TotalMessages = firstActiveSession.MessageCount
 		
 		// Combine model distributions from all active sessions
@@ -387,9 +467,15 @@ func (td *TerminalDisplay) CalculateAggregatedMetrics(sessions []*Session) *mode
 				aggregated.PredictedEndTime = firstActiveSession.ResetTime
 			}
 		} else if planLimits.TokenLimit > 0 && firstActiveSession.TokensPerMinute > 0 {
-			remainingTokens := float64(planLimits.TokenLimit) - float64(firstActiveSession.TotalTokens)
-			if remainingTokens > 0 {
-				minutesToLimit := remainingTokens / firstActiveSession.TokensPerMinute
+			// Only the counted share of the token rate consumes the limit
+			limitTokens := aggregated.GetLimitTokens()
+			limitTokensPerMinute := firstActiveSession.TokensPerMinute
+			if firstActiveSession.TotalTokens > 0 {
+				limitTokensPerMinute *= float64(limitTokens) / float64(firstActiveSession.TotalTokens)
+			}
+			remainingTokens := float64(planLimits.TokenLimit) - float64(limitTokens)
+			if remainingTokens > 0 && limitTokensPerMinute > 0 {
+				minutesToLimit := remainingTokens / limitTokensPerMinute
 				aggregated.PredictedEndTime = currentTime + int64(minutesToLimit*60)
 			} else {
 				// Token limit reached, set PredictedEndTime to ResetTime
@@ -399,8 +485,22 @@ func (td *TerminalDisplay) CalculateAggregatedMetrics(sessions []*Session) *mode
 
 		// Use reset time and window information from the first active session
 		aggregated.ResetTime = firstActiveSession.ResetTime
+		if td.config.ClampResetTime {
+			aggregated.ResetTime, aggregated.ResetTimeClamped = clampResetTime(firstActiveSession, currentTime, aggregated.GetSessionDuration())
+		}
+		if aggregated.ResetTime > currentTime {
+			aggregated.TimeRemaining = time.Duration(aggregated.ResetTime-currentTime) * time.Second
+		}
 		aggregated.WindowSource = firstActiveSession.WindowSource
 		aggregated.IsWindowDetected = firstActiveSession.IsWindowDetected
+		aggregated.ProjectedTokens = firstActiveSession.ProjectedTokens
+		aggregated.ProjectedCost = firstActiveSession.ProjectedCost
+		aggregated.ProjectionConfidence = firstActiveSession.ProjectionConfidence
+		aggregated.PacingLead = firstActiveSession.PacingLead
+		if project, ok := firstActiveSession.Projects[td.config.FollowProject]; ok && td.config.FollowProject != "" {
+			aggregated.FollowedProjectTokens = project.TokenCount
+			aggregated.FollowedProjectCost = project.Cost
+		}
 
 		util.LogDebug(fmt.Sprintf("Display using session %s - EndTime: %s, ResetTime: %s, PredictedEndTime: %s, WindowSource: %s",
 			firstActiveSession.ID,
@@ -410,7 +510,7 @@ func (td *TerminalDisplay) CalculateAggregatedMetrics(sessions []*Session) *mode
 			firstActiveSession.WindowSource))
 
 		// Update cache with the first active session info
-		td.lastResetTime = firstActiveSession.ResetTime
+		td.lastResetTime = aggregated.ResetTime
 		td.lastPredictedEndTime = aggregated.PredictedEndTime
 	}
 
@@ -419,7 +519,7 @@ func (td *TerminalDisplay) CalculateAggregatedMetrics(sessions []*Session) *mode
 	if planLimits.CostLimit > 0 && aggregated.TotalCost >= planLimits.CostLimit {
 		aggregated.LimitExceeded = true
 		aggregated.LimitExceededReason = "COST LIMIT EXCEEDED"
-	} else if planLimits.TokenLimit > 0 && aggregated.TotalTokens >= planLimits.TokenLimit {
+	} else if planLimits.TokenLimit > 0 && aggregated.GetLimitTokens() >= planLimits.TokenLimit {
 		aggregated.LimitExceeded = true
 		aggregated.LimitExceededReason = "TOKEN LIMIT EXCEEDED"
 	} else if plan.MessageLimit > 0 && aggregated.TotalMessages >= plan.MessageLimit {
@@ -447,15 +547,18 @@ func (td *TerminalDisplay) renderHelp() {
 	fmt.Println()
 	fmt.Println("  q/Esc/Ctrl+C - Quit the program")
 	fmt.Println("  r         - Force refresh data")
-	fmt.Println("  t         - Change layout style (Full → Minimal)")
+	fmt.Println("  t         - Change layout style (Full → Minimal → Compact)")
 	fmt.Println("  c         - Clear memory cache")
 	fmt.Println("  p         - Pause/unpause auto-refresh")
+	fmt.Println("  e         - Export current view to JSON in the cache directory")
 	fmt.Println("  h         - Show this help")
 	fmt.Println("  ESC       - Close help/details (or quit if nothing is open)")
 	fmt.Println()
 	fmt.Println("Layout Styles:")
 	fmt.Println("  Full Dashboard - Complete view with progress bars and detailed metrics")
 	fmt.Println("  Minimal        - Ultra-compact view for quick checks")
+	fmt.Println("  Compact        - Token gauge, reset countdown and cost for narrow terminals")
+	fmt.Printf("                   (used automatically below %d columns)\n", layout.CompactWidthThreshold)
 	fmt.Println()
 	fmt.Println("Status Colors:")
 	fmt.Println("  🟢 Green  - Normal usage (below 60% of limit)")
@@ -3,20 +3,21 @@ package formatter
 import (
 	"encoding/csv"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
-type CSVFormatter struct{}
+type CSVFormatter struct {
+	output
+}
 
 func NewCSVFormatter() *CSVFormatter {
 	return &CSVFormatter{}
 }
 
 func (f *CSVFormatter) Format(data []GroupedData) error {
-	w := csv.NewWriter(os.Stdout)
+	w := csv.NewWriter(f.writer())
 	defer w.Flush()
 
 	headers := []string{
@@ -2,17 +2,97 @@ package formatter
 
 import (
 	"encoding/json"
-	"os"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
-type JSONFormatter struct{}
+// Meta describes the tool version and effective configuration that produced
+// a JSON report so its numbers can be reproduced
+type Meta struct {
+	Version     string     `json:"version"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Config      MetaConfig `json:"config"`
+}
+
+// MetaConfig is the effective configuration of a report; settings that do not
+// apply to the command are omitted
+type MetaConfig struct {
+	Plan              string   `json:"plan,omitempty"`
+	PricingSource     string   `json:"pricing_source"`
+	PricingOffline    bool     `json:"pricing_offline"`
+	StrictPricing     bool     `json:"strict_pricing,omitempty"` // Unknown models are unpriced instead of billed at default rates
+	CacheReadDiscount float64  `json:"cache_read_discount"`
+	PricingFile       string   `json:"pricing_file,omitempty"`
+	Currency          string   `json:"currency,omitempty"`      // Display currency; costs in the data stay in USD
+	ExchangeRate      float64  `json:"exchange_rate,omitempty"` // Units of Currency per USD
+	Timezone          string   `json:"timezone"`
+	SessionDuration   string   `json:"session_duration,omitempty"`
+	TimelineMode      string   `json:"timeline_mode,omitempty"` // How sessions are formed from the log timeline: windows or activity
+	Duration          string   `json:"duration,omitempty"`
+	Since             string   `json:"since,omitempty"` // --since as given
+	Until             string   `json:"until,omitempty"` // --until as given
+	GroupBy           string   `json:"group_by,omitempty"`
+	Models            []string `json:"models,omitempty"`           // --model globs
+	Projects          []string `json:"projects,omitempty"`         // --project patterns
+	ExcludeProjects   []string `json:"exclude_projects,omitempty"` // --exclude-project patterns
+}
+
+// jsonReport is the JSON output when a Meta block is embedded
+type jsonReport struct {
+	Meta *Meta       `json:"meta"`
+	Data interface{} `json:"data"`
+}
+
+// currencyRow is a row with its cost both in USD and in the display currency
+type currencyRow struct {
+	GroupedData
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	TotalCost    float64 `json:"total_cost"` // In the display currency
+}
+
+type JSONFormatter struct {
+	output
+	meta     *Meta
+	currency *util.Currency
+}
 
 func NewJSONFormatter() *JSONFormatter {
 	return &JSONFormatter{}
 }
 
+// SetMeta wraps the output in an object holding meta and the rows as data
+func (f *JSONFormatter) SetMeta(meta *Meta) {
+	f.meta = meta
+}
+
+// SetCurrency adds total_cost_usd and total_cost, converted to currency, to
+// every row; Cost stays in USD
+func (f *JSONFormatter) SetCurrency(currency util.Currency) {
+	f.currency = &currency
+}
+
 func (f *JSONFormatter) Format(data []GroupedData) error {
-	encoder := json.NewEncoder(os.Stdout)
+	encoder := json.NewEncoder(f.writer())
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(data)
+	// An empty report is an empty array, not null
+	if data == nil {
+		data = []GroupedData{}
+	}
+	var rows interface{} = data
+	if f.currency != nil {
+		converted := make([]currencyRow, len(data))
+		for i, row := range data {
+			converted[i] = currencyRow{
+				GroupedData:  row,
+				TotalCostUSD: row.Cost,
+				TotalCost:    f.currency.Convert(row.Cost),
+			}
+		}
+		rows = converted
+	}
+	if f.meta != nil {
+		return encoder.Encode(jsonReport{Meta: f.meta, Data: rows})
+	}
+	return encoder.Encode(rows)
 }
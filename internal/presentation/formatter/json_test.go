@@ -6,6 +6,9 @@ import (
 	"io"
 	"os"
 	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
 func TestNewJSONFormatter(t *testing.T) {
@@ -293,4 +296,89 @@ func TestJSONFormatterEdgeCases(t *testing.T) {
 			t.Errorf("Large cost not preserved: got %f, want %f", result[0].Cost, data[0].Cost)
 		}
 	})
-}
\ No newline at end of file
+}
+func TestJSONFormatterMeta(t *testing.T) {
+	formatter := NewJSONFormatter()
+	buf := new(bytes.Buffer)
+	formatter.SetWriter(buf)
+	formatter.SetMeta(&Meta{
+		Version:     "v1.2.3",
+		GeneratedAt: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		Config:      MetaConfig{PricingSource: "litellm", Timezone: "UTC", GroupBy: "model"},
+	})
+
+	data := []GroupedData{{Date: "2024-01-15", TotalTokens: 1500, Cost: 0.0225}}
+	if err := formatter.Format(data); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	var result struct {
+		Meta map[string]interface{} `json:"meta"`
+		Data []GroupedData          `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if result.Meta["version"] != "v1.2.3" {
+		t.Errorf("meta version = %v, want v1.2.3", result.Meta["version"])
+	}
+	if result.Meta["generated_at"] != "2024-01-15T10:00:00Z" {
+		t.Errorf("meta generated_at = %v", result.Meta["generated_at"])
+	}
+	config, _ := result.Meta["config"].(map[string]interface{})
+	if config["pricing_source"] != "litellm" || config["group_by"] != "model" {
+		t.Errorf("meta config = %v", config)
+	}
+	if _, ok := config["plan"]; ok {
+		t.Errorf("meta config should omit the unset plan: %v", config)
+	}
+	if len(result.Data) != 1 || result.Data[0].TotalTokens != 1500 {
+		t.Errorf("data = %+v", result.Data)
+	}
+}
+
+func TestJSONFormatterCurrency(t *testing.T) {
+	eur, err := util.NewCurrency("EUR", 0.5)
+	if err != nil {
+		t.Fatalf("NewCurrency returned error: %v", err)
+	}
+
+	formatter := NewJSONFormatter()
+	buf := new(bytes.Buffer)
+	formatter.SetWriter(buf)
+	formatter.SetCurrency(eur)
+
+	data := []GroupedData{{Date: "2024-01-15", TotalTokens: 1500, Cost: 3}}
+	if err := formatter.Format(data); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(rows))
+	}
+	row := rows[0]
+	if row["Cost"] != 3.0 || row["total_cost_usd"] != 3.0 {
+		t.Errorf("USD costs = %v / %v, want 3", row["Cost"], row["total_cost_usd"])
+	}
+	if row["total_cost"] != 1.5 {
+		t.Errorf("total_cost = %v, want 1.5", row["total_cost"])
+	}
+	if row["Date"] != "2024-01-15" {
+		t.Errorf("Row fields should be kept: %v", row)
+	}
+
+	// Without a currency the rows carry only Cost
+	plain := NewJSONFormatter()
+	buf.Reset()
+	plain.SetWriter(buf)
+	if err := plain.Format(data); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("total_cost")) {
+		t.Errorf("Expected no converted costs without a currency:\n%s", buf.String())
+	}
+}
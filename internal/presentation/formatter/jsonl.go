@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"encoding/json"
+)
+
+// JSONLFormatter writes one JSON object per line so large results can be
+// streamed: a "row" record per grouped row followed by a "summary" record
+type JSONLFormatter struct {
+	output
+	rowsOnly bool // ndjson: every line is a row, no summary record
+}
+
+// JSONLRow is a single grouped row in JSON Lines output
+type JSONLRow struct {
+	Type string
+	GroupedData
+}
+
+// JSONLSummary is the final record of JSON Lines output
+type JSONLSummary struct {
+	Type          string
+	Rows          int
+	InputTokens   int
+	OutputTokens  int
+	CacheCreation int
+	CacheRead     int
+	TotalTokens   int
+	Cost          float64
+}
+
+func NewJSONLFormatter() *JSONLFormatter {
+	return &JSONLFormatter{}
+}
+
+// NewNDJSONFormatter creates the formatter of ndjson output: the row records
+// of jsonl output without the summary, so each line stands on its own
+func NewNDJSONFormatter() *JSONLFormatter {
+	return &JSONLFormatter{rowsOnly: true}
+}
+
+func (f *JSONLFormatter) Format(data []GroupedData) error {
+	encoder := json.NewEncoder(f.writer())
+	summary := JSONLSummary{Type: "summary", Rows: len(data)}
+
+	for _, row := range data {
+		if err := encoder.Encode(JSONLRow{Type: "row", GroupedData: row}); err != nil {
+			return err
+		}
+		summary.InputTokens += row.InputTokens
+		summary.OutputTokens += row.OutputTokens
+		summary.CacheCreation += row.CacheCreation
+		summary.CacheRead += row.CacheRead
+		summary.TotalTokens += row.TotalTokens
+		summary.Cost += row.Cost
+	}
+
+	if f.rowsOnly {
+		return nil
+	}
+	return encoder.Encode(summary)
+}
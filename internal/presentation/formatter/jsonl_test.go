@@ -0,0 +1,147 @@
+package formatter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONLFormatterFormat(t *testing.T) {
+	data := []GroupedData{
+		{Date: "2024-01-15", Models: []string{"claude-3-5-sonnet"}, InputTokens: 1000, OutputTokens: 500, TotalTokens: 1500, Cost: 0.02},
+		{Date: "2024-01-16", Models: []string{"claude-3-5-haiku"}, InputTokens: 2000, OutputTokens: 1000, TotalTokens: 3000, Cost: 0.01},
+		{Date: "2024-01-17", Models: []string{"claude-opus-4-20250514"}, InputTokens: 300, OutputTokens: 200, TotalTokens: 500, Cost: 0.05},
+	}
+
+	var buf bytes.Buffer
+	formatter := NewJSONLFormatter()
+	formatter.SetWriter(&buf)
+	if err := formatter.Format(data); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	var rows []JSONLRow
+	var summaries []JSONLSummary
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var record struct{ Type string }
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("Line is not valid JSON: %v\nLine: %s", err, line)
+		}
+		switch record.Type {
+		case "row":
+			var row JSONLRow
+			if err := json.Unmarshal(line, &row); err != nil {
+				t.Fatalf("Failed to unmarshal row: %v", err)
+			}
+			rows = append(rows, row)
+		case "summary":
+			var summary JSONLSummary
+			if err := json.Unmarshal(line, &summary); err != nil {
+				t.Fatalf("Failed to unmarshal summary: %v", err)
+			}
+			summaries = append(summaries, summary)
+		default:
+			t.Errorf("Unexpected record type %q", record.Type)
+		}
+	}
+
+	if len(rows) != len(data) {
+		t.Fatalf("Expected %d rows, got %d", len(data), len(rows))
+	}
+	for i, row := range rows {
+		if row.Date != data[i].Date || row.TotalTokens != data[i].TotalTokens {
+			t.Errorf("Row %d mismatch: got %s/%d, want %s/%d", i, row.Date, row.TotalTokens, data[i].Date, data[i].TotalTokens)
+		}
+	}
+
+	if len(summaries) != 1 {
+		t.Fatalf("Expected exactly one summary record, got %d", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.Rows != len(data) {
+		t.Errorf("Expected summary row count %d, got %d", len(data), summary.Rows)
+	}
+	if summary.TotalTokens != 5000 || summary.InputTokens != 3300 {
+		t.Errorf("Unexpected summary totals: total=%d input=%d", summary.TotalTokens, summary.InputTokens)
+	}
+}
+
+func TestJSONLFormatterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := NewJSONLFormatter()
+	formatter.SetWriter(&buf)
+	if err := formatter.Format(nil); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	var summary JSONLSummary
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &summary); err != nil {
+		t.Fatalf("Expected a single summary line, got %q: %v", buf.String(), err)
+	}
+	if summary.Type != "summary" || summary.Rows != 0 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestNDJSONFormatterFormat(t *testing.T) {
+	data := []GroupedData{
+		{
+			Date: "2024-01-15", Project: "web", Models: []string{"claude-3-5-sonnet", "claude-3-5-haiku"},
+			InputTokens: 1000, OutputTokens: 500, TotalTokens: 1500, Cost: 0.02,
+			ModelDetails: []ModelDetail{
+				{Model: "claude-3-5-sonnet", InputTokens: 800, OutputTokens: 400, TotalTokens: 1200, Cost: 0.018},
+				{Model: "claude-3-5-haiku", InputTokens: 200, OutputTokens: 100, TotalTokens: 300, Cost: 0.002},
+			},
+		},
+		{Date: "2024-01-16", Project: "api", Excluded: true},
+	}
+
+	var buf bytes.Buffer
+	formatter := NewNDJSONFormatter()
+	formatter.SetWriter(&buf)
+	if err := formatter.Format(data); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	var rows []JSONLRow
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var row JSONLRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("Line is not valid JSON: %v\nLine: %s", err, scanner.Bytes())
+		}
+		if row.Type != "row" {
+			t.Errorf("Expected only row records, got %q", row.Type)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) != len(data) {
+		t.Fatalf("Expected one line per row (%d), got %d", len(data), len(rows))
+	}
+	first := rows[0]
+	if first.Project != "web" || first.Date != "2024-01-15" || first.TotalTokens != 1500 || first.Cost != 0.02 {
+		t.Errorf("Unexpected first row: %+v", first)
+	}
+	if len(first.ModelDetails) != 2 || first.ModelDetails[0].Model != "claude-3-5-sonnet" || first.ModelDetails[0].TotalTokens != 1200 {
+		t.Errorf("Unexpected model breakdown: %+v", first.ModelDetails)
+	}
+	if !rows[1].Excluded {
+		t.Errorf("Expected an excluded row, got %+v", rows[1])
+	}
+}
+
+func TestNDJSONFormatterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := NewNDJSONFormatter()
+	formatter.SetWriter(&buf)
+	if err := formatter.Format(nil); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output for no rows, got %q", buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+)
+
+// prometheusMetric is one gauge of the Prometheus output
+type prometheusMetric struct {
+	name  string
+	help  string
+	value func(sess *session.Session) float64
+}
+
+// prometheusMetrics are read from the values CalculateMetrics stored on each
+// window during detection
+var prometheusMetrics = []prometheusMetric{
+	{
+		name:  "claude_session_total_tokens",
+		help:  "Total tokens used in the session window.",
+		value: func(sess *session.Session) float64 { return float64(sess.TotalTokens) },
+	},
+	{
+		name:  "claude_session_total_cost",
+		help:  "Total cost of the session window in USD.",
+		value: func(sess *session.Session) float64 { return sess.TotalCost },
+	},
+	{
+		name:  "claude_tokens_per_minute",
+		help:  "Token burn rate of the session window.",
+		value: func(sess *session.Session) float64 { return sess.TokensPerMinute },
+	},
+	{
+		name:  "claude_session_time_remaining_seconds",
+		help:  "Seconds until the session window resets.",
+		value: func(sess *session.Session) float64 { return sess.TimeRemaining.Seconds() },
+	},
+}
+
+// WritePrometheus writes the detected windows in the Prometheus text
+// exposition format, one gauge sample per window and metric. Gaps are skipped.
+func WritePrometheus(w io.Writer, sessions []*session.Session) error {
+	var b strings.Builder
+	for _, metric := range prometheusMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", metric.name)
+		for _, sess := range sessions {
+			if sess.IsGap {
+				continue
+			}
+			fmt.Fprintf(&b, "%s{project=\"%s\",session_id=\"%s\",active=\"%t\"} %s\n",
+				metric.name,
+				escapePrometheusLabel(sess.ProjectName),
+				escapePrometheusLabel(sess.ID),
+				sess.IsActive,
+				strconv.FormatFloat(metric.value(sess), 'g', -1, 64))
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// escapePrometheusLabel escapes a label value for the text exposition format
+func escapePrometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
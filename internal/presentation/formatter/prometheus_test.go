@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/session"
+)
+
+// parsePrometheus is a minimal exposition check: every metric is declared as
+// a gauge before its samples and each sample is `name{labels} value`. It
+// returns the label sets of the samples by metric name.
+func parsePrometheus(t *testing.T, text string) map[string][]string {
+	t.Helper()
+	declared := make(map[string]bool)
+	samples := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			if len(fields) != 4 || fields[3] != "gauge" {
+				t.Fatalf("Malformed TYPE line %q", line)
+			}
+			declared[fields[2]] = true
+			continue
+		}
+		open := strings.Index(line, "{")
+		end := strings.LastIndex(line, "} ")
+		if open <= 0 || end <= open {
+			t.Fatalf("Malformed sample %q", line)
+		}
+		name := line[:open]
+		if !declared[name] {
+			t.Fatalf("Sample %q before its TYPE line", line)
+		}
+		if _, err := strconv.ParseFloat(line[end+2:], 64); err != nil {
+			t.Fatalf("Sample %q has an invalid value: %v", line, err)
+		}
+		samples[name] = append(samples[name], line[open+1:end])
+	}
+	return samples
+}
+
+func TestWritePrometheus(t *testing.T) {
+	sessions := []*session.Session{
+		{ID: "s2", ProjectName: "Multiple", IsActive: true, TotalTokens: 1500, TotalCost: 0.25,
+			TokensPerMinute: 12.5, TimeRemaining: 90 * time.Minute},
+		{ID: "gap", IsGap: true},
+		{ID: "s1", ProjectName: `my "app"`, TotalTokens: 300, TotalCost: 0.05},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, sessions); err != nil {
+		t.Fatalf("WritePrometheus returned error: %v", err)
+	}
+	output := buf.String()
+	samples := parsePrometheus(t, output)
+
+	if len(samples) != 4 {
+		t.Errorf("Expected 4 metrics, got %d", len(samples))
+	}
+	for name, labels := range samples {
+		// One series per non-gap window
+		if len(labels) != 2 {
+			t.Errorf("Expected 2 series of %s, got %d", name, len(labels))
+		}
+	}
+	wantLabels := []string{
+		`project="Multiple",session_id="s2",active="true"`,
+		`project="my \"app\"",session_id="s1",active="false"`,
+	}
+	for i, want := range wantLabels {
+		if got := samples["claude_session_total_tokens"][i]; got != want {
+			t.Errorf("Expected labels %s, got %s", want, got)
+		}
+	}
+	if !strings.Contains(output, `claude_session_time_remaining_seconds{project="Multiple",session_id="s2",active="true"} 5400`) {
+		t.Errorf("Expected time remaining of 5400 seconds, got:\n%s", output)
+	}
+	if strings.Contains(output, "gap") {
+		t.Errorf("Gap sessions should be skipped, got:\n%s", output)
+	}
+}
@@ -9,13 +9,25 @@ import (
 )
 
 // SummaryFormatter is responsible for formatting and outputting summary reports.
-type SummaryFormatter struct{}
+type SummaryFormatter struct {
+	output
+	averagePeriod string // Period ("day", "week") averaged over; empty = no averages
+	periods       int    // Number of periods averaged over
+}
 
 // NewSummaryFormatter creates a new instance of SummaryFormatter.
 func NewSummaryFormatter() *SummaryFormatter {
 	return &SummaryFormatter{}
 }
 
+// SetAveragePeriod reports the average usage over the given number of
+// periods, such as business days or weeks, which may include periods
+// without usage and so without a row
+func (f *SummaryFormatter) SetAveragePeriod(period string, periods int) {
+	f.averagePeriod = period
+	f.periods = periods
+}
+
 // Format formats and outputs the summary information of grouped data.
 func (f *SummaryFormatter) Format(data []GroupedData) error {
 	// Calculate totals for all fields.
@@ -37,6 +49,11 @@ func (f *SummaryFormatter) Format(data []GroupedData) error {
 				modelStats[model] = &ModelDetail{Model: model}
 			}
 		}
+		for _, model := range row.UnpricedModels {
+			if stat, ok := modelStats[model]; ok {
+				stat.Unpriced = true
+			}
+		}
 
 		// Accumulate model details for summary reports.
 		if len(row.ModelDetails) > 0 {
@@ -66,48 +83,60 @@ func (f *SummaryFormatter) Format(data []GroupedData) error {
 	}
 
 	// Output the summary report in English.
-	fmt.Println(strings.Repeat("=", 60))
-	fmt.Println("Claude Code Usage Summary Report")
-	fmt.Println(strings.Repeat("=", 60))
-	fmt.Println()
+	w := f.writer()
+	fmt.Fprintln(w, strings.Repeat("=", 60))
+	fmt.Fprintln(w, "Claude Code Usage Summary Report")
+	fmt.Fprintln(w, strings.Repeat("=", 60))
+	fmt.Fprintln(w)
 
 	// Add Date Range section
 	if len(data) > 0 {
 		firstDate := data[0].Date
 		lastDate := data[len(data)-1].Date
 		if firstDate == lastDate {
-			fmt.Printf("Date Range: %s\n", firstDate)
+			fmt.Fprintf(w, "Date Range: %s\n", firstDate)
 		} else {
-			fmt.Printf("Date Range: %s to %s\n", firstDate, lastDate)
+			fmt.Fprintf(w, "Date Range: %s to %s\n", firstDate, lastDate)
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 
 	// Check if there's any data
 	if len(data) == 0 {
-		fmt.Println("No data to summarize")
-		fmt.Println()
-		fmt.Println(strings.Repeat("=", 60))
+		fmt.Fprintln(w, "No data to summarize")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, strings.Repeat("=", 60))
 		return nil
 	}
 
 	// Token Breakdown section
-	fmt.Println("Token Breakdown:")
-	fmt.Printf("  Input: %s\n", formatNumber(totalInput))
-	fmt.Printf("  Output: %s\n", formatNumber(totalOutput))
-	fmt.Printf("  Cache Creation: %s\n", formatNumber(totalCacheCreate))
-	fmt.Printf("  Cache Read: %s\n", formatNumber(totalCacheRead))
-	fmt.Printf("  Total Tokens: %s\n", formatNumber(totalTokens))
-	fmt.Println()
+	fmt.Fprintln(w, "Token Breakdown:")
+	fmt.Fprintf(w, "  Input: %s\n", formatNumber(totalInput))
+	fmt.Fprintf(w, "  Output: %s\n", formatNumber(totalOutput))
+	fmt.Fprintf(w, "  Cache Creation: %s\n", formatNumber(totalCacheCreate))
+	fmt.Fprintf(w, "  Cache Read: %s\n", formatNumber(totalCacheRead))
+	fmt.Fprintf(w, "  Total Tokens: %s\n", formatNumber(totalTokens))
+	fmt.Fprintln(w)
 
 	// Cost Breakdown section
-	fmt.Println("Cost Breakdown:")
-	fmt.Printf("  Total Cost: %s USD\n", util.FormatCurrency(totalCost))
-	fmt.Println()
+	fmt.Fprintln(w, "Cost Breakdown:")
+	fmt.Fprintf(w, "  Total Cost: %s %s\n", util.FormatCurrency(totalCost), util.DisplayCurrency().Code)
+	fmt.Fprintln(w)
+
+	if periods := f.periods; f.averagePeriod != "" && periods > 0 {
+		unit := f.averagePeriod + "s"
+		if periods == 1 {
+			unit = f.averagePeriod
+		}
+		fmt.Fprintf(w, "Average per %s (%d %s):\n", strings.ToUpper(f.averagePeriod[:1])+f.averagePeriod[1:], periods, unit)
+		fmt.Fprintf(w, "  Tokens: %s\n", formatNumber(totalTokens/periods))
+		fmt.Fprintf(w, "  Cost: %s %s\n", util.FormatCurrency(totalCost/float64(periods)), util.DisplayCurrency().Code)
+		fmt.Fprintln(w)
+	}
 
 	if len(modelStats) > 0 {
-		fmt.Println("Model Usage:")
-		fmt.Println(strings.Repeat("-", 60))
+		fmt.Fprintln(w, "Model Usage:")
+		fmt.Fprintln(w, strings.Repeat("-", 60))
 
 		var models []string
 		for model := range modelStats {
@@ -117,18 +146,22 @@ func (f *SummaryFormatter) Format(data []GroupedData) error {
 
 		for _, model := range models {
 			stat := modelStats[model]
-			fmt.Printf("\n%s:\n", model)
-			fmt.Printf("  Input Tokens:         %s\n", formatNumber(stat.InputTokens))
-			fmt.Printf("  Output Tokens:        %s\n", formatNumber(stat.OutputTokens))
-			fmt.Printf("  Cache Creation:       %s\n", formatNumber(stat.CacheCreation))
-			fmt.Printf("  Cache Read:           %s\n", formatNumber(stat.CacheRead))
-			fmt.Printf("  Total Tokens:         %s\n", formatNumber(stat.TotalTokens))
-			fmt.Printf("  Cost:                 %s USD\n", util.FormatCurrency(stat.Cost))
+			if stat.Unpriced {
+				fmt.Fprintf(w, "\n%s (unpriced):\n", model)
+			} else {
+				fmt.Fprintf(w, "\n%s:\n", model)
+			}
+			fmt.Fprintf(w, "  Input Tokens:         %s\n", formatNumber(stat.InputTokens))
+			fmt.Fprintf(w, "  Output Tokens:        %s\n", formatNumber(stat.OutputTokens))
+			fmt.Fprintf(w, "  Cache Creation:       %s\n", formatNumber(stat.CacheCreation))
+			fmt.Fprintf(w, "  Cache Read:           %s\n", formatNumber(stat.CacheRead))
+			fmt.Fprintf(w, "  Total Tokens:         %s\n", formatNumber(stat.TotalTokens))
+			fmt.Fprintf(w, "  Cost:                 %s %s\n", util.FormatCurrency(stat.Cost), util.DisplayCurrency().Code)
 		}
 	}
 
-	fmt.Println()
-	fmt.Println(strings.Repeat("=", 60))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.Repeat("=", 60))
 
 	return nil
 }
@@ -6,6 +6,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/penwyp/go-claude-monitor/internal/util"
 )
 
 func TestNewSummaryFormatter(t *testing.T) {
@@ -230,6 +232,29 @@ func TestSummaryFormatterFormat(t *testing.T) {
 	}
 }
 
+func TestSummaryFormatterCurrency(t *testing.T) {
+	defer util.SetDisplayCurrency(util.DisplayCurrency())
+	util.SetDisplayCurrency(util.Currency{Code: "EUR", Symbol: "€", Rate: 0.5, Decimals: 2})
+
+	var buf bytes.Buffer
+	formatter := NewSummaryFormatter()
+	formatter.SetWriter(&buf)
+	data := []GroupedData{
+		{Date: "2024-01-15", Models: []string{"claude-3-5-sonnet"}, TotalTokens: 1000, Cost: 3},
+	}
+	if err := formatter.Format(data); err != nil {
+		t.Fatalf("Format returned error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Total Cost: €1.50 EUR") {
+		t.Errorf("Expected converted total cost in EUR, got:\n%s", output)
+	}
+	if strings.Contains(output, "$") || strings.Contains(output, "USD") {
+		t.Errorf("Expected no USD amounts, got:\n%s", output)
+	}
+}
+
 func TestSummaryFormatterEdgeCases(t *testing.T) {
 	formatter := NewSummaryFormatter()
 	
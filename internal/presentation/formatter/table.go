@@ -9,6 +9,7 @@ import (
 )
 
 type TableFormatter struct {
+	output
 	headers []string
 }
 
@@ -16,7 +17,8 @@ func NewTableFormatter() *TableFormatter {
 	return &TableFormatter{
 		headers: []string{
 			"Date", "Models", "Input", "Output",
-			"Cache Create", "Cache Read", "Total Tokens", "Cost (USD)",
+			"Cache Create", "Cache Read", "Total Tokens",
+			fmt.Sprintf("Cost (%s)", util.DisplayCurrency().Code),
 		},
 	}
 }
@@ -245,33 +247,35 @@ func (f *TableFormatter) printBorder(widths []int, borderType string) {
 		left, middle, right, separator = "└", "┴", "┘", "─"
 	}
 
-	fmt.Print(left)
+	w := f.writer()
+	fmt.Fprint(w, left)
 	for i, width := range widths {
-		fmt.Print(strings.Repeat(separator, width+2)) // +2 for padding spaces
+		fmt.Fprint(w, strings.Repeat(separator, width+2)) // +2 for padding spaces
 		if i < len(widths)-1 {
-			fmt.Print(middle)
+			fmt.Fprint(w, middle)
 		}
 	}
-	fmt.Println(right)
+	fmt.Fprintln(w, right)
 }
 
 // printRow prints a data row with proper alignment
 func (f *TableFormatter) printRow(values []string, widths []int, rowType string) {
-	fmt.Print("│")
+	w := f.writer()
+	fmt.Fprint(w, "│")
 	for i, value := range values {
 		// Special handling for breakdown rows to ensure proper alignment
 		if rowType == "breakdown" && i == 1 {
 			// For breakdown rows, Models column should be left-aligned with proper indentation
-			fmt.Printf(" %-*s │", widths[i], value)
+			fmt.Fprintf(w, " %-*s │", widths[i], value)
 		} else if i == 0 || i == 1 {
 			// Date and Models columns are left-aligned
-			fmt.Printf(" %-*s │", widths[i], value)
+			fmt.Fprintf(w, " %-*s │", widths[i], value)
 		} else {
 			// Numeric columns are right-aligned
-			fmt.Printf(" %*s │", widths[i], value)
+			fmt.Fprintf(w, " %*s │", widths[i], value)
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 func formatNumber(n int) string {
@@ -1,24 +1,72 @@
 package formatter
 
+import (
+	"io"
+	"os"
+)
+
+// Formatter renders grouped usage data
+type Formatter interface {
+	Format(data []GroupedData) error
+	SetWriter(w io.Writer)
+}
+
+// output holds the destination shared by all formatters; stdout by default
+type output struct {
+	w io.Writer
+}
+
+// SetWriter directs formatted output to w instead of stdout
+func (o *output) SetWriter(w io.Writer) {
+	o.w = w
+}
+
+func (o *output) writer() io.Writer {
+	if o.w == nil {
+		return os.Stdout
+	}
+	return o.w
+}
+
 type GroupedData struct {
-	Date          string
-	Models        []string
-	InputTokens   int
-	OutputTokens  int
-	CacheCreation int
-	CacheRead     int
-	TotalTokens   int
-	Cost          float64
-	ShowBreakdown bool
-	ModelDetails  []ModelDetail
+	Date          string        `yaml:"Date"`
+	Models        []string      `yaml:"Models"`
+	InputTokens   int           `yaml:"InputTokens"`
+	OutputTokens  int           `yaml:"OutputTokens"`
+	CacheCreation int           `yaml:"CacheCreation"`
+	CacheRead     int           `yaml:"CacheRead"`
+	TotalTokens   int           `yaml:"TotalTokens"`
+	Cost          float64       `yaml:"Cost"`
+	ShowBreakdown bool          `yaml:"ShowBreakdown"`
+	ModelDetails  []ModelDetail `yaml:"ModelDetails"`
+	TierDetails   []TierDetail  `json:"TierDetails,omitempty" yaml:"TierDetails,omitempty"`
+	// UnpricedModels lists models whose cost is unknown and reported as 0
+	UnpricedModels []string `json:"UnpricedModels,omitempty" yaml:"UnpricedModels,omitempty"`
+	// Excluded marks a non-business day listed with zero usage; it is not
+	// counted in averages
+	Excluded bool `json:"Excluded,omitempty" yaml:"Excluded,omitempty"`
+	// Project is set when rows are split by project within each group, as
+	// for ndjson output
+	Project string `json:"Project,omitempty" yaml:"Project,omitempty"`
 }
 
 type ModelDetail struct {
-	Model         string
-	InputTokens   int
-	OutputTokens  int
-	CacheCreation int
-	CacheRead     int
-	TotalTokens   int
-	Cost          float64
+	Model         string  `yaml:"Model"`
+	InputTokens   int     `yaml:"InputTokens"`
+	OutputTokens  int     `yaml:"OutputTokens"`
+	CacheCreation int     `yaml:"CacheCreation"`
+	CacheRead     int     `yaml:"CacheRead"`
+	TotalTokens   int     `yaml:"TotalTokens"`
+	Cost          float64 `yaml:"Cost"`
+	Unpriced      bool    `json:"Unpriced,omitempty" yaml:"Unpriced,omitempty"` // No pricing entry; Cost is 0
+}
+
+type TierDetail struct {
+	ServiceTier   string  `yaml:"ServiceTier"`
+	InputTokens   int     `yaml:"InputTokens"`
+	OutputTokens  int     `yaml:"OutputTokens"`
+	CacheCreation int     `yaml:"CacheCreation"`
+	CacheRead     int     `yaml:"CacheRead"`
+	TotalTokens   int     `yaml:"TotalTokens"`
+	Cost          float64 `yaml:"Cost"`
 }
@@ -0,0 +1,23 @@
+package formatter
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLFormatter writes the same rows and keys as JSONFormatter as YAML
+type YAMLFormatter struct {
+	output
+}
+
+func NewYAMLFormatter() *YAMLFormatter {
+	return &YAMLFormatter{}
+}
+
+func (f *YAMLFormatter) Format(data []GroupedData) error {
+	encoder := yaml.NewEncoder(f.writer())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(data); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAMLFormatterMirrorsJSON(t *testing.T) {
+	data := []GroupedData{
+		{
+			Date:          "2024-01-15",
+			Models:        []string{"claude-3-5-sonnet"},
+			InputTokens:   1000,
+			OutputTokens:  500,
+			CacheCreation: 100,
+			CacheRead:     50,
+			TotalTokens:   1650,
+			Cost:          0.0225,
+			ShowBreakdown: true,
+			ModelDetails: []ModelDetail{
+				{Model: "claude-3-5-sonnet", InputTokens: 1000, OutputTokens: 500, TotalTokens: 1500, Cost: 0.0225},
+			},
+			UnpricedModels: []string{"custom-model"},
+		},
+	}
+
+	var jsonBuf, yamlBuf bytes.Buffer
+	jsonFormatter := NewJSONFormatter()
+	jsonFormatter.SetWriter(&jsonBuf)
+	if err := jsonFormatter.Format(data); err != nil {
+		t.Fatalf("JSON Format returned error: %v", err)
+	}
+	yamlFormatter := NewYAMLFormatter()
+	yamlFormatter.SetWriter(&yamlBuf)
+	if err := yamlFormatter.Format(data); err != nil {
+		t.Fatalf("YAML Format returned error: %v", err)
+	}
+
+	var fromJSON, fromYAML []map[string]interface{}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if err := yaml.Unmarshal(yamlBuf.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("Failed to unmarshal YAML: %v", err)
+	}
+
+	// Numbers decode differently, so compare the re-encoded JSON
+	want, _ := json.Marshal(fromJSON)
+	got, err := json.Marshal(fromYAML)
+	if err != nil {
+		t.Fatalf("Failed to re-encode YAML: %v", err)
+	}
+	var wantValue, gotValue interface{}
+	json.Unmarshal(want, &wantValue)
+	json.Unmarshal(got, &gotValue)
+	if !reflect.DeepEqual(wantValue, gotValue) {
+		t.Errorf("YAML structure differs from JSON:\nJSON: %s\nYAML: %s", want, got)
+	}
+}
@@ -20,6 +20,16 @@ func (b *BaseStrategy) CreateZeroMetrics(original *model.AggregatedMetrics) *mod
 		CostLimit:         original.CostLimit,
 		TokenLimit:        original.TokenLimit,
 		MessageLimit:      original.MessageLimit,
+		SessionDuration:   original.SessionDuration,
+		// The day's usage spans windows, so it survives the window ending
+		HasDailyUsage: original.HasDailyUsage,
+		DailyTokens:   original.DailyTokens,
+		DailyCost:     original.DailyCost,
+		// So does the trailing week's usage
+		HasWeeklyUsage:   original.HasWeeklyUsage,
+		WeeklyTokens:     original.WeeklyTokens,
+		WeeklyTokenLimit: original.WeeklyTokenLimit,
+		WeeklyResetTime:  original.WeeklyResetTime,
 		// All other fields remain zero
 	}
 }
@@ -116,14 +126,14 @@ func (b *BaseStrategy) FormatCostInfo(metrics *model.AggregatedMetrics, params m
 		percentage)
 }
 
-// FormatTokenInfo formats token information
+// FormatTokenInfo formats the tokens counted toward the token limit
 func (b *BaseStrategy) FormatTokenInfo(metrics *model.AggregatedMetrics, params model.LayoutParam) string {
 	percentage := 0.0
 	if metrics.TokenLimit > 0 {
-		percentage = (float64(metrics.TotalTokens) / float64(metrics.TokenLimit)) * 100
+		percentage = (float64(metrics.GetLimitTokens()) / float64(metrics.TokenLimit)) * 100
 	}
 	return fmt.Sprintf("🔤 Tokens: %s / %s (%.1f%%)", 
-		util.FormatNumber(metrics.TotalTokens), 
+		util.FormatNumber(metrics.GetLimitTokens()), 
 		util.FormatNumber(metrics.TokenLimit), 
 		percentage)
 }
@@ -274,6 +274,22 @@ func TestBaseStrategyFormatMetrics(t *testing.T) {
 	})
 }
 
+func TestBaseStrategyFormatTokenInfoUsesLimitTokens(t *testing.T) {
+	strategy := &BaseStrategy{}
+	// Cache creation is not counted toward the limit
+	metrics := model.AggregatedMetrics{
+		TotalTokens:    1000000,
+		LimitTokens:    125000,
+		HasLimitTokens: true,
+		TokenLimit:     500000,
+	}
+
+	result := strategy.FormatTokenInfo(&metrics, model.LayoutParam{})
+	if !strings.Contains(result, "125.0K / 500.0K (25.0%)") {
+		t.Errorf("Expected the limit tokens against the limit, got %q", result)
+	}
+}
+
 func TestBaseStrategyEdgeCases(t *testing.T) {
 	strategy := &BaseStrategy{}
 	
@@ -0,0 +1,52 @@
+package layout
+
+import (
+	"fmt"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// Token gauge width bounds
+const (
+	compactGaugeMinBarWidth = 10
+	compactGaugeMaxBarWidth = 40
+)
+
+// CompactGaugeLayoutStrategy renders a few short lines that fit narrow terminals:
+// a token gauge against the limit, the reset countdown and the cost
+type CompactGaugeLayoutStrategy struct {
+	BaseStrategy
+}
+
+func (s *CompactGaugeLayoutStrategy) GetName() string {
+	return "Compact Gauge"
+}
+
+func (s *CompactGaugeLayoutStrategy) Render(aggregated *model.AggregatedMetrics, param model.LayoutParam) {
+	tp := util.GetTimeProvider()
+	currentTimeStr := tp.FormatNow(util.ClockLayout(param.TimeFormat, true))
+
+	// If no active session, create a zero-value metrics object
+	if !aggregated.HasActiveSession {
+		aggregated = s.CreateZeroMetrics(aggregated)
+	}
+
+	fmt.Printf("Claude %s  %s\n", getPlanType(param.Plan), currentTimeStr)
+
+	// The gauge takes whatever width is left after the label and percentage
+	label := "🪙 "
+	percentage := aggregated.GetTokenPercentage()
+	suffix := fmt.Sprintf(" %5.1f%%", percentage)
+	barWidth := TerminalWidth() - getDisplayWidth(label) - getDisplayWidth(suffix) - 2
+	barWidth = max(compactGaugeMinBarWidth, min(barWidth, compactGaugeMaxBarWidth))
+	if aggregated.TokenLimit > 0 {
+		fmt.Printf("%s%s%s\n", label, CreateProgressBar(percentage, barWidth), suffix)
+		fmt.Printf("   %s/%s tokens\n", util.FormatNumber(aggregated.GetLimitTokens()), util.FormatNumber(aggregated.TokenLimit))
+	} else {
+		fmt.Printf("%s%s tokens\n", label, util.FormatNumber(aggregated.TotalTokens))
+	}
+
+	fmt.Printf("⏰ %s (%s)\n", aggregated.FormatRemainingTime(), aggregated.FormatResetTime(param))
+	fmt.Printf("💰 %s/%s\n", util.FormatCurrency(aggregated.TotalCost), util.FormatCurrency(aggregated.CostLimit))
+}
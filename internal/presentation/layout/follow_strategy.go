@@ -0,0 +1,89 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/penwyp/go-claude-monitor/internal/core/model"
+	"github.com/penwyp/go-claude-monitor/internal/util"
+)
+
+// bigGlyphs are five-row block glyphs for the follow view's countdown
+var bigGlyphs = map[rune][5]string{
+	'0': {"███", "█ █", "█ █", "█ █", "███"},
+	'1': {" █ ", "██ ", " █ ", " █ ", "███"},
+	'2': {"███", "  █", "███", "█  ", "███"},
+	'3': {"███", "  █", "███", "  █", "███"},
+	'4': {"█ █", "█ █", "███", "  █", "  █"},
+	'5': {"███", "█  ", "███", "  █", "███"},
+	'6': {"███", "█  ", "███", "█ █", "███"},
+	'7': {"███", "  █", "  █", "  █", "  █"},
+	'8': {"███", "█ █", "███", "█ █", "███"},
+	'9': {"███", "█ █", "███", "  █", "███"},
+	':': {" ", "█", " ", "█", " "},
+}
+
+// followBarWidthMargin is the terminal width left around the usage bar
+const followBarWidthMargin = 12
+
+// FollowLayoutStrategy renders a single followed window full-screen: a large
+// countdown to the reset and a usage bar spanning the terminal
+type FollowLayoutStrategy struct {
+	BaseStrategy
+}
+
+func (s *FollowLayoutStrategy) GetName() string {
+	return "Follow"
+}
+
+func (s *FollowLayoutStrategy) Render(aggregated *model.AggregatedMetrics, param model.LayoutParam) {
+	tp := util.GetTimeProvider()
+	currentTimeStr := tp.FormatNow(util.ClockLayout(param.TimeFormat, true))
+
+	fmt.Printf("Claude %s  Following %s  %s\n\n", getPlanType(param.Plan), param.FollowProject, currentTimeStr)
+
+	if !aggregated.HasActiveSession {
+		fmt.Printf("No active window for %s\n", param.FollowProject)
+		return
+	}
+
+	for _, line := range BigCountdown(aggregated.TimeRemaining) {
+		fmt.Println("  " + line)
+	}
+	fmt.Printf("\n  until reset %s\n\n", aggregated.FormatResetTime(param))
+
+	percentage := aggregated.GetTokenPercentage()
+	barWidth := max(compactGaugeMinBarWidth, TerminalWidth()-followBarWidthMargin)
+	if aggregated.TokenLimit > 0 {
+		fmt.Printf("  %s %5.1f%%\n", CreateProgressBar(percentage, barWidth), percentage)
+		fmt.Printf("  🪙 %s/%s tokens in window\n", util.FormatNumber(aggregated.GetLimitTokens()), util.FormatNumber(aggregated.TokenLimit))
+	} else {
+		fmt.Printf("  🪙 %s tokens in window\n", util.FormatNumber(aggregated.TotalTokens))
+	}
+	fmt.Printf("  📁 %s: %s tokens, %s\n", param.FollowProject,
+		util.FormatNumber(aggregated.FollowedProjectTokens), util.FormatCurrency(aggregated.FollowedProjectCost))
+	fmt.Printf("  💰 %s/%s\n", util.FormatCurrency(aggregated.TotalCost), util.FormatCurrency(aggregated.CostLimit))
+}
+
+// BigCountdown renders d as HH:MM:SS in five rows of block glyphs
+func BigCountdown(d time.Duration) []string {
+	if d < 0 {
+		d = 0
+	}
+	total := int(d.Seconds())
+	text := fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+
+	var rows [5][]string
+	for _, r := range text {
+		glyph := bigGlyphs[r]
+		for i := range rows {
+			rows[i] = append(rows[i], glyph[i])
+		}
+	}
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = strings.Join(row, " ")
+	}
+	return lines
+}
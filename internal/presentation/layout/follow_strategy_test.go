@@ -0,0 +1,29 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBigCountdown(t *testing.T) {
+	lines := BigCountdown(2*time.Hour + 5*time.Minute + 9*time.Second)
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 rows, got %d", len(lines))
+	}
+	// "02:05:09": six three-wide digits, two colons and seven separating spaces
+	wantWidth := 6*3 + 2*1 + 7
+	for i, line := range lines {
+		if width := len([]rune(line)); width != wantWidth {
+			t.Errorf("Row %d: expected width %d, got %d (%q)", i, wantWidth, width, line)
+		}
+	}
+	if !strings.HasPrefix(lines[0], "███ ███") {
+		t.Errorf("Expected the countdown to start with a zero digit, got %q", lines[0])
+	}
+
+	// Negative durations show as zero
+	if got := BigCountdown(-time.Minute); strings.Join(got, "\n") != strings.Join(BigCountdown(0), "\n") {
+		t.Error("Expected a negative duration to render as 00:00:00")
+	}
+}
@@ -20,10 +20,7 @@ func (s *FullLayoutStrategy) GetName() string {
 
 func (s *FullLayoutStrategy) Render(aggregated *model.AggregatedMetrics, param model.LayoutParam) {
 	now := util.GetTimeProvider().Now()
-	timeStr := now.Format("15:04:05")
-	if param.TimeFormat == "12h" {
-		timeStr = now.Format("3:04:05 PM")
-	}
+	timeStr := now.Format(util.ClockLayout(param.TimeFormat, true))
 
 	maxWidth := s.GetSizer().GetMaxWidth()
 
@@ -38,11 +35,14 @@ func (s *FullLayoutStrategy) Render(aggregated *model.AggregatedMetrics, param m
 	costPercent, tokenPercent, _ := s.resourceUsageData(aggregated, maxWidth, sep) // Resource usage section
 	s.costLine(aggregated, costPercent, maxWidth)                                  // Cost line with progress bar
 	s.tokenLine(aggregated, tokenPercent, maxWidth)                                // Token line with progress bar
+	if aggregated.HasWeeklyUsage {
+		s.weeklyLine(aggregated, param, maxWidth) // Weekly cap line with progress bar
+	}
 	//s.messageLine(aggregated, messagePercent, maxWidth)                                         // Message line with progress bar
 	s.sessionLine(aggregated, maxWidth) // Session line with progress bar
 
 	s.performanceSection(aggregated, param, sep, maxWidth, now) // Performance metrics section
-	s.modelDistribution(aggregated, sep, maxWidth)              // Model distribution section
+	s.modelDistribution(aggregated, param, sep, maxWidth)       // Model distribution section
 	s.predictionsSection(aggregated, param, sep, maxWidth)      // Predictions section
 	s.bottomBorder(maxWidth)                                    // Bottom border
 
@@ -76,6 +76,10 @@ func (s *FullLayoutStrategy) predictionsSection(aggregated *model.AggregatedMetr
 		// Show limit exceeded warning
 		rightPredCol1 = fmt.Sprintf("⚠️  %s", aggregated.LimitExceededReason)
 		rightPredCol1Colored = fmt.Sprintf("⚠️  %s%s%s", util.ColorRed, aggregated.LimitExceededReason, util.ColorReset)
+	} else if warning := aggregated.FormatPacingWarning(); warning != "" {
+		// Warn about a front-loaded burst before the limit is actually close
+		rightPredCol1 = fmt.Sprintf("⚠️  %s", warning)
+		rightPredCol1Colored = fmt.Sprintf("⚠️  %s%s%s", util.ColorYellow, warning, util.ColorReset)
 	}
 
 	// Calculate display widths using plain text (without color codes)
@@ -113,9 +117,33 @@ func (s *FullLayoutStrategy) predictionsSection(aggregated *model.AggregatedMetr
 		leftPredCol1, strings.Repeat(" ", predLeftPadding1),
 		displayRightPredCol1, strings.Repeat(" ", predRightPadding1))
 	fmt.Println(predLine1)
+
+	// Second row: window-end projection, flagged when it is still unreliable
+	leftPredCol2 := fmt.Sprintf("📈 Projected: %s", aggregated.FormatProjection())
+	displayLeftPredCol2 := leftPredCol2
+	if aggregated.ProjectionConfidence == model.ProjectionConfidenceLow {
+		displayLeftPredCol2 = fmt.Sprintf("%s%s%s", util.ColorYellow, leftPredCol2, util.ColorReset)
+	}
+	predLeftPadding2 := predLeftColumnWidth - getDisplayWidth(leftPredCol2)
+	if predLeftPadding2 < 0 {
+		predLeftPadding2 = 0
+	}
+	// Cumulative usage of the day, across every window so far
+	rightPredCol2 := ""
+	if aggregated.HasDailyUsage {
+		rightPredCol2 = fmt.Sprintf("📅 Today: %s", aggregated.FormatDailyUsage())
+	}
+	predRightPadding2 := predRightColumnWidth - getDisplayWidth(rightPredCol2)
+	if predRightPadding2 < 0 {
+		predRightPadding2 = 0
+	}
+	predLine2 := fmt.Sprintf("│ %s%s │ %s%s │",
+		displayLeftPredCol2, strings.Repeat(" ", predLeftPadding2),
+		rightPredCol2, strings.Repeat(" ", predRightPadding2))
+	fmt.Println(predLine2)
 }
 
-func (s *FullLayoutStrategy) modelDistribution(aggregated *model.AggregatedMetrics, sep string, maxWidth int) {
+func (s *FullLayoutStrategy) modelDistribution(aggregated *model.AggregatedMetrics, param model.LayoutParam, sep string, maxWidth int) {
 	if len(aggregated.ModelDistribution) > 0 {
 		fmt.Println(sep)
 
@@ -126,6 +154,16 @@ func (s *FullLayoutStrategy) modelDistribution(aggregated *model.AggregatedMetri
 		}
 		models = util.SortModels(models)
 
+		// Collapse to the dominant model when requested
+		moreSuffix := ""
+		if param.CollapseModels {
+			dominant, more := model.CollapseModelDistribution(aggregated.ModelDistribution)
+			models = []string{dominant}
+			if more > 0 {
+				moreSuffix = fmt.Sprintf(" +%d more", more)
+			}
+		}
+
 		// Calculate total tokens for current model distribution
 		var currentModelTokens int
 		for _, stats := range aggregated.ModelDistribution {
@@ -160,7 +198,7 @@ func (s *FullLayoutStrategy) modelDistribution(aggregated *model.AggregatedMetri
 			if strings.Contains(simplifiedModel, "Opus") {
 				modelEmoji = "🎯"
 			}
-			modelLine := fmt.Sprintf("│ %s %-*s    %s %.1f%%", modelEmoji, maxModelNameWidth, simplifiedModel, modelBar, percentage)
+			modelLine := fmt.Sprintf("│ %s %-*s    %s %.1f%%%s", modelEmoji, maxModelNameWidth, simplifiedModel, modelBar, percentage, moreSuffix)
 			// Calculate proper padding using display width
 			currentWidth := getDisplayWidth(modelLine)
 			paddingNeeded := maxWidth - currentWidth - 2
@@ -178,12 +216,15 @@ func (s *FullLayoutStrategy) performanceSection(aggregated *model.AggregatedMetr
 	fmt.Println(sep)
 
 	resetAt := aggregated.FormatResetTime(param)
+	if aggregated.ResetTimeClamped {
+		resetAt += " (capped)"
+	}
 	//resetAt = aggregated.AppendWindowIndicator(resetAt)
 
 	// Performance metrics - two columns with dynamic width calculation
 	// First, collect all content to find the maximum widths
 	leftCol1 := fmt.Sprintf("⚡ Burn Rate: %s", util.FormatBurnRate(aggregated.TokenBurnRate))
-	leftCol2 := fmt.Sprintf("💵 Cost Rate: $%.2f/min", aggregated.CostPerMinute)
+	leftCol2 := fmt.Sprintf("💵 Cost Rate: %s/min", util.FormatCurrency(aggregated.CostPerMinute))
 	rightCol1 := fmt.Sprintf("⏰️ Time Left: %s", aggregated.FormatRemainingTime())
 	rightCol2 := fmt.Sprintf("⏰️ Reset At: %s", resetAt)
 
@@ -238,7 +279,11 @@ func (s *FullLayoutStrategy) performanceSection(aggregated *model.AggregatedMetr
 
 func (s *FullLayoutStrategy) tokenLine(aggregated *model.AggregatedMetrics, tokenPercent float64, maxWidth int) int {
 	tokenBar := CreateProgressBar(tokenPercent, 40)
-	tokenValues := fmt.Sprintf("%s / %s", util.FormatNumber(aggregated.TotalTokens), util.FormatNumber(aggregated.TokenLimit))
+	tokenValues := fmt.Sprintf("%s / %s", util.FormatNumber(aggregated.GetLimitTokens()), util.FormatNumber(aggregated.TokenLimit))
+	// Tokens the limit does not count, such as cache creation, are shown apart
+	if aggregated.GetLimitTokens() != aggregated.TotalTokens {
+		tokenValues += fmt.Sprintf(" (%s total)", util.FormatNumber(aggregated.TotalTokens))
+	}
 	tokenLine := fmt.Sprintf("│ 🪙 Tokens   %s %s %.1f%%",
 		getPercentageEmoji(tokenPercent), tokenBar, tokenPercent)
 	// Calculate spacing to align values using display width
@@ -251,9 +296,27 @@ func (s *FullLayoutStrategy) tokenLine(aggregated *model.AggregatedMetrics, toke
 	return spacing
 }
 
+// weeklyLine shows the usage of the trailing week against the plan's weekly
+// cap and when its oldest usage ages out
+func (s *FullLayoutStrategy) weeklyLine(aggregated *model.AggregatedMetrics, param model.LayoutParam, maxWidth int) {
+	weeklyPercent := aggregated.GetWeeklyPercentage()
+	weeklyBar := CreateProgressBar(weeklyPercent, 40)
+	weeklyValues := fmt.Sprintf("%s / %s (frees %s)", util.FormatNumber(aggregated.WeeklyTokens),
+		util.FormatNumber(aggregated.WeeklyTokenLimit), aggregated.FormatWeeklyReset(param))
+	weeklyLine := fmt.Sprintf("│ 📆 Weekly   %s %s %.1f%%",
+		getPercentageEmoji(weeklyPercent), weeklyBar, weeklyPercent)
+	// Calculate spacing to align values using display width
+	spacing := maxWidth - getDisplayWidth(weeklyLine) - getDisplayWidth(weeklyValues) - 3
+	if spacing < 2 {
+		spacing = 2
+	}
+	weeklyLine = fmt.Sprintf("%s%s%s  │", weeklyLine, strings.Repeat(" ", spacing), weeklyValues)
+	fmt.Println(weeklyLine)
+}
+
 func (s *FullLayoutStrategy) costLine(aggregated *model.AggregatedMetrics, costPercent float64, maxWidth int) {
 	costBar := CreateProgressBar(costPercent, 40)
-	costValues := fmt.Sprintf("$%.2f / $%.2f", aggregated.TotalCost, aggregated.CostLimit)
+	costValues := fmt.Sprintf("%s / %s", util.FormatCurrency(aggregated.TotalCost), util.FormatCurrency(aggregated.CostLimit))
 	costLine := fmt.Sprintf("│ 💰 Cost     %s %s %.1f%%",
 		getPercentageEmoji(costPercent), costBar, costPercent)
 	// Calculate spacing to align values using display width
@@ -321,11 +384,10 @@ func (s *FullLayoutStrategy) header(param model.LayoutParam, timeStr string, max
 }
 
 func (s *FullLayoutStrategy) sessionLine(aggregated *model.AggregatedMetrics, maxWidth int) {
-	// Calculate session duration (5 hours total)
-	totalSessionDuration := 5 * time.Hour
+	totalSessionDuration := aggregated.GetSessionDuration()
 
 	// Calculate elapsed time using the common function
-	elapsedTime, remainingTime := CalculateSessionElapsedTime(aggregated.ResetTime)
+	elapsedTime, remainingTime := CalculateSessionElapsedTime(aggregated.ResetTime, totalSessionDuration)
 
 	var sessionValues string
 	var sessionLine string
@@ -340,7 +402,7 @@ func (s *FullLayoutStrategy) sessionLine(aggregated *model.AggregatedMetrics, ma
 			sessionBar, 0.0)
 	} else {
 		// Active session
-		sessionPercent = CalculateSessionPercentage(elapsedTime)
+		sessionPercent = CalculateSessionPercentage(elapsedTime, totalSessionDuration)
 		sessionBar := CreateProgressBar(sessionPercent, 40)
 
 		if remainingTime == 0 && elapsedTime == totalSessionDuration {
@@ -19,10 +19,7 @@ func (s *MinimalLayoutStrategy) Render(aggregated *model.AggregatedMetrics, para
 	tp := util.GetTimeProvider()
 
 	// Get current time
-	currentTimeStr := tp.FormatNow("15:04:05")
-	if param.TimeFormat == "12h" {
-		currentTimeStr = tp.FormatNow("3:04:05 PM")
-	}
+	currentTimeStr := tp.FormatNow(util.ClockLayout(param.TimeFormat, true))
 
 	// If no active session, create a zero-value metrics object
 	if !aggregated.HasActiveSession {
@@ -33,7 +30,7 @@ func (s *MinimalLayoutStrategy) Render(aggregated *model.AggregatedMetrics, para
 	percentage := aggregated.GetTokenPercentage()
 
 	tokenInfo := fmt.Sprintf("%s/%s (%.1f%%)",
-		util.FormatNumber(aggregated.TotalTokens),
+		util.FormatNumber(aggregated.GetLimitTokens()),
 		util.FormatNumber(aggregated.TokenLimit),
 		percentage)
 	if aggregated.TokenLimit <= 0 {
@@ -41,7 +38,7 @@ func (s *MinimalLayoutStrategy) Render(aggregated *model.AggregatedMetrics, para
 	}
 
 	// Format cost info
-	costInfo := fmt.Sprintf("$%.2f/$%.2f", aggregated.TotalCost, aggregated.CostLimit)
+	costInfo := fmt.Sprintf("%s/%s", util.FormatCurrency(aggregated.TotalCost), util.FormatCurrency(aggregated.CostLimit))
 
 	// Build the single line
 	line := fmt.Sprintf("Claude: 💰 %s | 🪙 %s | ⚡️ %s | 🔮 %s | ⏰ %s | %s",
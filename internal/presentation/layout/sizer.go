@@ -35,6 +35,16 @@ func (i Sizer) PadString(s string, width int, leftAlign bool) string {
 	return padding + s
 }
 
+// TerminalWidth returns the current width of the terminal on stdout, or 0
+// when stdout is not a terminal
+func TerminalWidth() int {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
 func (i Sizer) GetMaxWidth() int {
 	// Get terminal width with fallback
 	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
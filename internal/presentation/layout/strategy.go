@@ -10,11 +10,25 @@ type LayoutStrategy interface {
 	GetName() string
 }
 
+// Layout styles cycled with the 't' key
+const (
+	StyleFull = iota
+	StyleMinimal
+	StyleCompactGauge
+
+	StyleCount // Number of layout styles
+)
+
+// CompactWidthThreshold is the terminal width below which the full dashboard
+// overflows and the compact gauge is rendered instead
+const CompactWidthThreshold = 90
+
 // GetLayoutStrategy returns the appropriate layout strategy based on the style
 func GetLayoutStrategy(layoutStyle int) LayoutStrategy {
 	strategies := map[int]LayoutStrategy{
-		0: &FullLayoutStrategy{},
-		1: &MinimalLayoutStrategy{},
+		StyleFull:         &FullLayoutStrategy{},
+		StyleMinimal:      &MinimalLayoutStrategy{},
+		StyleCompactGauge: &CompactGaugeLayoutStrategy{},
 	}
 
 	if strategy, exists := strategies[layoutStyle]; exists {
@@ -24,3 +38,14 @@ func GetLayoutStrategy(layoutStyle int) LayoutStrategy {
 	// Default to full dashboard if invalid style
 	return &FullLayoutStrategy{}
 }
+
+// SelectLayoutStrategy returns the strategy for the style at the given
+// terminal width. The full dashboard falls back to the compact gauge on
+// terminals narrower than CompactWidthThreshold; a width of 0 means unknown.
+func SelectLayoutStrategy(layoutStyle int, termWidth int) LayoutStrategy {
+	strategy := GetLayoutStrategy(layoutStyle)
+	if _, isFull := strategy.(*FullLayoutStrategy); isFull && termWidth > 0 && termWidth < CompactWidthThreshold {
+		return &CompactGaugeLayoutStrategy{}
+	}
+	return strategy
+}
@@ -22,6 +22,11 @@ func TestGetLayoutStrategy(t *testing.T) {
 			layoutStyle: 1,
 			wantType:    "*layout.MinimalLayoutStrategy",
 		},
+		{
+			name:        "compact_gauge_style",
+			layoutStyle: 2,
+			wantType:    "*layout.CompactGaugeLayoutStrategy",
+		},
 		{
 			name:        "unknown_style_defaults_to_full",
 			layoutStyle: 99,
@@ -65,6 +70,11 @@ func TestLayoutStrategyInterface(t *testing.T) {
 			strategy: &MinimalLayoutStrategy{},
 			expectedName: "Minimal Dashboard",
 		},
+		{
+			name:     "compact_gauge_layout",
+			strategy: &CompactGaugeLayoutStrategy{},
+			expectedName: "Compact Gauge",
+		},
 	}
 	
 	metrics := &model.AggregatedMetrics{
@@ -125,6 +135,31 @@ func TestLayoutStrategyInterface(t *testing.T) {
 	}
 }
 
+func TestSelectLayoutStrategy(t *testing.T) {
+	tests := []struct {
+		name         string
+		layoutStyle  int
+		termWidth    int
+		expectedName string
+	}{
+		{"full_on_wide_terminal", StyleFull, 120, "Full Dashboard"},
+		{"full_at_threshold", StyleFull, CompactWidthThreshold, "Full Dashboard"},
+		{"compact_below_threshold", StyleFull, 80, "Compact Gauge"},
+		{"unknown_width_keeps_full", StyleFull, 0, "Full Dashboard"},
+		{"minimal_kept_on_narrow_terminal", StyleMinimal, 80, "Minimal Dashboard"},
+		{"compact_on_wide_terminal", StyleCompactGauge, 120, "Compact Gauge"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := SelectLayoutStrategy(tt.layoutStyle, tt.termWidth).GetName()
+			if name != tt.expectedName {
+				t.Errorf("SelectLayoutStrategy(%d, %d) = %v, want %v", tt.layoutStyle, tt.termWidth, name, tt.expectedName)
+			}
+		})
+	}
+}
+
 func TestStrategyErrorHandling(t *testing.T) {
 	strategy := &MinimalLayoutStrategy{}
 	
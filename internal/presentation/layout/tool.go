@@ -34,11 +34,11 @@ func getPercentageEmoji(percentage float64) string {
 }
 
 // CalculateSessionElapsedTime is now available from util.CalculateSessionElapsedTime
-func CalculateSessionElapsedTime(resetTime int64) (elapsedTime time.Duration, remainingTime time.Duration) {
-	return util.CalculateSessionElapsedTime(resetTime)
+func CalculateSessionElapsedTime(resetTime int64, totalSessionDuration time.Duration) (elapsedTime time.Duration, remainingTime time.Duration) {
+	return util.CalculateSessionElapsedTime(resetTime, totalSessionDuration)
 }
 
 // CalculateSessionPercentage is now available from util.CalculateSessionPercentage
-func CalculateSessionPercentage(elapsedTime time.Duration) float64 {
-	return util.CalculateSessionPercentage(elapsedTime)
+func CalculateSessionPercentage(elapsedTime, totalSessionDuration time.Duration) float64 {
+	return util.CalculateSessionPercentage(elapsedTime, totalSessionDuration)
 }
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Currency is a display currency. Costs are computed in USD everywhere and
+// only converted when they are rendered.
+type Currency struct {
+	Code     string
+	Symbol   string
+	Rate     float64 // Units of the currency per USD
+	Decimals int     // Digits after the decimal point
+}
+
+// USD is the currency costs are computed in
+var USD = Currency{Code: "USD", Symbol: "$", Rate: 1, Decimals: 2}
+
+// currencies holds approximate exchange rates; pass an explicit rate when
+// exact amounts matter
+var currencies = map[string]Currency{
+	"USD": USD,
+	"EUR": {Code: "EUR", Symbol: "€", Rate: 0.92, Decimals: 2},
+	"GBP": {Code: "GBP", Symbol: "£", Rate: 0.79, Decimals: 2},
+	"JPY": {Code: "JPY", Symbol: "¥", Rate: 150, Decimals: 0},
+	"CNY": {Code: "CNY", Symbol: "CN¥", Rate: 7.2, Decimals: 2},
+	"CAD": {Code: "CAD", Symbol: "CA$", Rate: 1.37, Decimals: 2},
+	"AUD": {Code: "AUD", Symbol: "A$", Rate: 1.52, Decimals: 2},
+	"INR": {Code: "INR", Symbol: "₹", Rate: 83, Decimals: 2},
+}
+
+var (
+	displayCurrency   = USD
+	displayCurrencyMu sync.RWMutex
+)
+
+// NewCurrency returns the currency with the given ISO code. A non-zero rate
+// replaces the built-in exchange rate and is required for currencies without
+// one, which are rendered with their code as the symbol.
+func NewCurrency(code string, rate float64) (Currency, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		code = USD.Code
+	}
+	if rate < 0 {
+		return Currency{}, fmt.Errorf("exchange rate %g must not be negative", rate)
+	}
+	currency, ok := currencies[code]
+	if !ok {
+		if rate == 0 {
+			return Currency{}, fmt.Errorf("no built-in exchange rate for currency %q, pass one with --exchange-rate", code)
+		}
+		currency = Currency{Code: code, Symbol: code + " ", Decimals: 2}
+	}
+	if rate > 0 {
+		currency.Rate = rate
+	}
+	return currency, nil
+}
+
+// Convert converts an amount in USD to the currency
+func (c Currency) Convert(usd float64) float64 {
+	return usd * c.Rate
+}
+
+// Format converts an amount in USD and renders it with the currency symbol
+// and thousands separators
+func (c Currency) Format(usd float64) string {
+	return c.Symbol + formatAmount(c.Convert(usd), c.Decimals)
+}
+
+// SetDisplayCurrency sets the currency FormatCurrency renders costs in
+func SetDisplayCurrency(c Currency) {
+	displayCurrencyMu.Lock()
+	defer displayCurrencyMu.Unlock()
+	displayCurrency = c
+}
+
+// DisplayCurrency returns the currency FormatCurrency renders costs in
+func DisplayCurrency() Currency {
+	displayCurrencyMu.RLock()
+	defer displayCurrencyMu.RUnlock()
+	return displayCurrency
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCurrency(t *testing.T) {
+	eur, err := NewCurrency("eur", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", eur.Code)
+	assert.Equal(t, "€", eur.Symbol)
+	assert.Equal(t, 0.92, eur.Rate)
+
+	// An explicit rate replaces the built-in one
+	eur, err = NewCurrency("EUR", 0.9)
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, eur.Rate)
+
+	usd, err := NewCurrency("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, USD, usd)
+
+	// Currencies without a built-in rate need one
+	_, err = NewCurrency("CHF", 0)
+	assert.Error(t, err)
+	chf, err := NewCurrency("CHF", 0.88)
+	require.NoError(t, err)
+	assert.Equal(t, "CHF 8.80", chf.Format(10))
+
+	_, err = NewCurrency("EUR", -1)
+	assert.Error(t, err)
+}
+
+func TestCurrencyConversion(t *testing.T) {
+	gbp := Currency{Code: "GBP", Symbol: "£", Rate: 0.8, Decimals: 2}
+	assert.InDelta(t, 10.0, gbp.Convert(12.5), 1e-9)
+	assert.Equal(t, "£10.00", gbp.Format(12.5))
+	assert.Equal(t, "£1,000.00", gbp.Format(1250))
+
+	jpy := Currency{Code: "JPY", Symbol: "¥", Rate: 150, Decimals: 0}
+	assert.Equal(t, "¥1,875", jpy.Format(12.5))
+
+	assert.Equal(t, "$12.50", USD.Format(12.5))
+}
+
+func TestFormatCurrencyUsesDisplayCurrency(t *testing.T) {
+	defer SetDisplayCurrency(DisplayCurrency())
+
+	SetDisplayCurrency(Currency{Code: "EUR", Symbol: "€", Rate: 0.5, Decimals: 2})
+	assert.Equal(t, "€5.00", FormatCurrency(10))
+
+	SetDisplayCurrency(USD)
+	assert.Equal(t, "$10.00", FormatCurrency(10))
+}
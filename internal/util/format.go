@@ -37,10 +37,15 @@ func FormatBurnRate(rate float64) string {
 	}
 }
 
+// FormatCurrency renders a cost in USD in the display currency
 func FormatCurrency(amount float64) string {
-	// Format with comma separators for thousands
-	// First format with 2 decimal places
-	str := fmt.Sprintf("%.2f", amount)
+	return DisplayCurrency().Format(amount)
+}
+
+// formatAmount formats amount with the given decimals and comma separators
+// for thousands
+func formatAmount(amount float64, decimals int) string {
+	str := fmt.Sprintf("%.*f", decimals, amount)
 	
 	// Split into integer and decimal parts
 	parts := strings.Split(str, ".")
@@ -66,7 +71,16 @@ func FormatCurrency(amount float64) string {
 	
 	// Combine with decimal part
 	if decPart != "" {
-		return fmt.Sprintf("$%s.%s", intPart, decPart)
+		return intPart + "." + decPart
 	}
-	return fmt.Sprintf("$%s.00", intPart)
+	return intPart
+}
+
+// DualTimeLayout is the layout used for each half of FormatDualTime
+const DualTimeLayout = "2006-01-02 15:04:05 MST"
+
+// FormatDualTime renders t in its own location followed by the same instant in UTC,
+// e.g. "2025-01-15 18:30:00 CST (2025-01-15 10:30:00 UTC)"
+func FormatDualTime(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.Format(DualTimeLayout), t.UTC().Format(DualTimeLayout))
 }
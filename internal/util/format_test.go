@@ -425,4 +425,12 @@ func TestFormatDurationEdgeCases(t *testing.T) {
 			assert.Equal(t, tt.expected, result)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestFormatDualTime(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	local := time.Date(2025, 1, 15, 18, 30, 0, 0, loc)
+
+	result := FormatDualTime(local)
+
+	assert.Equal(t, "2025-01-15 18:30:00 CST (2025-01-15 10:30:00 UTC)", result)
+}
@@ -5,16 +5,13 @@ import (
 )
 
 // CalculateSessionElapsedTime calculates how much time has elapsed in the current session
-// Returns elapsed time and remaining time until reset
-func CalculateSessionElapsedTime(resetTime int64) (elapsedTime time.Duration, remainingTime time.Duration) {
-	// Total session duration is 5 hours
-	totalSessionDuration := 5 * time.Hour
-
+// of length totalSessionDuration. Returns elapsed time and remaining time until reset
+func CalculateSessionElapsedTime(resetTime int64, totalSessionDuration time.Duration) (elapsedTime time.Duration, remainingTime time.Duration) {
 	// Get current time
 	now := GetTimeProvider().Now()
 
 	// Calculate elapsed time from reset time
-	// ResetTime is 5 hours in the future, so elapsed = 5 hours - time remaining
+	// ResetTime is one session after the window start, so elapsed = session - time remaining
 	if resetTime != 0 {
 		resetTimeObj := time.Unix(resetTime, 0).UTC()
 		resetTimeLocal := GetTimeProvider().In(resetTimeObj)
@@ -39,9 +36,9 @@ func CalculateSessionElapsedTime(resetTime int64) (elapsedTime time.Duration, re
 	return elapsedTime, remainingTime
 }
 
-// CalculateSessionPercentage calculates the percentage of session time elapsed
-func CalculateSessionPercentage(elapsedTime time.Duration) float64 {
-	totalSessionDuration := 5 * time.Hour
+// CalculateSessionPercentage calculates the percentage of a session of length
+// totalSessionDuration that has elapsed
+func CalculateSessionPercentage(elapsedTime, totalSessionDuration time.Duration) float64 {
 	sessionPercent := (elapsedTime.Seconds() / totalSessionDuration.Seconds()) * 100
 	if sessionPercent > 100 {
 		sessionPercent = 100
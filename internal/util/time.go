@@ -1,29 +1,54 @@
 package util
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 )
 
 // TimeProvider is a global time utility that handles timezone-aware time operations
 type TimeProvider struct {
-	location *time.Location
-	mu       sync.RWMutex
+	location   *time.Location
+	systemZone string // System timezone when the provider was created
+	mu         sync.RWMutex
 }
 
 var (
 	globalTimeProvider *TimeProvider
 	mu                 sync.Mutex
+
+	// systemZone identifies the operating system's current timezone. Go loads
+	// time.Local once at startup, so it is read again from TZ or /etc/localtime.
+	systemZone = currentSystemZone
 )
 
+// Clock reports the current time. Detection and metrics read the time through
+// a Clock so tests can fix it, e.g. at a window boundary.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock
+type ClockFunc func() time.Time
+
+// Now returns f()
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock reads the operating system clock
+var SystemClock Clock = ClockFunc(time.Now)
+
 // InitializeTimeProvider initializes the global time provider with the specified timezone
 func InitializeTimeProvider(timezone string) error {
 	mu.Lock()
 	defer mu.Unlock()
 	
 	// Create a new provider
-	provider := &TimeProvider{}
+	provider := &TimeProvider{systemZone: systemZone()}
 	
 	// Try to set the timezone
 	if err := provider.SetTimezone(timezone); err != nil {
@@ -62,6 +87,78 @@ func (tp *TimeProvider) SetTimezone(timezone string) error {
 	return nil
 }
 
+// CheckSystemTimezone detects a change of the system timezone since the
+// provider was created, e.g. a laptop that travelled while top was running.
+// Times keep using the configured location, which for Local is the zone in
+// effect at startup; a warning is logged once per change. It reports whether
+// a change was detected.
+func (tp *TimeProvider) CheckSystemTimezone() bool {
+	current := systemZone()
+
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if current == "" || current == tp.systemZone {
+		return false
+	}
+	LogWarn(fmt.Sprintf("System timezone changed from %s to %s; times are still shown in %s, restart to pick up the new zone",
+		tp.systemZone, current, tp.location))
+	tp.systemZone = current
+	return true
+}
+
+// localtimePath is the system timezone file on Unix-like systems
+const localtimePath = "/etc/localtime"
+
+// currentSystemZone identifies the system timezone by name, so that the
+// zone's own DST transitions are not mistaken for a change. It returns an
+// empty string when the zone cannot be determined.
+func currentSystemZone() string {
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		return tz
+	}
+	return zoneOfFile(localtimePath)
+}
+
+// zoneFileCache remembers the identity of a copied zone file, so that it is
+// only read again after it changed on disk
+var zoneFileCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	zone    string
+}
+
+// zoneOfFile identifies the zone installed at path. A link into the zoneinfo
+// database names the zone, e.g. Europe/Berlin; a copied file is identified
+// by a digest of its tzdata.
+func zoneOfFile(path string) string {
+	if target, err := os.Readlink(path); err == nil {
+		if idx := strings.LastIndex(target, "zoneinfo/"); idx != -1 {
+			return target[idx+len("zoneinfo/"):]
+		}
+		return target
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	zoneFileCache.Lock()
+	defer zoneFileCache.Unlock()
+	if zoneFileCache.path == path && zoneFileCache.modTime.Equal(info.ModTime()) && zoneFileCache.size == info.Size() {
+		return zoneFileCache.zone
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	zone := fmt.Sprintf("%s (tzdata %x)", path, sum[:4])
+	zoneFileCache.path, zoneFileCache.modTime, zoneFileCache.size, zoneFileCache.zone = path, info.ModTime(), info.Size(), zone
+	return zone
+}
+
 // Now returns the current time in the configured timezone
 func (tp *TimeProvider) Now() time.Time {
 	tp.mu.RLock()
@@ -87,3 +184,65 @@ func (tp *TimeProvider) Format(t time.Time, layout string) string {
 func (tp *TimeProvider) FormatNow(layout string) string {
 	return tp.Format(time.Now(), layout)
 }
+
+// Time format presets accepted by --time-format. Any other value is used as a
+// Go time layout.
+const (
+	TimeFormat12h = "12h"
+	TimeFormat24h = "24h"
+	TimeFormatISO = "iso"
+)
+
+// timeFormatSample differs from the reference time in every field, so a
+// layout formats it to itself only when it contains no time elements
+var timeFormatSample = time.Date(2024, 11, 23, 9, 41, 37, 0, time.UTC)
+
+// ValidateTimeFormat checks that format is a preset or a Go layout that
+// renders at least one time element
+func ValidateTimeFormat(format string) error {
+	switch format {
+	case TimeFormat12h, TimeFormat24h, TimeFormatISO:
+		return nil
+	}
+	if format == "" || timeFormatSample.Format(format) == format {
+		return fmt.Errorf("invalid time format '%s': must be 12h, 24h, iso or a Go time layout such as '15:04'", format)
+	}
+	return nil
+}
+
+// ClockLayout returns the layout clock times are rendered with in the given
+// time format; seconds are included for the live clock. Custom layouts are
+// used as given either way.
+func ClockLayout(format string, withSeconds bool) string {
+	switch format {
+	case TimeFormat12h:
+		if withSeconds {
+			return "3:04:05 PM"
+		}
+		return "3:04 PM"
+	case TimeFormatISO:
+		if withSeconds {
+			return "2006-01-02T15:04:05Z07:00"
+		}
+		return "2006-01-02T15:04Z07:00"
+	case TimeFormat24h, "":
+		if withSeconds {
+			return "15:04:05"
+		}
+		return "15:04"
+	default:
+		return format
+	}
+}
+
+// DateTimeLayout returns the layout full timestamps are rendered with in the
+// given time format: ClockLayout preceded by the date. iso and custom layouts
+// are used as ClockLayout returns them.
+func DateTimeLayout(format string, withSeconds bool) string {
+	switch format {
+	case TimeFormat12h, TimeFormat24h, "":
+		return "2006-01-02 " + ClockLayout(format, withSeconds)
+	default:
+		return ClockLayout(format, withSeconds)
+	}
+}
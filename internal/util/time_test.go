@@ -1,6 +1,8 @@
 package util
 
 import (
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -315,4 +317,100 @@ func TestInitializeTimeProvider_ErrorMessage(t *testing.T) {
 	assert.Contains(t, err.Error(), "Valid examples:")
 	assert.Contains(t, err.Error(), "America/New_York")
 	assert.Contains(t, err.Error(), "Asia/Shanghai")
-}
\ No newline at end of file
+}
+func TestTimeProviderCheckSystemTimezone(t *testing.T) {
+	originalZone := systemZone
+	originalLocal := time.Local
+	defer func() {
+		systemZone = originalZone
+		time.Local = originalLocal
+	}()
+
+	zone := "Europe/Berlin"
+	systemZone = func() string { return zone }
+
+	for _, timezone := range []string{"Asia/Shanghai", "Local"} {
+		t.Run(timezone, func(t *testing.T) {
+			zone = "Europe/Berlin"
+			require.NoError(t, InitializeTimeProvider(timezone))
+			tp := GetTimeProvider()
+			configured := tp.Now().Location()
+			ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			want := ts.In(configured).Format("15:04 MST")
+
+			assert.False(t, tp.CheckSystemTimezone())
+
+			// Simulate travelling: the system zone and time.Local both move
+			zone = "Asia/Tokyo"
+			tokyo, err := time.LoadLocation("Asia/Tokyo")
+			require.NoError(t, err)
+			time.Local = tokyo
+
+			assert.True(t, tp.CheckSystemTimezone())
+			assert.False(t, tp.CheckSystemTimezone(), "a change is reported once")
+
+			assert.Equal(t, configured, tp.Now().Location())
+			assert.Equal(t, want, tp.Format(ts, "15:04 MST"))
+			time.Local = originalLocal
+		})
+	}
+}
+
+func TestZoneOfFile(t *testing.T) {
+	dir := t.TempDir()
+	zoneinfo := filepath.Join(dir, "zoneinfo")
+	for _, zone := range []string{"Europe/Berlin", "Asia/Tokyo"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(zoneinfo, filepath.Dir(zone)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(zoneinfo, zone), []byte("TZif "+zone), 0644))
+	}
+
+	// A link names the zone, whatever the time of year
+	link := filepath.Join(dir, "localtime")
+	require.NoError(t, os.Symlink(filepath.Join(zoneinfo, "Europe/Berlin"), link))
+	assert.Equal(t, "Europe/Berlin", zoneOfFile(link))
+	require.NoError(t, os.Remove(link))
+	require.NoError(t, os.Symlink(filepath.Join(zoneinfo, "Asia/Tokyo"), link))
+	assert.Equal(t, "Asia/Tokyo", zoneOfFile(link))
+
+	// A copy is identified by its contents
+	copied := filepath.Join(dir, "copied")
+	require.NoError(t, os.WriteFile(copied, []byte("TZif Europe/Berlin"), 0644))
+	berlin := zoneOfFile(copied)
+	assert.NotEmpty(t, berlin)
+	assert.Equal(t, berlin, zoneOfFile(copied))
+	require.NoError(t, os.WriteFile(copied, []byte("TZif Asia/Tokyo"), 0644))
+	assert.NotEqual(t, berlin, zoneOfFile(copied))
+
+	assert.Empty(t, zoneOfFile(filepath.Join(dir, "missing")))
+}
+
+func TestValidateTimeFormat(t *testing.T) {
+	for _, format := range []string{"12h", "24h", "iso", "15:04", "Mon 3:04PM", "2006-01-02 15:04"} {
+		assert.NoError(t, ValidateTimeFormat(format), format)
+	}
+
+	for _, format := range []string{"", "invalid", "HH:MM"} {
+		err := ValidateTimeFormat(format)
+		require.Error(t, err, format)
+		assert.Contains(t, err.Error(), "invalid time format")
+	}
+}
+
+func TestClockLayout(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+
+	assert.Equal(t, "14:07", ts.Format(ClockLayout("24h", false)))
+	assert.Equal(t, "14:07:09", ts.Format(ClockLayout("", true)))
+	assert.Equal(t, "2:07:09 PM", ts.Format(ClockLayout("12h", true)))
+	assert.Equal(t, "2024-03-05T14:07:09Z", ts.Format(ClockLayout("iso", true)))
+	assert.Equal(t, "14h07", ts.Format(ClockLayout("15h04", true)), "custom layouts ignore withSeconds")
+}
+
+func TestDateTimeLayout(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+
+	assert.Equal(t, "2024-03-05 14:07:09", ts.Format(DateTimeLayout("24h", true)))
+	assert.Equal(t, "2024-03-05 2:07 PM", ts.Format(DateTimeLayout("12h", false)))
+	assert.Equal(t, "2024-03-05T14:07Z", ts.Format(DateTimeLayout("iso", false)))
+	assert.Equal(t, "05/03 14h07", ts.Format(DateTimeLayout("02/01 15h04", true)))
+}